@@ -4,8 +4,12 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/gorilla/handlers"
@@ -20,6 +24,8 @@ const (
 	HTTP2Enabled = true
 )
 
+const cfgPath = "./configs/oam.json"
+
 type oamCfg struct {
 	TLSEndpoint    string `json:"TlsEndpoint"`
 	OpenEndpoint   string `json:"OpenEndpoint"`
@@ -29,10 +35,119 @@ type oamCfg struct {
 	NgcTestData    string `json:"NgcTestData"`
 	ServerCertPath string `json:"ServerCertPath"`
 	ServerKeyPath  string `json:"ServerKeyPath"`
+	// Log level (err, info, debug...), unchanged if empty
+	LogLevel string `json:"LogLevel"`
+	// Interval in seconds of the checks of the configuration file changes,
+	// 0 reloads the configuration only on SIGHUP
+	ConfigWatchInterval int `json:"ConfigWatchInterval"`
 }
 
 var log = logger.DefaultLogger.WithField("oam-main", nil)
 
+// oamLiveSettings are the settings of oamCfg applied by a reload, the server
+// certificate only if it was loaded at start
+var oamLiveSettings = map[string]bool{
+	"NgcEndpoint":    true,
+	"NgcType":        true,
+	"NgcTestData":    true,
+	"LogLevel":       true,
+	"ServerCertPath": true,
+	"ServerKeyPath":  true,
+}
+
+// setLogLevel sets the level of the logger if configured
+func setLogLevel(level string) error {
+
+	if level == "" {
+		return nil
+	}
+	lvl, err := logger.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	logger.SetLevel(lvl)
+	return nil
+}
+
+// reloadConfig reads the configuration file again and applies the NGC
+// settings, the log level and the server certificate. The NGC test data is
+// loaded again if the NGC settings changed, the records added since the
+// previous load are kept. The other settings changed in the file need a
+// restart. Nothing is applied if the configuration is invalid.
+func reloadConfig(cfg *oamCfg, certs *config.CertReloader) error {
+
+	var (
+		newCfg          oamCfg
+		records         []oam.AFService
+		applied         []string
+		restartRequired []string
+	)
+
+	if err := config.LoadJSONConfig(cfgPath, &newCfg); err != nil {
+		return err
+	}
+	if newCfg.LogLevel != "" {
+		if _, err := logger.ParseLevel(newCfg.LogLevel); err != nil {
+			return err
+		}
+	}
+	if newCfg.NgcType != "APISTUB" {
+		return errors.New("Unsupported NgcType " + newCfg.NgcType)
+	}
+	ngcChange := cfg.NgcEndpoint != newCfg.NgcEndpoint ||
+		cfg.NgcType != newCfg.NgcType ||
+		cfg.NgcTestData != newCfg.NgcTestData
+	if ngcChange {
+		var err error
+		if records, err = oam.APIStubLoadTestData(
+			newCfg.NgcTestData); err != nil {
+			return err
+		}
+	}
+	// The certificate is swapped only if it is valid, the settings below
+	// are all validated
+	if certs != nil {
+		if err := certs.Reload(newCfg.ServerCertPath,
+			newCfg.ServerKeyPath); err != nil {
+			return err
+		}
+	}
+
+	changed := config.ChangedFields(*cfg, newCfg)
+	for _, f := range changed {
+		if oamLiveSettings[f] &&
+			(certs != nil || !strings.HasPrefix(f, "Server")) {
+			applied = append(applied, f)
+		} else {
+			restartRequired = append(restartRequired, f)
+		}
+	}
+
+	if ngcChange {
+		if err := oam.ReloadProxy(newCfg.NgcEndpoint, newCfg.NgcType,
+			records); err != nil {
+			return err
+		}
+		cfg.NgcEndpoint = newCfg.NgcEndpoint
+		cfg.NgcType = newCfg.NgcType
+		cfg.NgcTestData = newCfg.NgcTestData
+	}
+	if err := setLogLevel(newCfg.LogLevel); err != nil {
+		return err
+	}
+	cfg.LogLevel = newCfg.LogLevel
+	if certs != nil {
+		cfg.ServerCertPath = newCfg.ServerCertPath
+		cfg.ServerKeyPath = newCfg.ServerKeyPath
+	}
+
+	log.Infof("OAM configuration reloaded, applied: %v", applied)
+	if len(restartRequired) != 0 {
+		log.Infof("OAM restart required for: %v", restartRequired)
+	}
+	return nil
+}
+
 func main() {
 
 	lvl, err := logger.ParseLevel("info")
@@ -43,11 +158,15 @@ func main() {
 	logger.SetLevel(lvl)
 
 	var cfg oamCfg
-	err = config.LoadJSONConfig("./configs/oam.json", &cfg)
+	err = config.LoadJSONConfig(cfgPath, &cfg)
 	if err != nil {
 		log.Errf("Failed to load config: %s", err.Error())
 		os.Exit(1)
 	}
+	if err = setLogLevel(cfg.LogLevel); err != nil {
+		log.Errf("Failed to parse log level: %s", err.Error())
+		os.Exit(1)
+	}
 	log.Infof("LocalConfig: %s, %s, %s, %s, %s, %s, %s, %s\n",
 		cfg.TLSEndpoint,
 		cfg.OpenEndpoint,
@@ -79,6 +198,28 @@ func main() {
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 	}
+
+	// The certificate of the server is replaced on configuration reload,
+	// the server loads it from the files if it cannot be loaded now
+	certFile, keyFile := cfg.ServerCertPath, cfg.ServerKeyPath
+	certs, err := config.NewCertReloader(certFile, keyFile)
+	if err != nil {
+		log.Errf("Failed to load the server certificate: %s", err.Error())
+	} else {
+		serverOAM.TLSConfig = &tls.Config{
+			GetCertificate: certs.GetCertificate}
+		certFile, keyFile = "", ""
+	}
+
+	// Reloads the configuration on SIGHUP or when the file changes
+	go config.WatchReload(context.Background(), []string{cfgPath},
+		time.Duration(cfg.ConfigWatchInterval)*time.Second, func() {
+			if rerr := reloadConfig(&cfg, certs); rerr != nil {
+				log.Errf("OAM configuration reload failed: %s",
+					rerr.Error())
+			}
+		})
+
 	if HTTP2Enabled == true {
 		if err = http2.ConfigureServer(serverOAM, &http2.Server{}); err != nil {
 			log.Errf("OAM failed at configuring HTTP2 server ")
@@ -86,8 +227,8 @@ func main() {
 		}
 
 		log.Infof("OAM HTTP2 Server Listening on:  %s\n", cfg.OpenEndpoint)
-		if err = serverOAM.ListenAndServeTLS(cfg.ServerCertPath,
-			cfg.ServerKeyPath); err != http.ErrServerClosed {
+		if err = serverOAM.ListenAndServeTLS(certFile,
+			keyFile); err != http.ErrServerClosed {
 			log.Errf("HTTP2: OAM CNCA server error: " + err.Error())
 			os.Exit(1)
 		}
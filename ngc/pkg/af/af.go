@@ -5,7 +5,9 @@ package af
 
 import (
 	"context"
+	"crypto/tls"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/gorilla/handlers"
@@ -28,8 +30,15 @@ type TransactionIDs map[int]TrafficInfluSub
 // NotifSubscryptions type
 type NotifSubscryptions map[string]map[string]TrafficInfluSub
 
-// Store the  Access token
-var nefAccessToken string
+// TestNotifications type, the last test notification received per AF
+// transaction ID
+type TestNotifications map[string]EventNotification
+
+// Source of the NEF access tokens of the AF, replaced on reload
+var (
+	nefTokenMu     sync.Mutex
+	nefTokenSource *oauth2.TokenSource
+)
 
 // ServerConfig struct
 type ServerConfig struct {
@@ -39,6 +48,14 @@ type ServerConfig struct {
 	UIEndpoint     string `json:"UIEndpoint"`
 	ServerCertPath string `json:"ServerCertPath"`
 	ServerKeyPath  string `json:"ServerKeyPath"`
+	// Require an OAuth2 access token of the NEF with the notifications
+	NotifOAuth2 bool `json:"NotifOAuth2"`
+	// Root CA certificate verifying the client certificates of the NEF on
+	// the notification server, the certificate authenticates the NEF
+	NotifClientCACert string `json:"NotifClientCACert"`
+	// Identity of the NEF in its client certificate: subject DN or CN, DNS,
+	// URI or email SAN, NEF if empty
+	NotifClientIdentity string `json:"NotifClientIdentity"`
 }
 
 //Config struct
@@ -48,13 +65,31 @@ type Config struct {
 	LocationPrefixPfd string       `json:"LocationPrefixPfd"`
 	SrvCfg            ServerConfig `json:"ServerConfig"`
 	CliCfg            CliConfig    `json:"CliConfig"`
+	// OAuth2 configuration file of the AF, configs/oauth2.json if empty
+	OAuth2ConfigPath string `json:"OAuth2ConfigPath"`
+	// Log level (err, info, debug...), unchanged if empty
+	LogLevel string `json:"LogLevel"`
+	// Interval in seconds of the checks of the configuration file changes,
+	// 0 reloads the configuration only on SIGHUP
+	ConfigWatchInterval int `json:"ConfigWatchInterval"`
 }
 
 //Context struct
 type Context struct {
 	subscriptions NotifSubscryptions
 	transactions  TransactionIDs
-	cfg           Config
+	testNotifs    TestNotifications
+	// testNotifsMu protects testNotifs, the notifications are received
+	// concurrently
+	testNotifsMu sync.Mutex
+	cfg          Config
+	// Path of the configuration file, read again on reload
+	cfgPath string
+	// cfgMu protects the settings of cfg applied by a reload
+	cfgMu    sync.RWMutex
+	reloadMu sync.Mutex
+	// Certificate of the CNCA and notification servers, replaced on reload
+	certs *config.CertReloader
 }
 
 var (
@@ -80,9 +115,22 @@ func runServer(ctx context.Context, AfCtx *Context) error {
 
 	AfCtx.transactions = make(TransactionIDs)
 	AfCtx.subscriptions = make(NotifSubscryptions)
+	AfCtx.testNotifs = make(TestNotifications)
 	AfRouter = NewAFRouter(AfCtx)
 	NotifRouter = NewNotifRouter(AfCtx)
 
+	// The certificate of the servers is replaced on configuration reload,
+	// the servers load it from the files if it cannot be loaded now
+	certFile := AfCtx.cfg.SrvCfg.ServerCertPath
+	keyFile := AfCtx.cfg.SrvCfg.ServerKeyPath
+	certs, cerr := config.NewCertReloader(certFile, keyFile)
+	if cerr != nil {
+		log.Errf("AF failed at loading the server certificate: %v", cerr)
+	} else {
+		AfCtx.certs = certs
+		certFile, keyFile = "", ""
+	}
+
 	serverCNCA := &http.Server{
 		Addr:         AfCtx.cfg.SrvCfg.CNCAEndpoint,
 		Handler:      handlers.CORS(headersOK, originsOK, methodsOK)(AfRouter),
@@ -90,6 +138,11 @@ func runServer(ctx context.Context, AfCtx *Context) error {
 		WriteTimeout: 10 * time.Second,
 	}
 
+	if AfCtx.certs != nil {
+		serverCNCA.TLSConfig = &tls.Config{
+			GetCertificate: AfCtx.certs.GetCertificate}
+	}
+
 	if HTTP2Enabled == true {
 		if err = http2.ConfigureServer(
 			serverCNCA, &http2.Server{}); err != nil {
@@ -104,6 +157,18 @@ func runServer(ctx context.Context, AfCtx *Context) error {
 		WriteTimeout: 10 * time.Second,
 	}
 
+	if serverNotif.TLSConfig, err = notifTLSConfig(
+		AfCtx.cfg.SrvCfg); err != nil {
+		log.Errf("AF failed at configuring the NEF client certificates")
+		return err
+	}
+	if AfCtx.certs != nil {
+		if serverNotif.TLSConfig == nil {
+			serverNotif.TLSConfig = &tls.Config{}
+		}
+		serverNotif.TLSConfig.GetCertificate = AfCtx.certs.GetCertificate
+	}
+
 	if err = http2.ConfigureServer(serverNotif, &http2.Server{}); err != nil {
 		log.Errf("AF failed at configuring HTTP2 server (NEF Server)")
 		return err
@@ -111,13 +176,31 @@ func runServer(ctx context.Context, AfCtx *Context) error {
 
 	if AfCtx.cfg.CliCfg.OAuth2Support {
 		log.Infoln("Fetching NEF access token")
-		if fetchNEFAuthorizationToken() != nil {
+		if initNEFAuthorizationToken(AfCtx.cfg) != nil {
 			log.Infoln("Failed to get access token")
-			return err
+			// The token server may not be up yet, the token is
+			// requested again with the first request to the NEF
+			if AfCtx.cfg.CliCfg.OAuth2TokenURL == "" {
+				return err
+			}
 		}
 	} else {
 		log.Infoln("OAuth2 DISABLED")
 	}
+	if AfCtx.cfg.SrvCfg.NotifOAuth2 && oauth2.SharedKeySigning() {
+		log.Err("NEF notification tokens verified with the HS256 shared " +
+			"key: its holders can forge them, the notification " +
+			"authentication is void")
+	}
+
+	// Reloads the configuration on SIGHUP or when the file changes
+	go config.WatchReload(ctx, []string{AfCtx.cfgPath},
+		time.Duration(AfCtx.cfg.ConfigWatchInterval)*time.Second,
+		func() {
+			if _, rerr := AfCtx.reloadConfig(); rerr != nil {
+				log.Errf("AF configuration reload failed: %v", rerr)
+			}
+		})
 
 	stopServerCh := make(chan bool, 2)
 	go func(stopServerCh chan bool) {
@@ -138,8 +221,7 @@ func runServer(ctx context.Context, AfCtx *Context) error {
 		log.Infof("Serving AF Notifications on: %s",
 			AfCtx.cfg.SrvCfg.NotifPort)
 		if err = serverNotif.ListenAndServeTLS(
-			AfCtx.cfg.SrvCfg.ServerCertPath,
-			AfCtx.cfg.SrvCfg.ServerKeyPath); err != http.ErrServerClosed {
+			certFile, keyFile); err != http.ErrServerClosed {
 
 			log.Errf("AF Notifications server error: " + err.Error())
 		}
@@ -149,8 +231,7 @@ func runServer(ctx context.Context, AfCtx *Context) error {
 	if HTTP2Enabled == true {
 		log.Infof("Serving AF (CNCA HTTP2 Requests) on: %s",
 			AfCtx.cfg.SrvCfg.CNCAEndpoint)
-		err = serverCNCA.ListenAndServeTLS(AfCtx.cfg.SrvCfg.ServerCertPath,
-			AfCtx.cfg.SrvCfg.ServerKeyPath)
+		err = serverCNCA.ListenAndServeTLS(certFile, keyFile)
 	} else {
 		log.Infof("Serving AF (CNCA HTTP Requests) on: %s",
 			AfCtx.cfg.SrvCfg.CNCAEndpoint)
@@ -179,14 +260,23 @@ func printConfig(cfg Config) {
 	log.Infoln("ServerCertPath: ", cfg.SrvCfg.ServerCertPath)
 	log.Infoln("ServerKeyPath: ", cfg.SrvCfg.ServerKeyPath)
 	log.Infoln("UIEndpoint: ", cfg.SrvCfg.UIEndpoint)
+	log.Infoln("NotifOAuth2: ", cfg.SrvCfg.NotifOAuth2)
+	log.Infoln("NotifClientCACert: ", cfg.SrvCfg.NotifClientCACert)
+	log.Infoln("NotifClientIdentity: ", cfg.SrvCfg.NotifClientIdentity)
 	log.Infoln("------------------------- CLIENT TO NEF ---------------------")
 	log.Infoln("Protocol: ", cfg.CliCfg.Protocol)
 	log.Infoln("NEFPort: ", cfg.CliCfg.NEFPort)
 	log.Infoln("NEFBasePath: ", cfg.CliCfg.NEFBasePath)
 	log.Infoln("NEFPFDBasePath: ", cfg.CliCfg.NEFPFDBasePath)
+	log.Infoln("NEFQoSBasePath: ", cfg.CliCfg.NEFQoSBasePath)
 	log.Infoln("UserAgent: ", cfg.CliCfg.UserAgent)
 	log.Infoln("NEFCliCertPath: ", cfg.CliCfg.NEFCliCertPath)
+	log.Infoln("AFClientCertPath: ", cfg.CliCfg.AFClientCertPath)
 	log.Infoln("OAuth2Support: ", cfg.CliCfg.OAuth2Support)
+	log.Infoln("OAuth2TokenURL: ", cfg.CliCfg.OAuth2TokenURL)
+	log.Infoln("OAuth2ConfigPath: ", cfg.OAuth2ConfigPath)
+	log.Infoln("LogLevel: ", cfg.LogLevel)
+	log.Infoln("ConfigWatchInterval: ", cfg.ConfigWatchInterval)
 	log.Infoln("*************************************************************")
 
 }
@@ -196,6 +286,7 @@ func Run(parentCtx context.Context, cfgPath string) error {
 
 	var AfCtx Context
 
+	AfCtx.cfgPath = cfgPath
 	// load AF configuration from file
 	err := config.LoadJSONConfig(cfgPath, &AfCtx.cfg)
 
@@ -203,25 +294,95 @@ func Run(parentCtx context.Context, cfgPath string) error {
 		log.Errf("Failed to load AF configuration: %v", err)
 		return err
 	}
+	if err = setLogLevel(AfCtx.cfg.LogLevel); err != nil {
+		log.Errf("Invalid AF log level: %v", err)
+		return err
+	}
+	if AfCtx.cfg.OAuth2ConfigPath != "" {
+		oauth2.CfgPath = AfCtx.cfg.OAuth2ConfigPath
+	}
 	printConfig(AfCtx.cfg)
 
 	return runServer(parentCtx, &AfCtx)
 }
 
-func fetchNEFAuthorizationToken() error {
+// initNEFAuthorizationToken creates the token source of the AF and gets the
+// first access token. The AF ID is the client ID of the token requests.
+func initNEFAuthorizationToken(cfg Config) error {
 
-	var err error
+	tokens := oauth2.NewTokenSource(cfg.CliCfg.OAuth2TokenURL,
+		cfg.AfID, cfg.CliCfg.OAuth2ClientSecret, oauth2.AFScopes,
+		tokenHTTPClient(cfg.CliCfg))
 
-	nefAccessToken, err = oauth2.GetAccessToken()
-	if err != nil {
-		log.Errf("Failed to Fetch Access Token ")
+	nefTokenMu.Lock()
+	nefTokenSource = tokens
+	nefTokenMu.Unlock()
+
+	if _, err := tokens.Token(); err != nil {
+		log.Errf("Failed to Fetch Access Token: %v", err)
 		return err
 	}
-	log.Errf("Got Access Token ", nefAccessToken)
+	log.Infoln("Got Access Token")
 	return nil
 }
 
+// fetchNEFAuthorizationToken replaces the access token rejected by the NEF
+func fetchNEFAuthorizationToken() error {
+
+	tokens := currentNEFTokenSource()
+	if tokens == nil {
+		return nil
+	}
+	token, err := tokens.Token()
+	if err != nil {
+		return err
+	}
+	tokens.Invalidate(token)
+	_, err = tokens.Token()
+	return err
+}
+
+// getNEFAuthorizationToken returns the access token, refreshed before its
+// expiry. An empty token is returned if none can be obtained, the NEF then
+// rejects the request.
 func getNEFAuthorizationToken() (token string, err error) {
 
-	return nefAccessToken, nil
+	tokens := currentNEFTokenSource()
+	if tokens == nil {
+		return "", nil
+	}
+	if token, err = tokens.Token(); err != nil {
+		log.Errf("Failed to get the access token: %v", err)
+	}
+	return token, nil
+}
+
+// invalidateNEFAuthorizationToken drops the access token rejected by the
+// NEF, a new one is requested by the next getNEFAuthorizationToken
+func invalidateNEFAuthorizationToken(token string) {
+
+	if tokens := currentNEFTokenSource(); tokens != nil {
+		tokens.Invalidate(token)
+	}
+}
+
+// currentNEFTokenSource returns the token source of the AF, nil if OAuth2 is
+// not initialized
+func currentNEFTokenSource() *oauth2.TokenSource {
+
+	nefTokenMu.Lock()
+	defer nefTokenMu.Unlock()
+	return nefTokenSource
+}
+
+// tokenHTTPClient returns the HTTP client of the token endpoint with the TLS
+// configuration of the NEF connections
+func tokenHTTPClient(cfg CliConfig) *http.Client {
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	if cfg.NEFCliCertPath == "" {
+		return client
+	}
+	client.Transport = &http.Transport{TLSClientConfig: nefTLSConfig(&cfg)}
+	return client
 }
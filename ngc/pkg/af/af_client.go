@@ -16,6 +16,7 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
+	"strings"
 	"time"
 
 	"golang.org/x/net/http2"
@@ -50,6 +51,12 @@ type Client struct {
 	PfdManagementAppDeleteAPI *PfdManagementTransactionAppDeleteAPIService
 	PfdManagementAppPutAPI    *PfdManagementTransactionAppPutAPIService
 	PfdManagementAppPatchAPI  *PfdManagementTransactionAppPatchAPIService
+	QosSubGetAllAPI           *AsSessionWithQoSSubGetAllAPIService
+	QosSubGetAPI              *AsSessionWithQoSSubGetAPIService
+	QosSubPostAPI             *AsSessionWithQoSSubPostAPIService
+	QosSubPutAPI              *AsSessionWithQoSSubPutAPIService
+	QosSubPatchAPI            *AsSessionWithQoSSubPatchAPIService
+	QosSubDeleteAPI           *AsSessionWithQoSSubDeleteAPIService
 }
 
 type service struct {
@@ -79,21 +86,10 @@ func NewClient(cfg *CliConfig) *Client {
 	}
 	if cfg.HTTPClient == nil || !TestAf {
 
-		CACert, err := ioutil.ReadFile(cfg.NEFCliCertPath)
-		if err != nil {
-			log.Errf("Error: %v", err)
-		}
-
-		CACertPool := x509.NewCertPool()
-		CACertPool.AppendCertsFromPEM(CACert)
-
 		cfg.HTTPClient = &http.Client{
 			Timeout: 15 * time.Second,
 			Transport: &http2.Transport{
-				TLSClientConfig: &tls.Config{
-					RootCAs:    CACertPool,
-					MinVersion: tls.VersionTLS12,
-				},
+				TLSClientConfig: nefTLSConfig(cfg),
 			},
 		}
 	}
@@ -133,13 +129,78 @@ func NewClient(cfg *CliConfig) *Client {
 		(*PfdManagementTransactionAppPutAPIService)(&c.common)
 	c.PfdManagementAppPatchAPI =
 		(*PfdManagementTransactionAppPatchAPIService)(&c.common)
+	c.QosSubGetAllAPI = (*AsSessionWithQoSSubGetAllAPIService)(&c.common)
+	c.QosSubGetAPI = (*AsSessionWithQoSSubGetAPIService)(&c.common)
+	c.QosSubPostAPI = (*AsSessionWithQoSSubPostAPIService)(&c.common)
+	c.QosSubPutAPI = (*AsSessionWithQoSSubPutAPIService)(&c.common)
+	c.QosSubPatchAPI = (*AsSessionWithQoSSubPatchAPIService)(&c.common)
+	c.QosSubDeleteAPI = (*AsSessionWithQoSSubDeleteAPIService)(&c.common)
 
 	return c
 }
 
+// nefTLSConfig returns the TLS configuration of the connections to the NEF,
+// with the client certificate of the AF if mutual TLS is configured
+func nefTLSConfig(cfg *CliConfig) *tls.Config {
+
+	CACert, err := ioutil.ReadFile(cfg.NEFCliCertPath)
+	if err != nil {
+		log.Errf("Error: %v", err)
+	}
+
+	CACertPool := x509.NewCertPool()
+	CACertPool.AppendCertsFromPEM(CACert)
+
+	tlsCfg := &tls.Config{
+		RootCAs:    CACertPool,
+		MinVersion: tls.VersionTLS12,
+	}
+	if cfg.AFClientCertPath != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.AFClientCertPath,
+			cfg.AFClientKeyPath)
+		if err != nil {
+			log.Errf("AF client certificate loading Error: %v", err)
+		} else {
+			tlsCfg.Certificates = []tls.Certificate{cert}
+		}
+	}
+	return tlsCfg
+}
+
 // callAPI do the request.
 func (c *Client) callAPI(request *http.Request) (*http.Response, error) {
-	return c.cfg.HTTPClient.Do(request)
+
+	rsp, err := c.cfg.HTTPClient.Do(request)
+	if err != nil || rsp.StatusCode != http.StatusUnauthorized ||
+		!c.cfg.OAuth2Support || request.GetBody == nil {
+		return rsp, err
+	}
+
+	// The token was rejected before its expiry (e.g. the signing key was
+	// rotated), the request is sent once again with a new token
+	body, err := request.GetBody()
+	if err != nil {
+		return rsp, nil
+	}
+	invalidateNEFAuthorizationToken(strings.TrimPrefix(
+		request.Header.Get("Authorization"), "Bearer "))
+	auth, err := getNEFAuthorizationToken()
+	if err != nil || auth == "" {
+		return rsp, nil
+	}
+	if cerr := rsp.Body.Close(); cerr != nil {
+		log.Errf("Failed to close the response: %v", cerr)
+	}
+
+	log.Infoln("Access token rejected, retrying with a new token")
+	retry := request.WithContext(request.Context())
+	retry.Body = body
+	retry.Header = make(http.Header, len(request.Header))
+	for h, v := range request.Header {
+		retry.Header[h] = v
+	}
+	retry.Header.Set("Authorization", "Bearer "+auth)
+	return c.cfg.HTTPClient.Do(retry)
 }
 
 func genNewRequest(body io.Reader, url string,
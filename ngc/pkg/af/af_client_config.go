@@ -14,24 +14,40 @@ type CliConfig struct {
 	NEFPort        string `json:"NEFPort"`
 	NEFBasePath    string `json:"NEFBasePath"`
 	NEFPFDBasePath string `json:"NEFPFDBasePath"`
+	NEFQoSBasePath string `json:"NEFQoSBasePath"`
 	UserAgent      string `json:"UserAgent"`
 	NEFCliCertPath string `json:"NEFCliCertPath"`
 	HTTPClient     *http.Client
 	OAuth2Support  bool `json:"OAuth2Support"`
+	// Token endpoint of the OAuth2 server, the tokens are generated with
+	// the local OAuth2 configuration if empty
+	OAuth2TokenURL string `json:"OAuth2TokenURL"`
+	// Secret of the AF authenticating the token requests
+	OAuth2ClientSecret string `json:"OAuth2ClientSecret"`
+	// Client certificate and key of the AF for mutual TLS with the NEF
+	AFClientCertPath string `json:"AFClientCertPath"`
+	AFClientKeyPath  string `json:"AFClientKeyPath"`
 }
 
 // NewConfiguration function initializes client configuration
 func NewConfiguration(afCtx *Context) *CliConfig {
 
+	afCtx.cfgMu.RLock()
+	defer afCtx.cfgMu.RUnlock()
+
 	cfg := &CliConfig{
 		Protocol:       afCtx.cfg.CliCfg.Protocol,
 		NEFPort:        afCtx.cfg.CliCfg.NEFPort,
 		NEFHostname:    afCtx.cfg.CliCfg.NEFHostname,
 		NEFBasePath:    afCtx.cfg.CliCfg.NEFBasePath,
 		NEFPFDBasePath: afCtx.cfg.CliCfg.NEFPFDBasePath,
+		NEFQoSBasePath: afCtx.cfg.CliCfg.NEFQoSBasePath,
 		UserAgent:      afCtx.cfg.CliCfg.UserAgent,
 		NEFCliCertPath: afCtx.cfg.CliCfg.NEFCliCertPath,
 		OAuth2Support:  afCtx.cfg.CliCfg.OAuth2Support,
+
+		AFClientCertPath: afCtx.cfg.CliCfg.AFClientCertPath,
+		AFClientKeyPath:  afCtx.cfg.CliCfg.AFClientKeyPath,
 	}
 
 	return cfg
@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package af
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/open-ness/epcforedge/ngc/pkg/oauth2"
+)
+
+// notifTLSConfig returns the TLS configuration of the notification server
+// verifying the client certificates of the NEF, nil if none is configured
+func notifTLSConfig(cfg ServerConfig) (*tls.Config, error) {
+
+	if cfg.NotifClientCACert == "" {
+		return nil, nil
+	}
+	CACert, err := ioutil.ReadFile(filepath.Clean(cfg.NotifClientCACert))
+	if err != nil {
+		return nil, err
+	}
+	CACertPool := x509.NewCertPool()
+	if !CACertPool.AppendCertsFromPEM(CACert) {
+		return nil, errors.New("Invalid NotifClientCACert")
+	}
+	// The NEF may also authenticate by its access token
+	return &tls.Config{
+		ClientCAs:  CACertPool,
+		ClientAuth: tls.VerifyClientCertIfGiven,
+		MinVersion: tls.VersionTLS12,
+	}, nil
+}
+
+// notifAudience returns the audience of the NEF access tokens sent to the
+// notification server, its host and port
+func notifAudience(cfg ServerConfig) string {
+
+	_, port, err := net.SplitHostPort(cfg.NotifPort)
+	if err != nil {
+		port = strings.TrimPrefix(cfg.NotifPort, ":")
+	}
+	return net.JoinHostPort(cfg.Hostname, port)
+}
+
+// nefCertificate returns true if an identity of the certificate is the NEF
+// identity of the configuration
+func nefCertificate(cfg ServerConfig, cert *x509.Certificate) bool {
+
+	identity := cfg.NotifClientIdentity
+	if identity == "" {
+		identity = oauth2.NefSubject
+	}
+	ids := []string{cert.Subject.String(), cert.Subject.CommonName}
+	ids = append(ids, cert.DNSNames...)
+	ids = append(ids, cert.EmailAddresses...)
+	for _, u := range cert.URIs {
+		ids = append(ids, u.String())
+	}
+	for _, id := range ids {
+		if id == identity {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticateNotif checks that the notification is sent by the NEF: with a
+// client certificate of the NEF identity verified by the NotifClientCACert
+// or with an access token bound to the notification server whose subject is
+// the NEF granting the af-notification scope. A 401 response is sent if not.
+// The notifications are accepted as is if no authentication is configured.
+func authenticateNotif(w http.ResponseWriter, r *http.Request,
+	cfg ServerConfig) bool {
+
+	if !cfg.NotifOAuth2 && cfg.NotifClientCACert == "" {
+		return true
+	}
+
+	// The certificate chain was verified during the handshake, the CA may
+	// also issue the certificates of other NFs
+	if cfg.NotifClientCACert != "" && r.TLS != nil &&
+		len(r.TLS.VerifiedChains) > 0 {
+		if nefCertificate(cfg, r.TLS.VerifiedChains[0][0]) {
+			return true
+		}
+		log.Infoln("Notification certificate is not the NEF one")
+	}
+
+	if cfg.NotifOAuth2 {
+		reqToken := r.Header.Get("Authorization")
+		if strings.HasPrefix(reqToken, "Bearer ") {
+			claims, _, err := oauth2.ParseAudienceAccessToken(
+				strings.TrimPrefix(reqToken, "Bearer "), notifAudience(cfg))
+			if err == nil && claims.Subject == oauth2.NefSubject &&
+				claims.HasScope(oauth2.ScopeAfNotification) {
+				return true
+			}
+			log.Infoln("Notification token rejected")
+		}
+	}
+
+	log.Infoln("Unauthenticated notification rejected")
+	w.Header().Set("WWW-Authenticate", "Bearer realm="+r.RequestURI)
+	w.WriteHeader(http.StatusUnauthorized)
+	return false
+}
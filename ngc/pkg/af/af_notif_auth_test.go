@@ -0,0 +1,162 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package af
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/open-ness/epcforedge/ngc/pkg/oauth2"
+)
+
+var _ = ginkgo.Describe("AF notification authentication", func() {
+
+	var (
+		dir       string
+		savedPath string
+		afCtx     *Context
+		router    http.Handler
+	)
+
+	notifyTLS := func(auth string, state *tls.ConnectionState) int {
+		body, err := ioutil.ReadFile(
+			"./testdata/AF_SB_NOTIFY_POST006.json")
+		Expect(err).ShouldNot(HaveOccurred())
+		req, err := http.NewRequest(http.MethodPost,
+			"https://localhost:8081"+DefaultNotifURL, bytes.NewReader(body))
+		Expect(err).ShouldNot(HaveOccurred())
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		req.TLS = state
+		rsp := httptest.NewRecorder()
+		router.ServeHTTP(rsp, req)
+		return rsp.Code
+	}
+
+	notify := func(auth string) int {
+		return notifyTLS(auth, nil)
+	}
+
+	// audienceToken returns a token of the subject bound to the audience
+	audienceToken := func(subject string, scope string,
+		audience string) string {
+		t, err := oauth2.GetAudienceAccessToken(subject, scope, audience,
+			time.Minute)
+		Expect(err).ShouldNot(HaveOccurred())
+		return "Bearer " + t
+	}
+
+	token := func(subject string, scope string) string {
+		return audienceToken(subject, scope, "localhost:8051")
+	}
+
+	// verifiedCert returns the TLS state of a client certificate verified
+	// during the handshake
+	verifiedCert := func(cn string) *tls.ConnectionState {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: cn}}
+		return &tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{cert}}}
+	}
+
+	ginkgo.BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "afnotifauth")
+		Expect(err).ShouldNot(HaveOccurred())
+		savedPath = oauth2.CfgPath
+		oauth2.CfgPath = filepath.Join(dir, "oauth2.json")
+		Expect(ioutil.WriteFile(oauth2.CfgPath,
+			[]byte(`{"signingkey": "TESTKEY", "algorithm": "HS256",
+			"expiration": 600}`),
+			0600)).ShouldNot(HaveOccurred())
+
+		afCtx = &Context{testNotifs: make(TestNotifications),
+			transactions: make(TransactionIDs)}
+		afCtx.cfg.SrvCfg.NotifOAuth2 = true
+		afCtx.cfg.SrvCfg.Hostname = "localhost"
+		afCtx.cfg.SrvCfg.NotifPort = ":8051"
+		router = NewNotifRouter(afCtx)
+	})
+
+	ginkgo.AfterEach(func() {
+		oauth2.CfgPath = savedPath
+		os.RemoveAll(dir)
+	})
+
+	ginkgo.It("Rejects the notifications without a valid NEF token", func() {
+
+		Expect(notify("")).To(Equal(http.StatusUnauthorized))
+		Expect(notify("Bearer invalid.token")).To(
+			Equal(http.StatusUnauthorized))
+		Expect(notify(token(oauth2.NefSubject,
+			oauth2.ScopeTrafficInfluence))).To(
+			Equal(http.StatusUnauthorized))
+		// The token of another NF is rejected even with the scope
+		Expect(notify(token("AF_01", oauth2.ScopeAfNotification))).To(
+			Equal(http.StatusUnauthorized))
+		Expect(notify(token(oauth2.NefSubject,
+			oauth2.ScopeAfNotification))).To(Equal(http.StatusOK))
+	})
+
+	ginkgo.It("Rejects the NEF tokens of another audience", func() {
+
+		Expect(notify(audienceToken(oauth2.NefSubject,
+			oauth2.ScopeAfNotification, "other-af:8051"))).To(
+			Equal(http.StatusUnauthorized))
+		Expect(notify(audienceToken(oauth2.NefSubject,
+			oauth2.ScopeAfNotification, "localhost:8050"))).To(
+			Equal(http.StatusUnauthorized))
+
+		// The tokens without audience are not bound to the AF
+		t, err := oauth2.GetNEFAccessTokenFromNRF(oauth2.AccessTokenReq{
+			NfInstanceID: oauth2.NefSubject,
+			Scope:        oauth2.ScopeAfNotification})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(notify("Bearer " + t)).To(Equal(http.StatusUnauthorized))
+	})
+
+	ginkgo.It("Accepts only the client certificates of the NEF", func() {
+
+		afCtx.cfg.SrvCfg.NotifOAuth2 = false
+		afCtx.cfg.SrvCfg.NotifClientCACert = "root-ca.pem"
+
+		Expect(notifyTLS("", nil)).To(Equal(http.StatusUnauthorized))
+		// An AF certificate issued by the same CA
+		Expect(notifyTLS("", verifiedCert("AF_01"))).To(
+			Equal(http.StatusUnauthorized))
+		Expect(notifyTLS("", verifiedCert(oauth2.NefSubject))).To(
+			Equal(http.StatusOK))
+
+		afCtx.cfg.SrvCfg.NotifClientIdentity = "nef.example.org"
+		Expect(notifyTLS("", verifiedCert(oauth2.NefSubject))).To(
+			Equal(http.StatusUnauthorized))
+		Expect(notifyTLS("", verifiedCert("nef.example.org"))).To(
+			Equal(http.StatusOK))
+	})
+
+	ginkgo.It("Records the test notification apart from the events", func() {
+
+		// The transaction 1 of the test notification is not known
+		Expect(notify(token(oauth2.NefSubject,
+			oauth2.ScopeAfNotification))).To(Equal(http.StatusOK))
+
+		afCtx.testNotifsMu.Lock()
+		en, ok := afCtx.testNotifs["1"]
+		afCtx.testNotifsMu.Unlock()
+		Expect(ok).To(BeTrue())
+		Expect(en.SubscribedEvent).To(Equal(TestNotification))
+		Expect(afCtx.transactions).To(BeEmpty())
+		Expect(afCtx.subscriptions).To(BeEmpty())
+	})
+})
@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package af
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	logger "github.com/open-ness/common/log"
+	config "github.com/open-ness/epcforedge/ngc/pkg/config"
+)
+
+// configReloadResult reports the settings changed by a reload of the AF
+// configuration file
+type configReloadResult struct {
+	// Settings applied to the running AF
+	applied []string
+	// Settings changed in the file which need a restart of the AF
+	restartRequired []string
+}
+
+// afLiveSettings are the settings of Config applied by a reload besides the
+// client configuration, the server certificate only if it was loaded at
+// start
+var afLiveSettings = map[string]bool{
+	"LogLevel":              true,
+	"SrvCfg.NotifOAuth2":    true,
+	"SrvCfg.ServerCertPath": true,
+	"SrvCfg.ServerKeyPath":  true,
+}
+
+// afTokenSettings are the client settings used by the NEF token source
+var afTokenSettings = map[string]bool{
+	"CliCfg.OAuth2Support":      true,
+	"CliCfg.OAuth2TokenURL":     true,
+	"CliCfg.OAuth2ClientSecret": true,
+	"CliCfg.NEFCliCertPath":     true,
+	"CliCfg.AFClientCertPath":   true,
+	"CliCfg.AFClientKeyPath":    true,
+}
+
+// srvConfig returns the server configuration of the AF
+func (afCtx *Context) srvConfig() ServerConfig {
+
+	afCtx.cfgMu.RLock()
+	defer afCtx.cfgMu.RUnlock()
+	return afCtx.cfg.SrvCfg
+}
+
+// validateConfig checks the settings of a reloaded configuration
+func validateConfig(cfg Config) error {
+
+	if cfg.LogLevel != "" {
+		if _, err := logger.ParseLevel(cfg.LogLevel); err != nil {
+			return err
+		}
+	}
+	if cfg.CliCfg.NEFCliCertPath != "" {
+		if _, err := ioutil.ReadFile(
+			filepath.Clean(cfg.CliCfg.NEFCliCertPath)); err != nil {
+			return err
+		}
+	}
+	if cfg.CliCfg.AFClientCertPath != "" {
+		if _, err := tls.LoadX509KeyPair(cfg.CliCfg.AFClientCertPath,
+			cfg.CliCfg.AFClientKeyPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setLogLevel sets the level of the logger if configured
+func setLogLevel(level string) error {
+
+	if level == "" {
+		return nil
+	}
+	lvl, err := logger.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	logger.SetLevel(lvl)
+	return nil
+}
+
+// reloadConfig reads the configuration file again and applies the client
+// configuration of the NEF, the log level, the notification OAuth2 setting
+// and the server certificate. The NEF access token is requested again if the
+// OAuth2 client settings changed. The other settings changed in the file need
+// a restart. Nothing is applied if the configuration is invalid.
+func (afCtx *Context) reloadConfig() (configReloadResult, error) {
+
+	var (
+		cfg         Config
+		res         configReloadResult
+		tokenChange bool
+	)
+
+	afCtx.reloadMu.Lock()
+	defer afCtx.reloadMu.Unlock()
+
+	if err := config.LoadJSONConfig(afCtx.cfgPath, &cfg); err != nil {
+		return res, err
+	}
+	if err := validateConfig(cfg); err != nil {
+		return res, err
+	}
+	if afCtx.certs != nil {
+		if err := afCtx.certs.Reload(cfg.SrvCfg.ServerCertPath,
+			cfg.SrvCfg.ServerKeyPath); err != nil {
+			return res, err
+		}
+	}
+	if err := setLogLevel(cfg.LogLevel); err != nil {
+		return res, err
+	}
+
+	afCtx.cfgMu.Lock()
+	for _, f := range config.ChangedFields(afCtx.cfg, cfg) {
+		if strings.HasPrefix(f, "CliCfg.") || (afLiveSettings[f] &&
+			(afCtx.certs != nil || !strings.HasPrefix(f, "SrvCfg.Server"))) {
+			res.applied = append(res.applied, f)
+			tokenChange = tokenChange || afTokenSettings[f]
+		} else {
+			res.restartRequired = append(res.restartRequired, f)
+		}
+	}
+	afCtx.cfg.CliCfg = cfg.CliCfg
+	afCtx.cfg.SrvCfg.NotifOAuth2 = cfg.SrvCfg.NotifOAuth2
+	afCtx.cfg.LogLevel = cfg.LogLevel
+	if afCtx.certs != nil {
+		afCtx.cfg.SrvCfg.ServerCertPath = cfg.SrvCfg.ServerCertPath
+		afCtx.cfg.SrvCfg.ServerKeyPath = cfg.SrvCfg.ServerKeyPath
+	}
+	tokenCfg := afCtx.cfg
+	afCtx.cfgMu.Unlock()
+
+	log.Infof("AF configuration reloaded, applied: %v", res.applied)
+	if len(res.restartRequired) != 0 {
+		log.Infof("AF restart required for: %v", res.restartRequired)
+	}
+
+	if tokenChange && tokenCfg.CliCfg.OAuth2Support {
+		log.Infoln("Fetching NEF access token")
+		if initNEFAuthorizationToken(tokenCfg) != nil {
+			// The token is requested again with the next request
+			log.Infoln("Failed to get access token")
+		}
+	}
+	return res, nil
+}
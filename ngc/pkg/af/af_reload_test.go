@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package af
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	config "github.com/open-ness/epcforedge/ngc/pkg/config"
+	"github.com/open-ness/epcforedge/ngc/pkg/oauth2"
+)
+
+var _ = ginkgo.Describe("AF configuration reload", func() {
+
+	var (
+		dir         string
+		savedPath   string
+		savedTokens *oauth2.TokenSource
+		afCtx       *Context
+	)
+
+	writeCfg := func(cfg Config) {
+		b, err := json.Marshal(cfg)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(ioutil.WriteFile(afCtx.cfgPath, b,
+			0600)).ShouldNot(HaveOccurred())
+	}
+
+	ginkgo.BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "afreload")
+		Expect(err).ShouldNot(HaveOccurred())
+		savedPath = oauth2.CfgPath
+		oauth2.CfgPath = filepath.Join(dir, "oauth2.json")
+		Expect(ioutil.WriteFile(oauth2.CfgPath,
+			[]byte(`{"signingkey": "TESTKEY", "algorithm": "HS256",
+			"expiration": 600}`),
+			0600)).ShouldNot(HaveOccurred())
+		savedTokens = currentNEFTokenSource()
+
+		afCtx = &Context{cfgPath: filepath.Join(dir, "af.json")}
+		Expect(config.LoadJSONConfig("./testdata/testconfigs/af.json",
+			&afCtx.cfg)).ShouldNot(HaveOccurred())
+		afCtx.cfg.CliCfg.OAuth2Support = false
+	})
+
+	ginkgo.AfterEach(func() {
+		nefTokenMu.Lock()
+		nefTokenSource = savedTokens
+		nefTokenMu.Unlock()
+		oauth2.CfgPath = savedPath
+		os.RemoveAll(dir)
+	})
+
+	ginkgo.It("Applies the client settings and reports the others", func() {
+
+		cfg := afCtx.cfg
+		cfg.AfID = "AF_RELOADED"
+		cfg.LogLevel = "info"
+		cfg.SrvCfg.NotifOAuth2 = !cfg.SrvCfg.NotifOAuth2
+		cfg.CliCfg.NEFPort = ":8099"
+		cfg.CliCfg.OAuth2Support = true
+		cfg.CliCfg.OAuth2TokenURL = ""
+		cfg.CliCfg.NEFCliCertPath = ""
+		writeCfg(cfg)
+
+		res, err := afCtx.reloadConfig()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(res.applied).Should(ContainElement("CliCfg.NEFPort"))
+		Expect(res.applied).Should(ContainElement("SrvCfg.NotifOAuth2"))
+		Expect(res.applied).Should(ContainElement("LogLevel"))
+		Expect(res.restartRequired).Should(ConsistOf("AfID"))
+
+		Expect(NewConfiguration(afCtx).NEFPort).Should(Equal(":8099"))
+		Expect(afCtx.srvConfig().NotifOAuth2).Should(
+			Equal(cfg.SrvCfg.NotifOAuth2))
+		Expect(afCtx.cfg.AfID).ShouldNot(Equal("AF_RELOADED"))
+
+		// The token source is created with the new OAuth2 settings
+		Expect(currentNEFTokenSource()).ShouldNot(Equal(savedTokens))
+		token, err := getNEFAuthorizationToken()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(token).ShouldNot(BeEmpty())
+	})
+
+	ginkgo.It("Keeps the configuration if the new one is invalid", func() {
+
+		cfg := afCtx.cfg
+		cfg.LogLevel = "verbose"
+		cfg.CliCfg.NEFPort = ":8099"
+		writeCfg(cfg)
+
+		_, err := afCtx.reloadConfig()
+		Expect(err).Should(HaveOccurred())
+		Expect(NewConfiguration(afCtx).NEFPort).ShouldNot(Equal(":8099"))
+	})
+})
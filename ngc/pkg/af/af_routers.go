@@ -72,6 +72,9 @@ func NewNotifRouter(afCtx *Context) *mux.Router {
 				r.Context(),
 				keyType("af-ctx"),
 				afCtx)
+			if !authenticateNotif(w, r, afCtx.srvConfig()) {
+				return
+			}
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	})
@@ -196,4 +199,48 @@ var afRoutes = Routes{
 		"/af/v1/pfd/transactions/{transactionId}/applications/{appId}",
 		PatchPfdAppTransaction,
 	},
+
+	// AS Session With QoS routes
+
+	Route{
+		"GetAllQosSubscriptions",
+		strings.ToUpper("Get"),
+		"/af/v1/qos/subscriptions",
+		GetAllQosSubscriptions,
+	},
+
+	Route{
+		"CreateQosSubscription",
+		strings.ToUpper("Post"),
+		"/af/v1/qos/subscriptions",
+		CreateQosSubscription,
+	},
+
+	Route{
+		"GetQosSubscription",
+		strings.ToUpper("Get"),
+		"/af/v1/qos/subscriptions/{subscriptionId}",
+		GetQosSubscription,
+	},
+
+	Route{
+		"PutQosSubscription",
+		strings.ToUpper("Put"),
+		"/af/v1/qos/subscriptions/{subscriptionId}",
+		PutQosSubscription,
+	},
+
+	Route{
+		"PatchQosSubscription",
+		strings.ToUpper("Patch"),
+		"/af/v1/qos/subscriptions/{subscriptionId}",
+		PatchQosSubscription,
+	},
+
+	Route{
+		"DeleteQosSubscription",
+		strings.ToUpper("Delete"),
+		"/af/v1/qos/subscriptions/{subscriptionId}",
+		DeleteQosSubscription,
+	},
 }
@@ -842,6 +842,29 @@ var _ = Describe("AF", func() {
 
 				})
 
+				Specify("Sending NOTIFY 006 request", func() {
+
+					By("Preparing test Notify request with unknown Trans ID")
+					ntfBody, err := ioutil.ReadFile(
+						"./testdata/AF_SB_NOTIFY_POST006.json")
+					Expect(err).ShouldNot(HaveOccurred())
+
+					ntfBodyBytes := bytes.NewReader(ntfBody)
+					req, err := http.NewRequest(http.MethodPost,
+						"http://localhost:8081/af/v1/notifications",
+						ntfBodyBytes)
+					Expect(err).ShouldNot(HaveOccurred())
+
+					By("Sending request")
+					resp := httptest.NewRecorder()
+					ctx := context.WithValue(req.Context(),
+						KeyType("af-ctx"), af.AfCtx)
+					af.NotifRouter.ServeHTTP(resp, req.WithContext(ctx))
+
+					Expect(resp.Code).To(Equal(http.StatusOK))
+
+				})
+
 			})
 
 			Specify("DELETE Subcription 01", func() {
@@ -2253,6 +2276,108 @@ var _ = Describe("AF", func() {
 
 	})
 
+	Describe("Cnca AS Session With QoS requests to AF : ", func() {
+
+		qosURL := "http://localhost:8080/af/v1/qos/subscriptions"
+		nefLoc := "https://localhost:8060/3gpp-as-session-with-qos/v1/" +
+			"AF_01/subscriptions/11111"
+
+		sendQosReq := func(method string, url string, body []byte,
+			status int, rspBody []byte) *httptest.ResponseRecorder {
+
+			req, err := http.NewRequest(method, url,
+				bytes.NewReader(body))
+			Expect(err).ShouldNot(HaveOccurred())
+
+			resp := httptest.NewRecorder()
+			ctx := context.WithValue(req.Context(),
+				KeyType("af-ctx"), af.AfCtx)
+			httpclient :=
+				testingAFClient(func(req *http.Request) *http.Response {
+					header := make(http.Header)
+					if req.Method == http.MethodPost {
+						header.Set("Location", nefLoc)
+					}
+					return &http.Response{
+						StatusCode: status,
+						Body: ioutil.NopCloser(
+							bytes.NewReader(rspBody)),
+						Header: header,
+					}
+				})
+
+			af.TestAf = true
+			af.SetHTTPClient(httpclient)
+			af.AfRouter.ServeHTTP(resp, req.WithContext(ctx))
+			af.TestAf = false
+			return resp
+		}
+
+		Context("QoS subscription requests", func() {
+			qosBody, _ := ioutil.ReadFile(
+				"./testdata/qos/AF_NB_QOS_POST001.json")
+			patchBody, _ := ioutil.ReadFile(
+				"./testdata/qos/AF_NB_QOS_PATCH001.json")
+
+			Specify("POST QoS subscription", func() {
+				resp := sendQosReq(http.MethodPost, qosURL, qosBody,
+					http.StatusCreated, qosBody)
+				Expect(resp.Code).To(Equal(http.StatusCreated))
+				Expect(resp.Header().Get("Location")).To(Equal(nefLoc))
+			})
+
+			Specify("POST QoS subscription rejected by NEF", func() {
+				pd := []byte(`{"title":"Invalid QoS","status":400}`)
+				resp := sendQosReq(http.MethodPost, qosURL, qosBody,
+					http.StatusBadRequest, pd)
+				Expect(resp.Code).To(Equal(http.StatusBadRequest))
+				Expect(resp.Body.Bytes()).To(Equal(pd))
+			})
+
+			Specify("POST QoS subscription invalid json", func() {
+				resp := sendQosReq(http.MethodPost, qosURL, []byte("{"),
+					http.StatusCreated, qosBody)
+				Expect(resp.Code).To(Equal(http.StatusBadRequest))
+			})
+
+			Specify("GET all QoS subscriptions", func() {
+				resp := sendQosReq(http.MethodGet, qosURL, nil,
+					http.StatusOK, []byte("["+string(qosBody)+"]"))
+				Expect(resp.Code).To(Equal(http.StatusOK))
+			})
+
+			Specify("GET QoS subscription", func() {
+				resp := sendQosReq(http.MethodGet, qosURL+"/11111", nil,
+					http.StatusOK, qosBody)
+				Expect(resp.Code).To(Equal(http.StatusOK))
+			})
+
+			Specify("GET QoS subscription not found", func() {
+				resp := sendQosReq(http.MethodGet, qosURL+"/11112", nil,
+					http.StatusNotFound, []byte(`{"title":"Not Found"}`))
+				Expect(resp.Code).To(Equal(http.StatusNotFound))
+			})
+
+			Specify("PUT QoS subscription", func() {
+				resp := sendQosReq(http.MethodPut, qosURL+"/11111", qosBody,
+					http.StatusOK, qosBody)
+				Expect(resp.Code).To(Equal(http.StatusOK))
+			})
+
+			Specify("PATCH QoS subscription", func() {
+				resp := sendQosReq(http.MethodPatch, qosURL+"/11111",
+					patchBody, http.StatusOK, qosBody)
+				Expect(resp.Code).To(Equal(http.StatusOK))
+			})
+
+			Specify("DELETE QoS subscription", func() {
+				resp := sendQosReq(http.MethodDelete, qosURL+"/11111", nil,
+					http.StatusNoContent, nil)
+				Expect(resp.Code).To(Equal(http.StatusNoContent))
+			})
+		})
+	})
+
 	Describe("Stop the AF Server", func() {
 		It("Disconnect AF Server", func() {
 			srvCancel()
@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright © 2020 Intel Corporation
+
+package af
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// Linger please
+var (
+	_ context.Context
+)
+
+// AsSessionWithQoSSubDeleteAPIService type
+type AsSessionWithQoSSubDeleteAPIService service
+
+/*
+QosSubscriptionDelete deletes an already existing AS session with QoS
+subscription
+  - @param ctx context.Context - for authentication, logging, cancellation,
+  - deadlines, tracing, etc. Passed from http.Request or context.Background().
+  - @param afID Identifier of the AF
+  - @param subscriptionID Identifier of the subscription resource
+*/
+func (a *AsSessionWithQoSSubDeleteAPIService) QosSubscriptionDelete(
+	ctx context.Context, afID string, subscriptionID string) (*http.Response,
+	[]byte, error) {
+	var (
+		method     = strings.ToUpper("Delete")
+		deleteBody interface{}
+		respBody   []byte
+	)
+
+	path := a.client.cfg.Protocol + "://" + a.client.cfg.NEFHostname +
+		a.client.cfg.NEFPort + a.client.cfg.NEFQoSBasePath + "/" + afID +
+		"/subscriptions/" + subscriptionID
+
+	headerParams := make(map[string]string)
+
+	headerParams["Content-Type"] = contentType
+	headerParams["Accept"] = contentType
+
+	r, err := a.client.prepareRequest(ctx, path, method,
+		deleteBody, headerParams)
+	if err != nil {
+		return nil, respBody, err
+	}
+
+	resp, err := a.client.callAPI(r)
+	if err != nil || resp == nil {
+		return resp, respBody, err
+	}
+
+	respBody, err = ioutil.ReadAll(resp.Body)
+	defer func() {
+		err = resp.Body.Close()
+		if err != nil {
+			log.Errf("response body was not closed properly")
+		}
+	}()
+
+	if err != nil {
+		log.Errf("http response body could not be read")
+		return resp, respBody, err
+	}
+
+	if resp.StatusCode > 300 {
+		if err = handleGetErrorResp(resp, respBody); err != nil {
+			return resp, respBody, err
+		}
+	}
+
+	return resp, respBody, nil
+}
@@ -0,0 +1,101 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright © 2020 Intel Corporation
+
+package af
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// Linger please
+var (
+	_ context.Context
+)
+
+// AsSessionWithQoSSubPatchAPIService type
+type AsSessionWithQoSSubPatchAPIService service
+
+func (a *AsSessionWithQoSSubPatchAPIService) handlePatchResponse(
+	qos *AsSessionWithQoSSub, r *http.Response, body []byte) error {
+
+	if r.StatusCode == 200 {
+		err := json.Unmarshal(body, qos)
+		if err != nil {
+			log.Errf("Error decoding response body %s, ", err.Error())
+		}
+		return err
+	}
+
+	return handlePostPutPatchErrorResp(r, body)
+}
+
+/*
+QosSubscriptionPatch updates an existing AS session with QoS subscription
+resource
+  - @param ctx context.Context - for authentication, logging, cancellation,
+  - deadlines, tracing, etc. Passed from http.Request or context.Background().
+  - @param afID Identifier of the AF
+  - @param subscriptionID Identifier of the subscription resource
+  - @param body Provides a patch for the QoS subscription
+
+@return AsSessionWithQoSSub
+*/
+func (a *AsSessionWithQoSSubPatchAPIService) QosSubscriptionPatch(
+	ctx context.Context, afID string, subscriptionID string,
+	body AsSessionWithQoSSubPatch) (AsSessionWithQoSSub, *http.Response,
+	[]byte, error) {
+
+	var (
+		method    = strings.ToUpper("Patch")
+		patchBody interface{}
+		ret       AsSessionWithQoSSub
+		respBody  []byte
+	)
+
+	path := a.client.cfg.Protocol + "://" + a.client.cfg.NEFHostname +
+		a.client.cfg.NEFPort + a.client.cfg.NEFQoSBasePath + "/" + afID +
+		"/subscriptions/" + subscriptionID
+
+	headerParams := make(map[string]string)
+
+	headerParams["Content-Type"] = contentType
+	headerParams["Accept"] = contentType
+
+	// body params
+	patchBody = &body
+	r, err := a.client.prepareRequest(ctx, path, method,
+		patchBody, headerParams)
+	if err != nil {
+		return ret, nil, respBody, err
+	}
+
+	resp, err := a.client.callAPI(r)
+	if err != nil || resp == nil {
+		return ret, resp, respBody, err
+	}
+
+	respBody, err = ioutil.ReadAll(resp.Body)
+	defer func() {
+		err = resp.Body.Close()
+		if err != nil {
+			log.Errf("response body was not closed properly")
+		}
+	}()
+
+	if err != nil {
+		log.Errf("http response body could not be read")
+		return ret, resp, respBody, err
+	}
+
+	if err = a.handlePatchResponse(&ret, resp,
+		respBody); err != nil {
+
+		return ret, resp, respBody, err
+	}
+
+	return ret, resp, respBody, nil
+}
@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright © 2020 Intel Corporation
+
+package af
+
+import (
+	"context"
+	"net/http"
+)
+
+func deleteQosSubscription(cliCtx context.Context, afCtx *Context,
+	subscriptionID string) (*http.Response, []byte, error) {
+
+	cliCfg := NewConfiguration(afCtx)
+	cli := NewClient(cliCfg)
+
+	return cli.QosSubDeleteAPI.QosSubscriptionDelete(cliCtx,
+		afCtx.cfg.AfID, subscriptionID)
+}
+
+// DeleteQosSubscription function - Delete an AS session with QoS
+func DeleteQosSubscription(w http.ResponseWriter, r *http.Request) {
+
+	var (
+		err            error
+		resp           *http.Response
+		respBody       []byte
+		subscriptionID string
+	)
+
+	afCtx := r.Context().Value(keyType("af-ctx")).(*Context)
+	if afCtx == nil {
+		qosErrRspHeader(&w, "DELETE", "af-ctx retrieved from request is nil",
+			http.StatusInternalServerError)
+		return
+	}
+
+	cliCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	if subscriptionID, err = getSubsIDFromURL(r.URL); err != nil {
+		qosErrRspHeader(&w, "DELETE", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, respBody, err = deleteQosSubscription(cliCtx, afCtx,
+		subscriptionID)
+	if err != nil {
+		qosErrRsp(&w, "DELETE", err, resp, respBody)
+		return
+	}
+
+	w.WriteHeader(resp.StatusCode)
+}
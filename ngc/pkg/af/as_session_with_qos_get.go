@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright © 2020 Intel Corporation
+
+package af
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+func getQosSubscription(cliCtx context.Context, afCtx *Context,
+	subscriptionID string) (AsSessionWithQoSSub, *http.Response, []byte,
+	error) {
+
+	cliCfg := NewConfiguration(afCtx)
+	cli := NewClient(cliCfg)
+
+	qosResp, resp, respBody, err := cli.QosSubGetAPI.QosSubscriptionGet(
+		cliCtx, afCtx.cfg.AfID, subscriptionID)
+
+	if err != nil {
+		return AsSessionWithQoSSub{}, resp, respBody, err
+	}
+	return qosResp, resp, respBody, nil
+}
+
+// GetQosSubscription function - Read an AS session with QoS
+func GetQosSubscription(w http.ResponseWriter, r *http.Request) {
+
+	var (
+		err            error
+		qosResp        AsSessionWithQoSSub
+		resp           *http.Response
+		respBody       []byte
+		qosRespJSON    []byte
+		subscriptionID string
+	)
+
+	afCtx := r.Context().Value(keyType("af-ctx")).(*Context)
+	if afCtx == nil {
+		qosErrRspHeader(&w, "GET", "af-ctx retrieved from request is nil",
+			http.StatusInternalServerError)
+		return
+	}
+
+	cliCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	if subscriptionID, err = getSubsIDFromURL(r.URL); err != nil {
+		qosErrRspHeader(&w, "GET", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	qosResp, resp, respBody, err = getQosSubscription(cliCtx, afCtx,
+		subscriptionID)
+	if err != nil {
+		qosErrRsp(&w, "GET", err, resp, respBody)
+		return
+	}
+
+	if qosRespJSON, err = json.Marshal(qosResp); err != nil {
+		qosErrRspHeader(&w, "GET", err.Error(),
+			http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(resp.StatusCode)
+
+	if _, err = w.Write(qosRespJSON); err != nil {
+		log.Errf("AS Session With QoS GET : %s", err.Error())
+	}
+}
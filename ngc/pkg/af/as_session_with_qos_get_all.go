@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright © 2020 Intel Corporation
+
+package af
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+func getAllQosSubscriptions(cliCtx context.Context, afCtx *Context) (
+	[]AsSessionWithQoSSub, *http.Response, []byte, error) {
+
+	cliCfg := NewConfiguration(afCtx)
+	cli := NewClient(cliCfg)
+
+	qosSubs, resp, respBody, err :=
+		cli.QosSubGetAllAPI.QosSubscriptionsGetAll(cliCtx, afCtx.cfg.AfID)
+
+	if err != nil {
+		return nil, resp, respBody, err
+	}
+	return qosSubs, resp, respBody, nil
+}
+
+// GetAllQosSubscriptions function - Read all the AS sessions with QoS
+func GetAllQosSubscriptions(w http.ResponseWriter, r *http.Request) {
+
+	var (
+		err         error
+		qosResp     []AsSessionWithQoSSub
+		resp        *http.Response
+		respBody    []byte
+		qosRespJSON []byte
+	)
+
+	afCtx := r.Context().Value(keyType("af-ctx")).(*Context)
+	if afCtx == nil {
+		qosErrRspHeader(&w, "GET", "af-ctx retrieved from request is nil",
+			http.StatusInternalServerError)
+		return
+	}
+
+	cliCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	qosResp, resp, respBody, err = getAllQosSubscriptions(cliCtx, afCtx)
+	if err != nil {
+		qosErrRsp(&w, "GET", err, resp, respBody)
+		return
+	}
+
+	if qosRespJSON, err = json.Marshal(qosResp); err != nil {
+		qosErrRspHeader(&w, "GET", err.Error(),
+			http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(resp.StatusCode)
+
+	if _, err = w.Write(qosRespJSON); err != nil {
+		log.Errf("AS Session With QoS GET : %s", err.Error())
+	}
+}
@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright © 2020 Intel Corporation
+
+package af
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+func patchQosSubscription(cliCtx context.Context,
+	qos AsSessionWithQoSSubPatch, afCtx *Context, subscriptionID string) (AsSessionWithQoSSub,
+	*http.Response, []byte, error) {
+
+	cliCfg := NewConfiguration(afCtx)
+	cli := NewClient(cliCfg)
+
+	qosResp, resp, respBody, err := cli.QosSubPatchAPI.QosSubscriptionPatch(
+		cliCtx, afCtx.cfg.AfID, subscriptionID, qos)
+
+	if err != nil {
+		return AsSessionWithQoSSub{}, resp, respBody, err
+	}
+	return qosResp, resp, respBody, nil
+}
+
+// PatchQosSubscription function - Update an AS session with QoS
+func PatchQosSubscription(w http.ResponseWriter, r *http.Request) {
+
+	var (
+		err            error
+		qos            AsSessionWithQoSSubPatch
+		qosResp        AsSessionWithQoSSub
+		resp           *http.Response
+		respBody       []byte
+		qosRespJSON    []byte
+		subscriptionID string
+	)
+
+	afCtx := r.Context().Value(keyType("af-ctx")).(*Context)
+	if afCtx == nil {
+		qosErrRspHeader(&w, "PATCH", "af-ctx retrieved from request is nil",
+			http.StatusInternalServerError)
+		return
+	}
+
+	cliCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	if err = json.NewDecoder(r.Body).Decode(&qos); err != nil {
+		qosErrRspHeader(&w, "PATCH", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if subscriptionID, err = getSubsIDFromURL(r.URL); err != nil {
+		qosErrRspHeader(&w, "PATCH", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	qosResp, resp, respBody, err = patchQosSubscription(cliCtx, qos, afCtx,
+		subscriptionID)
+	if err != nil {
+		qosErrRsp(&w, "PATCH", err, resp, respBody)
+		return
+	}
+
+	if qosRespJSON, err = json.Marshal(qosResp); err != nil {
+		qosErrRspHeader(&w, "PATCH", err.Error(),
+			http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(resp.StatusCode)
+
+	if _, err = w.Write(qosRespJSON); err != nil {
+		log.Errf("AS Session With QoS PATCH : %s", err.Error())
+	}
+}
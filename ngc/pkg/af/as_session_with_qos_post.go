@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright © 2020 Intel Corporation
+
+package af
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+func createQosSubscription(cliCtx context.Context, qos AsSessionWithQoSSub,
+	afCtx *Context) (AsSessionWithQoSSub, *http.Response, []byte, error) {
+
+	cliCfg := NewConfiguration(afCtx)
+	cli := NewClient(cliCfg)
+
+	qosResp, resp, respBody, err := cli.QosSubPostAPI.QosSubscriptionPost(
+		cliCtx, afCtx.cfg.AfID, qos)
+
+	if err != nil {
+		return AsSessionWithQoSSub{}, resp, respBody, err
+	}
+	return qosResp, resp, respBody, nil
+}
+
+// CreateQosSubscription function - Create an AS session with QoS
+func CreateQosSubscription(w http.ResponseWriter, r *http.Request) {
+
+	var (
+		err         error
+		qos         AsSessionWithQoSSub
+		qosResp     AsSessionWithQoSSub
+		resp        *http.Response
+		respBody    []byte
+		qosRespJSON []byte
+	)
+
+	afCtx := r.Context().Value(keyType("af-ctx")).(*Context)
+	if afCtx == nil {
+		qosErrRspHeader(&w, "POST", "af-ctx retrieved from request is nil",
+			http.StatusInternalServerError)
+		return
+	}
+
+	cliCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	if err = json.NewDecoder(r.Body).Decode(&qos); err != nil {
+		qosErrRspHeader(&w, "POST", err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	qosResp, resp, respBody, err = createQosSubscription(cliCtx, qos, afCtx)
+	if err != nil {
+		qosErrRsp(&w, "POST", err, resp, respBody)
+		return
+	}
+
+	if qosRespJSON, err = json.Marshal(qosResp); err != nil {
+		qosErrRspHeader(&w, "POST", err.Error(),
+			http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Location", resp.Header.Get("Location"))
+	w.WriteHeader(resp.StatusCode)
+
+	if _, err = w.Write(qosRespJSON); err != nil {
+		log.Errf("AS Session With QoS POST : %s", err.Error())
+	}
+}
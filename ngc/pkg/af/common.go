@@ -304,3 +304,23 @@ func errRspHeader(w *http.ResponseWriter, method string,
 	(*w).WriteHeader(statusCode)
 
 }
+
+func qosErrRspHeader(w *http.ResponseWriter, method string,
+	errString string, statusCode int) {
+	log.Errf("AS Session With QoS %s : %s", method, errString)
+	(*w).WriteHeader(statusCode)
+
+}
+
+// qosErrRsp passes the NEF error response through to the CNCA
+func qosErrRsp(w *http.ResponseWriter, method string, err error,
+	resp *http.Response, respBody []byte) {
+
+	qosErrRspHeader(w, method, err.Error(), getStatusCode(resp))
+	if resp == nil || len(respBody) == 0 {
+		return
+	}
+	if _, err = (*w).Write(respBody); err != nil {
+		log.Errf("AS Session With QoS %s : %s", method, err.Error())
+	}
+}
@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright © 2020 Intel Corporation
+
+package af
+
+// AsSessionWithQoSSub is the AS session with required QoS subscription
+// structure
+type AsSessionWithQoSSub struct {
+	// Link to the QoS subscription resource
+	Self Link `json:"self,omitempty"`
+	// Supported features
+	SuppFeat SupportedFeatures `json:"supportedFeatures,omitempty"`
+	// Data Network Name
+	DNN string `json:"dnn,omitempty"`
+	// Network slice identifier
+	SNSSAI *SNSSAI `json:"snssai,omitempty"`
+	// URL where notifications shall be sent
+	NotificationDestination Link `json:"notificationDestination,omitempty"`
+	// Describes the IP flows of the application
+	FlowInfo []FlowInfo `json:"flowInfo,omitempty"`
+	// Identifies Ethernet packet flows
+	EthFlowInfo []EthFlowDescription `json:"ethFlowInfo,omitempty"`
+	// Identifies a pre-defined QoS information
+	QosReference string `json:"qosReference,omitempty"`
+	// Ordered list of alternative pre-defined QoS information
+	AltQoSReferences []string `json:"altQoSReferences,omitempty"`
+	// IPv4 address of the UE
+	UEIPv4Addr IPv4Addr `json:"ueIpv4Addr,omitempty"`
+	// IPv6 address of the UE
+	UEIPv6Addr IPv6Addr `json:"ueIpv6Addr,omitempty"`
+	// MAC address of the UE
+	MacAddr MacAddr `json:"macAddr,omitempty"`
+	// Set to true by the AF to request the NEF to send a test notification
+	RequestTestNotification bool `json:"requestTestNotification,omitempty"`
+	// Configuration used for sending notifications though web sockets
+	WebsockNotifConfig *WebsockNotifConfig `json:"websockNotifConfig,omitempty"`
+}
+
+// AsSessionWithQoSSubPatch is the AS session with required QoS subscription
+// patch structure
+type AsSessionWithQoSSubPatch struct {
+	// Describes the IP flows of the application
+	FlowInfo []FlowInfo `json:"flowInfo,omitempty"`
+	// Identifies Ethernet packet flows
+	EthFlowInfo []EthFlowDescription `json:"ethFlowInfo,omitempty"`
+	// Identifies a pre-defined QoS information
+	QosReference string `json:"qosReference,omitempty"`
+	// Ordered list of alternative pre-defined QoS information
+	AltQoSReferences []string `json:"altQoSReferences,omitempty"`
+	// URL where notifications shall be sent
+	NotificationDestination Link `json:"notificationDestination,omitempty"`
+}
@@ -7,6 +7,10 @@ package af
 // be notified when the UP path changes for the PDU isession.
 type SubscribedEvent string
 
+// ActivationState : Indicates if the traffic influence is applied in the 5GC.
+// Possible values are ACTIVE and INACTIVE.
+type ActivationState string
+
 // Link is a string identifying the referenced resource URI
 // URI string formatted accordingding to IETF RFC 3986
 type Link string
@@ -63,6 +67,9 @@ type DNAI string
 const (
 	//UP_PATH_CHANGE SubscribedEvent = "UP_PATH_CHANGE" >> causing lint error
 	UPPathChange SubscribedEvent = "UP_PATH_CHANGE"
+	// TestNotification is set by the NEF in the test notification sent
+	// when requestTestNotification is set in the subscription
+	TestNotification SubscribedEvent = "TEST_NOTIFICATION"
 )
 
 // DNAIChangeType type
@@ -128,6 +135,10 @@ type TrafficInfluSub struct {
 	// Identifies a geographic zone that the AF request applies only to the
 	// traffic of UE(s) located in this specific zone.
 	ValidGeoZoneIDs []string `json:"validGeoZoneIds,omitempty"`
+	// Indicates if the traffic influence is currently applied in the 5GC,
+	// INACTIVE outside the temporal validities. Set by the NEF when the
+	// subscription is read.
+	ActivationState ActivationState `json:"activationState,omitempty"`
 	// String identifying supported features per Traffic Influence service
 	SuppFeat SupportedFeatures `json:"suppFeat,omitempty"`
 	// Configuration used for sending notifications though web sockets
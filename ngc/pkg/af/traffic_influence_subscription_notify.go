@@ -100,6 +100,18 @@ func NotificationPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The test notification only checks that the notifications reach the
+	// AF, it is recorded apart from the events of the transactions
+	if en.SubscribedEvent == TestNotification {
+		log.Infof("Traffic Influance Subscription test notification "+
+			"received for transaction ID %s", en.AFTransID)
+		afCtx.testNotifsMu.Lock()
+		afCtx.testNotifs[en.AFTransID] = en
+		afCtx.testNotifsMu.Unlock()
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
 	if statusCode, err = verifyAFTransID(afCtx, en.AFTransID,
 		&problem); err != nil {
 
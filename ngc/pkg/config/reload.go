@@ -0,0 +1,150 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package config
+
+import (
+	"context"
+	"crypto/tls"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// WatchReload calls reload when the process receives SIGHUP and, if the
+// interval is positive, when the modification time of one of the files
+// changes. The files are checked every interval. It returns when the context
+// is done.
+func WatchReload(ctx context.Context, paths []string, interval time.Duration,
+	reload func()) {
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+	defer signal.Stop(sigCh)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	modTimes := fileModTimes(paths)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sigCh:
+			modTimes = fileModTimes(paths)
+			reload()
+		case <-tick:
+			m := fileModTimes(paths)
+			if !reflect.DeepEqual(m, modTimes) {
+				modTimes = m
+				reload()
+			}
+		}
+	}
+}
+
+// fileModTimes returns the modification times of the files, zero for the
+// files which cannot be read
+func fileModTimes(paths []string) []time.Time {
+
+	m := make([]time.Time, len(paths))
+	for i, p := range paths {
+		if fi, err := os.Stat(filepath.Clean(p)); err == nil {
+			m[i] = fi.ModTime()
+		}
+	}
+	return m
+}
+
+// ChangedFields returns the names of the fields of the structures which
+// differ, the fields of the nested structures are named Parent.Field
+func ChangedFields(old interface{}, new interface{}) []string {
+
+	return changedFields(reflect.ValueOf(old), reflect.ValueOf(new), "")
+}
+
+func changedFields(old reflect.Value, new reflect.Value,
+	prefix string) []string {
+
+	var changed []string
+
+	t := old.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := prefix + f.Name
+		o, n := old.Field(i), new.Field(i)
+		switch {
+		case f.Anonymous && f.Type.Kind() == reflect.Struct:
+			changed = append(changed, changedFields(o, n, prefix)...)
+		case f.Type.Kind() == reflect.Struct:
+			changed = append(changed, changedFields(o, n, name+".")...)
+		case !reflect.DeepEqual(o.Interface(), n.Interface()):
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
+
+// CertReloader provides the certificate of a TLS server through
+// GetCertificate so that it can be replaced without restarting the server
+type CertReloader struct {
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+// NewCertReloader loads the certificate and key of the server
+func NewCertReloader(certPath string, keyPath string) (*CertReloader,
+	error) {
+
+	c := &CertReloader{}
+	if err := c.Reload(certPath, keyPath); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reload loads the certificate and key used for the next TLS handshakes.
+// The current certificate is kept if they cannot be loaded.
+func (c *CertReloader) Reload(certPath string, keyPath string) error {
+
+	cert, err := tls.LoadX509KeyPair(filepath.Clean(certPath),
+		filepath.Clean(keyPath))
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.cert = &cert
+	c.mu.Unlock()
+	return nil
+}
+
+// GetCertificate returns the current certificate, to be set in the
+// tls.Config of the server
+func (c *CertReloader) GetCertificate(
+	*tls.ClientHelloInfo) (*tls.Certificate, error) {
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cert, nil
+}
+
+// GetClientCertificate returns the current certificate, to be set in the
+// tls.Config of a client
+func (c *CertReloader) GetClientCertificate(
+	*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cert, nil
+}
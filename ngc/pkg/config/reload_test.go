@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package config
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// writeCert writes a self-signed certificate for the name and its key
+func writeCert(dir string, cn string) (string, string) {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).To(BeNil())
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl,
+		&key.PublicKey, key)
+	Expect(err).To(BeNil())
+	b, err := x509.MarshalECPrivateKey(key)
+	Expect(err).To(BeNil())
+
+	certPath := filepath.Join(dir, cn+"-cert.pem")
+	keyPath := filepath.Join(dir, cn+"-key.pem")
+	Expect(ioutil.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{
+		Type: "CERTIFICATE", Bytes: der}), 0600)).To(BeNil())
+	Expect(ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{
+		Type: "EC PRIVATE KEY", Bytes: b}), 0600)).To(BeNil())
+	return certPath, keyPath
+}
+
+var _ = Describe("Configuration reload", func() {
+
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "configreload")
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("Will list the changed fields", func() {
+		type inner struct {
+			A int
+			B []string
+		}
+		type conf struct {
+			Name  string
+			Inner inner
+			other int
+		}
+		old := conf{Name: "x", Inner: inner{A: 1, B: []string{"b"}}}
+		Expect(ChangedFields(old, old)).To(BeEmpty())
+		Expect(ChangedFields(old, conf{Name: "y", other: 1,
+			Inner: inner{A: 1, B: []string{"c"}}})).To(
+			Equal([]string{"Name", "Inner.B"}))
+	})
+
+	It("Will swap the certificate and keep it on failure", func() {
+		certA, keyA := writeCert(dir, "a.test")
+		certB, keyB := writeCert(dir, "b.test")
+
+		_, err := NewCertReloader(certA, keyB)
+		Expect(err).NotTo(BeNil())
+		c, err := NewCertReloader(certA, keyA)
+		Expect(err).To(BeNil())
+		cert, _ := c.GetCertificate(nil)
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		Expect(err).To(BeNil())
+		Expect(leaf.Subject.CommonName).To(Equal("a.test"))
+
+		Expect(c.Reload(certB, keyB)).To(BeNil())
+		Expect(c.Reload("nonexistent-file", keyB)).NotTo(BeNil())
+		cert, _ = c.GetCertificate(nil)
+		leaf, err = x509.ParseCertificate(cert.Certificate[0])
+		Expect(err).To(BeNil())
+		Expect(leaf.Subject.CommonName).To(Equal("b.test"))
+	})
+
+	It("Will reload on SIGHUP and when the file changes", func() {
+		var reloads int32
+
+		path := filepath.Join(dir, "conf.json")
+		Expect(ioutil.WriteFile(path, []byte(`{"Val": 1}`),
+			0600)).To(BeNil())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go WatchReload(ctx, []string{path}, 20*time.Millisecond, func() {
+			atomic.AddInt32(&reloads, 1)
+		})
+		time.Sleep(50 * time.Millisecond)
+		Expect(atomic.LoadInt32(&reloads)).To(Equal(int32(0)))
+
+		later := time.Now().Add(time.Minute)
+		Expect(os.Chtimes(path, later, later)).To(BeNil())
+		Eventually(func() int32 {
+			return atomic.LoadInt32(&reloads)
+		}).Should(Equal(int32(1)))
+
+		Expect(syscall.Kill(os.Getpid(), syscall.SIGHUP)).To(BeNil())
+		Eventually(func() int32 {
+			return atomic.LoadInt32(&reloads)
+		}).Should(Equal(int32(2)))
+	})
+})
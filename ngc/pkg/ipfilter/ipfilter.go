@@ -0,0 +1,427 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+// Package ipfilter parses and validates the IPFilterRule of IETF RFC 6733
+// used for the flow descriptions of the PFDs and the traffic filters, ex:
+//
+//	permit out 17 from 192.0.2.0/24 5000-5010 to assigned
+//
+// The syntax is: action dir proto from src [ports] to dst [ports] [options]
+package ipfilter
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Action of the rule
+type Action string
+
+// Possible values of Action
+const (
+	Permit Action = "permit"
+	Deny   Action = "deny"
+)
+
+// Direction of the rule, in is from the terminal and out is to the terminal
+type Direction string
+
+// Possible values of Direction
+const (
+	In  Direction = "in"
+	Out Direction = "out"
+)
+
+// AnyProtocol is the protocol of the rules matching any protocol ("ip")
+const AnyProtocol = -1
+
+// PortRange is a range of ports including its boundaries
+type PortRange struct {
+	First uint16
+	Last  uint16
+}
+
+// Address is the source or destination of the rule
+type Address struct {
+	// The rule matches all the addresses but this one
+	Not bool
+	// Matches any address
+	Any bool
+	// Matches the address assigned to the terminal
+	Assigned bool
+	// IPv4 or IPv6 address if not Any or Assigned
+	IP net.IP
+	// Width of the mask of IP, -1 if not given
+	Bits int
+	// Ports, all the ports match if empty
+	Ports []PortRange
+}
+
+// Option is an option of the rule with its arguments
+type Option struct {
+	Name string
+	Args []string
+}
+
+// Rule is a parsed IPFilterRule
+type Rule struct {
+	Action    Action
+	Direction Direction
+	// IP protocol number or AnyProtocol
+	Protocol int
+	Src      Address
+	Dst      Address
+	Options  []Option
+}
+
+// Arguments allowed for the options taking a list, nil if any number is
+// allowed
+var optionArgs = map[string][]string{
+	"ipoptions":  {"ssrr", "lsrr", "rr", "ts"},
+	"tcpoptions": {"mss", "window", "sack", "ts", "cc"},
+	"tcpflags":   {"fin", "syn", "rst", "psh", "ack", "urg"},
+	"icmptypes":  nil,
+}
+
+// Options applying to TCP only
+var tcpOptions = map[string]bool{
+	"tcpoptions":  true,
+	"tcpflags":    true,
+	"established": true,
+	"setup":       true,
+}
+
+const (
+	protoTCP    = 6
+	protoICMP   = 1
+	protoICMPv6 = 58
+)
+
+// Parse parses and validates the rule
+func Parse(s string) (*Rule, error) {
+
+	toks := strings.Fields(s)
+	if len(toks) == 0 {
+		return nil, errors.New("empty rule")
+	}
+	r := &Rule{}
+	p := &parser{toks: toks}
+
+	switch Action(strings.ToLower(p.next())) {
+	case Permit:
+		r.Action = Permit
+	case Deny:
+		r.Action = Deny
+	default:
+		return nil, errors.New("invalid action " + strconv.Quote(p.last()) +
+			", expected permit or deny")
+	}
+
+	switch Direction(strings.ToLower(p.next())) {
+	case In:
+		r.Direction = In
+	case Out:
+		r.Direction = Out
+	default:
+		return nil, errors.New("invalid direction " +
+			strconv.Quote(p.last()) + ", expected in or out")
+	}
+
+	proto := strings.ToLower(p.next())
+	if proto == "ip" {
+		r.Protocol = AnyProtocol
+	} else {
+		n, err := strconv.ParseUint(proto, 10, 8)
+		if err != nil {
+			return nil, errors.New("invalid protocol " +
+				strconv.Quote(p.last()) + ", expected ip or 0-255")
+		}
+		r.Protocol = int(n)
+	}
+
+	if err := p.keyword("from"); err != nil {
+		return nil, err
+	}
+	var err error
+	if r.Src, err = p.address("source", "to"); err != nil {
+		return nil, err
+	}
+	if err = p.keyword("to"); err != nil {
+		return nil, err
+	}
+	if r.Dst, err = p.address("destination", ""); err != nil {
+		return nil, err
+	}
+
+	if r.Options, err = p.options(r.Protocol); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Normalize parses the rule and returns it in its normalized form: lower
+// case keywords, single spaces, IP addresses and ports in canonical form
+func Normalize(s string) (string, error) {
+
+	r, err := Parse(s)
+	if err != nil {
+		return "", err
+	}
+	return r.String(), nil
+}
+
+// String returns the rule in its normalized form
+func (r *Rule) String() string {
+
+	proto := "ip"
+	if r.Protocol != AnyProtocol {
+		proto = strconv.Itoa(r.Protocol)
+	}
+	parts := []string{string(r.Action), string(r.Direction), proto,
+		"from", r.Src.String(), "to", r.Dst.String()}
+	for _, o := range r.Options {
+		parts = append(parts, o.Name)
+		if len(o.Args) != 0 {
+			parts = append(parts, strings.Join(o.Args, ","))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// String returns the address followed by its ports
+func (a Address) String() string {
+
+	var s string
+	switch {
+	case a.Any:
+		s = "any"
+	case a.Assigned:
+		s = "assigned"
+	default:
+		s = a.IP.String()
+		if a.Bits >= 0 {
+			s += "/" + strconv.Itoa(a.Bits)
+		}
+	}
+	if a.Not {
+		s = "!" + s
+	}
+	if len(a.Ports) == 0 {
+		return s
+	}
+
+	ports := make([]string, len(a.Ports))
+	for i, pr := range a.Ports {
+		ports[i] = strconv.Itoa(int(pr.First))
+		if pr.Last != pr.First {
+			ports[i] += "-" + strconv.Itoa(int(pr.Last))
+		}
+	}
+	return s + " " + strings.Join(ports, ",")
+}
+
+// parser walks through the tokens of the rule
+type parser struct {
+	toks []string
+	pos  int
+}
+
+// next returns the next token, an empty string at the end of the rule
+func (p *parser) next() string {
+
+	if p.pos >= len(p.toks) {
+		p.pos++
+		return ""
+	}
+	p.pos++
+	return p.toks[p.pos-1]
+}
+
+// last returns the token returned by next
+func (p *parser) last() string {
+
+	if p.pos > len(p.toks) {
+		return ""
+	}
+	return p.toks[p.pos-1]
+}
+
+// peek returns the next token without consuming it
+func (p *parser) peek() string {
+
+	if p.pos >= len(p.toks) {
+		return ""
+	}
+	return p.toks[p.pos]
+}
+
+func (p *parser) keyword(kw string) error {
+
+	if strings.ToLower(p.next()) != kw {
+		return errors.New("expected " + kw + " instead of " +
+			strconv.Quote(p.last()))
+	}
+	return nil
+}
+
+// address parses an address and its optional ports, end is the keyword
+// following them if any
+func (p *parser) address(name string, end string) (a Address, err error) {
+
+	tok := p.next()
+	if tok == "!" {
+		a.Not = true
+		tok = p.next()
+	} else if strings.HasPrefix(tok, "!") {
+		a.Not = true
+		tok = tok[1:]
+	}
+
+	a.Bits = -1
+	switch strings.ToLower(tok) {
+	case "":
+		return a, errors.New("missing " + name + " address")
+	case "any":
+		a.Any = true
+	case "assigned":
+		a.Assigned = true
+	default:
+		if a.IP, a.Bits, err = parseIP(tok); err != nil {
+			return a, errors.New("invalid " + name + " address " +
+				strconv.Quote(tok) + ": " + err.Error())
+		}
+	}
+
+	// The ports are the only token starting with a digit after an address
+	next := p.peek()
+	if next == "" || strings.ToLower(next) == end ||
+		next[0] < '0' || next[0] > '9' {
+		return a, nil
+	}
+	if a.Ports, err = parsePorts(p.next()); err != nil {
+		return a, errors.New("invalid " + name + " ports " +
+			strconv.Quote(p.last()) + ": " + err.Error())
+	}
+	return a, nil
+}
+
+// parseIP parses an IPv4 or IPv6 address with an optional mask width
+func parseIP(s string) (ip net.IP, bits int, err error) {
+
+	bits = -1
+	addr := s
+	if i := strings.IndexByte(s, '/'); i >= 0 {
+		addr = s[:i]
+		if bits, err = strconv.Atoi(s[i+1:]); err != nil || bits < 0 {
+			return nil, -1, errors.New("invalid mask width")
+		}
+	}
+
+	ip = net.ParseIP(addr)
+	if ip == nil {
+		return nil, -1, errors.New("not an IP address")
+	}
+	maxBits := 128
+	if !strings.Contains(addr, ":") {
+		ip = ip.To4()
+		maxBits = 32
+	}
+	if bits > maxBits {
+		return nil, -1, errors.New("mask width above " +
+			strconv.Itoa(maxBits))
+	}
+	return ip, bits, nil
+}
+
+// parsePorts parses a comma separated list of ports and port ranges
+func parsePorts(s string) ([]PortRange, error) {
+
+	var ports []PortRange
+	for _, item := range strings.Split(s, ",") {
+		first, last := item, item
+		if i := strings.IndexByte(item, '-'); i >= 0 {
+			first, last = item[:i], item[i+1:]
+		}
+		f, err := strconv.ParseUint(first, 10, 16)
+		if err != nil {
+			return nil, errors.New("port " + strconv.Quote(first) +
+				" not in 0-65535")
+		}
+		l, err := strconv.ParseUint(last, 10, 16)
+		if err != nil {
+			return nil, errors.New("port " + strconv.Quote(last) +
+				" not in 0-65535")
+		}
+		if l < f {
+			return nil, errors.New("port range " + strconv.Quote(item) +
+				" is reversed")
+		}
+		ports = append(ports, PortRange{First: uint16(f), Last: uint16(l)})
+	}
+	return ports, nil
+}
+
+// options parses the options following the destination
+func (p *parser) options(proto int) (opts []Option, err error) {
+
+	for p.peek() != "" {
+		name := strings.ToLower(p.next())
+		if tcpOptions[name] && proto != protoTCP {
+			return nil, errors.New("option " + name +
+				" only applies to TCP (6)")
+		}
+		if name == "icmptypes" && proto != protoICMP &&
+			proto != protoICMPv6 {
+			return nil, errors.New("option icmptypes only applies to " +
+				"ICMP (1 or 58)")
+		}
+
+		allowed, withArgs := optionArgs[name]
+		if !withArgs {
+			if name != "frag" && name != "established" && name != "setup" {
+				return nil, errors.New("unknown option " +
+					strconv.Quote(p.last()))
+			}
+			opts = append(opts, Option{Name: name})
+			continue
+		}
+
+		spec := strings.ToLower(p.next())
+		if spec == "" {
+			return nil, errors.New("missing arguments of option " + name)
+		}
+		o := Option{Name: name, Args: strings.Split(spec, ",")}
+		for _, arg := range o.Args {
+			if err = checkOptionArg(name, allowed, arg); err != nil {
+				return nil, err
+			}
+		}
+		opts = append(opts, o)
+	}
+	return opts, nil
+}
+
+// checkOptionArg checks an argument of an option, the arguments of
+// ipoptions, tcpoptions and tcpflags may be negated with "!"
+func checkOptionArg(name string, allowed []string, arg string) error {
+
+	if allowed == nil {
+		// icmptypes: ICMP type numbers
+		if _, err := strconv.ParseUint(arg, 10, 8); err != nil {
+			return errors.New("invalid ICMP type " + strconv.Quote(arg))
+		}
+		return nil
+	}
+
+	arg = strings.TrimPrefix(arg, "!")
+	for _, a := range allowed {
+		if arg == a {
+			return nil
+		}
+	}
+	return errors.New("invalid argument " + strconv.Quote(arg) +
+		" of option " + name + ", expected one of " +
+		strings.Join(allowed, ","))
+}
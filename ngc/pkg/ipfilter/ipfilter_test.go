@@ -0,0 +1,105 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package ipfilter
+
+import (
+	"net"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestIPFilter(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "IPFilterRule suite")
+}
+
+var _ = Describe("IPFilterRule", func() {
+
+	Describe("Parse a valid rule", func() {
+		It("Will parse the addresses, ports and protocol", func() {
+			r, err := Parse("permit out 17 from 192.0.2.0/24 5000-5010,6000" +
+				" to assigned 80")
+			Expect(err).To(BeNil())
+			Expect(r.Action).To(Equal(Permit))
+			Expect(r.Direction).To(Equal(Out))
+			Expect(r.Protocol).To(Equal(17))
+			Expect(r.Src.IP).To(Equal(net.ParseIP("192.0.2.0").To4()))
+			Expect(r.Src.Bits).To(Equal(24))
+			Expect(r.Src.Ports).To(Equal([]PortRange{{5000, 5010},
+				{6000, 6000}}))
+			Expect(r.Dst.Assigned).To(BeTrue())
+			Expect(r.Dst.Ports).To(Equal([]PortRange{{80, 80}}))
+		})
+
+		It("Will parse any protocol, negation and options", func() {
+			r, err := Parse("deny in ip from ! 2001:db8::/32 to any")
+			Expect(err).To(BeNil())
+			Expect(r.Protocol).To(Equal(AnyProtocol))
+			Expect(r.Src.Not).To(BeTrue())
+			Expect(r.Src.Bits).To(Equal(32))
+			Expect(r.Dst.Any).To(BeTrue())
+
+			r, err = Parse("permit out 6 from any to 10.0.0.1 443 " +
+				"tcpflags syn,!ack established")
+			Expect(err).To(BeNil())
+			Expect(r.Options).To(Equal([]Option{
+				{Name: "tcpflags", Args: []string{"syn", "!ack"}},
+				{Name: "established"}}))
+		})
+	})
+
+	Describe("Normalize a rule", func() {
+		It("Will return the canonical form", func() {
+			Expect(Normalize("  PERMIT  Out 17 from 2001:DB8:0:0::1 0080" +
+				" to !10.0.0.0/8  1000-1000")).To(Equal(
+				"permit out 17 from 2001:db8::1 80 to !10.0.0.0/8 1000"))
+			Expect(Normalize("permit in ip from 10.11.12.123 80 to any")).
+				To(Equal("permit in ip from 10.11.12.123 80 to any"))
+		})
+	})
+
+	Describe("Reject an invalid rule", func() {
+		It("Will explain what is wrong", func() {
+			invalid := map[string]string{
+				"": "empty rule",
+				"allow out 17 from any to any": "invalid action " +
+					"\"allow\", expected permit or deny",
+				"permit up 17 from any to any": "invalid direction " +
+					"\"up\", expected in or out",
+				"permit out 256 from any to any": "invalid protocol " +
+					"\"256\", expected ip or 0-255",
+				"permit out 17 any to any": "expected from instead of " +
+					"\"any\"",
+				"permit out 17 from 10.0.0.300 to any": "invalid source " +
+					"address \"10.0.0.300\": not an IP address",
+				"permit out 17 from 10.0.0.0/33 to any": "invalid source " +
+					"address \"10.0.0.0/33\": mask width above 32",
+				"permit out 17 from any 70000 to any": "invalid source " +
+					"ports \"70000\": port \"70000\" not in 0-65535",
+				"permit out 17 from any to any 90-80": "invalid " +
+					"destination ports \"90-80\": port range \"90-80\" " +
+					"is reversed",
+				"permit out 17 from any": "expected to instead of \"\"",
+				"permit out 17 from any to": "missing destination " +
+					"address",
+				"permit out 17 from any to any setup": "option setup " +
+					"only applies to TCP (6)",
+				"permit out 6 from any to any tcpflags syn,foo": "invalid " +
+					"argument \"foo\" of option tcpflags, expected one of " +
+					"fin,syn,rst,psh,ack,urg",
+				"permit out 6 from any to any bogus": "unknown option " +
+					"\"bogus\"",
+				"permit out 1 from any to any icmptypes": "missing " +
+					"arguments of option icmptypes",
+			}
+			for rule, reason := range invalid {
+				_, err := Parse(rule)
+				Expect(err).NotTo(BeNil(), rule)
+				Expect(err.Error()).To(Equal(reason), rule)
+			}
+		})
+	})
+})
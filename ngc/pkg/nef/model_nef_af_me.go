@@ -0,0 +1,152 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef
+
+// MonitoringType is the type of monitoring event (3GPP TS 29.122)
+type MonitoringType string
+
+// List of MonitoringType supported by the NEF
+const (
+	MonitoringTypeLossOfConnectivity MonitoringType = "LOSS_OF_CONNECTIVITY"
+	MonitoringTypeUeReachability     MonitoringType = "UE_REACHABILITY"
+	MonitoringTypeLocationReporting  MonitoringType = "LOCATION_REPORTING"
+)
+
+// ReachabilityType is the type of reachability the AF is interested in
+// - SMS
+// - DATA
+type ReachabilityType string
+
+// List of ReachabilityType
+const (
+	ReachabilityTypeSms  ReachabilityType = "SMS"
+	ReachabilityTypeData ReachabilityType = "DATA"
+)
+
+// LocationType is the type of location requested by the AF
+// - CURRENT_LOCATION
+// - LAST_KNOWN_LOCATION
+type LocationType string
+
+// List of LocationType
+const (
+	LocationTypeCurrent   LocationType = "CURRENT_LOCATION"
+	LocationTypeLastKnown LocationType = "LAST_KNOWN_LOCATION"
+)
+
+// Accuracy is the accuracy of the location requested by the AF
+// - CGI_ECGI
+// - TA_RA
+// - GEO_AREA
+type Accuracy string
+
+// MonitoringEventSubscription is the monitoring event subscription
+// structure (3GPP TS 29.122)
+type MonitoringEventSubscription struct {
+	// URL of created subscription resource
+	Self Link `json:"self,omitempty"`
+	// String identifying supported features per monitoring event service
+	SupportedFeatures SupportedFeatures `json:"supportedFeatures,omitempty"`
+	// Identifies a user, one of externalId, msisdn or externalGroupId is
+	// required
+	ExternalID string `json:"externalId,omitempty"`
+	// Identifies the MS internal PSTN/ISDN number allocated for a UE
+	Msisdn string `json:"msisdn,omitempty"`
+	// Identifies a user group
+	ExternalGroupID ExternalGroupID `json:"externalGroupId,omitempty"`
+	// URL where notifications shall be sent
+	// Required: true
+	NotificationDestination Link `json:"notificationDestination"`
+	// Set to true by the AF to request the NEF to send a test notification.
+	// Set to false or omitted otherwise.
+	RequestTestNotification bool `json:"requestTestNotification,omitempty"`
+	// Configuration used for sending notifications though web sockets
+	WebsockNotifConfig *WebsockNotifConfig `json:"websockNotifConfig,omitempty"`
+	// Type of the monitoring event
+	// Required: true
+	MonitoringType MonitoringType `json:"monitoringType"`
+	// Maximum number of reports after which the subscription is removed
+	MaximumNumberOfReports int32 `json:"maximumNumberOfReports,omitempty"`
+	// Time at which the subscription expires
+	MonitorExpireTime DateTime `json:"monitorExpireTime,omitempty"`
+	// Maximum time in seconds without a signal from the UE after which the
+	// loss of connectivity is reported
+	MaximumDetectionTime DurationSec `json:"maximumDetectionTime,omitempty"`
+	// Reachability type, required for UE_REACHABILITY
+	ReachabilityType ReachabilityType `json:"reachabilityType,omitempty"`
+	// Maximum delay in seconds acceptable for downlink data
+	MaximumLatency DurationSec `json:"maximumLatency,omitempty"`
+	// Time in seconds the UE stays reachable
+	MaximumResponseTime DurationSec `json:"maximumResponseTime,omitempty"`
+	// Location type, required for LOCATION_REPORTING
+	LocationType LocationType `json:"locationType,omitempty"`
+	// Accuracy of the location
+	Accuracy Accuracy `json:"accuracy,omitempty"`
+
+	// The following fields have been omitted as they are not supported
+	// addedExternalIds, addedMsisdns, excludedExternalIds, excludedMsisdns,
+	// ipv4Addr, ipv6Addr, dnn, repPeriod, groupReportGuardTime,
+	// minimumReportInterval, associationType, plmnIndication,
+	// monitoringEventReport, suggestedNumberOfDlPackets, idleStatusIndication,
+	// locationArea, locationArea5G
+}
+
+// LocationInfo is the location of the UE reported in a monitoring event
+type LocationInfo struct {
+	// Elapsed time in minutes since the last network contact of the UE
+	AgeOfLocationInfo int32 `json:"ageOfLocationInfo,omitempty"`
+	// Cell ID where the UE is located
+	CellID string `json:"cellId,omitempty"`
+	// eNodeB ID where the UE is located
+	EnodeBID string `json:"enodeBId,omitempty"`
+	// Routing area ID where the UE is located
+	RoutingAreaID string `json:"routingAreaId,omitempty"`
+	// Tracking area ID where the UE is located
+	TrackingAreaID string `json:"trackingAreaId,omitempty"`
+	// PLMN ID where the UE is located
+	PlmnID *PlmnID `json:"plmnId,omitempty"`
+}
+
+// MonitoringEventReport is a monitoring event reported to the AF
+type MonitoringEventReport struct {
+	// Identifies a user
+	ExternalID string `json:"externalId,omitempty"`
+	// Identifies the MS internal PSTN/ISDN number allocated for a UE
+	Msisdn string `json:"msisdn,omitempty"`
+	// Location of the UE for LOCATION_REPORTING
+	LocationInfo *LocationInfo `json:"locationInfo,omitempty"`
+	// Reason of the loss of connectivity for LOSS_OF_CONNECTIVITY, encoded
+	// as the Loss-Of-Connectivity-Reason of 3GPP TS 29.336
+	LossOfConnectReason *int32 `json:"lossOfConnectReason,omitempty"`
+	// Time until which the UE is expected to be reachable
+	MaxUEAvailabilityTime DateTime `json:"maxUEAvailabilityTime,omitempty"`
+	// Type of the monitoring event
+	// Required: true
+	MonitoringType MonitoringType `json:"monitoringType"`
+	// Reachability type for UE_REACHABILITY
+	ReachabilityType ReachabilityType `json:"reachabilityType,omitempty"`
+	// Time at which the event was detected
+	EventTime DateTime `json:"eventTime,omitempty"`
+}
+
+// MonitoringNotification is sent by the NEF to the AF notification
+// destination
+type MonitoringNotification struct {
+	// Link to the monitoring event subscription
+	// Required: true
+	Subscription Link `json:"subscription"`
+	// Monitoring event reports
+	MonitoringEventReports []MonitoringEventReport `json:"monitoringEventReports,omitempty"`
+	// Set to true if the subscription is cancelled by the NEF, this is the
+	// last notification of the subscription
+	CancelInd bool `json:"cancelInd,omitempty"`
+}
+
+// Loss-Of-Connectivity-Reason values (3GPP TS 29.336) reported to the AF
+const (
+	lossOfConnectUeDetached          int32 = 0
+	lossOfConnectMaxDetectionExpired int32 = 2
+	lossOfConnectUePurged            int32 = 4
+)
@@ -0,0 +1,44 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef
+
+// AfNotifStats contains the delivery counters of the notifications sent to
+// the AFs since the NEF started
+type AfNotifStats struct {
+	// Notifications accepted in the queues
+	Queued uint64 `json:"queued"`
+	// Notifications delivered to the AF
+	Delivered uint64 `json:"delivered"`
+	// Attempts which failed and were retried later
+	Retried uint64 `json:"retried"`
+	// Notifications moved to the dead-letter list after failing
+	Failed uint64 `json:"failed"`
+	// Notifications moved to the dead-letter list because the queue of the
+	// AF destination was full
+	Dropped uint64 `json:"dropped"`
+	// Notifications waiting in the queues
+	Pending int `json:"pending"`
+}
+
+// AfNotifDeadLetter is a notification which could not be delivered to the AF
+type AfNotifDeadLetter struct {
+	// Notification URI of the AF
+	Destination URI `json:"destination"`
+	// Body of the notification
+	Notification interface{} `json:"notification"`
+	// Number of attempts made to send it
+	Attempts int `json:"attempts"`
+	// Reason of the last failure
+	Cause string `json:"cause"`
+	// Time the notification was given up, RFC 3339
+	Time string `json:"time"`
+}
+
+// AfNotifStatus is the status of the dispatcher of the notifications sent to
+// the AFs returned by the admin API
+type AfNotifStatus struct {
+	Stats       AfNotifStats        `json:"stats"`
+	DeadLetters []AfNotifDeadLetter `json:"deadLetters"`
+}
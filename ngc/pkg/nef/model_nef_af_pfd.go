@@ -50,6 +50,9 @@ type PfdManagement struct {
 	// This attribute shall be provided in the POST request and in the
 	// response of successful resource creation.
 	SuppFeat *SupportedFeatures `json:"suppFeat,omitempty"`
+	// URI of the AF where the PFD reports of the applications removed by
+	// the NEF, ex: after their caching time, are sent
+	NotificationDestination Link `json:"notificationDestination,omitempty"`
 	// Each element uniquely identifies the PFDs for an external application
 	// identifier. Each element is identified in the map via an external
 	// application identifier as key. The response shall include successfully
@@ -80,6 +83,9 @@ const (
 	AppIDDuplicated = "APP_ID_DUPLICATED"
 	// Other reason specified
 	OtherReason = "OTHER_REASON"
+	// The caching time of the PFDs elapsed without the PFDs being updated,
+	// they are removed. Not defined by 3GPP, only sent in the notifications.
+	PfdExpired = "PFD_EXPIRED"
 )
 
 // PfdReport is the type that represents a PFD report to indicate the
@@ -0,0 +1,60 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef
+
+// AsSessionWithQoSSubscription is the AS session with required QoS
+// subscription structure (3GPP TS 29.122)
+type AsSessionWithQoSSubscription struct {
+	// URL of created subscription resource
+	Self Link `json:"self,omitempty"`
+	// String identifying supported features per AS session with QoS service
+	SupportedFeatures SupportedFeatures `json:"supportedFeatures,omitempty"`
+	// Identifies data network name
+	Dnn Dnn `json:"dnn,omitempty"`
+	// Network slice identifier
+	Snssai *Snssai `json:"snssai,omitempty"`
+	// URL where notifications shall be sent
+	// Required: true
+	NotificationDestination Link `json:"notificationDestination"`
+	// Describes the IP flows of the application
+	FlowInfo []FlowInfo `json:"flowInfo,omitempty"`
+	// Identifies Ethernet packet flows.
+	EthFlowInfo []EthFlowDescription `json:"ethFlowInfo,omitempty"`
+	// Identifies a pre-defined QoS information
+	QosReference string `json:"qosReference,omitempty"`
+	// Identifies an ordered list of pre-defined QoS information. The lower the
+	// index of the array for a given entry, the higher the priority.
+	AltQoSReferences []string `json:"altQoSReferences,omitempty"`
+	// The Ipv4 address of the UE
+	UeIpv4Addr Ipv4Addr `json:"ueIpv4Addr,omitempty"`
+	// The Ipv6 address of the UE
+	UeIpv6Addr Ipv6Addr `json:"ueIpv6Addr,omitempty"`
+	// The MAC address of the UE
+	MacAddr MacAddr48 `json:"macAddr,omitempty"`
+	// Set to true by the AF to request the NEF to send a test notification.
+	// Set to false or omitted otherwise.
+	RequestTestNotification bool `json:"requestTestNotification,omitempty"`
+	// Configuration used for sending notifications though web sockets
+	WebsockNotifConfig *WebsockNotifConfig `json:"websockNotifConfig,omitempty"`
+
+	// The following fields have been omitted as they are not supported
+	// usageThreshold - Required when usage monitoring is supported
+	// sponsorInfo - Required when Sponsored Connectivity is supported
+}
+
+// AsSessionWithQoSSubscriptionPatch AS session with required QoS
+// subscription patch structure
+type AsSessionWithQoSSubscriptionPatch struct {
+	// Describes the IP flows of the application
+	FlowInfo []FlowInfo `json:"flowInfo,omitempty"`
+	// Identifies Ethernet packet flows.
+	EthFlowInfo []EthFlowDescription `json:"ethFlowInfo,omitempty"`
+	// Identifies a pre-defined QoS information
+	QosReference string `json:"qosReference,omitempty"`
+	// Identifies an ordered list of pre-defined QoS information.
+	AltQoSReferences []string `json:"altQoSReferences,omitempty"`
+	// URL where notifications shall be sent
+	NotificationDestination Link `json:"notificationDestination,omitempty"`
+}
@@ -8,6 +8,15 @@ package ngcnef
 // be notified of
 type SubscribedEvent string
 
+// ActivationState indicates if the traffic influence is applied in the 5GC
+type ActivationState string
+
+// List of ActivationState
+const (
+	ActivationStateActive   ActivationState = "ACTIVE"
+	ActivationStateInactive ActivationState = "INACTIVE"
+)
+
 /*
 // List of SubscribedEvent
 const (
@@ -16,6 +25,10 @@ const (
 )
 */
 
+// TestNotification is the SubscribedEvent of the test notification sent to
+// the AF when requestTestNotification is set in the subscription
+const TestNotification SubscribedEvent = "TEST_NOTIFICATION"
+
 // TrafficInfluSub is Traffic Influence Subscription structure
 type TrafficInfluSub struct {
 	// Identifies a service on behalf of which the AF is issuing the request.
@@ -71,6 +84,10 @@ type TrafficInfluSub struct {
 	// Identifies a geographic zone that the AF request applies only to the
 	// traffic of UE(s) located in this specific zone.
 	ValidGeoZoneIDs []string `json:"validGeoZoneIds,omitempty"`
+	// Indicates if the traffic influence is currently applied in the 5GC,
+	// INACTIVE outside the temporal validities. Set by the NEF when the
+	// subscription is read.
+	ActivationState ActivationState `json:"activationState,omitempty"`
 	// String identifying supported features per Traffic Influence service
 	SuppFeat SupportedFeatures `json:"suppFeat,omitempty"`
 	// Identifies whether an pplication can be relocated once a location of the
@@ -287,21 +287,25 @@ type PresenceInfo struct {
 	PraID string `json:"praId,omitempty"`
 	// presence state
 	PresenceState PresenceState `json:"presenceState,omitempty"`
+	// tracking area list
+	// Min Items: 1
+	TrackingAreaList []Tai `json:"trackingAreaList,omitempty"`
 	// ecgi list
 	// Min Items: 1
-	EcgiList []Ecgi `json:"ecgiList"`
+	EcgiList []Ecgi `json:"ecgiList,omitempty"`
 	// ncgi list
 	// Min Items: 1
-	NcgiList []Ncgi `json:"ncgiList"`
+	NcgiList []Ncgi `json:"ncgiList,omitempty"`
 	// global ran node Id list
 	// Min Items: 1
-	GlobalRanNodeIDList []GlobalRanNodeID `json:"globalRanNodeIdList"`
+	GlobalRanNodeIDList []GlobalRanNodeID `json:"globalRanNodeIdList,omitempty"`
 }
 
 // SpatialValidity Describes the spatial validity of an AF request for
 // influencing traffic routing
 type SpatialValidity struct {
-	PresenceInfoList PresenceInfo `json:"presenceInfoList"`
+	// Presence reporting areas keyed by the praId
+	PresenceInfoList map[string]PresenceInfo `json:"presenceInfoList"`
 }
 
 // DateTime is in the date-time format
@@ -0,0 +1,22 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef
+
+// GeoZone maps a geographic zone identifier used by the AF in the
+// validGeoZoneIds of a traffic influence subscription onto the network area
+// it covers. At least one of the area lists has to be present.
+type GeoZone struct {
+	// Identifies the geographic zone
+	// Required: true
+	GeoZoneID string `json:"geoZoneId"`
+	// List of tracking area identities of the zone
+	Tais []Tai `json:"tais,omitempty"`
+	// List of E-UTRA cell identities of the zone
+	Ecgis []Ecgi `json:"ecgis,omitempty"`
+	// List of NR cell identities of the zone
+	Ncgis []Ncgi `json:"ncgis,omitempty"`
+	// List of NG RAN nodes of the zone
+	GRanNodeIds []GlobalRanNodeID `json:"gRanNodeIds,omitempty"`
+}
@@ -0,0 +1,96 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef
+
+// NFType : Type of the network function (3GPP TS 29.510)
+type NFType string
+
+// List of NFType used by the NEF
+const (
+	NFTypeNEF NFType = "NEF"
+	NFTypePCF NFType = "PCF"
+	NFTypeUDR NFType = "UDR"
+	NFTypeSMF NFType = "SMF"
+	NFTypeUDM NFType = "UDM"
+)
+
+// NFStatus : Status of the network function or of a service
+type NFStatus string
+
+// List of NFStatus
+const (
+	NFStatusRegistered     NFStatus = "REGISTERED"
+	NFStatusSuspended      NFStatus = "SUSPENDED"
+	NFStatusUndiscoverable NFStatus = "UNDISCOVERABLE"
+)
+
+// NFProfile Information of a network function instance registered in the NRF
+type NFProfile struct {
+	// Unique identity of the NF Instance, UUID
+	NfInstanceID string `json:"nfInstanceId"`
+	// Type of the network function
+	NfType NFType `json:"nfType"`
+	// Status of the NF Instance
+	NfStatus NFStatus `json:"nfStatus"`
+	// Time in seconds expected between 2 consecutive heart-beat messages
+	HeartBeatTimer int `json:"heartBeatTimer,omitempty"`
+	// FQDN of the network function
+	Fqdn string `json:"fqdn,omitempty"`
+	// IPv4 addresses of the network function
+	Ipv4Addresses []string `json:"ipv4Addresses,omitempty"`
+	// S-NSSAIs of the network function
+	SNssais []Snssai `json:"sNssais,omitempty"`
+	// Services provided by the network function
+	NfServices []NFService `json:"nfServices,omitempty"`
+}
+
+// NFService Information of a service instance of a network function
+type NFService struct {
+	// Unique ID of the service instance within the NF Instance
+	ServiceInstanceID string `json:"serviceInstanceId"`
+	// Name of the service ex: "npcf-policyauthorization"
+	ServiceName string `json:"serviceName"`
+	// API versions supported by the service
+	Versions []NFServiceVersion `json:"versions"`
+	// URI scheme "http" or "https"
+	Scheme string `json:"scheme"`
+	// Status of the service instance
+	NfServiceStatus NFStatus `json:"nfServiceStatus"`
+	// FQDN of the service instance
+	Fqdn string `json:"fqdn,omitempty"`
+	// IP addresses and ports of the service instance
+	IPEndPoints []IPEndPoint `json:"ipEndPoints,omitempty"`
+	// Optional path segments used to construct the API root
+	APIPrefix string `json:"apiPrefix,omitempty"`
+}
+
+// NFServiceVersion API version of a service
+type NFServiceVersion struct {
+	// Version in the URI ex: "v1"
+	APIVersionInURI string `json:"apiVersionInUri"`
+	// Full version ex: "1.0.0"
+	APIFullVersion string `json:"apiFullVersion"`
+}
+
+// IPEndPoint IP address and port of a service instance
+type IPEndPoint struct {
+	Ipv4Address string `json:"ipv4Address,omitempty"`
+	Port        int    `json:"port,omitempty"`
+}
+
+// SearchResult Result of a NF discovery request
+type SearchResult struct {
+	// Time in seconds during which the result can be cached
+	ValidityPeriod int `json:"validityPeriod,omitempty"`
+	// NF Instances matching the discovery request
+	NfInstances []NFProfile `json:"nfInstances"`
+}
+
+// PatchItem JSON patch (IETF RFC 6902) operation
+type PatchItem struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
@@ -45,6 +45,9 @@ type AppSessionContextReqData struct {
 	UeIpv6 Ipv6Addr `json:"ueIpv6,omitempty"`
 	// ue mac
 	UeMac MacAddr48 `json:"ueMac,omitempty"`
+	// Media components of the application session, keyed by the media
+	// component number. Used for the AS session with QoS.
+	MedComponents map[string]MediaComponent `json:"medComponents,omitempty"`
 
 	// The following fields have been omitted as they are not required for
 	// Traffic Influ feature
@@ -52,7 +55,6 @@ type AppSessionContextReqData struct {
 	// AspId - Required when Sponspored Connnectivity is supported
 	// bdtRefId - Required when BDT is supported
 	// ipDomain - Required when Qos is supported
-	// medComponents - Required when Qos is supported
 	// mpsId - Required when Multimedia Priority Service is supported
 	// sponId - Required when Sponspored Connnectivity is supported
 	// sponStatus - Required when Sponspored Connnectivity is supported
@@ -66,13 +68,14 @@ type AppSessionContextUpdateData struct {
 	// Indicates the AF traffic routing requirements. It shall be included if
 	//  Influence on Traffic Routing feature is supported
 	AfRoutReq AfRoutingRequirement `json:"afRoutReq,omitempty"`
+	// Media components to add or modify, keyed by the media component number
+	MedComponents map[string]MediaComponent `json:"medComponents,omitempty"`
 
 	// The following fields have been omitted as they are not required for
 	// Traffic Influ feature
 	// evSubsc and notifUri - Not Required
 	// AspId - Required when Sponspored Connnectivity is supported
 	// bdtRefId - Required when BDT is supported
-	// medComponents - Required when Qos is supported
 	// mpsId - Required when Multimedia Priority Service is supported
 	// sponId - Required when Sponspored Connnectivity is supported
 	// sponStatus - Required when Sponspored Connnectivity is supported
@@ -88,7 +91,7 @@ type AfRoutingRequirement struct {
 	// Min Items: 1
 	RouteToLocs []RouteToLocation `json:"routeToLocs"`
 	// sp val
-	SpVal SpatialValidity `json:"spVal,omitempty"`
+	SpVal *SpatialValidity `json:"spVal,omitempty"`
 	// temp vals
 	// Min Items: 1
 	TempVals []TemporalValidity `json:"tempVals"`
@@ -100,6 +103,56 @@ type AfRoutingRequirement struct {
 	AddrPreserInd bool `json:"addrPreserInd,omitempty"`
 }
 
+// FlowStatus : Describes how the flows of a media component are enabled
+// Possible values are ENABLED-UPLINK, ENABLED-DOWNLINK, ENABLED, DISABLED and
+// REMOVED
+type FlowStatus string
+
+// List of FlowStatus
+const (
+	FlowStatusEnabled  FlowStatus = "ENABLED"
+	FlowStatusDisabled FlowStatus = "DISABLED"
+	FlowStatusRemoved  FlowStatus = "REMOVED"
+)
+
+// MediaComponent Identifies a media component (3GPP TS 29.514)
+type MediaComponent struct {
+	// Identifies the media component number
+	// Required: true
+	MedCompN int32 `json:"medCompN"`
+	// Identifies the pre-defined QoS information requested for the media
+	// component
+	QosReference string `json:"qosReference,omitempty"`
+	// Ordered list of alternative pre-defined QoS information
+	AltSerReqs []string `json:"altSerReqs,omitempty"`
+	// Flow status of the media component
+	FStatus FlowStatus `json:"fStatus,omitempty"`
+	// Media subcomponents keyed by the flow number
+	MedSubComps map[string]MediaSubComponent `json:"medSubComps,omitempty"`
+
+	// The following fields have been omitted as they are not required for
+	// the AS session with QoS
+	// afAppId, afRoutReq, codecs, contVer, desMaxLatency, desMaxLoss,
+	// flusId, marBwDl, marBwUl, maxPacketLossRateDl, maxPacketLossRateUl,
+	// maxSuppBwDl, maxSuppBwUl, medType, minDesBwDl, minDesBwUl, mirBwDl,
+	// mirBwUl, preemptCap, preemptVuln, prioSharingInd, resPrio,
+	// rrBw, rsBw, sharingKeyDl, sharingKeyUl, tsnQos
+}
+
+// MediaSubComponent Identifies a media subcomponent, i.e. a flow of a media
+// component
+type MediaSubComponent struct {
+	// Identifies the flow number
+	// Required: true
+	FNum int32 `json:"fNum"`
+	// IP flow descriptions of the flow, minItems: 1, maxItems: 2
+	FDescs []FlowDescription `json:"fDescs,omitempty"`
+	// Ethernet flow descriptions of the flow, minItems: 1, maxItems: 2
+	EthfDescs []EthFlowDescription `json:"ethfDescs,omitempty"`
+	// Flow status of the flow
+	FStatus FlowStatus `json:"fStatus,omitempty"`
+}
+
 //FlowDescription : Defines a packet filter of an IP flow.
 type FlowDescription string
 
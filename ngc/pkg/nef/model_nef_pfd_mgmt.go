@@ -0,0 +1,32 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef
+
+// PfdSubscription represents a subscription of an NF (e.g. SMF) to the PFD
+// changes of the Nnef_PFDManagement service, refer 3GPP TS 29.551
+type PfdSubscription struct {
+	// Application identifiers of the PFDs the NF is interested in. All the
+	// applications are notified if the list is absent.
+	ApplicationIds []ApplicationID `json:"applicationIds,omitempty"`
+	// URI where the PFD change notifications are sent
+	// Required: true
+	NotifyURI URI `json:"notifyUri"`
+	// Supported features of the Nnef_PFDManagement service
+	SupportedFeatures SupportedFeatures `json:"supportedFeatures,omitempty"`
+}
+
+// PfdChangeNotification represents the change of the PFDs of an application
+// identifier notified to the NF
+type PfdChangeNotification struct {
+	// Identifier of the application
+	// Required: true
+	ApplicationID ApplicationID `json:"applicationId"`
+	// Set when the PFDs of the application are removed
+	RemovalFlag bool `json:"removalFlag,omitempty"`
+	// Set when only part of the PFDs of the application are included
+	PartialFlag bool `json:"partialFlag,omitempty"`
+	// PFDs of the application, absent when they are removed
+	Pfds []PfdContent `json:"pfds,omitempty"`
+}
@@ -85,3 +85,44 @@ type NsmEventNotification struct {
 // - PLMN_CH
 // - UE_IP_CH
 type SmfEvent string
+
+// NsmfEventExposure Represents an Individual SMF Notification Subscription
+// resource. Only the attributes required for the UP path change event of
+// Traffic Influence are supported.
+type NsmfEventExposure struct {
+	// Subscription Permanent Identifier
+	Supi Supi `json:"supi,omitempty"`
+	// Generic Public Subscription Identifier
+	Gpsi Gpsi `json:"gpsi,omitempty"`
+	// Any UE indication. This IE shall be present if the event subscription
+	// is applicable to any UE. Default value "false" is used, if not present.
+	AnyUeInd bool `json:"anyUeInd,omitempty"`
+	// Identifies a group of UEs
+	GroupID string `json:"groupId,omitempty"`
+	// Data Network Name
+	Dnn Dnn `json:"dnn,omitempty"`
+	// Single Network Slice Selection Assistance Information
+	Snssai *Snssai `json:"snssai,omitempty"`
+	// Identifies an Individual SMF Notification Subscription. Set by the SMF
+	// in the response
+	SubID string `json:"subId,omitempty"`
+	// Notification Correlation ID assigned by the NF service consumer
+	NotifID string `json:"notifId"`
+	// Identifies the recipient of Notifications sent by SMF
+	NotifURI URI `json:"notifUri"`
+	// Subscribed events
+	EventSubs []EventSubscription `json:"eventSubs"`
+	// Supported features
+	SupportedFeatures SupportedFeatures `json:"supportedFeatures,omitempty"`
+}
+
+// EventSubscription Represents the subscription to a single event
+type EventSubscription struct {
+	// Event that is subscribed
+	Event SmfEvent `json:"event"`
+	// DNAI Change Type. Shall be included for event "UP_PATH_CH"
+	DnaiChgType DnaiChangeType `json:"dnaiChgType,omitempty"`
+}
+
+// SmfEventUpPathCh is the UP path change event of the SMF
+const SmfEventUpPathCh SmfEvent = "UP_PATH_CH"
@@ -0,0 +1,167 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef
+
+// UdmEventType is the type of event subscribed in the UDM (3GPP TS 29.503)
+type UdmEventType string
+
+// List of UdmEventType used by the NEF
+const (
+	UdmEventLossOfConnectivity UdmEventType = "LOSS_OF_CONNECTIVITY"
+	UdmEventUeReachabilityData UdmEventType = "UE_REACHABILITY_FOR_DATA"
+	UdmEventUeReachabilitySms  UdmEventType = "UE_REACHABILITY_FOR_SMS"
+	UdmEventLocationReporting  UdmEventType = "LOCATION_REPORTING"
+)
+
+// Prefixes of the ueIdentity of the UDM event exposure resources
+const (
+	udmUeIdentityExternalID      = "extid-"
+	udmUeIdentityMsisdn          = "msisdn-"
+	udmUeIdentityExternalGroupID = "extgroupid-"
+)
+
+// EeSubscription is an event exposure subscription in the UDM
+type EeSubscription struct {
+	// URI where the UDM sends the notifications
+	// Required: true
+	CallbackReference URI `json:"callbackReference"`
+	// Events subscribed, the key is the reference ID of the event
+	// Required: true
+	MonitoringConfigurations map[string]MonitoringConfiguration `json:"monitoringConfigurations"`
+	// Reporting options of the events
+	ReportingOptions *ReportingOptions `json:"reportingOptions,omitempty"`
+	// Supported features
+	SupportedFeatures SupportedFeatures `json:"supportedFeatures,omitempty"`
+}
+
+// MonitoringConfiguration is the configuration of a single event
+type MonitoringConfiguration struct {
+	// Type of the event
+	// Required: true
+	EventType UdmEventType `json:"eventType"`
+	// Set to report the current status of the event immediately
+	ImmediateFlag bool `json:"immediateFlag,omitempty"`
+	// Configuration of LOCATION_REPORTING
+	LocationReportingConfiguration *LocationReportingConfiguration `json:"locationReportingConfiguration,omitempty"`
+	// Configuration of LOSS_OF_CONNECTIVITY
+	LossConnectivityCfg *LossConnectivityCfg `json:"lossConnectivityCfg,omitempty"`
+	// Maximum delay acceptable for downlink data for UE_REACHABILITY
+	MaximumLatency DurationSec `json:"maximumLatency,omitempty"`
+	// Time the UE stays reachable for UE_REACHABILITY
+	MaximumResponseTime DurationSec `json:"maximumResponseTime,omitempty"`
+}
+
+// LocationReportingConfiguration is the configuration of LOCATION_REPORTING
+type LocationReportingConfiguration struct {
+	// Set for the current location, the last known location is reported
+	// otherwise
+	// Required: true
+	CurrentLocation bool `json:"currentLocation"`
+	// Set for a single report
+	OneTime bool `json:"oneTime,omitempty"`
+	// Accuracy of the location
+	// - CELL_LEVEL
+	// - TA_LEVEL
+	Accuracy string `json:"accuracy,omitempty"`
+}
+
+// LossConnectivityCfg is the configuration of LOSS_OF_CONNECTIVITY
+type LossConnectivityCfg struct {
+	// Maximum time without a signal from the UE
+	MaxDetectionTime DurationSec `json:"maxDetectionTime,omitempty"`
+}
+
+// ReportingOptions of the events subscribed in the UDM
+type ReportingOptions struct {
+	// Maximum number of reports
+	MaxNumOfReports int32 `json:"maxNumOfReports,omitempty"`
+	// Time at which the subscription expires
+	Expiry DateTime `json:"expiry,omitempty"`
+}
+
+// CreatedEeSubscription is returned by the UDM when the subscription is
+// created
+type CreatedEeSubscription struct {
+	// The subscription created
+	// Required: true
+	EeSubscription EeSubscription `json:"eeSubscription"`
+	// Number of UEs of the group
+	NumberOfUes int32 `json:"numberOfUes,omitempty"`
+	// Immediate reports of the events subscribed with the immediateFlag
+	EventReports []MonitoringReport `json:"eventReports,omitempty"`
+}
+
+// MonitoringReport is an event reported by the UDM. The UDM sends an array
+// of MonitoringReport to the callbackReference.
+type MonitoringReport struct {
+	// Reference ID of the event in the monitoringConfigurations
+	// Required: true
+	ReferenceID int32 `json:"referenceId"`
+	// Type of the event
+	// Required: true
+	EventType UdmEventType `json:"eventType"`
+	// Details of the event
+	Report *UdmReport `json:"report,omitempty"`
+	// Identifies the UE, present for group subscriptions
+	Gpsi Gpsi `json:"gpsi,omitempty"`
+	// Time at which the event was detected
+	// Required: true
+	TimeStamp DateTime `json:"timeStamp"`
+}
+
+// UdmReport contains the details of the event, only the attributes of the
+// event type are present
+type UdmReport struct {
+	// LOSS_OF_CONNECTIVITY reason
+	// - DEREGISTERED
+	// - MAX_DETECTION_TIME_EXPIRED
+	// - PURGED
+	LossOfConnectReason string `json:"lossOfConnectReason,omitempty"`
+	// LOCATION_REPORTING location of the UE
+	Location *UserLocation `json:"location,omitempty"`
+	// UE_REACHABILITY status
+	// - REACHABLE
+	// - UNREACHABLE
+	// - REGULATORY_ONLY
+	Reachability string `json:"reachability,omitempty"`
+	// UE_REACHABILITY time until which the UE is expected to be reachable
+	MaxAvailabilityTime DateTime `json:"maxAvailabilityTime,omitempty"`
+}
+
+// UserLocation is the location of the UE (3GPP TS 29.571)
+type UserLocation struct {
+	// E-UTRA location
+	EutraLocation *EutraLocation `json:"eutraLocation,omitempty"`
+	// NR location
+	NrLocation *NrLocation `json:"nrLocation,omitempty"`
+}
+
+// EutraLocation is the E-UTRA location of the UE
+type EutraLocation struct {
+	// Tracking area of the UE
+	// Required: true
+	Tai Tai `json:"tai"`
+	// Cell of the UE
+	// Required: true
+	Ecgi Ecgi `json:"ecgi"`
+	// Elapsed time in minutes since the last network contact of the UE
+	AgeOfLocationInformation int32 `json:"ageOfLocationInformation,omitempty"`
+	// Time at which the location was obtained
+	UeLocationTimestamp DateTime `json:"ueLocationTimestamp,omitempty"`
+}
+
+// NrLocation is the NR location of the UE
+type NrLocation struct {
+	// Tracking area of the UE
+	// Required: true
+	Tai Tai `json:"tai"`
+	// Cell of the UE
+	// Required: true
+	Ncgi Ncgi `json:"ncgi"`
+	// Elapsed time in minutes since the last network contact of the UE
+	AgeOfLocationInformation int32 `json:"ageOfLocationInformation,omitempty"`
+	// Time at which the location was obtained
+	UeLocationTimestamp DateTime `json:"ueLocationTimestamp,omitempty"`
+}
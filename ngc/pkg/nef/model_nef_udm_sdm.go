@@ -0,0 +1,17 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef
+
+// IDTranslationResult is the result of the translation of a GPSI or an
+// external identifier by the UDM (3GPP TS 29.503)
+type IDTranslationResult struct {
+	// String identifying the supported features
+	SupportedFeatures SupportedFeatures `json:"supportedFeatures,omitempty"`
+	// SUPI of the UE
+	// Required: true
+	Supi Supi `json:"supi"`
+	// GPSI of the UE
+	Gpsi Gpsi `json:"gpsi,omitempty"`
+}
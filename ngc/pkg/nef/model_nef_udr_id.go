@@ -44,7 +44,7 @@ type TrafficInfluData struct {
 	ValidStartTime DateTime `json:"validStartTime,omitempty"`
 	// Identifies a network area information that the request applies only to
 	// the traffic of UE(s) located in this specific zone
-	NwAreaInfo NetworkAreaInfo `json:"nwAreaInfo,omitempty"`
+	NwAreaInfo *NetworkAreaInfo `json:"nwAreaInfo,omitempty"`
 	// Contains the Notification Correlation Id allocated by the NEF for the
 	// UP path change notification.
 	UpPathChgNotifCorreID string `json:"upPathChgNotifCorreId,omitempty"`
@@ -84,7 +84,7 @@ type TrafficInfluDataPatch struct {
 	// Format: date-time
 	ValidStartTime DateTime `json:"validStartTime,omitempty"`
 	// nw area info
-	NwAreaInfo NetworkAreaInfo `json:"nwAreaInfo,omitempty"`
+	NwAreaInfo *NetworkAreaInfo `json:"nwAreaInfo,omitempty"`
 	// up path chg notif Uri
 	UpPathChgNotifURI URI `json:"upPathChgNotifUri,omitempty"`
 }
@@ -95,14 +95,14 @@ type TrafficInfluDataPatch struct {
 type NetworkAreaInfo struct {
 	// Contains a list of E-UTRA cell identities.
 	// Min Items: 1
-	Ecgis []Ecgi `json:"ecgis"`
+	Ecgis []Ecgi `json:"ecgis,omitempty"`
 	// Contains a list of NR cell identities.
 	// Min Items: 1
-	Ncgis []Ncgi `json:"ncgis"`
+	Ncgis []Ncgi `json:"ncgis,omitempty"`
 	// Contains a list of NG RAN nodes.
 	// Min Items: 1
-	GRanNodeIds []GlobalRanNodeID `json:"gRanNodeIds"`
+	GRanNodeIds []GlobalRanNodeID `json:"gRanNodeIds,omitempty"`
 	// Contains a list of tracking area identities.
 	// Min Items: 1
-	Tais []Tai `json:"tais"`
+	Tais []Tai `json:"tais,omitempty"`
 }
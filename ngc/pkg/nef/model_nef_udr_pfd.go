@@ -7,7 +7,7 @@ package ngcnef
 // PfdContent represents the content of a PFD for an application identifier.
 type PfdContent struct {
 	// Identifies a PDF of an application identifier.
-	PfdID string `json:"pfdID,omitempty"`
+	PfdID string `json:"pfdId"`
 	// Represents a 3-tuple with protocol, server ip and server port for
 	// UL/DL application traffic.
 	FlowDescriptions []string `json:"flowDescriptions,omitempty"`
@@ -22,7 +22,7 @@ type PfdContent struct {
 // PfdDataForApp represents the PFDs for an application identifier
 type PfdDataForApp struct {
 	// Identifier of an application.
-	AppID ApplicationID `json:"appID"`
+	AppID ApplicationID `json:"applicationId"`
 	// PFDs for the application identifier.
 	Pfds []PfdContent `json:"pfds"`
 	// Caching time for an application identifier.
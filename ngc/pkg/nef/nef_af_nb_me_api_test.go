@@ -0,0 +1,368 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	ngcnef "github.com/open-ness/epcforedge/ngc/pkg/nef"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+const baseMeAPIURL = "http://localhost:8091/3gpp-monitoring-event/" +
+	"v1/AF_01/subscriptions"
+
+func CreateMeReqForNEF(ctx context.Context, method string, subID string,
+	body []byte) (*httptest.ResponseRecorder, *http.Request) {
+
+	url := baseMeAPIURL
+	if len(subID) > 0 {
+		url += "/" + subID
+	}
+	var req *http.Request
+	if body != nil {
+		req, _ = http.NewRequest(method, url, bytes.NewBuffer(body))
+	} else {
+		req, _ = http.NewRequest(method, url, nil)
+	}
+	return httptest.NewRecorder(), req.WithContext(ctx)
+}
+
+// CreateUdmNotifForNEF creates the notification of the UDM sent to the
+// callback reference of the event exposure subscription
+func CreateUdmNotifForNEF(ctx context.Context, callback ngcnef.URI,
+	body []byte) (*httptest.ResponseRecorder, *http.Request) {
+
+	u, err := url.Parse(string(callback))
+	Expect(err).Should(BeNil())
+	req, _ := http.NewRequest("POST", "http://localhost:8091"+u.Path,
+		bytes.NewBuffer(body))
+	return httptest.NewRecorder(), req.WithContext(ctx)
+}
+
+// newFakeAFNotif starts an AF receiving the monitoring notifications
+func newFakeAFNotif() (*httptest.Server, chan ngcnef.MonitoringNotification) {
+
+	notifs := make(chan ngcnef.MonitoringNotification, 10)
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			var notif ngcnef.MonitoringNotification
+			if err := json.NewDecoder(r.Body).Decode(&notif); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			notifs <- notif
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	return server, notifs
+}
+
+var _ = Describe("Test NEF Server Monitoring Event NB API's", func() {
+
+	var (
+		udm *fakeUDM
+		dir string
+	)
+
+	postbody, _ := ioutil.ReadFile(testJSONPath + "AF_NEF_ME_POST_01.json")
+	putbody, _ := ioutil.ReadFile(testJSONPath + "AF_NEF_ME_PUT_01.json")
+	notifbody, _ := ioutil.ReadFile(testJSONPath + "UDM_NEF_NOTIF_01.json")
+
+	// meBody returns the POST body with the notification destination and
+	// the maximum number of reports changed
+	meBody := func(dest string, maxReports int32) []byte {
+		var me ngcnef.MonitoringEventSubscription
+		Expect(json.Unmarshal(postbody, &me)).Should(BeNil())
+		me.NotificationDestination = ngcnef.Link(dest)
+		me.MaximumNumberOfReports = maxReports
+		b, _ := json.Marshal(me)
+		return b
+	}
+
+	BeforeEach(func() {
+		var err error
+		udm = newFakeUDM()
+		dir, err = ioutil.TempDir("", "nefme")
+		Expect(err).Should(BeNil())
+	})
+
+	AfterEach(func() {
+		udm.server.Close()
+		os.RemoveAll(dir)
+	})
+
+	It("Create, read, update and delete a monitoring event in the UDM",
+		func() {
+
+			ctx, cancel := startNefWithCfg(createUDMCfg(dir, udm.server.URL))
+			defer cancel()
+
+			rr, req := CreateMeReqForNEF(ctx, "POST", "", postbody)
+			ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+			Expect(rr.Code).Should(Equal(http.StatusCreated))
+			loc := rr.Header().Get("Location")
+			Expect(loc).Should(Equal("https://localhost:8090/" +
+				"3gpp-monitoring-event/v1/AF_01/subscriptions/11111"))
+
+			var me ngcnef.MonitoringEventSubscription
+			Expect(json.Unmarshal(rr.Body.Bytes(), &me)).Should(BeNil())
+			Expect(string(me.Self)).Should(Equal(loc))
+
+			// The location reporting is subscribed for the UE in the UDM
+			Expect(udm.ueIdentities()).Should(
+				Equal([]string{"extid-123456@domain.com"}))
+			ees := udm.subscriptions()
+			Expect(len(ees)).Should(Equal(1))
+			Expect(string(ees[0].CallbackReference)).Should(Equal(
+				"https://localhost:8090/3gpp-monitoring-event/v1/" +
+					"notification/udm/11131"))
+			mc := ees[0].MonitoringConfigurations["1"]
+			Expect(mc.EventType).Should(
+				Equal(ngcnef.UdmEventLocationReporting))
+			Expect(mc.LocationReportingConfiguration.CurrentLocation).Should(
+				BeTrue())
+			Expect(mc.LocationReportingConfiguration.Accuracy).Should(
+				Equal("CELL_LEVEL"))
+			Expect(ees[0].ReportingOptions.MaxNumOfReports).Should(
+				Equal(int32(3)))
+			callback := ees[0].CallbackReference
+
+			rr, req = CreateMeReqForNEF(ctx, "GET", "", nil)
+			ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+			Expect(rr.Code).Should(Equal(http.StatusOK))
+			var meList []ngcnef.MonitoringEventSubscription
+			Expect(json.Unmarshal(rr.Body.Bytes(), &meList)).Should(BeNil())
+			Expect(len(meList)).Should(Equal(1))
+
+			// The UDM subscription is replaced keeping the callback
+			rr, req = CreateMeReqForNEF(ctx, "PUT", "11111", putbody)
+			ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+			Expect(rr.Code).Should(Equal(http.StatusOK))
+			ees = udm.subscriptions()
+			Expect(len(ees)).Should(Equal(1))
+			Expect(ees[0].CallbackReference).Should(Equal(callback))
+			mc = ees[0].MonitoringConfigurations["1"]
+			Expect(mc.EventType).Should(
+				Equal(ngcnef.UdmEventUeReachabilityData))
+			Expect(mc.MaximumLatency).Should(
+				Equal(ngcnef.DurationSec(60)))
+
+			rr, req = CreateMeReqForNEF(ctx, "GET", "11111", nil)
+			ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+			Expect(rr.Code).Should(Equal(http.StatusOK))
+			Expect(json.Unmarshal(rr.Body.Bytes(), &me)).Should(BeNil())
+			Expect(me.MonitoringType).Should(
+				Equal(ngcnef.MonitoringTypeUeReachability))
+			Expect(string(me.Self)).Should(Equal(loc))
+
+			rr, req = CreateMeReqForNEF(ctx, "DELETE", "11111", nil)
+			ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+			Expect(rr.Code).Should(Equal(http.StatusNoContent))
+			Expect(len(udm.subscriptions())).Should(Equal(0))
+
+			rr, req = CreateMeReqForNEF(ctx, "GET", "11111", nil)
+			ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+			Expect(rr.Code).Should(Equal(http.StatusNotFound))
+		})
+
+	It("Delivers the UDM reports to the AF until the maximum is reached",
+		func() {
+
+			af, notifs := newFakeAFNotif()
+			defer af.Close()
+
+			ctx, cancel := startNefWithCfg(createUDMCfg(dir, udm.server.URL))
+			defer cancel()
+
+			rr, req := CreateMeReqForNEF(ctx, "POST", "", meBody(af.URL, 2))
+			ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+			Expect(rr.Code).Should(Equal(http.StatusCreated))
+			loc := rr.Header().Get("Location")
+			callback := udm.subscriptions()[0].CallbackReference
+
+			rr, req = CreateUdmNotifForNEF(ctx, callback, notifbody)
+			ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+			Expect(rr.Code).Should(Equal(http.StatusNoContent))
+
+			var notif ngcnef.MonitoringNotification
+			Eventually(notifs).Should(Receive(&notif))
+			Expect(string(notif.Subscription)).Should(Equal(loc))
+			Expect(notif.CancelInd).Should(BeFalse())
+			Expect(len(notif.MonitoringEventReports)).Should(Equal(1))
+			report := notif.MonitoringEventReports[0]
+			Expect(report.ExternalID).Should(Equal("123456@domain.com"))
+			Expect(report.MonitoringType).Should(
+				Equal(ngcnef.MonitoringTypeLocationReporting))
+			Expect(report.LocationInfo.CellID).Should(Equal("000000001"))
+			Expect(report.LocationInfo.TrackingAreaID).Should(
+				Equal("000001"))
+			Expect(string(report.LocationInfo.PlmnID.Mcc)).Should(
+				Equal("123"))
+			Expect(string(report.EventTime)).Should(
+				Equal("2020-05-01T10:00:00Z"))
+
+			// The last report cancels the subscription
+			rr, req = CreateUdmNotifForNEF(ctx, callback, notifbody)
+			ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+			Expect(rr.Code).Should(Equal(http.StatusNoContent))
+			Eventually(notifs).Should(Receive(&notif))
+			Expect(notif.CancelInd).Should(BeTrue())
+
+			rr, req = CreateMeReqForNEF(ctx, "GET", "11111", nil)
+			ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+			Expect(rr.Code).Should(Equal(http.StatusNotFound))
+
+			rr, req = CreateUdmNotifForNEF(ctx, callback, notifbody)
+			ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+			Expect(rr.Code).Should(Equal(http.StatusNotFound))
+		})
+
+	It("Delivers the immediate reports of the UDM to the AF", func() {
+
+		af, notifs := newFakeAFNotif()
+		defer af.Close()
+
+		udm.reports = []ngcnef.MonitoringReport{{ReferenceID: 1,
+			EventType: ngcnef.UdmEventLocationReporting,
+			TimeStamp: "2020-05-01T10:00:00Z"}}
+
+		ctx, cancel := startNefWithCfg(createUDMCfg(dir, udm.server.URL))
+		defer cancel()
+
+		rr, req := CreateMeReqForNEF(ctx, "POST", "", meBody(af.URL, 0))
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+
+		var notif ngcnef.MonitoringNotification
+		Eventually(notifs).Should(Receive(&notif))
+		Expect(string(notif.Subscription)).Should(
+			Equal(rr.Header().Get("Location")))
+		Expect(len(notif.MonitoringEventReports)).Should(Equal(1))
+
+		rr, req = CreateMeReqForNEF(ctx, "DELETE", "11111", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusNoContent))
+	})
+
+	It("Rejects invalid monitoring events and notifications", func() {
+
+		ctx, cancel := startNefWithCfg(createUDMCfg(dir, udm.server.URL))
+		defer cancel()
+
+		var me ngcnef.MonitoringEventSubscription
+		Expect(json.Unmarshal(postbody, &me)).Should(BeNil())
+
+		// More than one UE identity
+		bad := me
+		bad.Msisdn = "918369110173"
+		body, _ := json.Marshal(bad)
+		rr, req := CreateMeReqForNEF(ctx, "POST", "", body)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusBadRequest))
+
+		// The location type is required for location reporting
+		bad = me
+		bad.LocationType = ""
+		body, _ = json.Marshal(bad)
+		rr, req = CreateMeReqForNEF(ctx, "POST", "", body)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusBadRequest))
+
+		bad = me
+		bad.MonitoringType = "NUMBER_OF_UES_IN_AN_AREA"
+		body, _ = json.Marshal(bad)
+		rr, req = CreateMeReqForNEF(ctx, "POST", "", body)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusBadRequest))
+		Expect(len(udm.subscriptions())).Should(Equal(0))
+
+		rr, req = CreateMeReqForNEF(ctx, "POST", "", postbody)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+		callback := udm.subscriptions()[0].CallbackReference
+
+		// The UE of the subscription can not be changed
+		body, _ = ioutil.ReadFile(testJSONPath + "AF_NEF_ME_POST_02.json")
+		rr, req = CreateMeReqForNEF(ctx, "PUT", "11111", body)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusBadRequest))
+
+		rr, req = CreateUdmNotifForNEF(ctx, callback, []byte("[]"))
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusBadRequest))
+
+		rr, req = CreateUdmNotifForNEF(ctx, callback+"0", notifbody)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusNotFound))
+
+		rr, req = CreateMeReqForNEF(ctx, "DELETE", "11111", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusNoContent))
+	})
+
+	It("Fails if the UDM rejects the monitoring event", func() {
+
+		server := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				problem(w, http.StatusForbidden, "MONITORING_NOT_ALLOWED")
+			}), &http2.Server{}))
+		defer server.Close()
+
+		ctx, cancel := startNefWithCfg(createUDMCfg(dir, server.URL))
+		defer cancel()
+
+		rr, req := CreateMeReqForNEF(ctx, "POST", "", postbody)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusBadRequest))
+		var pd ngcnef.ProblemDetails
+		Expect(json.Unmarshal(rr.Body.Bytes(), &pd)).Should(BeNil())
+		Expect(pd.Cause).Should(Equal("MONITORING_NOT_ALLOWED"))
+
+		rr, req = CreateMeReqForNEF(ctx, "GET", "", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+		Expect(rr.Body.String()).Should(Equal("null"))
+	})
+
+	It("Restores the monitoring events after a restart", func() {
+
+		cfgPath := createStoreCfg(dir)
+		ctx, cancel := startNefWithCfg(cfgPath)
+
+		body, _ := ioutil.ReadFile(testJSONPath + "AF_NEF_ME_POST_02.json")
+		rr, req := CreateMeReqForNEF(ctx, "POST", "", body)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+		cancel()
+		time.Sleep(1 * time.Second)
+
+		ctx, cancel = startNefWithCfg(filepath.Join(dir, "nef.json"))
+		defer cancel()
+
+		rr, req = CreateMeReqForNEF(ctx, "GET", "11111", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+		var me ngcnef.MonitoringEventSubscription
+		Expect(json.Unmarshal(rr.Body.Bytes(), &me)).Should(BeNil())
+		Expect(me.Msisdn).Should(Equal("918369110173"))
+
+		// The UDM stub lost the subscription, it is removed anyway
+		rr, req = CreateMeReqForNEF(ctx, "DELETE", "11111", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusNoContent))
+	})
+})
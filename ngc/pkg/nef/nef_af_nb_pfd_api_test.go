@@ -140,7 +140,6 @@ var _ = Describe("Test NEF Server PFD NB API's ", func() {
 			It("Will init NefServer",
 				func() {
 					ctx, cancel = context.WithCancel(context.Background())
-					defer cancel()
 					go func() {
 						err := ngcnef.Run(ctx, NefTestCfgBasepath+"valid.json")
 						Expect(err).To(BeNil())
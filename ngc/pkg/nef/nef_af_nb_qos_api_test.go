@@ -0,0 +1,222 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	ngcnef "github.com/open-ness/epcforedge/ngc/pkg/nef"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+const baseQosAPIURL = "http://localhost:8091/3gpp-as-session-with-qos/" +
+	"v1/AF_01/subscriptions"
+
+func CreateQosReqForNEF(ctx context.Context, method string, subID string,
+	body []byte) (*httptest.ResponseRecorder, *http.Request) {
+
+	url := baseQosAPIURL
+	if len(subID) > 0 {
+		url += "/" + subID
+	}
+	var req *http.Request
+	if body != nil {
+		req, _ = http.NewRequest(method, url, bytes.NewBuffer(body))
+	} else {
+		req, _ = http.NewRequest(method, url, nil)
+	}
+	return httptest.NewRecorder(), req.WithContext(ctx)
+}
+
+var _ = Describe("Test NEF Server AS Session With QoS NB API's", func() {
+
+	var (
+		pcf *fakePCF
+		dir string
+	)
+
+	postbody, _ := ioutil.ReadFile(testJSONPath + "AF_NEF_QOS_POST_01.json")
+	putbody, _ := ioutil.ReadFile(testJSONPath + "AF_NEF_QOS_PUT_01.json")
+	patchbody, _ := ioutil.ReadFile(testJSONPath + "AF_NEF_QOS_PATCH_01.json")
+
+	BeforeEach(func() {
+		var err error
+		pcf = newFakePCF()
+		dir, err = ioutil.TempDir("", "nefqos")
+		Expect(err).Should(BeNil())
+	})
+
+	AfterEach(func() {
+		pcf.server.Close()
+		os.RemoveAll(dir)
+	})
+
+	It("Create, read, update and delete a QoS session in the PCF", func() {
+
+		ctx, cancel := startNefWithCfg(createPCFCfg(dir, pcf.server.URL))
+		defer cancel()
+
+		rr, req := CreateQosReqForNEF(ctx, "POST", "", postbody)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+		loc := rr.Header().Get("Location")
+		Expect(loc).Should(Equal("https://localhost:8090/" +
+			"3gpp-as-session-with-qos/v1/AF_01/subscriptions/11111"))
+
+		var qos ngcnef.AsSessionWithQoSSubscription
+		Expect(json.Unmarshal(rr.Body.Bytes(), &qos)).Should(BeNil())
+		Expect(string(qos.Self)).Should(Equal(loc))
+
+		// The flows and the QoS reference are sent as a media component
+		ascs := pcf.appSessions()
+		Expect(len(ascs)).Should(Equal(1))
+		Expect(string(ascs[0].AscReqData.UeIpv4)).Should(
+			Equal("192.168.1.1"))
+		Expect(string(ascs[0].AscReqData.Dnn)).Should(Equal("edge"))
+		medComp := ascs[0].AscReqData.MedComponents["1"]
+		Expect(medComp.QosReference).Should(Equal("qos_video_hd"))
+		Expect(medComp.AltSerReqs).Should(Equal([]string{"qos_video_sd"}))
+		Expect(len(medComp.MedSubComps)).Should(Equal(2))
+		Expect(len(medComp.MedSubComps["1"].FDescs)).Should(Equal(2))
+
+		rr, req = CreateQosReqForNEF(ctx, "GET", "", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+		var qosList []ngcnef.AsSessionWithQoSSubscription
+		Expect(json.Unmarshal(rr.Body.Bytes(), &qosList)).Should(BeNil())
+		Expect(len(qosList)).Should(Equal(1))
+
+		// The flow missing in the PUT is removed
+		rr, req = CreateQosReqForNEF(ctx, "PUT", "11111", putbody)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+		medComp = pcf.appSessions()[0].AscReqData.MedComponents["1"]
+		Expect(medComp.QosReference).Should(Equal("qos_video_sd"))
+		Expect(medComp.MedSubComps["1"].FStatus).Should(
+			Equal(ngcnef.FlowStatusEnabled))
+		Expect(medComp.MedSubComps["2"].FStatus).Should(
+			Equal(ngcnef.FlowStatusRemoved))
+
+		rr, req = CreateQosReqForNEF(ctx, "PATCH", "11111", patchbody)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+		Expect(json.Unmarshal(rr.Body.Bytes(), &qos)).Should(BeNil())
+		Expect(qos.QosReference).Should(Equal("qos_video_4k"))
+		Expect(len(qos.FlowInfo)).Should(Equal(1))
+		Expect(string(qos.Self)).Should(Equal(loc))
+
+		rr, req = CreateQosReqForNEF(ctx, "GET", "11111", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+		Expect(json.Unmarshal(rr.Body.Bytes(), &qos)).Should(BeNil())
+		Expect(qos.AltQoSReferences).Should(Equal(
+			[]string{"qos_video_hd", "qos_video_sd"}))
+
+		rr, req = CreateQosReqForNEF(ctx, "DELETE", "11111", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusNoContent))
+		Expect(pcf.sessions()).Should(Equal(0))
+
+		rr, req = CreateQosReqForNEF(ctx, "GET", "11111", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusNotFound))
+	})
+
+	It("Rejects invalid QoS sessions", func() {
+
+		ctx, cancel := startNefWithCfg(createPCFCfg(dir, pcf.server.URL))
+		defer cancel()
+
+		// qosReference is missing
+		body, _ := ioutil.ReadFile(testJSONPath + "AF_NEF_QOS_POST_02.json")
+		rr, req := CreateQosReqForNEF(ctx, "POST", "", body)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusBadRequest))
+
+		rr, req = CreateQosReqForNEF(ctx, "POST", "", []byte("{"))
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusBadRequest))
+
+		rr, req = CreateQosReqForNEF(ctx, "POST", "", postbody)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+
+		// The UE of the session can not be changed
+		var qos ngcnef.AsSessionWithQoSSubscription
+		Expect(json.Unmarshal(putbody, &qos)).Should(BeNil())
+		qos.UeIpv4Addr = "192.168.1.2"
+		body, _ = json.Marshal(qos)
+		rr, req = CreateQosReqForNEF(ctx, "PUT", "11111", body)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusBadRequest))
+
+		rr, req = CreateQosReqForNEF(ctx, "PATCH", "11112", patchbody)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusNotFound))
+
+		rr, req = CreateQosReqForNEF(ctx, "DELETE", "11111", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusNoContent))
+	})
+
+	It("Fails if the PCF rejects the QoS session", func() {
+
+		server := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				problem(w, http.StatusForbidden,
+					"REQUESTED_SERVICE_NOT_AUTHORIZED")
+			}), &http2.Server{}))
+		defer server.Close()
+
+		ctx, cancel := startNefWithCfg(createPCFCfg(dir, server.URL))
+		defer cancel()
+
+		rr, req := CreateQosReqForNEF(ctx, "POST", "", postbody)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusBadRequest))
+		var pd ngcnef.ProblemDetails
+		Expect(json.Unmarshal(rr.Body.Bytes(), &pd)).Should(BeNil())
+		Expect(pd.Cause).Should(Equal("REQUESTED_SERVICE_NOT_AUTHORIZED"))
+
+		rr, req = CreateQosReqForNEF(ctx, "GET", "", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+		Expect(rr.Body.String()).Should(Equal("null"))
+	})
+
+	It("Restores the QoS sessions after a restart", func() {
+
+		cfgPath := createStoreCfg(dir)
+		ctx, cancel := startNefWithCfg(cfgPath)
+
+		rr, req := CreateQosReqForNEF(ctx, "POST", "", postbody)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+		cancel()
+		time.Sleep(1 * time.Second)
+
+		ctx, cancel = startNefWithCfg(filepath.Join(dir, "nef.json"))
+		defer cancel()
+
+		rr, req = CreateQosReqForNEF(ctx, "GET", "11111", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+
+		rr, req = CreateQosReqForNEF(ctx, "DELETE", "11111", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusNoContent))
+	})
+})
@@ -14,10 +14,16 @@ import (
 	"encoding/json"
 	"errors"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
+	"path/filepath"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/open-ness/epcforedge/ngc/pkg/config"
+	oauth2 "github.com/open-ness/epcforedge/ngc/pkg/oauth2"
 	"golang.org/x/net/http2"
 )
 
@@ -39,79 +45,252 @@ Cache-Control: Not supported and not added
 If-Module-Since: Not supported and not added
 If-Match : Not supported and not added
 Via : This is added by proxies and managed by the GO HTTP stack
-Authorization: Not supported and not added. Might be needed in future when
-OAuth 2.0 would be supported
+Authorization: Bearer access token of the NEF bound to the host of the AF
+when AfNotifConfig.OAuth2 is set, the AF may also authenticate the NEF by its
+client certificate. No token is sent to the SMFs.
 */
 
-// AfClient is an implementation of the Af Notification
+// AfClient is an implementation of the Af Notification. The HTTP clients
+// are created once so the connections towards the AF are reused, the https
+// client is replaced when its certificates are reloaded.
 type AfClient struct {
-	af string
+	af        string
+	userAgent string
+	// Client for http and https AF notification URIs, nil if the AF
+	// certificate could not be loaded
+	httpClient *http.Client
+	// tlsMu guards httpsClient and clientCerts
+	tlsMu       sync.RWMutex
+	httpsClient *http.Client
+	// Client certificate of the NEF, nil if none is presented
+	clientCerts *config.CertReloader
+	// Access tokens of the NEF per AF host, nil if no token is sent
+	tokensMu      sync.Mutex
+	tokens        map[string]*oauth2.TokenSource
+	tokenLifetime time.Duration
 }
 
-// NewAfClient creates a new Udr Client
+// afNotifError is the failure of a notification answered by the AF with an
+// error status or not sent because of its URI. Only the statuses showing
+// that the AF is temporarily unable to handle it are retried.
+type afNotifError struct {
+	status int
+	reason string
+	retry  bool
+}
+
+func (e *afNotifError) Error() string {
+	if e.status != 0 {
+		return "AF notification failed with status " +
+			strconv.Itoa(e.status) + ": " + e.reason
+	}
+	return e.reason
+}
+
+// NewAfClient creates a new Af Client
 func NewAfClient(cfg *Config) *AfClient {
 
 	c := &AfClient{}
 	c.af = "Af Notification Client"
+	c.userAgent = "NEF-OPENNESS-1912"
+	c.httpClient = &http.Client{Timeout: 15 * time.Second}
+	if cfg == nil {
+		return c
+	}
+	if cfg.UserAgent != "" {
+		c.userAgent = cfg.UserAgent
+	}
+	if cfg.AfNotifConfig.OAuth2 {
+		c.tokens = make(map[string]*oauth2.TokenSource)
+		c.tokenLifetime = time.Duration(cfg.AfNotifConfig.TokenLifetime) *
+			time.Second
+		if c.tokenLifetime <= 0 {
+			c.tokenLifetime = defAfNotifTokenLifetime * time.Second
+		}
+	}
+
+	if err := c.reloadTLS(cfg); err != nil {
+		log.Errf("Af Certification loading Error: %v", err)
+	}
 	return c
 }
 
+// tlsLoaded returns true if the https client was created
+func (af *AfClient) tlsLoaded() bool {
+
+	af.tlsMu.RLock()
+	defer af.tlsMu.RUnlock()
+	return af.httpsClient != nil
+}
+
+// reloadTLS reads the root CA of the AFs and the client certificate of the
+// NEF again and replaces the https client, the next notifications use new
+// connections. The current client is kept if they cannot be loaded.
+func (af *AfClient) reloadTLS(cfg *Config) error {
+
+	CACert, err := ioutil.ReadFile(filepath.Clean(
+		cfg.HTTP2Config.AfClientCert))
+	if err != nil {
+		return err
+	}
+	CACertPool := x509.NewCertPool()
+	if !CACertPool.AppendCertsFromPEM(CACert) {
+		return errors.New("Invalid AfClientCert " +
+			cfg.HTTP2Config.AfClientCert)
+	}
+
+	af.tlsMu.Lock()
+	defer af.tlsMu.Unlock()
+
+	tlsCfg := &tls.Config{
+		RootCAs: CACertPool,
+	}
+	certs := af.clientCerts
+	if cfg.AfNotifConfig.ClientCert == "" {
+		certs = nil
+	} else if certs == nil {
+		certs, err = config.NewCertReloader(cfg.AfNotifConfig.ClientCert,
+			cfg.AfNotifConfig.ClientKey)
+	} else {
+		err = certs.Reload(cfg.AfNotifConfig.ClientCert,
+			cfg.AfNotifConfig.ClientKey)
+	}
+	if err != nil {
+		return errors.New("NEF client certificate loading Error: " +
+			err.Error())
+	}
+	if certs != nil {
+		tlsCfg.GetClientCertificate = certs.GetClientCertificate
+	}
+
+	if af.httpsClient != nil {
+		af.httpsClient.Transport.(*http2.Transport).CloseIdleConnections()
+	}
+	af.clientCerts = certs
+	af.httpsClient = &http.Client{
+		Timeout: 15 * time.Second,
+		Transport: &http2.Transport{
+			TLSClientConfig: tlsCfg,
+		},
+	}
+	return nil
+}
+
 // AfNotificationUpfEvent is an implementation for sending upf event
 func (af *AfClient) AfNotificationUpfEvent(ctx context.Context,
 	afURI URI, body EventNotification) error {
 
-	var client http.Client
+	log.Infof("AfNotificationUpfEvent uri :%s", afURI)
+	return af.afNotificationPost(ctx, afURI, body, true)
+}
 
-	nefCtx := ctx.Value(nefCtxKey("nefCtx")).(*nefContext)
+// AfNotificationMonitoringEvent is an implementation for sending monitoring
+// event reports
+func (af *AfClient) AfNotificationMonitoringEvent(ctx context.Context,
+	afURI URI, body MonitoringNotification) error {
 
-	log.Infof("AfNotificationUpfEvent uri :%s", afURI)
+	log.Infof("AfNotificationMonitoringEvent uri :%s", afURI)
+	return af.afNotificationPost(ctx, afURI, body, true)
+}
+
+// AfNotificationPfdReport is an implementation for sending the PFD reports
+func (af *AfClient) AfNotificationPfdReport(ctx context.Context,
+	afURI URI, body []PfdReport) error {
+
+	log.Infof("AfNotificationPfdReport uri :%s", afURI)
+	return af.afNotificationPost(ctx, afURI, body, true)
+}
+
+// PfdChangeNotify is an implementation for sending the PFD changes to the
+// subscribed NF, the AF notification token is not sent to the NFs
+func (af *AfClient) PfdChangeNotify(ctx context.Context,
+	notifyURI URI, body []PfdChangeNotification) error {
+
+	log.Infof("PfdChangeNotify uri :%s", notifyURI)
+	return af.afNotificationPost(ctx, notifyURI, body, false)
+}
+
+// tokenSource returns the source of the NEF access tokens bound to the AF
+// host, nil if no token is sent
+func (af *AfClient) tokenSource(u *url.URL) *oauth2.TokenSource {
+
+	if af.tokens == nil {
+		return nil
+	}
+	host := u.Host
+	if u.Port() == "" {
+		port := "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	af.tokensMu.Lock()
+	defer af.tokensMu.Unlock()
+	src, ok := af.tokens[host]
+	if !ok {
+		src = oauth2.NewAudienceTokenSource(oauth2.NefSubject,
+			[]string{oauth2.ScopeAfNotification}, host, af.tokenLifetime)
+		af.tokens[host] = src
+	}
+	return src
+}
+
+// afNotificationPost sends the notification body to the AF, with the NEF
+// access token of the AF host if withToken is set
+func (af *AfClient) afNotificationPost(ctx context.Context,
+	afURI URI, body interface{}, withToken bool) error {
+
+	var client *http.Client
 
 	/* Check the url type - if its https or http */
 	u, err := url.Parse(string(afURI))
 	if err != nil {
 		log.Errf("AfNotification URl error :%v", err)
-		return err
+		return &afNotifError{reason: err.Error()}
 	}
 
-	// If https then load the certificate
 	if u.Scheme == "https" {
-		CACert, err1 := ioutil.ReadFile(nefCtx.cfg.HTTP2Config.AfClientCert)
-		if err1 != nil {
-			log.Errf("Af Certification loading Error: %v", err)
-			return err1
-		}
-
-		CACertPool := x509.NewCertPool()
-		CACertPool.AppendCertsFromPEM(CACert)
-
-		client = http.Client{
-			Timeout: 15 * time.Second,
-			Transport: &http2.Transport{
-				TLSClientConfig: &tls.Config{
-					RootCAs: CACertPool,
-				},
-			},
+		af.tlsMu.RLock()
+		client = af.httpsClient
+		af.tlsMu.RUnlock()
+		if client == nil {
+			return &afNotifError{reason: "Af certificate not loaded"}
 		}
 	} else if u.Scheme == "http" {
-		client = http.Client{Timeout: 15 * time.Second}
+		client = af.httpClient
 	} else {
 		log.Errf("Unsupported url scheme: %s", u.Scheme)
-		return errors.New("Unsupported url scheme")
-
+		return &afNotifError{reason: "Unsupported url scheme"}
 	}
 
 	requestBody, err := json.Marshal(body)
 	if err != nil {
 		log.Err(err)
-		return err
+		return &afNotifError{reason: err.Error()}
 	}
-	//log.Infof("POST body ==> \n %s", string(requestBody))
 	// Set request type as POST
-	req, _ := http.NewRequest("POST", string(afURI),
+	req, err := http.NewRequest("POST", string(afURI),
 		bytes.NewBuffer(requestBody))
+	if err != nil {
+		return &afNotifError{reason: err.Error()}
+	}
 	// Add user-agent header and content-type header
-	req.Header.Set("User-Agent", "NEF-OPENNESS-1912")
+	req.Header.Set("User-Agent", af.userAgent)
 	req.Header.Set("Content-Type", "application/json")
+	token := ""
+	var tokens *oauth2.TokenSource
+	if withToken {
+		tokens = af.tokenSource(u)
+	}
+	if tokens != nil {
+		if token, err = tokens.Token(); err != nil {
+			log.Errf("Failed to get the NEF access token: %v", err)
+			return &afNotifError{reason: err.Error(), retry: true}
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 	req = req.WithContext(ctx)
 	log.Info("Sending a request to the server")
 	resp, err := client.Do(req)
@@ -133,5 +312,22 @@ func (af *AfClient) AfNotificationUpfEvent(ctx context.Context,
 	log.Info("Body in the response =>")
 	respbody, err := ioutil.ReadAll(resp.Body)
 	log.Infof(string(respbody))
-	return err
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized && token != "" {
+		// The token is replaced for the retry
+		tokens.Invalidate(token)
+		return &afNotifError{status: resp.StatusCode,
+			reason: http.StatusText(resp.StatusCode), retry: true}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &afNotifError{status: resp.StatusCode,
+			reason: http.StatusText(resp.StatusCode),
+			retry: resp.StatusCode >= 500 ||
+				resp.StatusCode == http.StatusRequestTimeout ||
+				resp.StatusCode == http.StatusTooManyRequests}
+	}
+	return nil
 }
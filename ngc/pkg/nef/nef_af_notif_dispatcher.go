@@ -0,0 +1,323 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Defaults of the AfNotifConfig
+const (
+	defAfNotifWorkers          = 4
+	defAfNotifQueueSize        = 100
+	defAfNotifMaxAttempts      = 5
+	defAfNotifRetryInterval    = 500
+	defAfNotifMaxRetryInterval = 30000
+	defAfNotifDeadLetterSize   = 100
+	defAfNotifTokenLifetime    = 60
+)
+
+// afNotifJob is a notification waiting to be sent to the AF
+type afNotifJob struct {
+	uri      URI
+	body     interface{}
+	send     func(ctx context.Context) error
+	attempts int
+}
+
+// afNotifQueue holds the notifications of an AF destination, they are sent
+// in order by one worker at a time
+type afNotifQueue struct {
+	dest string
+	jobs []*afNotifJob
+	// Owned by a worker, waiting for a worker or for a retry
+	running bool
+}
+
+// afNotifDispatcher sends the notifications to the AFs asynchronously. The
+// notifications are queued per AF destination (scheme and host), a pool of
+// workers sends them and the failed ones are retried with an exponential
+// backoff. The notifications which cannot be delivered are kept in a
+// bounded dead-letter list.
+type afNotifDispatcher struct {
+	ctx      context.Context
+	client   AfNotification
+	cfg      AfNotifConfig
+	workerWg sync.WaitGroup
+
+	//mu guards all the fields below
+	mu          sync.Mutex
+	cond        *sync.Cond
+	stopped     bool
+	queues      map[string]*afNotifQueue
+	ready       []*afNotifQueue
+	stats       AfNotifStats
+	deadLetters []AfNotifDeadLetter
+}
+
+// newAfNotifDispatcher creates the dispatcher and starts its workers, they
+// stop when ctx is done or stop is called
+func newAfNotifDispatcher(ctx context.Context, cfg *Config,
+	client AfNotification) *afNotifDispatcher {
+
+	d := &afNotifDispatcher{ctx: ctx, client: client, cfg: cfg.AfNotifConfig,
+		queues: make(map[string]*afNotifQueue)}
+	d.cond = sync.NewCond(&d.mu)
+
+	if d.cfg.Workers <= 0 {
+		d.cfg.Workers = defAfNotifWorkers
+	}
+	if d.cfg.QueueSize <= 0 {
+		d.cfg.QueueSize = defAfNotifQueueSize
+	}
+	if d.cfg.MaxAttempts <= 0 {
+		d.cfg.MaxAttempts = defAfNotifMaxAttempts
+	}
+	if d.cfg.RetryInterval <= 0 {
+		d.cfg.RetryInterval = defAfNotifRetryInterval
+	}
+	if d.cfg.MaxRetryInterval < d.cfg.RetryInterval {
+		d.cfg.MaxRetryInterval = defAfNotifMaxRetryInterval
+		if d.cfg.MaxRetryInterval < d.cfg.RetryInterval {
+			d.cfg.MaxRetryInterval = d.cfg.RetryInterval
+		}
+	}
+	if d.cfg.DeadLetterSize <= 0 {
+		d.cfg.DeadLetterSize = defAfNotifDeadLetterSize
+	}
+
+	for i := 0; i < d.cfg.Workers; i++ {
+		d.workerWg.Add(1)
+		go d.worker()
+	}
+	go func() {
+		<-ctx.Done()
+		d.stop()
+	}()
+	return d
+}
+
+// dispatchUpfEvent queues the UP path change event for the AF
+func (d *afNotifDispatcher) dispatchUpfEvent(afURI URI,
+	ev EventNotification) {
+
+	d.enqueue(&afNotifJob{uri: afURI, body: ev,
+		send: func(ctx context.Context) error {
+			return d.client.AfNotificationUpfEvent(ctx, afURI, ev)
+		}})
+}
+
+// dispatchMonitoringEvent queues the monitoring event reports for the AF
+func (d *afNotifDispatcher) dispatchMonitoringEvent(afURI URI,
+	notif MonitoringNotification) {
+
+	d.enqueue(&afNotifJob{uri: afURI, body: notif,
+		send: func(ctx context.Context) error {
+			return d.client.AfNotificationMonitoringEvent(ctx, afURI, notif)
+		}})
+}
+
+// dispatchPfdReports queues the PFD reports for the AF
+func (d *afNotifDispatcher) dispatchPfdReports(afURI URI,
+	reports []PfdReport) {
+
+	d.enqueue(&afNotifJob{uri: afURI, body: reports,
+		send: func(ctx context.Context) error {
+			return d.client.AfNotificationPfdReport(ctx, afURI, reports)
+		}})
+}
+
+// dispatchPfdChange queues the PFD change notifications for the subscribed
+// NF
+func (d *afNotifDispatcher) dispatchPfdChange(notifyURI URI,
+	notifs []PfdChangeNotification) {
+
+	d.enqueue(&afNotifJob{uri: notifyURI, body: notifs,
+		send: func(ctx context.Context) error {
+			return d.client.PfdChangeNotify(ctx, notifyURI, notifs)
+		}})
+}
+
+// afNotifDestination returns the destination whose queue is used for the
+// AF notification URI
+func afNotifDestination(afURI URI) string {
+
+	u, err := url.Parse(string(afURI))
+	if err != nil || u.Host == "" {
+		return string(afURI)
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+func (d *afNotifDispatcher) enqueue(job *afNotifJob) {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.stopped {
+		log.Errf("AF notification to %s dropped, NEF stopped", job.uri)
+		return
+	}
+
+	dest := afNotifDestination(job.uri)
+	q, ok := d.queues[dest]
+	if !ok {
+		q = &afNotifQueue{dest: dest}
+		d.queues[dest] = q
+	}
+	if len(q.jobs) >= d.cfg.QueueSize {
+		log.Errf("AF notification to %s dropped, queue full", job.uri)
+		d.stats.Dropped++
+		d.addDeadLetter(job, "Queue of the AF destination full")
+		return
+	}
+
+	q.jobs = append(q.jobs, job)
+	d.stats.Queued++
+	d.stats.Pending++
+	if !q.running {
+		q.running = true
+		d.makeReady(q)
+	}
+}
+
+// makeReady gives the queue to a worker, the caller must hold mu
+func (d *afNotifDispatcher) makeReady(q *afNotifQueue) {
+
+	d.ready = append(d.ready, q)
+	d.cond.Signal()
+}
+
+// backoff returns the delay before the next attempt of a job which failed
+// attempts times
+func (d *afNotifDispatcher) backoff(attempts int) time.Duration {
+
+	delay := d.cfg.RetryInterval
+	for i := 1; i < attempts && delay < d.cfg.MaxRetryInterval; i++ {
+		delay *= 2
+	}
+	if delay > d.cfg.MaxRetryInterval {
+		delay = d.cfg.MaxRetryInterval
+	}
+	return time.Duration(delay) * time.Millisecond
+}
+
+func (d *afNotifDispatcher) worker() {
+
+	defer d.workerWg.Done()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for {
+		for len(d.ready) == 0 && !d.stopped {
+			d.cond.Wait()
+		}
+		if d.stopped {
+			return
+		}
+		q := d.ready[0]
+		d.ready = d.ready[1:]
+		job := q.jobs[0]
+
+		d.mu.Unlock()
+		err := job.send(d.ctx)
+		d.mu.Lock()
+
+		job.attempts++
+		if err != nil {
+			nErr, ok := err.(*afNotifError)
+			if (!ok || nErr.retry) && job.attempts < d.cfg.MaxAttempts &&
+				!d.stopped {
+				// The queue waits for the retry to keep the order
+				d.stats.Retried++
+				delay := d.backoff(job.attempts)
+				log.Errf("AF notification to %s failed, retry in %v: %v",
+					job.uri, delay, err)
+				time.AfterFunc(delay, func() {
+					d.mu.Lock()
+					defer d.mu.Unlock()
+					if !d.stopped {
+						d.makeReady(q)
+					}
+				})
+				continue
+			}
+			log.Errf("AF notification to %s failed after %d attempts: %v",
+				job.uri, job.attempts, err)
+			d.stats.Failed++
+			d.addDeadLetter(job, err.Error())
+		} else {
+			d.stats.Delivered++
+		}
+
+		q.jobs = q.jobs[1:]
+		d.stats.Pending--
+		if len(q.jobs) != 0 {
+			d.makeReady(q)
+		} else {
+			q.running = false
+			delete(d.queues, q.dest)
+		}
+	}
+}
+
+// addDeadLetter keeps the job in the dead-letter list dropping the oldest
+// entry when it is full, the caller must hold mu
+func (d *afNotifDispatcher) addDeadLetter(job *afNotifJob, cause string) {
+
+	if len(d.deadLetters) >= d.cfg.DeadLetterSize {
+		d.deadLetters = d.deadLetters[1:]
+	}
+	d.deadLetters = append(d.deadLetters, AfNotifDeadLetter{
+		Destination: job.uri, Notification: job.body,
+		Attempts: job.attempts, Cause: cause,
+		Time: time.Now().UTC().Format(time.RFC3339)})
+}
+
+// status returns the counters and a copy of the dead-letter list
+func (d *afNotifDispatcher) status() AfNotifStatus {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	st := AfNotifStatus{Stats: d.stats,
+		DeadLetters: make([]AfNotifDeadLetter, len(d.deadLetters))}
+	copy(st.DeadLetters, d.deadLetters)
+	return st
+}
+
+// stop stops the workers once they have finished their current attempt, the
+// queued notifications are lost
+func (d *afNotifDispatcher) stop() {
+
+	d.mu.Lock()
+	if d.stopped {
+		d.mu.Unlock()
+		return
+	}
+	d.stopped = true
+	if d.stats.Pending != 0 {
+		log.Errf("%d AF notifications not sent, NEF stopped",
+			d.stats.Pending)
+	}
+	d.cond.Broadcast()
+	d.mu.Unlock()
+
+	d.workerWg.Wait()
+}
+
+// ReadAfNotificationStatus : Returns the delivery counters and the
+// dead-letter list of the notifications sent to the AFs
+func ReadAfNotificationStatus(w http.ResponseWriter, r *http.Request) {
+
+	nefCtx := r.Context().Value(nefCtxKey("nefCtx")).(*nefContext)
+
+	sendAdminRsp(w, http.StatusOK, nefCtx.nef.afNotifier.status())
+}
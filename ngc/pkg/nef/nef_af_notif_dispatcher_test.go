@@ -0,0 +1,386 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	ngcnef "github.com/open-ness/epcforedge/ngc/pkg/nef"
+	"github.com/open-ness/epcforedge/ngc/pkg/oauth2"
+)
+
+const afNotifStatusURL = "http://localhost:8091/nef-admin/v1/af-notifications"
+
+// createAfNotifCfg creates a configuration using the PCF at pcfAPIRoot and
+// retrying the AF notifications quickly
+func createAfNotifCfg(dir string, pcfAPIRoot string) string {
+	return createNefCfg(dir, map[string]interface{}{
+		"PCFConfig": sbConfig(pcfAPIRoot),
+		"AfNotifConfig": map[string]int{"workers": 2, "queueSize": 10,
+			"maxAttempts": 3, "retryInterval": 20, "maxRetryInterval": 50}})
+}
+
+// fakeAF is an AF notification server answering with the statuses in
+// order, the last one is repeated
+type fakeAF struct {
+	server   *httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	requests int
+	bodies   [][]byte
+	auths    []string
+}
+
+func newFakeAF(statuses ...int) *fakeAF {
+
+	af := &fakeAF{statuses: statuses}
+	af.server = httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			b, _ := ioutil.ReadAll(r.Body)
+			af.mu.Lock()
+			af.bodies = append(af.bodies, b)
+			af.auths = append(af.auths, r.Header.Get("Authorization"))
+			i := af.requests
+			if i >= len(af.statuses) {
+				i = len(af.statuses) - 1
+			}
+			af.requests++
+			af.mu.Unlock()
+			w.WriteHeader(af.statuses[i])
+		}))
+	return af
+}
+
+func (af *fakeAF) received() int {
+	af.mu.Lock()
+	defer af.mu.Unlock()
+	return af.requests
+}
+
+// event returns the event notification of the i-th request
+func (af *fakeAF) event(i int) ngcnef.EventNotification {
+	var ev ngcnef.EventNotification
+	af.mu.Lock()
+	defer af.mu.Unlock()
+	Expect(json.Unmarshal(af.bodies[i], &ev)).Should(BeNil())
+	return ev
+}
+
+var _ = Describe("Test NEF AF Notification Dispatcher", func() {
+
+	var (
+		pcf *fakePCF
+		dir string
+	)
+
+	postbody, _ := ioutil.ReadFile(testJSONPath + "AF_NEF_POST_01.json")
+	notifbody, _ := ioutil.ReadFile(testJSONPath + "SMF_NEF_NOTIF_01.json")
+
+	// subscribe creates a traffic influence subscription notified at afURL
+	// and sends an UP path change of the SMF for it
+	subscribeAndNotify := func(ctx context.Context, afURL string) {
+		var ti ngcnef.TrafficInfluSub
+		Expect(json.Unmarshal(postbody, &ti)).Should(BeNil())
+		ti.NotificationDestination = ngcnef.Link(afURL)
+		b, _ := json.Marshal(ti)
+
+		rr, req := CreateReqForNEF(ctx, "POST", "", b)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+
+		req, _ = http.NewRequest("POST", NefTIFApiPrefixHTTP2+
+			"notification/upf", bytes.NewBuffer(notifbody))
+		rr = httptest.NewRecorder()
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+	}
+
+	status := func(ctx context.Context) ngcnef.AfNotifStatus {
+		var st ngcnef.AfNotifStatus
+		req, _ := http.NewRequest("GET", afNotifStatusURL, nil)
+		rr := httptest.NewRecorder()
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+		Expect(json.Unmarshal(rr.Body.Bytes(), &st)).Should(BeNil())
+		return st
+	}
+
+	BeforeEach(func() {
+		var err error
+		pcf = newFakePCF()
+		dir, err = ioutil.TempDir("", "nefafnotif")
+		Expect(err).Should(BeNil())
+	})
+
+	AfterEach(func() {
+		pcf.server.Close()
+		os.RemoveAll(dir)
+	})
+
+	It("Retries the notification while the AF is unavailable", func() {
+
+		af := newFakeAF(http.StatusServiceUnavailable,
+			http.StatusServiceUnavailable, http.StatusNoContent)
+		defer af.server.Close()
+
+		ctx, cancel := startNefWithCfg(createAfNotifCfg(dir, pcf.server.URL))
+		defer cancel()
+
+		subscribeAndNotify(ctx, af.server.URL)
+		Eventually(af.received, 2*time.Second,
+			10*time.Millisecond).Should(Equal(3))
+		Eventually(func() uint64 { return status(ctx).Stats.Delivered },
+			2*time.Second, 10*time.Millisecond).Should(Equal(uint64(1)))
+
+		st := status(ctx)
+		Expect(st.Stats).Should(Equal(ngcnef.AfNotifStats{Queued: 1,
+			Delivered: 1, Retried: 2}))
+		Expect(st.DeadLetters).Should(BeEmpty())
+	})
+
+	It("Keeps the notifications rejected by the AF as dead letters", func() {
+
+		af := newFakeAF(http.StatusBadRequest)
+		defer af.server.Close()
+
+		ctx, cancel := startNefWithCfg(createAfNotifCfg(dir, pcf.server.URL))
+		defer cancel()
+
+		subscribeAndNotify(ctx, af.server.URL)
+		Eventually(func() uint64 { return status(ctx).Stats.Failed },
+			2*time.Second, 10*time.Millisecond).Should(Equal(uint64(1)))
+
+		st := status(ctx)
+		Expect(af.received()).Should(Equal(1))
+		Expect(st.Stats.Retried).Should(Equal(uint64(0)))
+		Expect(len(st.DeadLetters)).Should(Equal(1))
+		Expect(st.DeadLetters[0].Destination).Should(
+			Equal(ngcnef.URI(af.server.URL)))
+		Expect(st.DeadLetters[0].Attempts).Should(Equal(1))
+		Expect(st.DeadLetters[0].Cause).Should(Equal(
+			"AF notification failed with status 400: Bad Request"))
+	})
+
+	It("Sends the test notification requested by the AF", func() {
+
+		af := newFakeAF(http.StatusNoContent)
+		defer af.server.Close()
+
+		ctx, cancel := startNefWithCfg(createAfNotifCfg(dir, pcf.server.URL))
+		defer cancel()
+
+		var ti ngcnef.TrafficInfluSub
+		Expect(json.Unmarshal(postbody, &ti)).Should(BeNil())
+		ti.NotificationDestination = ngcnef.Link(af.server.URL)
+		ti.RequestTestNotification = true
+		b, _ := json.Marshal(ti)
+
+		rr, req := CreateReqForNEF(ctx, "POST", "", b)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+
+		Eventually(af.received, 2*time.Second,
+			10*time.Millisecond).Should(Equal(1))
+		ev := af.event(0)
+		Expect(ev.AfTransID).Should(Equal(ti.AfTransID))
+		Expect(ev.SubscribedEvent).Should(Equal(ngcnef.TestNotification))
+
+		// Not sent again on update
+		patch, _ := json.Marshal(ngcnef.TrafficInfluSubPatch{
+			AppReloInd: true})
+		rr, req = CreateReqForNEF(ctx, "PATCH", "11111", patch)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+		Consistently(af.received, 200*time.Millisecond).Should(Equal(1))
+	})
+
+	It("Gives up after the maximum number of attempts", func() {
+
+		af := newFakeAF(http.StatusNoContent)
+		afURL := af.server.URL
+		af.server.Close()
+
+		ctx, cancel := startNefWithCfg(createAfNotifCfg(dir, pcf.server.URL))
+		defer cancel()
+
+		subscribeAndNotify(ctx, afURL)
+		Eventually(func() uint64 { return status(ctx).Stats.Failed },
+			2*time.Second, 10*time.Millisecond).Should(Equal(uint64(1)))
+
+		st := status(ctx)
+		Expect(st.Stats.Retried).Should(Equal(uint64(2)))
+		Expect(st.Stats.Pending).Should(Equal(0))
+		Expect(st.DeadLetters[0].Attempts).Should(Equal(3))
+	})
+
+	It("Reloads the certificates of the NEF client", func() {
+
+		var (
+			cfg     map[string]interface{}
+			cfgPath string
+			peersMu sync.Mutex
+			peers   []string
+		)
+
+		// The AF notification server requires a client certificate, its
+		// server certificate is issued by the CA of srvCA
+		var srvCA *testCert
+		af := httptest.NewUnstartedServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				peersMu.Lock()
+				peers = append(peers,
+					r.TLS.PeerCertificates[0].Subject.CommonName)
+				peersMu.Unlock()
+				w.WriteHeader(http.StatusNoContent)
+			}))
+		af.EnableHTTP2 = true
+		af.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert,
+			GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate,
+				error) {
+				peersMu.Lock()
+				defer peersMu.Unlock()
+				cert := issueCert(srvCA, "localhost", false).tlsCert()
+				return &cert, nil
+			}}
+		received := func() []string {
+			peersMu.Lock()
+			defer peersMu.Unlock()
+			return append([]string{}, peers...)
+		}
+		writeCfg := func(caPath string) {
+			cfg["HTTP2Config"].(map[string]interface{})["AfClientCert"] =
+				caPath
+			b, _ := json.Marshal(cfg)
+			Expect(ioutil.WriteFile(cfgPath, b, 0600)).Should(BeNil())
+		}
+
+		ca1 := issueCert(nil, "AF CA 1", true)
+		ca1Path, _ := ca1.write(dir, "ca1")
+		ca2 := issueCert(nil, "AF CA 2", true)
+		ca2Path, _ := ca2.write(dir, "ca2")
+		nefCert, nefKey := issueCert(ca1, "nef1.test", false).write(dir,
+			"nef")
+
+		cfgPath = createAfNotifCfg(dir, pcf.server.URL)
+		b, _ := ioutil.ReadFile(cfgPath)
+		Expect(json.Unmarshal(b, &cfg)).Should(BeNil())
+		cfg["AfNotifConfig"].(map[string]interface{})["ClientCert"] = nefCert
+		cfg["AfNotifConfig"].(map[string]interface{})["ClientKey"] = nefKey
+		writeCfg(ca1Path)
+
+		srvCA = ca1
+		af.StartTLS()
+		defer af.Close()
+		// Sent with the server name selecting the certificate
+		afURL := strings.Replace(af.URL, "127.0.0.1", "localhost", 1)
+
+		ctx, cancel := startNefWithCfg(cfgPath)
+		defer cancel()
+
+		subscribeAndNotify(ctx, afURL)
+		Eventually(received, 2*time.Second, 10*time.Millisecond).Should(
+			Equal([]string{"nef1.test"}))
+
+		// The client certificate is renewed in place and the AF server
+		// certificate issued by another root CA
+		issueCert(ca1, "nef2.test", false).write(dir, "nef")
+		writeCfg(ca2Path)
+		peersMu.Lock()
+		srvCA = ca2
+		peersMu.Unlock()
+		req, _ := http.NewRequest(http.MethodPost,
+			"http://localhost:8091/nef-admin/v1/config/reload", nil)
+		rr := httptest.NewRecorder()
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+		var res ngcnef.ConfigReloadResult
+		Expect(json.Unmarshal(rr.Body.Bytes(), &res)).Should(BeNil())
+		Expect(res.Applied).Should(ConsistOf("HTTP2Config.AfClientCert"))
+		Expect(res.RestartRequired).Should(BeEmpty())
+
+		subscribeAndNotify(ctx, afURL)
+		Eventually(received, 2*time.Second, 10*time.Millisecond).Should(
+			Equal([]string{"nef1.test", "nef2.test"}))
+	})
+
+	It("Sends the access token of the NEF with the notifications", func() {
+
+		var cfg map[string]interface{}
+
+		oauth2Path := oauth2.CfgPath
+		defer func() { oauth2.CfgPath = oauth2Path }()
+		oauth2.CfgPath = filepath.Join(dir, "oauth2.json")
+		Expect(ioutil.WriteFile(oauth2.CfgPath,
+			[]byte(`{"signingkey": "TESTKEY", "algorithm": "HS256",
+			"expiration": 600}`),
+			0600)).Should(BeNil())
+
+		cfgPath := createAfNotifCfg(dir, pcf.server.URL)
+		b, _ := ioutil.ReadFile(cfgPath)
+		Expect(json.Unmarshal(b, &cfg)).Should(BeNil())
+		cfg["AfNotifConfig"].(map[string]interface{})["OAuth2"] = true
+		b, _ = json.Marshal(cfg)
+		Expect(ioutil.WriteFile(cfgPath, b, 0600)).Should(BeNil())
+
+		// The AF rejects the first token, a new one is sent by the retry
+		af := newFakeAF(http.StatusUnauthorized, http.StatusNoContent)
+		defer af.server.Close()
+
+		ctx, cancel := startNefWithCfg(cfgPath)
+		defer cancel()
+
+		subscribeAndNotify(ctx, af.server.URL)
+		Eventually(func() uint64 { return status(ctx).Stats.Delivered },
+			2*time.Second, 10*time.Millisecond).Should(Equal(uint64(1)))
+		Expect(status(ctx).Stats.Retried).Should(Equal(uint64(1)))
+
+		// The tokens are bound to the AF host and short-lived
+		u, err := url.Parse(af.server.URL)
+		Expect(err).Should(BeNil())
+		af.mu.Lock()
+		auths := af.auths
+		af.mu.Unlock()
+		Expect(auths).Should(HaveLen(2))
+		for _, auth := range auths {
+			Expect(auth).Should(HavePrefix("Bearer "))
+			claims, _, err := oauth2.ParseAudienceAccessToken(
+				auth[len("Bearer "):], u.Host)
+			Expect(err).Should(BeNil())
+			Expect(claims.Subject).Should(Equal("NEF"))
+			Expect(claims.HasScope(oauth2.ScopeAfNotification)).Should(
+				BeTrue())
+			Expect(claims.StandardClaims.ExpiresAt.Time).Should(
+				BeTemporally("<=", time.Now().Add(60*time.Second)))
+			_, _, err = oauth2.ParseAudienceAccessToken(
+				auth[len("Bearer "):], "other-af:443")
+			Expect(err).ShouldNot(BeNil())
+		}
+
+		// No AF notification token is sent to the SMFs
+		smf := newFakeAF(http.StatusNoContent)
+		defer smf.server.Close()
+		var nefCfg ngcnef.Config
+		Expect(json.Unmarshal(b, &nefCfg)).Should(BeNil())
+		Expect(ngcnef.NewAfClient(&nefCfg).PfdChangeNotify(ctx,
+			ngcnef.URI(smf.server.URL), nil)).Should(BeNil())
+		smf.mu.Lock()
+		defer smf.mu.Unlock()
+		Expect(smf.auths).Should(Equal([]string{""}))
+	})
+})
@@ -18,4 +18,22 @@ type AfNotification interface {
 	AfNotificationUpfEvent(ctx context.Context,
 		afURI URI,
 		body EventNotification) error
+
+	// AfNotificationMonitoringEvent sends the monitoring event reports
+	// through POST method towards the AF
+	AfNotificationMonitoringEvent(ctx context.Context,
+		afURI URI,
+		body MonitoringNotification) error
+
+	// AfNotificationPfdReport sends the PFD reports of the applications
+	// removed by the NEF through POST method towards the AF
+	AfNotificationPfdReport(ctx context.Context,
+		afURI URI,
+		body []PfdReport) error
+
+	// PfdChangeNotify sends the PFD change notifications through POST
+	// method towards the NF subscribed to the PFDs
+	PfdChangeNotify(ctx context.Context,
+		notifyURI URI,
+		body []PfdChangeNotification) error
 }
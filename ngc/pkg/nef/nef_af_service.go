@@ -0,0 +1,266 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// AfServiceMapping maps the afServiceId of the traffic influence
+// subscriptions onto the DNN and S-NSSAI sent to the PCF, the UDR and the SMF
+type AfServiceMapping struct {
+	// Identifies the service on behalf of which the AF issues the request
+	AfServiceID string `json:"afServiceId"`
+	// Data network name of the service
+	Dnn Dnn `json:"dnn"`
+	// Network slice of the service
+	Snssai Snssai `json:"snssai"`
+	// DNAIs the traffic of the service may be routed to, any DNAI if empty
+	Dnais []Dnai `json:"dnais,omitempty"`
+}
+
+var sdPattern = regexp.MustCompile("^[A-Fa-f0-9]{6}$")
+
+// afServiceRegistry holds the AF service mappings. They are loaded from the
+// afServiceIDs of the configuration, changes done through the admin API are
+// only kept in memory.
+type afServiceRegistry struct {
+
+	//mu guards services
+	mu       sync.RWMutex
+	services map[string]AfServiceMapping
+}
+
+// newAfServiceRegistry creates the registry with the configured mappings
+func newAfServiceRegistry(mappings []AfServiceMapping) (*afServiceRegistry,
+	error) {
+
+	s := &afServiceRegistry{services: make(map[string]AfServiceMapping)}
+	for _, m := range mappings {
+		if reason := validateAfService(m); reason != "" {
+			return nil, errors.New("Invalid AF service " + m.AfServiceID +
+				": " + reason)
+		}
+		if _, ok := s.services[m.AfServiceID]; ok {
+			return nil, errors.New("Duplicate AF service " + m.AfServiceID)
+		}
+		s.services[m.AfServiceID] = m
+	}
+	log.Infof("Loaded %d AF services", len(s.services))
+	return s, nil
+}
+
+// validateAfService returns the reason why the mapping is invalid or an
+// empty string
+func validateAfService(m AfServiceMapping) string {
+
+	if m.AfServiceID == "" {
+		return "missing afServiceId"
+	}
+	if m.Dnn == "" {
+		return "missing dnn"
+	}
+	if m.Snssai.Sd != "" && !sdPattern.MatchString(m.Snssai.Sd) {
+		return "snssai sd is not 6 hexadecimal digits"
+	}
+	for _, dnai := range m.Dnais {
+		if dnai == "" {
+			return "empty dnai"
+		}
+	}
+	return ""
+}
+
+func (s *afServiceRegistry) get(id string) (AfServiceMapping, bool) {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	m, ok := s.services[id]
+	return m, ok
+}
+
+// list returns the mappings sorted by the service ID
+func (s *afServiceRegistry) list() []AfServiceMapping {
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	mappings := make([]AfServiceMapping, 0, len(s.services))
+	for _, m := range s.services {
+		mappings = append(mappings, m)
+	}
+	sort.Slice(mappings, func(i, j int) bool {
+		return mappings[i].AfServiceID < mappings[j].AfServiceID
+	})
+	return mappings
+}
+
+// put creates or replaces a mapping and returns true if it was created
+func (s *afServiceRegistry) put(m AfServiceMapping) bool {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, exists := s.services[m.AfServiceID]
+	s.services[m.AfServiceID] = m
+	return !exists
+}
+
+// delete removes a mapping and returns false if it does not exist
+func (s *afServiceRegistry) delete(id string) bool {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.services[id]; !exists {
+		return false
+	}
+	delete(s.services, id)
+	return true
+}
+
+// validate checks that the afServiceId of the subscription is known and that
+// its traffic routes only use the DNAIs allowed for the service
+func (s *afServiceRegistry) validate(ti TrafficInfluSub) (rsp nefSBRspData,
+	status bool) {
+
+	if ti.AfServiceID == "" {
+		return rsp, true
+	}
+
+	m, ok := s.get(ti.AfServiceID)
+	if !ok {
+		rsp.errorCode = 400
+		rsp.pd.Title = "Invalid afServiceId attribute"
+		rsp.pd.InvalidParams = []InvalidParam{{Param: "/afServiceId",
+			Reason: "Unknown AF service " + ti.AfServiceID}}
+		return rsp, false
+	}
+	if len(m.Dnais) == 0 {
+		return rsp, true
+	}
+
+	for i, route := range ti.TrafficRoutes {
+		if !containsDnai(m.Dnais, route.Dnai) {
+			rsp.pd.InvalidParams = append(rsp.pd.InvalidParams,
+				InvalidParam{Param: "/trafficRoutes/" + strconv.Itoa(i) +
+					"/dnai", Reason: "DNAI " + string(route.Dnai) +
+					" not allowed for AF service " + ti.AfServiceID})
+		}
+	}
+	if len(rsp.pd.InvalidParams) != 0 {
+		rsp.errorCode = 400
+		rsp.pd.Title = "Invalid trafficRoutes attribute"
+		return rsp, false
+	}
+	return rsp, true
+}
+
+// resolve returns the DNN and S-NSSAI of the subscription, the ones of its
+// AF service if known, else the ones given by the AF
+func (s *afServiceRegistry) resolve(ti TrafficInfluSub) (Dnn, Snssai) {
+
+	if ti.AfServiceID != "" {
+		if m, ok := s.get(ti.AfServiceID); ok {
+			return m.Dnn, m.Snssai
+		}
+		log.Infof("AF service %s not found, using the DNN and S-NSSAI "+
+			"of the subscription", ti.AfServiceID)
+	}
+	return ti.Dnn, ti.Snssai
+}
+
+func containsDnai(dnais []Dnai, dnai Dnai) bool {
+
+	for _, d := range dnais {
+		if d == dnai {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadAllAfServices : Returns all the AF service mappings
+func ReadAllAfServices(w http.ResponseWriter, r *http.Request) {
+
+	nefCtx := r.Context().Value(nefCtxKey("nefCtx")).(*nefContext)
+
+	sendAdminRsp(w, http.StatusOK, nefCtx.nef.afServices.list())
+}
+
+// ReadAfService : Returns an AF service mapping
+func ReadAfService(w http.ResponseWriter, r *http.Request) {
+
+	nefCtx := r.Context().Value(nefCtxKey("nefCtx")).(*nefContext)
+
+	vars := mux.Vars(r)
+	log.Infof(" AF SERVICE ID : %s", vars["afServiceId"])
+
+	m, ok := nefCtx.nef.afServices.get(vars["afServiceId"])
+	if !ok {
+		sendCustomeErrorRspToAF(w, 404, "AF service not found")
+		return
+	}
+	sendAdminRsp(w, http.StatusOK, m)
+}
+
+// PutAfService : Creates or replaces an AF service mapping. The existing
+// subscriptions use it when they are sent to the 5GC again.
+func PutAfService(w http.ResponseWriter, r *http.Request) {
+
+	nefCtx := r.Context().Value(nefCtxKey("nefCtx")).(*nefContext)
+
+	vars := mux.Vars(r)
+	log.Infof(" AF SERVICE ID : %s", vars["afServiceId"])
+
+	m := AfServiceMapping{}
+	if !readAdminBody(w, r, &m) {
+		return
+	}
+
+	if m.AfServiceID == "" {
+		m.AfServiceID = vars["afServiceId"]
+	}
+	if m.AfServiceID != vars["afServiceId"] {
+		sendCustomeErrorRspToAF(w, 400,
+			"afServiceId does not match the URI")
+		return
+	}
+	if reason := validateAfService(m); reason != "" {
+		sendCustomeErrorRspToAF(w, 400, reason)
+		return
+	}
+
+	if nefCtx.nef.afServices.put(m) {
+		sendAdminRsp(w, http.StatusCreated, m)
+		return
+	}
+	sendAdminRsp(w, http.StatusOK, m)
+}
+
+// DeleteAfService : Deletes an AF service mapping. The subscriptions
+// referring to it are not changed, the DNN and S-NSSAI given by the AF are
+// used when they are sent to the 5GC again.
+func DeleteAfService(w http.ResponseWriter, r *http.Request) {
+
+	nefCtx := r.Context().Value(nefCtxKey("nefCtx")).(*nefContext)
+
+	vars := mux.Vars(r)
+	log.Infof(" AF SERVICE ID : %s", vars["afServiceId"])
+
+	if !nefCtx.nef.afServices.delete(vars["afServiceId"]) {
+		sendCustomeErrorRspToAF(w, 404, "AF service not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+	log.Infof("HTTP Response sent: %d", http.StatusNoContent)
+}
@@ -0,0 +1,169 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	ngcnef "github.com/open-ness/epcforedge/ngc/pkg/nef"
+)
+
+const baseAfServiceAPIURL = "http://localhost:8091/nef-admin/v1/af-services"
+
+func CreateAfServiceReqForNEF(ctx context.Context, method string,
+	serviceID string, body []byte) (*httptest.ResponseRecorder,
+	*http.Request) {
+
+	url := baseAfServiceAPIURL
+	if len(serviceID) > 0 {
+		url += "/" + serviceID
+	}
+	var req *http.Request
+	if body != nil {
+		req, _ = http.NewRequest(method, url, bytes.NewBuffer(body))
+	} else {
+		req, _ = http.NewRequest(method, url, nil)
+	}
+	return httptest.NewRecorder(), req.WithContext(ctx)
+}
+
+var _ = Describe("Test NEF AF Service Mappings", func() {
+
+	var (
+		pcf *fakePCF
+		dir string
+	)
+
+	postbody, _ := ioutil.ReadFile(testJSONPath + "AF_NEF_POST_01.json")
+
+	edge := ngcnef.AfServiceMapping{AfServiceID: "EdgeService",
+		Dnn: "edge.operator.com", Snssai: ngcnef.Snssai{Sst: 2, Sd: "0A0B0C"},
+		Dnais: []ngcnef.Dnai{"edge-dnai-1"}}
+
+	// tiBody returns the POST body with the AF service and traffic route
+	tiBody := func(serviceID string, dnai ngcnef.Dnai) []byte {
+		var ti ngcnef.TrafficInfluSub
+		Expect(json.Unmarshal(postbody, &ti)).Should(BeNil())
+		ti.AfServiceID = serviceID
+		ti.TrafficRoutes = []ngcnef.RouteToLocation{{Dnai: dnai}}
+		b, _ := json.Marshal(ti)
+		return b
+	}
+
+	BeforeEach(func() {
+		var err error
+		pcf = newFakePCF()
+		dir, err = ioutil.TempDir("", "nefafservice")
+		Expect(err).Should(BeNil())
+	})
+
+	AfterEach(func() {
+		pcf.server.Close()
+		os.RemoveAll(dir)
+	})
+
+	It("Manages the AF services through the admin API", func() {
+
+		ctx, cancel := startNefWithCfg(createPCFCfg(dir, pcf.server.URL))
+		defer cancel()
+
+		rr, req := CreateAfServiceReqForNEF(ctx, "GET", "ServiceId01", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+		var m ngcnef.AfServiceMapping
+		Expect(json.Unmarshal(rr.Body.Bytes(), &m)).Should(BeNil())
+		Expect(m.Dnn).Should(Equal(ngcnef.Dnn("edge.example.com")))
+
+		b, _ := json.Marshal(edge)
+		rr, req = CreateAfServiceReqForNEF(ctx, "PUT", "EdgeService", b)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+		rr, req = CreateAfServiceReqForNEF(ctx, "PUT", "EdgeService", b)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+
+		invalid := edge
+		invalid.Snssai.Sd = "xyz"
+		b, _ = json.Marshal(invalid)
+		rr, req = CreateAfServiceReqForNEF(ctx, "PUT", "EdgeService", b)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusBadRequest))
+
+		rr, req = CreateAfServiceReqForNEF(ctx, "GET", "", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+		var mappings []ngcnef.AfServiceMapping
+		Expect(json.Unmarshal(rr.Body.Bytes(), &mappings)).Should(BeNil())
+		Expect(mappings[0]).Should(Equal(edge))
+
+		rr, req = CreateAfServiceReqForNEF(ctx, "DELETE", "EdgeService", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusNoContent))
+		rr, req = CreateAfServiceReqForNEF(ctx, "GET", "EdgeService", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusNotFound))
+	})
+
+	It("Resolves the DNN and S-NSSAI of the AF service", func() {
+
+		ctx, cancel := startNefWithCfg(createPCFCfg(dir, pcf.server.URL))
+		defer cancel()
+
+		b, _ := json.Marshal(edge)
+		rr, req := CreateAfServiceReqForNEF(ctx, "PUT", "EdgeService", b)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+
+		rr, req = CreateReqForNEF(ctx, "POST", "",
+			tiBody("UnknownService", "edge-dnai-1"))
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusBadRequest))
+		var pd ngcnef.ProblemDetails
+		Expect(json.Unmarshal(rr.Body.Bytes(), &pd)).Should(BeNil())
+		Expect(pd.InvalidParams).Should(Equal([]ngcnef.InvalidParam{{
+			Param:  "/afServiceId",
+			Reason: "Unknown AF service UnknownService"}}))
+
+		rr, req = CreateReqForNEF(ctx, "POST", "",
+			tiBody("EdgeService", "edge-dnai-2"))
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusBadRequest))
+		Expect(json.Unmarshal(rr.Body.Bytes(), &pd)).Should(BeNil())
+		Expect(pd.InvalidParams).Should(Equal([]ngcnef.InvalidParam{{
+			Param: "/trafficRoutes/0/dnai",
+			Reason: "DNAI edge-dnai-2 not allowed for AF service " +
+				"EdgeService"}}))
+		Expect(pcf.sessions()).Should(Equal(0))
+
+		rr, req = CreateReqForNEF(ctx, "POST", "",
+			tiBody("EdgeService", "edge-dnai-1"))
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+		ascs := pcf.appSessions()
+		Expect(ascs).Should(HaveLen(1))
+		Expect(ascs[0].AscReqData.Dnn).Should(Equal(edge.Dnn))
+		Expect(ascs[0].AscReqData.SliceInfo).Should(Equal(edge.Snssai))
+
+		// The traffic routes of a PATCH are checked against the service
+		patch := ngcnef.TrafficInfluSubPatch{TrafficRoutes: []ngcnef.
+			RouteToLocation{{Dnai: "edge-dnai-2"}}}
+		b, _ = json.Marshal(patch)
+		rr, req = CreateReqForNEF(ctx, "PATCH", "11111", b)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusBadRequest))
+
+		rr, req = CreateReqForNEF(ctx, "DELETE", "11111", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusNoContent))
+	})
+})
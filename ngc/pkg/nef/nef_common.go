@@ -40,20 +40,61 @@ func sendErrorResponseToAF(w http.ResponseWriter, rsp nefSBRspData) {
 	log.Infof("HTTP Response sent: %d", eCode)
 }
 
+// sendAdminRsp sends the data in JSON with the status code, used by the
+// handlers of the /nef-admin APIs
+func sendAdminRsp(w http.ResponseWriter, code int, data interface{}) {
+
+	mdata, err := json.Marshal(data)
+	if err != nil {
+		log.Err(err)
+		sendCustomeErrorRspToAF(w, 500, "Failed to Marshal response data")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(code)
+	if _, err = w.Write(mdata); err != nil {
+		log.Errf("Write Failed: %v", err)
+		return
+	}
+	log.Infof("HTTP Response sent: %d", code)
+}
+
+// readAdminBody unmarshals the body of a /nef-admin request into v. On
+// failure it sends the error response and returns false.
+func readAdminBody(w http.ResponseWriter, r *http.Request,
+	v interface{}) bool {
+
+	b, err := ioutil.ReadAll(r.Body)
+	defer closeReqBody(r)
+	if err != nil {
+		sendCustomeErrorRspToAF(w, 400, "Failed to read HTTP PUT Body")
+		return false
+	}
+
+	if err = json.Unmarshal(b, v); err != nil {
+		log.Err(err)
+		sendCustomeErrorRspToAF(w, 400, "Failed UnMarshal PUT data")
+		return false
+	}
+	return true
+}
+
+// afErrorCodes are the error status codes sent to the AF, the others are
+// sent as 404
+var afErrorCodes = map[int]bool{400: true, 403: true, 404: true, 411: true,
+	415: true, 500: true, 503: true}
+
 func createErrorJSON(rsp nefSBRspData) (mdata []byte, statusCode int) {
 
 	var err error
 	statusCode = 404
 
 	/*
-		TBD for future: Removed check for 401, 403, 413 and 429
-		due cyclometrix complexity lint warning. Once a better mechanism
-		is found to be added back. Anyhow currently these errors are not
-		supported
+		TBD for future: 401, 413 and 429 are not supported
 	*/
 
-	if rsp.errorCode == 400 || rsp.errorCode == 404 || rsp.errorCode == 411 ||
-		rsp.errorCode == 415 || rsp.errorCode == 500 || rsp.errorCode == 503 {
+	if afErrorCodes[rsp.errorCode] {
 		statusCode = rsp.errorCode
 		mdata, err = json.Marshal(rsp.pd)
 
@@ -77,15 +118,20 @@ func createErrorJSON(rsp nefSBRspData) (mdata []byte, statusCode int) {
 
 func logNef(nef *nefData) {
 
+	nef.mu.RLock()
+	defer nef.mu.RUnlock()
+
 	log.Infof("AF count %+v", len(nef.afs))
 	if len(nef.afs) > 0 {
 		for key, value := range nef.afs {
+			value.mu.Lock()
 			log.Infof(" AF ID : %+v, Sub Registered Count %+v",
 				key, len(value.subs))
 			for _, vs := range value.subs {
 				log.Infof("   SubId : %+v, ServiceId: %+v", vs.subid,
 					vs.ti.AfServiceID)
 			}
+			value.mu.Unlock()
 
 		}
 	}
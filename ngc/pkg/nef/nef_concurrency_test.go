@@ -0,0 +1,123 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	ngcnef "github.com/open-ness/epcforedge/ngc/pkg/nef"
+)
+
+var _ = Describe("NEF concurrent requests", func() {
+
+	var (
+		ctx    context.Context
+		cancel func()
+	)
+
+	It("Will init NefServer", func() {
+		ctx, cancel = context.WithCancel(context.Background())
+		go func() {
+			err := ngcnef.Run(ctx, NefTestCfgBasepath+"valid.json")
+			Expect(err).To(BeNil())
+		}()
+		time.Sleep(2 * time.Second)
+	})
+
+	It("Create, read, notify and delete subscriptions in parallel", func() {
+
+		postbody, _ := ioutil.ReadFile(testJSONPath + "AF_NEF_POST_01.json")
+		notifbody, _ := ioutil.ReadFile(NefTestJSONBasepath +
+			"SMF_NEF_NOTIF_01.json")
+
+		var wg sync.WaitGroup
+		codes := make(chan int, 32)
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer GinkgoRecover()
+
+				rr, req := CreateReqForNEF(ctx, "POST", "", postbody)
+				ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+				codes <- rr.Code
+				if rr.Code != http.StatusCreated {
+					return
+				}
+				subID := filepath.Base(rr.Header().Get("Location"))
+
+				rr, req = CreateReqForNEF(ctx, "GET", subID, nil)
+				ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+				Expect(rr.Code).Should(Equal(http.StatusOK))
+
+				req, _ = http.NewRequest("POST", NefTIFApiPrefix+
+					"notification/upf", bytes.NewBuffer(notifbody))
+				rr = httptest.NewRecorder()
+				ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+
+				rr, req = CreateReqForNEF(ctx, "GET", "", nil)
+				ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+				Expect(rr.Code).Should(Equal(http.StatusOK))
+
+				rr, req = CreateReqForNEF(ctx, "DELETE", subID, nil)
+				ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+				Expect(rr.Code).Should(Equal(http.StatusNoContent))
+			}()
+		}
+		wg.Wait()
+		close(codes)
+		for code := range codes {
+			Expect(code).Should(Equal(http.StatusCreated))
+		}
+	})
+
+	It("Only one of the parallel PFD transactions gets the application IDs",
+		func() {
+
+			pfdbody, _ := ioutil.ReadFile(testJSONPFDPath +
+				"AF_NEF_PFD_POST_001.json")
+
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			var created []string
+			for i := 0; i < 4; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer GinkgoRecover()
+
+					rr, req := CreatePFDReqForNEF(ctx, "POST", "", "",
+						pfdbody)
+					ngcnef.NefAppG.NefRouter.ServeHTTP(rr,
+						req.WithContext(ctx))
+					if rr.Code == http.StatusCreated {
+						mu.Lock()
+						created = append(created,
+							filepath.Base(rr.Header().Get("Location")))
+						mu.Unlock()
+						return
+					}
+					Expect(rr.Code).Should(
+						Equal(http.StatusInternalServerError))
+				}()
+			}
+			wg.Wait()
+			Expect(len(created)).Should(Equal(1))
+
+			rr, req := CreatePFDReqForNEF(ctx, "DELETE", created[0], "", nil)
+			ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+			Expect(rr.Code).Should(Equal(http.StatusNoContent))
+			cancel()
+		})
+})
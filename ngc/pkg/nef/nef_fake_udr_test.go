@@ -0,0 +1,191 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef_test
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	ngcnef "github.com/open-ness/epcforedge/ngc/pkg/nef"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// fakeUDRAddr is the address of the fake UDR used by the NEF test config
+const fakeUDRAddr = "localhost:8095"
+
+const fakeUDRInfluenceData = "/nudr-dr/v1/application-data/influenceData"
+const fakeUDRPfds = "/nudr-dr/v1/application-data/pfds/"
+
+// fakeUDR is a local Nudr_DataRepository server used by the NEF tests over
+// HTTP/2 clear text
+type fakeUDR struct {
+	server *httptest.Server
+	mu     sync.Mutex
+	tids   map[string]ngcnef.TrafficInfluData
+	pfds   map[string]ngcnef.PfdDataForApp
+}
+
+// newFakeUDR starts the fake UDR on addr, a random port is used if addr is
+// empty
+func newFakeUDR(addr string) *fakeUDR {
+
+	udr := &fakeUDR{tids: map[string]ngcnef.TrafficInfluData{},
+		pfds: map[string]ngcnef.PfdDataForApp{}}
+	udr.server = httptest.NewUnstartedServer(h2c.NewHandler(
+		http.HandlerFunc(udr.serveHTTP), &http2.Server{}))
+	if addr != "" {
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			panic(err)
+		}
+		_ = udr.server.Listener.Close()
+		udr.server.Listener = l
+	}
+	udr.server.Start()
+	return udr
+}
+
+func (udr *fakeUDR) influenceData() int {
+	udr.mu.Lock()
+	defer udr.mu.Unlock()
+	return len(udr.tids)
+}
+
+func (udr *fakeUDR) pfdApp(appID string) (ngcnef.PfdDataForApp, bool) {
+	udr.mu.Lock()
+	defer udr.mu.Unlock()
+	app, ok := udr.pfds[appID]
+	return app, ok
+}
+
+func (udr *fakeUDR) serveHTTP(w http.ResponseWriter, r *http.Request) {
+
+	udr.mu.Lock()
+	defer udr.mu.Unlock()
+
+	if r.ProtoMajor != 2 {
+		problem(w, http.StatusHTTPVersionNotSupported, "")
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, fakeUDRInfluenceData) {
+		udr.serveInfluenceData(w, r)
+		return
+	}
+	if strings.HasPrefix(r.URL.Path, fakeUDRPfds) {
+		udr.servePfds(w, r)
+		return
+	}
+	problem(w, http.StatusNotFound, "RESOURCE_URI_STRUCTURE_NOT_FOUND")
+}
+
+func (udr *fakeUDR) serveInfluenceData(w http.ResponseWriter,
+	r *http.Request) {
+
+	if r.URL.Path == fakeUDRInfluenceData && r.Method == http.MethodGet {
+		tids := []ngcnef.TrafficInfluData{}
+		for _, tid := range udr.tids {
+			tids = append(tids, tid)
+		}
+		writeJSON(w, http.StatusOK, "application/json", tids)
+		return
+	}
+
+	iid := strings.TrimPrefix(r.URL.Path, fakeUDRInfluenceData+"/")
+	tid, ok := udr.tids[iid]
+
+	switch r.Method {
+	case http.MethodPut:
+		tid = ngcnef.TrafficInfluData{}
+		if err := json.NewDecoder(r.Body).Decode(&tid); err != nil {
+			problem(w, http.StatusBadRequest, "")
+			return
+		}
+		udr.tids[iid] = tid
+		code := http.StatusOK
+		if !ok {
+			code = http.StatusCreated
+			w.Header().Set("Location", udr.server.URL+r.URL.Path)
+		}
+		writeJSON(w, code, "application/json", tid)
+	case http.MethodPatch:
+		if !ok {
+			problem(w, http.StatusNotFound, "DATA_NOT_FOUND")
+			return
+		}
+		patch := ngcnef.TrafficInfluDataPatch{}
+		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+			problem(w, http.StatusBadRequest, "")
+			return
+		}
+		tid.AppReloInd = patch.AppReloInd
+		if len(patch.TrafficRoutes) > 0 {
+			tid.TrafficRoutes = patch.TrafficRoutes
+		}
+		if len(patch.TrafficFilters) > 0 {
+			tid.TrafficFilters = patch.TrafficFilters
+		}
+		udr.tids[iid] = tid
+		writeJSON(w, http.StatusOK, "application/json", tid)
+	case http.MethodDelete:
+		if !ok {
+			problem(w, http.StatusNotFound, "DATA_NOT_FOUND")
+			return
+		}
+		delete(udr.tids, iid)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// servePfds handles the PFD data of an application. The errors injected in
+// the UDR stub by ngcnef.TestClient are returned as well.
+func (udr *fakeUDR) servePfds(w http.ResponseWriter, r *http.Request) {
+
+	appID := strings.TrimPrefix(r.URL.Path, fakeUDRPfds)
+	app, ok := udr.pfds[appID]
+
+	switch r.Method {
+	case http.MethodPut:
+		if ngcnef.TestClient {
+			problem(w, http.StatusBadRequest, "")
+			return
+		}
+		app = ngcnef.PfdDataForApp{}
+		if err := json.NewDecoder(r.Body).Decode(&app); err != nil ||
+			string(app.AppID) != appID {
+			problem(w, http.StatusBadRequest, "")
+			return
+		}
+		udr.pfds[appID] = app
+		code := http.StatusOK
+		if !ok {
+			code = http.StatusCreated
+			w.Header().Set("Location", udr.server.URL+r.URL.Path)
+		}
+		writeJSON(w, code, "application/json", app)
+	case http.MethodGet:
+		if !ok || ngcnef.TestClient {
+			problem(w, http.StatusNotFound, "DATA_NOT_FOUND")
+			return
+		}
+		writeJSON(w, http.StatusOK, "application/json", app)
+	case http.MethodDelete:
+		if ngcnef.TestClient {
+			problem(w, http.StatusBadRequest, "")
+			return
+		}
+		delete(udr.pfds, appID)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,60 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef
+
+import (
+	"strconv"
+
+	"github.com/open-ness/epcforedge/ngc/pkg/ipfilter"
+)
+
+// normalizeFlowDescs checks that the flow descriptions are IPFilterRules and
+// rewrites them in their normalized form. The invalid ones are returned as
+// invalid parameters of param.
+func normalizeFlowDescs(param string, fDescs []string) (
+	invalid []InvalidParam) {
+
+	for i, fDesc := range fDescs {
+		r, err := ipfilter.Parse(fDesc)
+		if err != nil {
+			invalid = append(invalid, InvalidParam{
+				Param: param + "/" + strconv.Itoa(i), Reason: err.Error()})
+			continue
+		}
+		fDescs[i] = r.String()
+	}
+	return invalid
+}
+
+// validateTrafficFilters checks the flow descriptions of the IP and Ethernet
+// traffic filters of a traffic influence subscription
+func validateTrafficFilters(filters []FlowInfo,
+	ethFilters []EthFlowDescription) (rsp nefSBRspData, status bool) {
+
+	for i, f := range filters {
+		rsp.pd.InvalidParams = append(rsp.pd.InvalidParams,
+			normalizeFlowDescs("/trafficFilters/"+strconv.Itoa(i)+
+				"/flowDescriptions", f.FlowDescriptions)...)
+	}
+	for i := range ethFilters {
+		if ethFilters[i].FDesc == "" {
+			continue
+		}
+		r, err := ipfilter.Parse(string(ethFilters[i].FDesc))
+		if err != nil {
+			rsp.pd.InvalidParams = append(rsp.pd.InvalidParams,
+				InvalidParam{Param: "/ethTrafficFilters/" +
+					strconv.Itoa(i) + "/fDesc", Reason: err.Error()})
+			continue
+		}
+		ethFilters[i].FDesc = FlowDescription(r.String())
+	}
+	if len(rsp.pd.InvalidParams) != 0 {
+		rsp.errorCode = 400
+		rsp.pd.Title = "Invalid traffic filters"
+		return rsp, false
+	}
+	return rsp, true
+}
@@ -0,0 +1,108 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	ngcnef "github.com/open-ness/epcforedge/ngc/pkg/nef"
+)
+
+var _ = Describe("Test NEF Flow Descriptions Validation", func() {
+
+	var (
+		pcf *fakePCF
+		dir string
+	)
+
+	tiPostBody, _ := ioutil.ReadFile(testJSONPath + "AF_NEF_POST_01.json")
+	pfdPostBody, _ := ioutil.ReadFile(testJSONPFDPath +
+		"AF_NEF_PFD_POST_001.json")
+
+	BeforeEach(func() {
+		var err error
+		pcf = newFakePCF()
+		dir, err = ioutil.TempDir("", "nefflowdesc")
+		Expect(err).Should(BeNil())
+	})
+
+	AfterEach(func() {
+		pcf.server.Close()
+		os.RemoveAll(dir)
+	})
+
+	It("Normalizes and rejects the traffic filters", func() {
+
+		ctx, cancel := startNefWithCfg(createPCFCfg(dir, pcf.server.URL))
+		defer cancel()
+
+		var ti ngcnef.TrafficInfluSub
+		Expect(json.Unmarshal(tiPostBody, &ti)).Should(BeNil())
+		ti.TrafficFilters = []ngcnef.FlowInfo{{FlowID: 1,
+			FlowDescriptions: []string{
+				"PERMIT out 17  from 10.10.10.10 to 192.168.1.1 05000"}}}
+		ti.EthTrafficFilters = []ngcnef.EthFlowDescription{{
+			FDesc: "permit out 17 from 10.10.10.10 to 192.168.1.300"}}
+		b, _ := json.Marshal(ti)
+
+		rr, req := CreateReqForNEF(ctx, "POST", "", b)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusBadRequest))
+
+		var pd ngcnef.ProblemDetails
+		Expect(json.Unmarshal(rr.Body.Bytes(), &pd)).Should(BeNil())
+		Expect(pd.InvalidParams).Should(Equal([]ngcnef.InvalidParam{{
+			Param: "/ethTrafficFilters/0/fDesc",
+			Reason: "invalid destination address \"192.168.1.300\": " +
+				"not an IP address"}}))
+
+		ti.EthTrafficFilters = nil
+		b, _ = json.Marshal(ti)
+		rr, req = CreateReqForNEF(ctx, "POST", "", b)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+
+		rr, req = CreateReqForNEF(ctx, "GET", "11111", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+		Expect(json.Unmarshal(rr.Body.Bytes(), &ti)).Should(BeNil())
+		Expect(ti.TrafficFilters[0].FlowDescriptions).Should(Equal(
+			[]string{"permit out 17 from 10.10.10.10 to 192.168.1.1 5000"}))
+
+		rr, req = CreateReqForNEF(ctx, "DELETE", "11111", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusNoContent))
+	})
+
+	It("Rejects the invalid flow descriptions of the PFDs", func() {
+
+		ctx, cancel := startNefWithCfg(createPfdCfg(dir, 0))
+		defer cancel()
+
+		var trans ngcnef.PfdManagement
+		Expect(json.Unmarshal(pfdPostBody, &trans)).Should(BeNil())
+		pfd := trans.PfdDatas["app2"].Pfds["pfd3"]
+		pfd.FlowDescriptions = []string{"permit in ip from any to any",
+			"permit in 17 from any to any 70000"}
+		trans.PfdDatas["app2"].Pfds["pfd3"] = pfd
+		b, _ := json.Marshal(trans)
+
+		rr, req := CreatePFDReqForNEF(ctx, "POST", "", "", b)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusBadRequest))
+
+		var pd ngcnef.ProblemDetails
+		Expect(json.Unmarshal(rr.Body.Bytes(), &pd)).Should(BeNil())
+		Expect(pd.InvalidParams).Should(Equal([]ngcnef.InvalidParam{{
+			Param: "/pfdDatas/app2/pfds/pfd3/flowDescriptions/1",
+			Reason: "invalid destination ports \"70000\": port " +
+				"\"70000\" not in 0-65535"}}))
+	})
+})
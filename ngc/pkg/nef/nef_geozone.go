@@ -0,0 +1,341 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// geoZoneRegistry maps the geographic zone IDs received from the AF onto the
+// network areas sent to the PCF and the UDR. The zones are loaded from the
+// GeoZoneConfig file and changes done through the admin API are written back
+// to it. If the path is empty the zones are only kept in memory.
+type geoZoneRegistry struct {
+	path string
+
+	//mu guards zones
+	mu    sync.RWMutex
+	zones map[string]GeoZone
+}
+
+// newGeoZoneRegistry loads the geo zones from the file at path. A missing
+// file results in an empty registry.
+func newGeoZoneRegistry(path string) (*geoZoneRegistry, error) {
+
+	g := &geoZoneRegistry{path: path, zones: make(map[string]GeoZone)}
+	if path == "" {
+		return g, nil
+	}
+
+	b, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.Infof("Geo zone file %s not found, starting empty", path)
+			return g, nil
+		}
+		return nil, err
+	}
+
+	var zones []GeoZone
+	if err = json.Unmarshal(b, &zones); err != nil {
+		return nil, errors.New("Failed to parse geo zone file " + path +
+			": " + err.Error())
+	}
+	for _, zone := range zones {
+		if reason := validateGeoZone(zone); reason != "" {
+			return nil, errors.New("Invalid geo zone " + zone.GeoZoneID +
+				": " + reason)
+		}
+		if _, ok := g.zones[zone.GeoZoneID]; ok {
+			return nil, errors.New("Duplicate geo zone " + zone.GeoZoneID)
+		}
+		g.zones[zone.GeoZoneID] = zone
+	}
+	log.Infof("Loaded %d geo zones from %s", len(g.zones), path)
+	return g, nil
+}
+
+// validateGeoZone returns the reason why the zone is invalid or an empty
+// string
+func validateGeoZone(zone GeoZone) string {
+
+	if zone.GeoZoneID == "" {
+		return "missing geoZoneId"
+	}
+	if len(zone.Tais) == 0 && len(zone.Ecgis) == 0 && len(zone.Ncgis) == 0 &&
+		len(zone.GRanNodeIds) == 0 {
+		return "one of tais, ecgis, ncgis or gRanNodeIds is required"
+	}
+	return ""
+}
+
+func (g *geoZoneRegistry) get(id string) (GeoZone, bool) {
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	zone, ok := g.zones[id]
+	return zone, ok
+}
+
+// list returns the zones sorted by the zone ID
+func (g *geoZoneRegistry) list() []GeoZone {
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	zones := make([]GeoZone, 0, len(g.zones))
+	for _, zone := range g.zones {
+		zones = append(zones, zone)
+	}
+	sort.Slice(zones, func(i, j int) bool {
+		return zones[i].GeoZoneID < zones[j].GeoZoneID
+	})
+	return zones
+}
+
+// put creates or replaces a zone and returns true if it was created
+func (g *geoZoneRegistry) put(zone GeoZone) (bool, error) {
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	old, exists := g.zones[zone.GeoZoneID]
+	g.zones[zone.GeoZoneID] = zone
+	if err := g.save(); err != nil {
+		if exists {
+			g.zones[zone.GeoZoneID] = old
+		} else {
+			delete(g.zones, zone.GeoZoneID)
+		}
+		return false, err
+	}
+	return !exists, nil
+}
+
+// delete removes a zone and returns false if it does not exist
+func (g *geoZoneRegistry) delete(id string) (bool, error) {
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	old, exists := g.zones[id]
+	if !exists {
+		return false, nil
+	}
+	delete(g.zones, id)
+	if err := g.save(); err != nil {
+		g.zones[id] = old
+		return false, err
+	}
+	return true, nil
+}
+
+// save writes the zones to the file, the caller must hold mu
+func (g *geoZoneRegistry) save() error {
+
+	if g.path == "" {
+		return nil
+	}
+
+	zones := make([]GeoZone, 0, len(g.zones))
+	for _, zone := range g.zones {
+		zones = append(zones, zone)
+	}
+	sort.Slice(zones, func(i, j int) bool {
+		return zones[i].GeoZoneID < zones[j].GeoZoneID
+	})
+	b, err := json.MarshalIndent(zones, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := g.path + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(b); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, g.path)
+}
+
+// validate checks that all the zone IDs are known. The unknown ones are
+// reported as invalid parameters of param.
+func (g *geoZoneRegistry) validate(param string, ids []string) (
+	rsp nefSBRspData, status bool) {
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	for i, id := range ids {
+		if _, ok := g.zones[id]; !ok {
+			rsp.pd.InvalidParams = append(rsp.pd.InvalidParams,
+				InvalidParam{Param: "/" + param + "/" + strconv.Itoa(i),
+					Reason: "Unknown geographic zone " + id})
+		}
+	}
+	if len(rsp.pd.InvalidParams) != 0 {
+		rsp.errorCode = 400
+		rsp.pd.Title = "Invalid " + param + " attribute"
+		return rsp, false
+	}
+	return rsp, true
+}
+
+// spatialValidity returns the presence reporting areas of the zones, one per
+// zone with the zone ID as praId, or nil if none of the zones is known
+func (g *geoZoneRegistry) spatialValidity(ids []string) *SpatialValidity {
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	var spVal *SpatialValidity
+	for _, id := range ids {
+		zone, ok := g.zones[id]
+		if !ok {
+			log.Infof("Geo zone %s not found, skipped", id)
+			continue
+		}
+		if spVal == nil {
+			spVal = &SpatialValidity{
+				PresenceInfoList: make(map[string]PresenceInfo)}
+		}
+		spVal.PresenceInfoList[id] = PresenceInfo{PraID: id,
+			TrackingAreaList: zone.Tais, EcgiList: zone.Ecgis,
+			NcgiList: zone.Ncgis, GlobalRanNodeIDList: zone.GRanNodeIds}
+	}
+	return spVal
+}
+
+// networkAreaInfo returns the union of the network areas of the zones or nil
+// if none of the zones is known
+func (g *geoZoneRegistry) networkAreaInfo(ids []string) *NetworkAreaInfo {
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	var nwAreaInfo *NetworkAreaInfo
+	for _, id := range ids {
+		zone, ok := g.zones[id]
+		if !ok {
+			log.Infof("Geo zone %s not found, skipped", id)
+			continue
+		}
+		if nwAreaInfo == nil {
+			nwAreaInfo = &NetworkAreaInfo{}
+		}
+		nwAreaInfo.Tais = append(nwAreaInfo.Tais, zone.Tais...)
+		nwAreaInfo.Ecgis = append(nwAreaInfo.Ecgis, zone.Ecgis...)
+		nwAreaInfo.Ncgis = append(nwAreaInfo.Ncgis, zone.Ncgis...)
+		nwAreaInfo.GRanNodeIds = append(nwAreaInfo.GRanNodeIds,
+			zone.GRanNodeIds...)
+	}
+	return nwAreaInfo
+}
+
+// ReadAllGeoZones : Returns all the geo zones of the registry
+func ReadAllGeoZones(w http.ResponseWriter, r *http.Request) {
+
+	nefCtx := r.Context().Value(nefCtxKey("nefCtx")).(*nefContext)
+
+	sendAdminRsp(w, http.StatusOK, nefCtx.nef.geoZones.list())
+}
+
+// ReadGeoZone : Returns a geo zone of the registry
+func ReadGeoZone(w http.ResponseWriter, r *http.Request) {
+
+	nefCtx := r.Context().Value(nefCtxKey("nefCtx")).(*nefContext)
+
+	vars := mux.Vars(r)
+	log.Infof(" GEO ZONE ID : %s", vars["geoZoneId"])
+
+	zone, ok := nefCtx.nef.geoZones.get(vars["geoZoneId"])
+	if !ok {
+		sendCustomeErrorRspToAF(w, 404, "Geo zone not found")
+		return
+	}
+	sendAdminRsp(w, http.StatusOK, zone)
+}
+
+// PutGeoZone : Creates or replaces a geo zone of the registry
+func PutGeoZone(w http.ResponseWriter, r *http.Request) {
+
+	nefCtx := r.Context().Value(nefCtxKey("nefCtx")).(*nefContext)
+
+	vars := mux.Vars(r)
+	log.Infof(" GEO ZONE ID : %s", vars["geoZoneId"])
+
+	zone := GeoZone{}
+	if !readAdminBody(w, r, &zone) {
+		return
+	}
+
+	if zone.GeoZoneID == "" {
+		zone.GeoZoneID = vars["geoZoneId"]
+	}
+	if zone.GeoZoneID != vars["geoZoneId"] {
+		sendCustomeErrorRspToAF(w, 400, "geoZoneId does not match the URI")
+		return
+	}
+	if reason := validateGeoZone(zone); reason != "" {
+		sendCustomeErrorRspToAF(w, 400, reason)
+		return
+	}
+
+	created, err := nefCtx.nef.geoZones.put(zone)
+	if err != nil {
+		log.Err(err)
+		sendCustomeErrorRspToAF(w, 500, "Failed to save the geo zone")
+		return
+	}
+	if created {
+		sendAdminRsp(w, http.StatusCreated, zone)
+		return
+	}
+	sendAdminRsp(w, http.StatusOK, zone)
+}
+
+// DeleteGeoZone : Deletes a geo zone of the registry. The subscriptions
+// referring to it are not changed, the zone is skipped when they are sent
+// to the 5GC again.
+func DeleteGeoZone(w http.ResponseWriter, r *http.Request) {
+
+	nefCtx := r.Context().Value(nefCtxKey("nefCtx")).(*nefContext)
+
+	vars := mux.Vars(r)
+	log.Infof(" GEO ZONE ID : %s", vars["geoZoneId"])
+
+	found, err := nefCtx.nef.geoZones.delete(vars["geoZoneId"])
+	if err != nil {
+		log.Err(err)
+		sendCustomeErrorRspToAF(w, 500, "Failed to save the geo zones")
+		return
+	}
+	if !found {
+		sendCustomeErrorRspToAF(w, 404, "Geo zone not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+	log.Infof("HTTP Response sent: %d", http.StatusNoContent)
+}
@@ -0,0 +1,227 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	ngcnef "github.com/open-ness/epcforedge/ngc/pkg/nef"
+)
+
+const baseGeoZoneAPIURL = "http://localhost:8091/nef-admin/v1/geo-zones"
+
+func CreateGeoZoneReqForNEF(ctx context.Context, method string, zoneID string,
+	body []byte) (*httptest.ResponseRecorder, *http.Request) {
+
+	url := baseGeoZoneAPIURL
+	if len(zoneID) > 0 {
+		url += "/" + zoneID
+	}
+	var req *http.Request
+	if body != nil {
+		req, _ = http.NewRequest(method, url, bytes.NewBuffer(body))
+	} else {
+		req, _ = http.NewRequest(method, url, nil)
+	}
+	return httptest.NewRecorder(), req.WithContext(ctx)
+}
+
+// createGeoZoneCfg creates a configuration with a copy of the test geo zone
+// file in dir and the PCF at pcfAPIRoot if not empty
+func createGeoZoneCfg(dir string, pcfAPIRoot string) string {
+
+	zones, err := ioutil.ReadFile(NefTestCfgBasepath + "geozones.json")
+	Expect(err).Should(BeNil())
+	zonePath := filepath.Join(dir, "geozones.json")
+	Expect(ioutil.WriteFile(zonePath, zones, 0600)).Should(BeNil())
+
+	cfg := map[string]interface{}{
+		"GeoZoneConfig": map[string]string{"path": zonePath}}
+	if pcfAPIRoot != "" {
+		cfg["PCFConfig"] = sbConfig(pcfAPIRoot)
+	}
+	return createNefCfg(dir, cfg)
+}
+
+var _ = Describe("Test NEF Geo Zone Registry", func() {
+
+	var dir string
+
+	postbody, _ := ioutil.ReadFile(testJSONPath + "AF_NEF_POST_01.json")
+
+	zone02 := ngcnef.GeoZone{GeoZoneID: "GEOZONE_02",
+		Tais: []ngcnef.Tai{{PlmnID: ngcnef.PlmnID{Mcc: "634", Mnc: "635"},
+			Tac: "TAC_02"}}}
+
+	// tiBody returns the POST body with the geo zones changed
+	tiBody := func(zoneIDs ...string) []byte {
+		var ti ngcnef.TrafficInfluSub
+		Expect(json.Unmarshal(postbody, &ti)).Should(BeNil())
+		ti.ValidGeoZoneIDs = zoneIDs
+		b, _ := json.Marshal(ti)
+		return b
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "nefgeozone")
+		Expect(err).Should(BeNil())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("Create, read, replace and delete geo zones", func() {
+
+		ctx, cancel := startNefWithCfg(createGeoZoneCfg(dir, ""))
+		defer cancel()
+
+		var zones []ngcnef.GeoZone
+		rr, req := CreateGeoZoneReqForNEF(ctx, "GET", "", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+		Expect(json.Unmarshal(rr.Body.Bytes(), &zones)).Should(BeNil())
+		Expect(len(zones)).Should(Equal(1))
+		Expect(zones[0].GeoZoneID).Should(Equal("GEOZONE_01"))
+
+		body, _ := json.Marshal(zone02)
+		rr, req = CreateGeoZoneReqForNEF(ctx, "PUT", "GEOZONE_02", body)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+
+		zone02.Ecgis = []ngcnef.Ecgi{{EutraCellID: "EUTRACELL_02",
+			PlmnID: ngcnef.PlmnID{Mcc: "634", Mnc: "635"}}}
+		body, _ = json.Marshal(zone02)
+		rr, req = CreateGeoZoneReqForNEF(ctx, "PUT", "GEOZONE_02", body)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+
+		var zone ngcnef.GeoZone
+		rr, req = CreateGeoZoneReqForNEF(ctx, "GET", "GEOZONE_02", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+		Expect(json.Unmarshal(rr.Body.Bytes(), &zone)).Should(BeNil())
+		Expect(zone).Should(Equal(zone02))
+
+		// The changes are written to the geo zone file
+		b, err := ioutil.ReadFile(filepath.Join(dir, "geozones.json"))
+		Expect(err).Should(BeNil())
+		Expect(json.Unmarshal(b, &zones)).Should(BeNil())
+		Expect(len(zones)).Should(Equal(2))
+		Expect(zones[1]).Should(Equal(zone02))
+
+		rr, req = CreateGeoZoneReqForNEF(ctx, "DELETE", "GEOZONE_02", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusNoContent))
+
+		rr, req = CreateGeoZoneReqForNEF(ctx, "GET", "GEOZONE_02", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusNotFound))
+
+		rr, req = CreateGeoZoneReqForNEF(ctx, "DELETE", "GEOZONE_02", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusNotFound))
+	})
+
+	It("Rejects invalid geo zones", func() {
+
+		ctx, cancel := startNefWithCfg(createGeoZoneCfg(dir, ""))
+		defer cancel()
+
+		// geoZoneId not matching the URI
+		body, _ := json.Marshal(zone02)
+		rr, req := CreateGeoZoneReqForNEF(ctx, "PUT", "GEOZONE_03", body)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusBadRequest))
+
+		// No network area
+		body, _ = json.Marshal(ngcnef.GeoZone{GeoZoneID: "GEOZONE_03"})
+		rr, req = CreateGeoZoneReqForNEF(ctx, "PUT", "GEOZONE_03", body)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusBadRequest))
+
+		rr, req = CreateGeoZoneReqForNEF(ctx, "PUT", "GEOZONE_03",
+			[]byte("{"))
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusBadRequest))
+	})
+
+	It("Sends the spatial validity of the geo zones to the PCF", func() {
+
+		pcf := newFakePCF()
+		defer pcf.server.Close()
+
+		ctx, cancel := startNefWithCfg(createGeoZoneCfg(dir,
+			pcf.server.URL))
+		defer cancel()
+
+		rr, req := CreateReqForNEF(ctx, "POST", "", tiBody("GEOZONE_01"))
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+
+		ascs := pcf.appSessions()
+		Expect(len(ascs)).Should(Equal(1))
+		spVal := ascs[0].AscReqData.AfRoutReq.SpVal
+		Expect(spVal).ShouldNot(BeNil())
+		Expect(len(spVal.PresenceInfoList)).Should(Equal(1))
+		pra := spVal.PresenceInfoList["GEOZONE_01"]
+		Expect(pra.PraID).Should(Equal("GEOZONE_01"))
+		Expect(pra.TrackingAreaList[0].Tac).Should(Equal(ngcnef.Tac("TAC_01")))
+		Expect(pra.EcgiList[0].EutraCellID).Should(
+			Equal(ngcnef.EutraCellID("EUTRACELL_01")))
+		Expect(pra.NcgiList[0].NrCellID).Should(
+			Equal(ngcnef.NrCellID("NRCELL_01")))
+
+		rr, req = CreateReqForNEF(ctx, "DELETE", "11111", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusNoContent))
+	})
+
+	It("Rejects traffic influence with unknown geo zones", func() {
+
+		ctx, cancel := startNefWithCfg(createGeoZoneCfg(dir, ""))
+		defer cancel()
+
+		rr, req := CreateReqForNEF(ctx, "POST", "",
+			tiBody("GEOZONE_01", "GEOZONE_09"))
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusBadRequest))
+
+		var pd ngcnef.ProblemDetails
+		Expect(json.Unmarshal(rr.Body.Bytes(), &pd)).Should(BeNil())
+		Expect(pd.InvalidParams).Should(Equal([]ngcnef.InvalidParam{{
+			Param:  "/validGeoZoneIds/1",
+			Reason: "Unknown geographic zone GEOZONE_09"}}))
+
+		rr, req = CreateReqForNEF(ctx, "POST", "", tiBody("GEOZONE_01"))
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+
+		patch, _ := json.Marshal(ngcnef.TrafficInfluSubPatch{
+			ValidGeoZoneIDs: []string{"GEOZONE_09"}})
+		rr, req = CreateReqForNEF(ctx, "PATCH", "11111", patch)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusBadRequest))
+
+		rr, req = CreateReqForNEF(ctx, "PUT", "11111",
+			tiBody("GEOZONE_09"))
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusBadRequest))
+
+		rr, req = CreateReqForNEF(ctx, "DELETE", "11111", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusNoContent))
+	})
+})
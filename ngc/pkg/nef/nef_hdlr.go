@@ -7,6 +7,11 @@ package ngcnef
 import (
 	"context"
 	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
 )
 
 const correlationIDOffset = 20
@@ -15,20 +20,74 @@ const pfdNotFound string = "PFD transaction Not Found"
 const appNotFound string = "Application in PFD transaction Not Found"
 const pfdAppsFailed string = "ALL PFD Apps Failed"
 
+// errAfDeleted is returned when an operation races with the removal of the AF
+var errAfDeleted = errors.New("AF entry deleted")
+
+// errGpsiNotTranslated is returned when the UDM does not know the GPSI of the
+// UE
+var errGpsiNotTranslated = errors.New("GPSI not translated by the UDM")
+
+// errUdmFailure is returned when the UDM fails to translate the GPSI of the UE
+var errUdmFailure = errors.New("GPSI translation failure of the UDM")
+
 //NEF context data
+//Locking order is nefData.mu -> afData.mu -> nefData.idxMu. The HTTP handlers
+//run concurrently so none of the maps are accessed without the lock guarding
+//them. The locks of pfdSubs and afNotifier are taken last.
 type nefData struct {
 	ctx                  context.Context
-	afCount              int
 	locationURLPrefix    string
 	locationURLPrefixPfd string
+	locationURLPrefixQos string
+	locationURLPrefixMe  string
 	pcfClient            PcfPolicyAuthorization
 	udrClient            UdrInfluenceData
+	smfClient            SmfEventExposure
 	udrPfdClient         UdrPfdData
-	corrID               uint
-	afs                  map[string]*afData
+	udmClient            UdmEventExposure
+	udmSdmClient         UdmIdentifierTranslation
+	nrf                  *nrfClient
 	upfNotificationURL   URI
+	udmNotificationURL   URI
+	store                nefStore
+	geoZones             *geoZoneRegistry
+	afServices           *afServiceRegistry
+	afNotifier           *afNotifDispatcher
+	afClient             *AfClient
+	pfdSubs              *pfdSubRegistry
+	pfdSubURLPrefix      string
+
+	//mu guards afs and afCount
+	mu      sync.RWMutex
+	afCount int
+	afs     map[string]*afData
+
+	//idxMu guards corrID and the lookup indexes
+	idxMu sync.Mutex
+	//Next notification correlation ID
+	corrID uint
+	//Notification correlation ID -> subscription
+	corrIDIdx map[string]nefSubRef
+	//PFD application ID -> PFD transaction
+	pfdAppIdx map[string]nefPfdTransRef
+}
+
+//Reference to a subscription of an AF used by the correlation ID index
+type nefSubRef struct {
+	af    *afData
+	subID string
+}
+
+//Reference to a PFD transaction of an AF used by the application ID index
+type nefPfdTransRef struct {
+	af      *afData
+	transID string
 }
 
+//NEFSBPostFn is the callback for SB API
+type NEFSBPostFn func(subData *afSubscription, nefCtx *nefContext,
+	ti TrafficInfluSub) (rsp nefSBRspData, err error)
+
 //NEFSBGetFn is the callback for SB API
 type NEFSBGetFn func(subData *afSubscription, nefCtx *nefContext) (
 	sub TrafficInfluSub, rsp nefSBRspData, err error)
@@ -77,18 +136,57 @@ type afSubscription struct {
 
 	//Applicable in case of Multiple UE only
 	iid                       InfluenceID
+	smfSubID                  SmfSubscriptionID
 	NotifCorreID              string
 	afNotificationDestination Link
+	NEFSBPost                 NEFSBPostFn
 	NEFSBGet                  NEFSBGetFn
 	NEFSBPut                  NEFSBPutFn
 	NEFSBPatch                NEFSBPatchFn
 	NEFSBDelete               NEFSBDeleteFn
+
+	//Policy installed in the 5GC, false outside the temporal validities
+	active bool
+	//Timer of the next temporal validity change, tvGen invalidates the
+	//timers stopped too late
+	tvTimer *time.Timer
+	tvGen   uint
+
+	//Websocket of the AF for the notifications, nil if not connected
+	ws *websocket.Conn
+}
+
+//AS session with QoS subscription data
+type afQosSubscription struct {
+	subid string
+	qos   AsSessionWithQoSSubscription
+
+	//App session context created in the PCF for the QoS
+	appSessionID AppSessionID
+}
+
+//Monitoring event subscription data
+type afMeSubscription struct {
+	subid string
+	me    MonitoringEventSubscription
+
+	//UE identity and the event exposure subscription created in the UDM
+	ueIdentity string
+	udmSubID   UdmEeSubscriptionID
+	//Correlation ID part of the notification URI given to the UDM
+	corrID string
+	//Number of reports sent to the AF
+	numReports int32
 }
 
 //PFD transaction data
 type afPfdTransaction struct {
 	transID       string
 	pfdManagement PfdManagement
+	//Caching time timers of the applications
+	cacheTimers map[string]*pfdCacheTimer
+	//Caching time expiries restored from the store, armed at start
+	restoredExpiries map[string]time.Time
 
 	NEFSBPfdGet    NEFSBGetPfdFn
 	NEFSBPfdPut    NEFSBPutPfdFn
@@ -98,11 +196,17 @@ type afPfdTransaction struct {
 
 //AF data
 type afData struct {
-	afID       string
+	afID string
+
+	//mu serializes the operations on the subscriptions and PFD transactions
+	//of the AF including the SB requests made for them
+	mu         sync.Mutex
+	deleted    bool
 	subIDnum   int
 	transIDnum int
-	maxSubSupp int
 	subs       map[string]*afSubscription
+	qosSubs    map[string]*afQosSubscription
+	meSubs     map[string]*afMeSubscription
 	pfdtrans   map[string]*afPfdTransaction
 }
 
@@ -124,8 +228,9 @@ func (af *afData) afCreate(nefCtx *nefContext, afID string) error {
 	af.afID = afID
 	af.subIDnum = nefCtx.cfg.SubStartID //Start Number
 	af.transIDnum = nefCtx.cfg.PfdTransStartID
-	af.maxSubSupp = nefCtx.cfg.MaxSubSupport
 	af.subs = make(map[string]*afSubscription)
+	af.qosSubs = make(map[string]*afQosSubscription)
+	af.meSubs = make(map[string]*afMeSubscription)
 	//PFD transaction
 	af.pfdtrans = make(map[string]*afPfdTransaction)
 	return nil
@@ -136,17 +241,84 @@ func (nef *nefData) nefCreate(ctx context.Context, cfg Config) error {
 
 	nef.ctx = ctx
 	nef.afCount = 0
-	nef.pcfClient = NewPCFClient(&cfg)
+	if cfg.NRFConfig.APIRoot != "" {
+		nrf, err := newNRFClient(&cfg)
+		if err != nil {
+			return errors.New("NRF Client creation failed")
+		}
+		nef.nrf = nrf
+	}
+	// The HTTP clients are used if the NF is configured or discovered
+	if cfg.PCFConfig.APIRoot != "" || nef.nrf != nil {
+		pcfClient, err := NewPCFHTTPClient(&cfg)
+		if err != nil {
+			return errors.New("PCF Client creation failed")
+		}
+		pcfClient.sb.useNRF(nef.nrf, NFTypePCF, pcfPolicyAuthService)
+		nef.pcfClient = pcfClient
+	} else {
+		nef.pcfClient = NewPCFClient(&cfg)
+	}
 	if nef.pcfClient == nil {
 		return errors.New("PCF Client creation failed")
 	}
-	nef.udrClient = NewUDRClient(&cfg)
-	nef.udrPfdClient = NewUDRPfdClient(&cfg)
+	if cfg.UDRConfig.APIRoot != "" || nef.nrf != nil {
+		udrClient, err := NewUDRHTTPClient(&cfg)
+		if err != nil {
+			return errors.New("UDR Client creation failed")
+		}
+		udrClient.sb.useNRF(nef.nrf, NFTypeUDR, udrDataRepositoryService)
+		nef.udrClient = udrClient
+	} else {
+		nef.udrClient = NewUDRClient(&cfg)
+	}
+	if cfg.SMFConfig.APIRoot != "" || nef.nrf != nil {
+		smfClient, err := NewSMFHTTPClient(&cfg)
+		if err != nil {
+			return errors.New("SMF Client creation failed")
+		}
+		smfClient.sb.useNRF(nef.nrf, NFTypeSMF, smfEventExposureService)
+		nef.smfClient = smfClient
+	} else {
+		nef.smfClient = NewSMFClient(&cfg)
+	}
+	if cfg.UDRConfig.APIRoot != "" || nef.nrf != nil {
+		udrPfdClient, err := NewUDRPfdHTTPClient(&cfg)
+		if err != nil {
+			return errors.New("UDR PFD Client creation failed")
+		}
+		udrPfdClient.sb.useNRF(nef.nrf, NFTypeUDR,
+			udrDataRepositoryService)
+		nef.udrPfdClient = udrPfdClient
+	} else {
+		nef.udrPfdClient = NewUDRPfdClient(&cfg)
+	}
+	if cfg.UDMConfig.APIRoot != "" || nef.nrf != nil {
+		udmClient, err := NewUDMHTTPClient(&cfg)
+		if err != nil {
+			return errors.New("UDM Client creation failed")
+		}
+		udmClient.sb.useNRF(nef.nrf, NFTypeUDM, udmEventExposureService)
+		nef.udmClient = udmClient
+
+		udmSdmClient, err := NewUDMSdmHTTPClient(&cfg)
+		if err != nil {
+			return errors.New("UDM SDM Client creation failed")
+		}
+		udmSdmClient.sb.useNRF(nef.nrf, NFTypeUDM, udmSdmService)
+		nef.udmSdmClient = udmSdmClient
+	} else {
+		nef.udmClient = NewUDMClient(&cfg)
+		nef.udmSdmClient = NewUDMSdmClient(&cfg)
+	}
 	if nef.udrClient == nil {
 		return errors.New("PCF Client creation failed")
 	}
 	nef.afs = make(map[string]*afData)
 	nef.corrID = uint(cfg.SubStartID + correlationIDOffset)
+	nef.corrIDIdx = make(map[string]nefSubRef)
+	nef.pfdAppIdx = make(map[string]nefPfdTransRef)
+	nef.pfdSubs = newPfdSubRegistry()
 
 	if cfg.NefAPIRoot == "" {
 		return errors.New("NefAPIRoot is empty")
@@ -164,15 +336,252 @@ func (nef *nefData) nefCreate(ctx context.Context, cfg Config) error {
 	nef.locationURLPrefixPfd = getNefLocationURLPrefixPfd(&cfg)
 	log.Infof("NEF Location URL Prefix :%s", nef.locationURLPrefixPfd)
 
+	// Generate the location url prefix for AS session with QoS
+	nef.locationURLPrefixQos = getNefLocationURLPrefixQos(&cfg)
+	log.Infof("NEF Location URL Prefix :%s", nef.locationURLPrefixQos)
+
+	// Generate the location url prefix for monitoring events
+	nef.locationURLPrefixMe = getNefLocationURLPrefixMe(&cfg)
+	log.Infof("NEF Location URL Prefix :%s", nef.locationURLPrefixMe)
+
+	// Generate the location url prefix for the PFD subscriptions of the SMF
+	nef.pfdSubURLPrefix = getNefPfdSubURLPrefix(&cfg)
+	log.Infof("NEF Location URL Prefix :%s", nef.pfdSubURLPrefix)
+
 	// Genereate the notification url
 	if cfg.UpfNotificationResURIPath == "" {
 		return errors.New("UpfNotificationResURIPath is empty")
 	}
 	nef.upfNotificationURL = getNefNotificationURI(&cfg)
 	log.Infof("SMF UPF Notification URL :%s", nef.upfNotificationURL)
+
+	if cfg.UdmNotificationResURIPath == "" {
+		return errors.New("UdmNotificationResURIPath is empty")
+	}
+	nef.udmNotificationURL = getNefUdmNotificationURI(&cfg)
+	log.Infof("UDM Notification URL :%s", nef.udmNotificationURL)
+
+	// Load the geo zones used for the spatial validity
+	geoZones, err := newGeoZoneRegistry(cfg.GeoZoneConfig.Path)
+	if err != nil {
+		return err
+	}
+	nef.geoZones = geoZones
+
+	// Load the AF service mappings used for the DNN and S-NSSAI
+	afServices, err := newAfServiceRegistry(cfg.AfServiceIDs)
+	if err != nil {
+		return err
+	}
+	nef.afServices = afServices
+
+	// Start the workers sending the notifications to the AFs
+	nef.afClient = NewAfClient(&cfg)
+	nef.afNotifier = newAfNotifDispatcher(ctx, &cfg, nef.afClient)
+
+	// Open the store and restore the data saved before the restart
+	store, err := newNefStore(&cfg)
+	if err != nil {
+		return err
+	}
+	nef.store = store
+	return nef.nefRestore(cfg)
+}
+
+//Restores the AF, subscription and PFD transaction data from the store
+func (nef *nefData) nefRestore(cfg Config) error {
+
+	state, err := nef.store.load()
+	if err != nil {
+		return err
+	}
+
+	if state.corrID > nef.corrID {
+		nef.corrID = state.corrID
+	}
+
+	for afID, afs := range state.afs {
+
+		af := &afData{afID: afID,
+			subIDnum:   afs.af.SubIDnum,
+			transIDnum: afs.af.TransIDnum,
+			subs:       make(map[string]*afSubscription),
+			qosSubs:    make(map[string]*afQosSubscription),
+			meSubs:     make(map[string]*afMeSubscription),
+			pfdtrans:   make(map[string]*afPfdTransaction)}
+
+		for subID, s := range afs.subs {
+			sub := &afSubscription{subid: subID, ti: s.Ti,
+				appSessionID:              s.AppSessionID,
+				iid:                       s.Iid,
+				smfSubID:                  s.SmfSubID,
+				NotifCorreID:              s.NotifCorreID,
+				afNotificationDestination: s.AfNotificationDestination,
+				active:                    !s.Inactive}
+			if isSingleUESub(s.Ti) {
+				sub.setPCFCallbacks()
+			} else {
+				sub.setUDRCallbacks()
+			}
+			af.subs[subID] = sub
+			nef.nefIndexSub(af, sub, "")
+		}
+
+		for subID, s := range afs.qosSubs {
+			af.qosSubs[subID] = &afQosSubscription{subid: subID, qos: s.Qos,
+				appSessionID: s.AppSessionID}
+		}
+
+		for subID, s := range afs.meSubs {
+			sub := &afMeSubscription{subid: subID, me: s.Me,
+				ueIdentity: s.UeIdentity, udmSubID: s.UdmSubID,
+				corrID: s.CorrID, numReports: s.NumReports}
+			af.meSubs[subID] = sub
+			nef.nefIndexMeSub(af, sub)
+		}
+
+		for transID, t := range afs.pfdtrans {
+			trans := &afPfdTransaction{transID: transID,
+				pfdManagement:    t.PfdManagement,
+				restoredExpiries: t.CacheExpiries}
+			if trans.pfdManagement.PfdReports == nil {
+				trans.pfdManagement.PfdReports = make(map[string]PfdReport)
+			}
+			trans.setUDRCallbacks()
+			af.pfdtrans[transID] = trans
+			_ = nef.nefReservePfdApps(af, transID,
+				pfdAppIDs(trans.pfdManagement))
+		}
+
+		nef.afs[afID] = af
+		nef.afCount++
+		log.Infof("NEF Restored AF %s with %d subscriptions, %d QoS "+
+			"subscriptions, %d monitoring event subscriptions and %d PFD "+
+			"transactions", afID, len(af.subs), len(af.qosSubs),
+			len(af.meSubs), len(af.pfdtrans))
+	}
 	return nil
 }
 
+//Saves the AF data in the store
+func (nef *nefData) nefSaveAf(af *afData) {
+
+	err := nef.store.putAf(nefStoreAf{AfID: af.afID, SubIDnum: af.subIDnum,
+		TransIDnum: af.transIDnum})
+	if err != nil {
+		log.Errf("NEF Store failed to save AF %s: %v", af.afID, err)
+	}
+}
+
+//Saves the subscription along with the AF data and correlation ID
+func (nef *nefData) nefSaveSub(af *afData, sub *afSubscription) {
+
+	nef.nefSaveAf(af)
+
+	nef.idxMu.Lock()
+	corrID := nef.corrID
+	nef.idxMu.Unlock()
+	if err := nef.store.putCorrID(corrID); err != nil {
+		log.Errf("NEF Store failed to save correlation ID: %v", err)
+	}
+
+	err := nef.store.putSub(af.afID, nefStoreSub{SubID: sub.subid,
+		Ti: sub.ti, AppSessionID: sub.appSessionID, Iid: sub.iid,
+		SmfSubID:                  sub.smfSubID,
+		NotifCorreID:              sub.NotifCorreID,
+		AfNotificationDestination: sub.afNotificationDestination,
+		Inactive:                  !sub.active})
+	if err != nil {
+		log.Errf("NEF Store failed to save subscription %s: %v", sub.subid,
+			err)
+	}
+}
+
+//Removes the subscription from the store
+func (nef *nefData) nefRemoveSub(af *afData, subID string) {
+
+	if err := nef.store.deleteSub(af.afID, subID); err != nil {
+		log.Errf("NEF Store failed to delete subscription %s: %v", subID,
+			err)
+	}
+}
+
+//Saves the AS session with QoS subscription along with the AF data
+func (nef *nefData) nefSaveQosSub(af *afData, sub *afQosSubscription) {
+
+	nef.nefSaveAf(af)
+
+	err := nef.store.putQosSub(af.afID, nefStoreQosSub{SubID: sub.subid,
+		Qos: sub.qos, AppSessionID: sub.appSessionID})
+	if err != nil {
+		log.Errf("NEF Store failed to save QoS subscription %s: %v",
+			sub.subid, err)
+	}
+}
+
+//Removes the AS session with QoS subscription from the store
+func (nef *nefData) nefRemoveQosSub(af *afData, subID string) {
+
+	if err := nef.store.deleteQosSub(af.afID, subID); err != nil {
+		log.Errf("NEF Store failed to delete QoS subscription %s: %v",
+			subID, err)
+	}
+}
+
+//Saves the monitoring event subscription along with the AF data and
+//correlation ID
+func (nef *nefData) nefSaveMeSub(af *afData, sub *afMeSubscription) {
+
+	nef.nefSaveAf(af)
+
+	nef.idxMu.Lock()
+	corrID := nef.corrID
+	nef.idxMu.Unlock()
+	if err := nef.store.putCorrID(corrID); err != nil {
+		log.Errf("NEF Store failed to save correlation ID: %v", err)
+	}
+
+	err := nef.store.putMeSub(af.afID, nefStoreMeSub{SubID: sub.subid,
+		Me: sub.me, UdmSubID: sub.udmSubID, UeIdentity: sub.ueIdentity,
+		CorrID: sub.corrID, NumReports: sub.numReports})
+	if err != nil {
+		log.Errf("NEF Store failed to save monitoring event subscription "+
+			"%s: %v", sub.subid, err)
+	}
+}
+
+//Removes the monitoring event subscription from the store
+func (nef *nefData) nefRemoveMeSub(af *afData, subID string) {
+
+	if err := nef.store.deleteMeSub(af.afID, subID); err != nil {
+		log.Errf("NEF Store failed to delete monitoring event subscription "+
+			"%s: %v", subID, err)
+	}
+}
+
+//Saves the PFD transaction along with the AF data
+func (nef *nefData) nefSavePfdTrans(af *afData, trans *afPfdTransaction) {
+
+	nef.nefSaveAf(af)
+
+	err := nef.store.putPfdTrans(af.afID, nefStorePfdTrans{
+		TransID: trans.transID, PfdManagement: trans.pfdManagement,
+		CacheExpiries: trans.pfdCacheExpiries()})
+	if err != nil {
+		log.Errf("NEF Store failed to save PFD transaction %s: %v",
+			trans.transID, err)
+	}
+}
+
+//Removes the PFD transaction from the store
+func (nef *nefData) nefRemovePfdTrans(af *afData, transID string) {
+
+	if err := nef.store.deletePfdTrans(af.afID, transID); err != nil {
+		log.Errf("NEF Store failed to delete PFD transaction %s: %v",
+			transID, err)
+	}
+}
+
 /*
 func NEFInit() error {
 
@@ -184,7 +593,15 @@ func (nef *nefData) nefAddAf(nefCtx *nefContext, afID string) (af *afData,
 
 	var afe afData
 
-	if len(nef.afs) >= nefCtx.cfg.MaxAFSupport {
+	nef.mu.Lock()
+	defer nef.mu.Unlock()
+
+	//Another request may have created the AF since nefGetAf
+	if afp, ok := nef.afs[afID]; ok {
+		return afp, nil
+	}
+
+	if len(nef.afs) >= nefCtx.liveConfig().MaxAFSupport {
 		log.Infoln("MAX AF exceeded ")
 		return af, errors.New("MAX AF exceeded")
 	}
@@ -194,12 +611,30 @@ func (nef *nefData) nefAddAf(nefCtx *nefContext, afID string) (af *afData,
 	_ = afe.afCreate(nefCtx, afID)
 	nef.afs[afID] = &afe
 	nef.afCount++
+	nef.nefSaveAf(&afe)
 
 	return &afe, nil
 }
 
+//Returns the AF entry and creates it if it is not present
+func (nef *nefData) nefGetOrAddAf(nefCtx *nefContext, afID string) (
+	af *afData, err error) {
+
+	af, err = nef.nefGetAf(afID)
+
+	if err != nil {
+		log.Err("NO AF PRESENT CREATE AF")
+		return nef.nefAddAf(nefCtx, afID)
+	}
+	log.Infoln("AF PRESENT")
+	return af, nil
+}
+
 func (nef *nefData) nefGetAf(afID string) (af *afData, err error) {
 
+	nef.mu.RLock()
+	defer nef.mu.RUnlock()
+
 	//Check if AF is already present
 	afe, ok := nef.afs[afID]
 
@@ -212,21 +647,40 @@ func (nef *nefData) nefGetAf(afID string) (af *afData, err error) {
 
 func (nef *nefData) nefCheckDeleteAf(afID string) {
 
-	af, _ := nef.nefGetAf(afID)
+	af, err := nef.nefGetAf(afID)
+	if err != nil {
+		return
+	}
 
 	// If the AF subcount and transaction count is 0 delete the AF
-	if af.afGetSubCount() == 0 && af.afGetPfdTransCount() == 0 {
-		_ = nef.nefDeleteAf(afID)
+	af.mu.Lock()
+	empty := af.afSubTotal() == 0 && len(af.pfdtrans) == 0
+	if empty {
+		af.deleted = true
+	}
+	af.mu.Unlock()
+
+	if empty {
+		_ = nef.nefDeleteAf(af)
 	}
 }
-func (nef *nefData) nefDeleteAf(afID string) (err error) {
+
+//Removes the AF entry if it is still the one linked with the AF ID. The AF
+//must have been marked as deleted.
+func (nef *nefData) nefDeleteAf(af *afData) (err error) {
+
+	nef.mu.Lock()
+	defer nef.mu.Unlock()
 
 	//Check if AF is already present
-	_, ok := nef.afs[afID]
+	afe, ok := nef.afs[af.afID]
 
-	if ok {
-		delete(nef.afs, afID)
+	if ok && afe == af {
+		delete(nef.afs, af.afID)
 		//nef.afCount--
+		if err = nef.store.deleteAf(af.afID); err != nil {
+			log.Errf("NEF Store failed to delete AF %s: %v", af.afID, err)
+		}
 		return nil
 	}
 
@@ -234,7 +688,147 @@ func (nef *nefData) nefDeleteAf(afID string) (err error) {
 	return err
 }
 
+//Returns the number of subscriptions of all the types of the AF. The afData
+//mu must be held by the caller.
+func (af *afData) afSubTotal() int {
+
+	return len(af.subs) + len(af.qosSubs) + len(af.meSubs)
+}
+
+//Generates a new notification correlation ID
+func (nef *nefData) nefNextCorrID() string {
+
+	nef.idxMu.Lock()
+	defer nef.idxMu.Unlock()
+
+	corrID := strconv.Itoa(int(nef.corrID))
+	nef.corrID++
+	return corrID
+}
+
+//Links the correlation ID of the subscription with it, replacing the link of
+//the old correlation ID if it has changed
+func (nef *nefData) nefIndexSub(af *afData, sub *afSubscription,
+	oldCorrID string) {
+
+	nef.idxMu.Lock()
+	defer nef.idxMu.Unlock()
+
+	if oldCorrID != "" && oldCorrID != sub.NotifCorreID {
+		delete(nef.corrIDIdx, oldCorrID)
+	}
+	if sub.NotifCorreID != "" {
+		nef.corrIDIdx[sub.NotifCorreID] = nefSubRef{af: af, subID: sub.subid}
+	}
+}
+
+//Removes the link of the correlation ID of the subscription
+func (nef *nefData) nefUnindexSub(sub *afSubscription) {
+
+	nef.idxMu.Lock()
+	defer nef.idxMu.Unlock()
+
+	if sub.NotifCorreID != "" {
+		delete(nef.corrIDIdx, sub.NotifCorreID)
+	}
+}
+
+//Returns the subscription linked with the correlation ID
+func (nef *nefData) nefLookupCorrID(corrID string) (ref nefSubRef, ok bool) {
+
+	nef.idxMu.Lock()
+	defer nef.idxMu.Unlock()
+
+	ref, ok = nef.corrIDIdx[corrID]
+	return ref, ok
+}
+
+//Links the correlation ID of the monitoring event subscription with it
+func (nef *nefData) nefIndexMeSub(af *afData, sub *afMeSubscription) {
+
+	nef.idxMu.Lock()
+	defer nef.idxMu.Unlock()
+
+	nef.corrIDIdx[sub.corrID] = nefSubRef{af: af, subID: sub.subid}
+}
+
+//Removes the link of the correlation ID of the monitoring event subscription
+func (nef *nefData) nefUnindexMeSub(sub *afMeSubscription) {
+
+	nef.idxMu.Lock()
+	defer nef.idxMu.Unlock()
+
+	delete(nef.corrIDIdx, sub.corrID)
+}
+
+//Links the application IDs with the PFD transaction. Application IDs already
+//linked with another PFD transaction are not linked and returned.
+func (nef *nefData) nefReservePfdApps(af *afData, transID string,
+	appIDs []string) (dup []string) {
+
+	nef.idxMu.Lock()
+	defer nef.idxMu.Unlock()
+
+	ref := nefPfdTransRef{af: af, transID: transID}
+	for _, appID := range appIDs {
+		if r, ok := nef.pfdAppIdx[appID]; ok && r != ref {
+			dup = append(dup, appID)
+			continue
+		}
+		nef.pfdAppIdx[appID] = ref
+	}
+	return dup
+}
+
+//Removes the links of the application IDs with the PFD transaction
+func (nef *nefData) nefReleasePfdApps(af *afData, transID string,
+	appIDs []string) {
+
+	nef.idxMu.Lock()
+	defer nef.idxMu.Unlock()
+
+	ref := nefPfdTransRef{af: af, transID: transID}
+	for _, appID := range appIDs {
+		if r, ok := nef.pfdAppIdx[appID]; ok && r == ref {
+			delete(nef.pfdAppIdx, appID)
+		}
+	}
+}
+
+//Checks if the application ID is part of any PFD transaction
+func (nef *nefData) nefPfdAppExists(appID string) bool {
+
+	nef.idxMu.Lock()
+	defer nef.idxMu.Unlock()
+
+	_, ok := nef.pfdAppIdx[appID]
+	return ok
+}
+
+//Registers the NEF in the NRF, the registration is kept alive until the NEF
+//context is cancelled
+func (nef *nefData) nefRegister() {
+
+	if nef.nrf != nil {
+		nef.nrf.start(nef.ctx)
+	}
+}
+
 func (nef *nefData) nefDestroy() {
 
-	// Todo
+	nef.nefStopTempValidity()
+	nef.nefStopPfdExpiry()
+	nef.nefCloseWebsockets()
+	if nef.afNotifier != nil {
+		nef.afNotifier.stop()
+	}
+	if nef.nrf != nil {
+		nef.nrf.stop()
+	}
+	if nef.store == nil {
+		return
+	}
+	if err := nef.store.close(); err != nil {
+		log.Errf("NEF Store close failed: %v", err)
+	}
 }
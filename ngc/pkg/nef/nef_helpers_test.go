@@ -0,0 +1,60 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+
+	. "github.com/onsi/gomega"
+	ngcnef "github.com/open-ness/epcforedge/ngc/pkg/nef"
+)
+
+// writeJSON writes the body encoded in JSON with the status code, used by
+// the fake network functions
+func writeJSON(w http.ResponseWriter, code int, contentType string,
+	body interface{}) {
+
+	b, _ := json.Marshal(body)
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(code)
+	_, _ = w.Write(b)
+}
+
+// problem writes a ProblemDetails of the status code with the cause
+func problem(w http.ResponseWriter, code int, cause string) {
+	writeJSON(w, code, "application/problem+json",
+		ngcnef.ProblemDetails{Title: http.StatusText(code),
+			Status: int32(code), Cause: cause})
+}
+
+// createNefCfg writes a copy of the valid NEF configuration in dir with the
+// top level settings of overrides replaced and returns its path
+func createNefCfg(dir string, overrides map[string]interface{}) string {
+
+	var cfg map[string]interface{}
+
+	b, err := ioutil.ReadFile(NefTestCfgBasepath + "valid.json")
+	Expect(err).Should(BeNil())
+	Expect(json.Unmarshal(b, &cfg)).Should(BeNil())
+
+	for k, v := range overrides {
+		cfg[k] = v
+	}
+	b, err = json.Marshal(cfg)
+	Expect(err).Should(BeNil())
+
+	cfgPath := filepath.Join(dir, "nef.json")
+	Expect(ioutil.WriteFile(cfgPath, b, 0600)).Should(BeNil())
+	return cfgPath
+}
+
+// sbConfig returns the configuration of a southbound network function at
+// the API root
+func sbConfig(apiRoot string) map[string]string {
+	return map[string]string{"apiRoot": apiRoot}
+}
@@ -0,0 +1,164 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+
+	"github.com/gorilla/mux"
+)
+
+// Client certificate policies of the HTTP2 server
+const (
+	clientAuthNone     = "none"
+	clientAuthOptional = "optional"
+	clientAuthRequired = "required"
+)
+
+// AfCertIdentity maps the identity of an AF client certificate onto the AF
+// IDs (afId/scsAsId) it may use
+type AfCertIdentity struct {
+	// Subject common name, subject DN, DNS, URI or email SAN
+	Identity string   `json:"identity"`
+	AfIDs    []string `json:"afIds"`
+}
+
+// nefServerTLSConfig returns the TLS configuration of the HTTP2 server
+// verifying the AF client certificates, nil if mTLS is disabled
+func nefServerTLSConfig(cfg HTTP2Config) (*tls.Config, error) {
+
+	var clientAuth tls.ClientAuthType
+
+	switch cfg.ClientAuth {
+	case "", clientAuthNone:
+		return nil, nil
+	case clientAuthOptional:
+		clientAuth = tls.VerifyClientCertIfGiven
+	case clientAuthRequired:
+		clientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, errors.New("Invalid ClientAuth " + cfg.ClientAuth)
+	}
+
+	if cfg.ClientCACert == "" {
+		return nil, errors.New("ClientCACert missing for mutual TLS")
+	}
+	caCert, err := ioutil.ReadFile(filepath.Clean(cfg.ClientCACert))
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCert) {
+		return nil, errors.New("Invalid ClientCACert " + cfg.ClientCACert)
+	}
+	return &tls.Config{
+		ClientCAs:  pool,
+		ClientAuth: clientAuth,
+		MinVersion: tls.VersionTLS12,
+	}, nil
+}
+
+// certIdentities returns the identities of the certificate: the subject
+// common name and DN and the SANs
+func certIdentities(cert *x509.Certificate) []string {
+
+	ids := []string{cert.Subject.String()}
+	if cert.Subject.CommonName != "" {
+		ids = append(ids, cert.Subject.CommonName)
+	}
+	ids = append(ids, cert.DNSNames...)
+	ids = append(ids, cert.EmailAddresses...)
+	for _, u := range cert.URIs {
+		ids = append(ids, u.String())
+	}
+	return ids
+}
+
+// certGrantsAf returns true if an identity of the certificate is mapped onto
+// the AF ID
+func certGrantsAf(mappings []AfCertIdentity, cert *x509.Certificate,
+	afID string) bool {
+
+	for _, id := range certIdentities(cert) {
+		for _, m := range mappings {
+			if m.Identity != id {
+				continue
+			}
+			for _, a := range m.AfIDs {
+				if a == afID {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// routeAfID returns the AF ID (afId or scsAsId) of the path if any
+func routeAfID(r *http.Request) (string, bool) {
+
+	vars := mux.Vars(r)
+	afID, ok := vars["afId"]
+	if !ok {
+		afID, ok = vars["scsAsId"]
+	}
+	return afID, ok
+}
+
+// nefAuthorizeCert checks the client certificate of the requests to the
+// resources of an AF (afId/scsAsId in the path) when mutual TLS is enabled.
+// These requests are refused on the HTTP 1.1 endpoint, without a verified
+// certificate if it is required, or if the certificate is not mapped onto the
+// AF ID of the path. A 403 response is sent if so.
+func nefAuthorizeCert(w http.ResponseWriter, r *http.Request,
+	cfg HTTP2Config) bool {
+
+	if cfg.ClientAuth == "" || cfg.ClientAuth == clientAuthNone {
+		return true
+	}
+	afID, ok := routeAfID(r)
+	if !ok {
+		return true
+	}
+
+	if r.TLS == nil {
+		log.Infof("Request of the AF %s over HTTP refused", afID)
+		sendCertErrorResponse(w, "The AF APIs are only served over "+
+			"HTTPS with mutual TLS")
+		return false
+	}
+	if len(r.TLS.VerifiedChains) == 0 {
+		if cfg.ClientAuth != clientAuthRequired {
+			return true
+		}
+		log.Infof("Request of the AF %s without client certificate", afID)
+		sendCertErrorResponse(w, "A client certificate is required")
+		return false
+	}
+
+	cert := r.TLS.PeerCertificates[0]
+	if !certGrantsAf(cfg.AfCertIdentities, cert, afID) {
+		log.Infof("Client certificate %s used for the AF %s",
+			cert.Subject.String(), afID)
+		sendCertErrorResponse(w, "The client certificate is not granted "+
+			"to the AF "+afID)
+		return false
+	}
+	return true
+}
+
+// sendCertErrorResponse sends the 403 response of a request refused by
+// nefAuthorizeCert
+func sendCertErrorResponse(w http.ResponseWriter, detail string) {
+
+	sendErrorResponseToAF(w, nefSBRspData{errorCode: 403,
+		pd: ProblemDetails{Title: "AF not authorized", Status: 403,
+			Detail: detail}})
+}
@@ -0,0 +1,159 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	ngcnef "github.com/open-ness/epcforedge/ngc/pkg/nef"
+)
+
+const mtlsTIURL = "https://localhost:8096/3gpp-traffic-influence/v1/AF_01/" +
+	"subscriptions"
+
+// testCert is a certificate and its key issued by the test CA
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// issueCert issues a certificate signed by the CA, self-signed if ca is nil
+func issueCert(ca *testCert, cn string, isCA bool) *testCert {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).Should(BeNil())
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth,
+			x509.ExtKeyUsageClientAuth},
+		DNSNames:    []string{cn},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if isCA {
+		tpl.IsCA = true
+		tpl.BasicConstraintsValid = true
+		tpl.KeyUsage |= x509.KeyUsageCertSign
+	}
+	parent, signer := tpl, key
+	if ca != nil {
+		parent, signer = ca.cert, ca.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, parent,
+		&key.PublicKey, signer)
+	Expect(err).Should(BeNil())
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).Should(BeNil())
+	return &testCert{cert: cert, key: key}
+}
+
+// write writes the certificate and its key in PEM files
+func (c *testCert) write(dir string, name string) (string, string) {
+
+	certPath := filepath.Join(dir, name+"-cert.pem")
+	keyPath := filepath.Join(dir, name+"-key.pem")
+	Expect(ioutil.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{
+		Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0600)).Should(BeNil())
+	b, err := x509.MarshalECPrivateKey(c.key)
+	Expect(err).Should(BeNil())
+	Expect(ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{
+		Type: "EC PRIVATE KEY", Bytes: b}), 0600)).Should(BeNil())
+	return certPath, keyPath
+}
+
+// tlsCert returns the certificate for the TLS configuration
+func (c *testCert) tlsCert() tls.Certificate {
+
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw},
+		PrivateKey: c.key, Leaf: c.cert}
+}
+
+var _ = Describe("Test NEF Mutual TLS", func() {
+
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "nefmtls")
+		Expect(err).Should(BeNil())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("Maps the AF client certificates onto the AF IDs", func() {
+
+		ca := issueCert(nil, "NEF test CA", true)
+		caPath, _ := ca.write(dir, "ca")
+		srvCert, srvKey := issueCert(ca, "localhost", false).write(dir,
+			"server")
+		af01 := issueCert(ca, "af01.test", false)
+		af02 := issueCert(ca, "af02.test", false)
+
+		cfgPath := createNefCfg(dir, map[string]interface{}{
+			"HTTP2Config": ngcnef.HTTP2Config{Endpoint: ":8096",
+				NefServerCert: srvCert, NefServerKey: srvKey,
+				ClientAuth: "required", ClientCACert: caPath,
+				AfCertIdentities: []ngcnef.AfCertIdentity{{
+					Identity: "af01.test", AfIDs: []string{"AF_01"}}}}})
+
+		_, cancel := startNefWithCfg(cfgPath)
+		defer cancel()
+
+		pool := x509.NewCertPool()
+		pool.AddCert(ca.cert)
+		client := func(certs ...tls.Certificate) *http.Client {
+			return &http.Client{Timeout: 5 * time.Second,
+				Transport: &http.Transport{TLSClientConfig: &tls.Config{
+					RootCAs: pool, Certificates: certs}}}
+		}
+
+		rsp, err := client(af01.tlsCert()).Get(mtlsTIURL)
+		Expect(err).Should(BeNil())
+		rsp.Body.Close()
+		Expect(rsp.StatusCode).Should(Equal(http.StatusOK))
+
+		// The certificate of another AF
+		rsp, err = client(af02.tlsCert()).Get(mtlsTIURL)
+		Expect(err).Should(BeNil())
+		rsp.Body.Close()
+		Expect(rsp.StatusCode).Should(Equal(http.StatusForbidden))
+
+		// No client certificate
+		_, err = client().Get(mtlsTIURL)
+		Expect(err).ShouldNot(BeNil())
+
+		// The AF APIs are not served on the HTTP endpoint
+		rsp, err = http.Get(baseAPIURL)
+		Expect(err).Should(BeNil())
+		rsp.Body.Close()
+		Expect(rsp.StatusCode).Should(Equal(http.StatusForbidden))
+
+		// The other APIs are
+		rsp, err = http.Get(afNotifStatusURL)
+		Expect(err).Should(BeNil())
+		rsp.Body.Close()
+		Expect(rsp.StatusCode).Should(Equal(http.StatusOK))
+	})
+})
@@ -0,0 +1,896 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+/* Handlers of the Monitoring Event API (3GPP TS 29.122). The monitoring
+events are subscribed in the UDM with the Nudm_EventExposure service, the UDM
+forwards the subscription to the AMF for the events detected by the AMF. The
+events reported by the UDM are delivered to the notification destination of
+the AF as MonitoringEventReports. */
+
+package ngcnef
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// Reference ID of the single event subscribed in the UDM per subscription
+const meReferenceID = 1
+
+func createNewMeSub(nefCtx *nefContext, afID string,
+	me MonitoringEventSubscription) (loc string, reports []MonitoringReport,
+	rsp nefSBRspData, err error) {
+
+	var af *afData
+	nef := &nefCtx.nef
+
+	for {
+		af, err = nef.nefGetOrAddAf(nefCtx, afID)
+		if err != nil {
+			return loc, reports, rsp, err
+		}
+
+		loc, reports, rsp, err = af.afAddMeSubscription(nefCtx, me)
+
+		// The AF got deleted after it was looked up, retry with a new entry
+		if err == errAfDeleted {
+			_ = nef.nefDeleteAf(af)
+			continue
+		}
+		return loc, reports, rsp, err
+	}
+}
+
+// ReadAllMonitoringEventSubscription : API to read all the monitoring event
+// subscriptions of the AF
+func ReadAllMonitoringEventSubscription(w http.ResponseWriter,
+	r *http.Request) {
+
+	var subslist []MonitoringEventSubscription
+
+	nefCtx := r.Context().Value(nefCtxKey("nefCtx")).(*nefContext)
+	nef := &nefCtx.nef
+
+	vars := mux.Vars(r)
+	log.Infof(" SCSASID : %s", vars["scsAsId"])
+
+	af, err := nef.nefGetAf(vars["scsAsId"])
+	if err != nil {
+		/* No subscription data will be returned to AF */
+		log.Infoln(err)
+	} else {
+		subslist = af.afGetMeSubscriptionList()
+	}
+
+	mdata, err := json.Marshal(subslist)
+	if err != nil {
+		sendCustomeErrorRspToAF(w, 400, "Failed to MARSHAL Subscription data ")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(mdata)
+	if err != nil {
+		log.Errf("Write Failed: %v", err)
+		return
+	}
+
+	log.Infof("HTTP Response sent: %d", http.StatusOK)
+}
+
+// CreateMonitoringEventSubscription : Handles the monitoring event
+// subscription requested by AF
+func CreateMonitoringEventSubscription(w http.ResponseWriter,
+	r *http.Request) {
+
+	nefCtx := r.Context().Value(nefCtxKey("nefCtx")).(*nefContext)
+
+	vars := mux.Vars(r)
+	log.Infof(" SCSASID : %s", vars["scsAsId"])
+
+	b, err := ioutil.ReadAll(r.Body)
+	defer closeReqBody(r)
+
+	if err != nil {
+		sendCustomeErrorRspToAF(w, 400, "Failed to read HTTP POST Body")
+		return
+	}
+
+	meBody := MonitoringEventSubscription{}
+	if err = json.Unmarshal(b, &meBody); err != nil {
+		log.Err(err)
+		sendCustomeErrorRspToAF(w, 400, "Failed UnMarshal POST data")
+		return
+	}
+
+	//validate the mandatory parameters
+	resRsp, status := validateAFMeData(meBody)
+	if !status {
+		log.Err(resRsp.pd.Title)
+		sendErrorResponseToAF(w, resRsp)
+		return
+	}
+
+	loc, reports, rsp, err := createNewMeSub(nefCtx, vars["scsAsId"], meBody)
+	if err != nil {
+		log.Err(err)
+		// we return bad request here since the UDM rejected the
+		// subscription or we have reached the max
+		rsp.errorCode = 400
+		sendErrorResponseToAF(w, rsp)
+		return
+	}
+	log.Infoln(loc)
+
+	meBody.Self = Link(loc)
+	mdata, err := json.Marshal(meBody)
+	if err != nil {
+		log.Err(err)
+		sendCustomeErrorRspToAF(w, 400, "Failed to Marshal POST response data")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set("Location", loc)
+	w.WriteHeader(http.StatusCreated)
+	log.Infof("CreateMonitoringEventSubscription responses => %d",
+		http.StatusCreated)
+	_, err = w.Write(mdata)
+	if err != nil {
+		log.Errf("Write Failed: %v", err)
+		return
+	}
+	logNef(&nefCtx.nef)
+
+	// The immediate reports are sent once the AF has the subscription
+	if len(reports) > 0 {
+		go nefCtx.nef.nefSendMeReports(nefCtx, vars["scsAsId"],
+			path.Base(loc), reports)
+	}
+}
+
+// ReadMonitoringEventSubscription : Read a particular monitoring event
+// subscription
+func ReadMonitoringEventSubscription(w http.ResponseWriter, r *http.Request) {
+
+	nefCtx := r.Context().Value(nefCtxKey("nefCtx")).(*nefContext)
+	nef := &nefCtx.nef
+
+	vars := mux.Vars(r)
+	log.Infof(" SCSASID : %s", vars["scsAsId"])
+	log.Infof(" SUBSCRIPTION ID  : %s", vars["subscriptionId"])
+
+	af, err := nef.nefGetAf(vars["scsAsId"])
+	if err != nil {
+		sendCustomeErrorRspToAF(w, 404, "Failed to find AF records")
+		return
+	}
+
+	rsp, me, err := af.afGetMeSubscription(vars["subscriptionId"])
+	if err != nil {
+		log.Err(err)
+		sendErrorResponseToAF(w, rsp)
+		return
+	}
+
+	sendMeSubToAF(w, me)
+}
+
+// UpdatePutMonitoringEventSubscription : Replaces a monitoring event
+// subscription created earlier (PUT Req)
+func UpdatePutMonitoringEventSubscription(w http.ResponseWriter,
+	r *http.Request) {
+
+	nefCtx := r.Context().Value(nefCtxKey("nefCtx")).(*nefContext)
+	nef := &nefCtx.nef
+
+	vars := mux.Vars(r)
+	log.Infof(" SCSASID : %s", vars["scsAsId"])
+	log.Infof(" SUBSCRIPTION ID  : %s", vars["subscriptionId"])
+
+	af, err := nef.nefGetAf(vars["scsAsId"])
+	if err != nil {
+		log.Infoln(err)
+		sendCustomeErrorRspToAF(w, 404, "Failed to find AF records")
+		return
+	}
+
+	b, err := ioutil.ReadAll(r.Body)
+	defer closeReqBody(r)
+
+	if err != nil {
+		log.Err(err)
+		sendCustomeErrorRspToAF(w, 400, "Failed to read HTTP PUT Body")
+		return
+	}
+
+	meBody := MonitoringEventSubscription{}
+	if err = json.Unmarshal(b, &meBody); err != nil {
+		log.Err(err)
+		sendCustomeErrorRspToAF(w, 400, "Failed UnMarshal PUT data")
+		return
+	}
+
+	resRsp, status := validateAFMeData(meBody)
+	if !status {
+		log.Err(resRsp.pd.Title)
+		sendErrorResponseToAF(w, resRsp)
+		return
+	}
+
+	rsp, me, reports, err := af.afUpdateMeSubscription(nefCtx,
+		vars["subscriptionId"], meBody)
+	if err != nil {
+		log.Err(err)
+		sendErrorResponseToAF(w, rsp)
+		return
+	}
+
+	sendMeSubToAF(w, me)
+
+	if len(reports) > 0 {
+		go nef.nefSendMeReports(nefCtx, vars["scsAsId"],
+			vars["subscriptionId"], reports)
+	}
+}
+
+// DeleteMonitoringEventSubscription : Deletes a monitoring event
+// subscription created by AF
+func DeleteMonitoringEventSubscription(w http.ResponseWriter,
+	r *http.Request) {
+
+	nefCtx := r.Context().Value(nefCtxKey("nefCtx")).(*nefContext)
+	nef := &nefCtx.nef
+
+	vars := mux.Vars(r)
+	log.Infof(" SCSASID : %s", vars["scsAsId"])
+	log.Infof(" SUBSCRIPTION ID  : %s", vars["subscriptionId"])
+
+	af, err := nef.nefGetAf(vars["scsAsId"])
+	if err != nil {
+		log.Err(err)
+		sendCustomeErrorRspToAF(w, 404, "Failed to find AF entry")
+		return
+	}
+
+	rsp, err := af.afDeleteMeSubscription(nefCtx, vars["subscriptionId"])
+	if err != nil {
+		log.Err(err)
+		sendErrorResponseToAF(w, rsp)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	log.Infof("HTTP Response sent: %d", http.StatusNoContent)
+
+	nef.nefCheckDeleteAf(vars["scsAsId"])
+	logNef(nef)
+}
+
+// NotifyUdmMonitoringEvent : Handles the monitoring reports sent by the UDM
+// for a monitoring event subscription. The subscription is found with the
+// correlation ID that is part of the notification URI.
+func NotifyUdmMonitoringEvent(w http.ResponseWriter, r *http.Request) {
+
+	nefCtx := r.Context().Value(nefCtxKey("nefCtx")).(*nefContext)
+	nef := &nefCtx.nef
+
+	vars := mux.Vars(r)
+	log.Infof(" CORRELATION ID : %s", vars["corrId"])
+
+	b, err := ioutil.ReadAll(r.Body)
+	defer closeReqBody(r)
+
+	if err != nil {
+		log.Errf("NotifyUdmMonitoringEvent body read error : %s",
+			err.Error())
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var reports []MonitoringReport
+	if err = json.Unmarshal(b, &reports); err != nil {
+		log.Errf("NotifyUdmMonitoringEvent unmarshal error : %s",
+			err.Error())
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if len(reports) == 0 {
+		log.Errf("NotifyUdmMonitoringEvent missing monitoring reports")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ref, ok := nef.nefLookupCorrID(vars["corrId"])
+	if !ok {
+		log.Errf("NotifyUdmMonitoringEvent correlation ID [%s]: %s",
+			vars["corrId"], subNotFound)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	afURI, notif, err := ref.af.afMeNotification(nefCtx, ref.subID,
+		vars["corrId"], reports)
+	if err != nil {
+		log.Errf("NotifyUdmMonitoringEvent correlation ID [%s]: %s",
+			vars["corrId"], err.Error())
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	// Queue the request towards AF, it is retried if the AF is unreachable
+	nef.afNotifier.dispatchMonitoringEvent(afURI, notif)
+
+	if notif.CancelInd {
+		nef.nefCheckDeleteAf(ref.af.afID)
+	}
+}
+
+// nefSendMeReports sends the immediate reports received from the UDM for
+// the subscription to the AF
+func (nef *nefData) nefSendMeReports(nefCtx *nefContext, afID string,
+	subID string, reports []MonitoringReport) {
+
+	af, err := nef.nefGetAf(afID)
+	if err != nil {
+		log.Errf("Monitoring event immediate reports: %s", err.Error())
+		return
+	}
+
+	afURI, notif, err := af.afMeNotification(nefCtx, subID, "", reports)
+	if err != nil {
+		log.Errf("Monitoring event immediate reports: %s", err.Error())
+		return
+	}
+
+	nef.afNotifier.dispatchMonitoringEvent(afURI, notif)
+
+	if notif.CancelInd {
+		nef.nefCheckDeleteAf(afID)
+	}
+}
+
+// sendMeSubToAF sends the monitoring event subscription in a 200 response
+func sendMeSubToAF(w http.ResponseWriter, me MonitoringEventSubscription) {
+
+	mdata, err := json.Marshal(me)
+	if err != nil {
+		log.Err(err)
+		sendCustomeErrorRspToAF(w, 400, "Failed to Marshal response data")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(mdata)
+	if err != nil {
+		log.Errf("Write Failed: %v", err)
+		return
+	}
+	log.Infof("HTTP Response sent: %d", http.StatusOK)
+}
+
+//validateAFMeData: Function to validate mandatory parameters of the
+//monitoring event subscription received from AF
+func validateAFMeData(me MonitoringEventSubscription) (rsp nefSBRspData,
+	status bool) {
+
+	rsp.errorCode = 400
+
+	if len(me.NotificationDestination) == 0 {
+		rsp.pd.Title = "Missing notificationDestination attribute"
+		return rsp, false
+	}
+
+	if len(meUeIdentity(me)) == 0 {
+		rsp.pd.Title = "One of externalId, msisdn, externalGroupId is " +
+			"required"
+		return rsp, false
+	}
+
+	switch me.MonitoringType {
+	case MonitoringTypeLossOfConnectivity:
+	case MonitoringTypeUeReachability:
+		if me.ReachabilityType != ReachabilityTypeData &&
+			me.ReachabilityType != ReachabilityTypeSms {
+			rsp.pd.Title = "reachabilityType is required with " +
+				"UE_REACHABILITY"
+			return rsp, false
+		}
+	case MonitoringTypeLocationReporting:
+		if me.LocationType != LocationTypeCurrent &&
+			me.LocationType != LocationTypeLastKnown {
+			rsp.pd.Title = "locationType is required with " +
+				"LOCATION_REPORTING"
+			return rsp, false
+		}
+	case "":
+		rsp.pd.Title = "Missing monitoringType attribute"
+		return rsp, false
+	default:
+		rsp.pd.Title = "Unsupported monitoringType " +
+			string(me.MonitoringType)
+		return rsp, false
+	}
+
+	if me.MaximumNumberOfReports < 0 {
+		rsp.pd.Title = "Invalid maximumNumberOfReports"
+		return rsp, false
+	}
+
+	rsp.errorCode = 0
+	return rsp, true
+}
+
+//meUeIdentity returns the UE identity of the UDM event exposure resource.
+//Empty string is returned unless exactly one identity is present.
+func meUeIdentity(me MonitoringEventSubscription) string {
+
+	var ueIdentity string
+	ids := 0
+	if len(me.ExternalID) > 0 {
+		ueIdentity = udmUeIdentityExternalID + me.ExternalID
+		ids++
+	}
+	if len(me.Msisdn) > 0 {
+		ueIdentity = udmUeIdentityMsisdn + me.Msisdn
+		ids++
+	}
+	if len(me.ExternalGroupID) > 0 {
+		ueIdentity = udmUeIdentityExternalGroupID + string(me.ExternalGroupID)
+		ids++
+	}
+	if ids != 1 {
+		return ""
+	}
+	return ueIdentity
+}
+
+//Creates a new monitoring event subscription
+func (af *afData) afAddMeSubscription(nefCtx *nefContext,
+	me MonitoringEventSubscription) (loc string, reports []MonitoringReport,
+	rsp nefSBRspData, err error) {
+
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
+	if af.deleted {
+		return "", nil, rsp, errAfDeleted
+	}
+
+	/*Check if max subscription reached */
+	if af.afSubTotal() >= nefCtx.liveConfig().MaxSubSupport {
+		rsp.errorCode = 400
+		rsp.pd.Title = "MAX Subscription Reached"
+		return "", nil, rsp, errors.New("MAX SUBS Created")
+	}
+
+	//Generate a unique subscription ID string
+	subIDStr := strconv.Itoa(af.subIDnum)
+	af.subIDnum++
+
+	afsub := afMeSubscription{subid: subIDStr, me: me,
+		ueIdentity: meUeIdentity(me),
+		corrID:     nefCtx.nef.nefNextCorrID()}
+
+	reports, rsp, err = nefSBUDMMeSubscribe(&afsub, nefCtx)
+	if err != nil {
+		return "", nil, rsp, err
+	}
+
+	//Link the subscription with the AF
+	af.meSubs[subIDStr] = &afsub
+	nefCtx.nef.nefIndexMeSub(af, &afsub)
+
+	//Create Location URI
+	loc = nefCtx.nef.locationURLPrefixMe + af.afID + "/subscriptions/" +
+		subIDStr
+
+	afsub.me.Self = Link(loc)
+	nefCtx.nef.nefSaveMeSub(af, &afsub)
+
+	log.Infoln(" NEW AF Monitoring Event Subscription added " + subIDStr)
+
+	return loc, reports, rsp, nil
+}
+
+//Replaces the monitoring event subscription. The UDM subscription can not
+//be modified so it is replaced with a new one.
+func (af *afData) afUpdateMeSubscription(nefCtx *nefContext, subID string,
+	me MonitoringEventSubscription) (rsp nefSBRspData,
+	updtMe MonitoringEventSubscription, reports []MonitoringReport,
+	err error) {
+
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
+	sub, ok := af.meSubs[subID]
+	if !ok {
+		rsp.errorCode = 404
+		rsp.pd.Title = subNotFound
+		return rsp, updtMe, nil, errors.New(subNotFound)
+	}
+
+	// The UDM subscription is bound to the UE
+	if meUeIdentity(me) != sub.ueIdentity {
+		rsp.errorCode = 400
+		rsp.pd.Title = "UE identity can not be changed"
+		return rsp, updtMe, nil, errors.New(rsp.pd.Title)
+	}
+
+	newSub := afMeSubscription{subid: subID, me: me,
+		ueIdentity: sub.ueIdentity, corrID: sub.corrID}
+
+	reports, rsp, err = nefSBUDMMeSubscribe(&newSub, nefCtx)
+	if err != nil {
+		log.Err("Failed to Update Monitoring Event Subscription")
+		return rsp, updtMe, nil, err
+	}
+
+	if _, err = nefSBUDMMeUnsubscribe(sub, nefCtx); err != nil {
+		log.Errf("Failed to remove the replaced UDM subscription %s",
+			string(sub.udmSubID))
+	}
+
+	newSub.me.Self = sub.me.Self
+	*sub = newSub
+	nefCtx.nef.nefSaveMeSub(af, sub)
+
+	log.Infoln("Update Monitoring Event Subscription Successful")
+	return rsp, sub.me, reports, nil
+}
+
+func (af *afData) afGetMeSubscription(subID string) (rsp nefSBRspData,
+	me MonitoringEventSubscription, err error) {
+
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
+	sub, ok := af.meSubs[subID]
+	if !ok {
+		rsp.errorCode = 404
+		rsp.pd.Title = subNotFound
+		return rsp, me, errors.New(subNotFound)
+	}
+
+	//Return locally
+	return rsp, sub.me, nil
+}
+
+func (af *afData) afGetMeSubscriptionList() (
+	subsList []MonitoringEventSubscription) {
+
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
+	//Return locally
+	for _, sub := range af.meSubs {
+		subsList = append(subsList, sub.me)
+	}
+	return subsList
+}
+
+func (af *afData) afDeleteMeSubscription(nefCtx *nefContext,
+	subID string) (rsp nefSBRspData, err error) {
+
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
+	sub, ok := af.meSubs[subID]
+	if !ok {
+		rsp.errorCode = 404
+		rsp.pd.Title = subNotFound
+		return rsp, errors.New(subNotFound)
+	}
+
+	rsp, err = nefSBUDMMeUnsubscribe(sub, nefCtx)
+	if err != nil {
+		log.Err("Failed to Delete Monitoring Event Subscription")
+		return rsp, err
+	}
+
+	//Delete local entry in map
+	af.afRemoveMeSubscription(nefCtx, sub)
+
+	return rsp, nil
+}
+
+//Removes the monitoring event subscription locally. The afData mu must be
+//held by the caller.
+func (af *afData) afRemoveMeSubscription(nefCtx *nefContext,
+	sub *afMeSubscription) {
+
+	delete(af.meSubs, sub.subid)
+	nefCtx.nef.nefUnindexMeSub(sub)
+	nefCtx.nef.nefRemoveMeSub(af, sub.subid)
+}
+
+//afMeNotification maps the reports of the UDM to the notification sent to
+//the AF. The subscription is removed once the maximum number of reports has
+//been reached. An empty corrID skips the check of the correlation ID.
+func (af *afData) afMeNotification(nefCtx *nefContext, subID string,
+	corrID string, reports []MonitoringReport) (afURI URI,
+	notif MonitoringNotification, err error) {
+
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
+	sub, ok := af.meSubs[subID]
+	if !ok || (corrID != "" && sub.corrID != corrID) {
+		return afURI, notif, errors.New(subNotFound)
+	}
+
+	afURI = URI(sub.me.NotificationDestination)
+	notif.Subscription = sub.me.Self
+	for _, report := range reports {
+		notif.MonitoringEventReports = append(notif.MonitoringEventReports,
+			getMonitoringEventReport(sub.me, report))
+	}
+
+	sub.numReports += int32(len(reports))
+	if sub.me.MaximumNumberOfReports > 0 &&
+		sub.numReports >= sub.me.MaximumNumberOfReports {
+		log.Infof("Monitoring Event Subscription %s reached the maximum "+
+			"number of reports", subID)
+		notif.CancelInd = true
+		af.afRemoveMeSubscription(nefCtx, sub)
+		return afURI, notif, nil
+	}
+	nefCtx.nef.nefSaveMeSub(af, sub)
+	return afURI, notif, nil
+}
+
+//getMonitoringEventReport maps a monitoring report of the UDM onto the
+//monitoring event report of the AF
+func getMonitoringEventReport(me MonitoringEventSubscription,
+	report MonitoringReport) MonitoringEventReport {
+
+	evReport := MonitoringEventReport{ExternalID: me.ExternalID,
+		Msisdn: me.Msisdn, MonitoringType: me.MonitoringType,
+		EventTime: report.TimeStamp}
+
+	if report.Report == nil {
+		return evReport
+	}
+
+	switch me.MonitoringType {
+	case MonitoringTypeLossOfConnectivity:
+		var reason int32
+		switch report.Report.LossOfConnectReason {
+		case "DEREGISTERED":
+			reason = lossOfConnectUeDetached
+		case "MAX_DETECTION_TIME_EXPIRED":
+			reason = lossOfConnectMaxDetectionExpired
+		case "PURGED":
+			reason = lossOfConnectUePurged
+		default:
+			return evReport
+		}
+		evReport.LossOfConnectReason = &reason
+
+	case MonitoringTypeUeReachability:
+		evReport.ReachabilityType = me.ReachabilityType
+		evReport.MaxUEAvailabilityTime = report.Report.MaxAvailabilityTime
+
+	case MonitoringTypeLocationReporting:
+		evReport.LocationInfo = getLocationInfo(report.Report.Location)
+	}
+	return evReport
+}
+
+//getLocationInfo maps the user location reported by the UDM onto the
+//location info of the AF
+func getLocationInfo(loc *UserLocation) *LocationInfo {
+
+	if loc == nil {
+		return nil
+	}
+
+	var locInfo LocationInfo
+	var plmnID PlmnID
+	if loc.NrLocation != nil {
+		locInfo.CellID = string(loc.NrLocation.Ncgi.NrCellID)
+		locInfo.TrackingAreaID = string(loc.NrLocation.Tai.Tac)
+		locInfo.AgeOfLocationInfo = loc.NrLocation.AgeOfLocationInformation
+		plmnID = loc.NrLocation.Tai.PlmnID
+	} else if loc.EutraLocation != nil {
+		locInfo.CellID = string(loc.EutraLocation.Ecgi.EutraCellID)
+		locInfo.TrackingAreaID = string(loc.EutraLocation.Tai.Tac)
+		locInfo.AgeOfLocationInfo =
+			loc.EutraLocation.AgeOfLocationInformation
+		plmnID = loc.EutraLocation.Tai.PlmnID
+	} else {
+		return nil
+	}
+	locInfo.PlmnID = &plmnID
+	return &locInfo
+}
+
+//getUdmEeSubscription maps the monitoring event subscription onto the event
+//exposure subscription of the UDM
+func getUdmEeSubscription(me MonitoringEventSubscription,
+	notifURI URI) EeSubscription {
+
+	cfg := MonitoringConfiguration{}
+	switch me.MonitoringType {
+	case MonitoringTypeLossOfConnectivity:
+		cfg.EventType = UdmEventLossOfConnectivity
+		if me.MaximumDetectionTime > 0 {
+			cfg.LossConnectivityCfg = &LossConnectivityCfg{
+				MaxDetectionTime: me.MaximumDetectionTime}
+		}
+
+	case MonitoringTypeUeReachability:
+		cfg.EventType = UdmEventUeReachabilityData
+		if me.ReachabilityType == ReachabilityTypeSms {
+			cfg.EventType = UdmEventUeReachabilitySms
+		}
+		cfg.MaximumLatency = me.MaximumLatency
+		cfg.MaximumResponseTime = me.MaximumResponseTime
+
+	case MonitoringTypeLocationReporting:
+		cfg.EventType = UdmEventLocationReporting
+		cfg.ImmediateFlag = me.LocationType == LocationTypeLastKnown
+		cfg.LocationReportingConfiguration = &LocationReportingConfiguration{
+			CurrentLocation: me.LocationType == LocationTypeCurrent,
+			OneTime:         me.MaximumNumberOfReports == 1,
+			Accuracy:        getUdmLocationAccuracy(me.Accuracy)}
+	}
+
+	eeSub := EeSubscription{CallbackReference: notifURI,
+		MonitoringConfigurations: map[string]MonitoringConfiguration{
+			strconv.Itoa(meReferenceID): cfg},
+		SupportedFeatures: me.SupportedFeatures}
+
+	if me.MaximumNumberOfReports > 0 || len(me.MonitorExpireTime) > 0 {
+		eeSub.ReportingOptions = &ReportingOptions{
+			MaxNumOfReports: me.MaximumNumberOfReports,
+			Expiry:          me.MonitorExpireTime}
+	}
+	return eeSub
+}
+
+//getUdmLocationAccuracy maps the accuracy requested by the AF onto the
+//accuracy of the UDM
+func getUdmLocationAccuracy(accuracy Accuracy) string {
+
+	switch accuracy {
+	case "CGI_ECGI":
+		return "CELL_LEVEL"
+	case "TA_RA":
+		return "TA_LEVEL"
+	}
+	return ""
+}
+
+// udmMeResponse : Converts the UDM response, any failure response is
+// returned as error
+func udmMeResponse(op string, udmResp UdmEventExposureResponse,
+	err error) (rsp nefSBRspData, _ error) {
+
+	rsp.errorCode = int(udmResp.ResponseCode)
+	if udmResp.Pd != nil {
+		rsp.pd = *udmResp.Pd
+	}
+	if err == nil && rsp.errorCode >= 300 {
+		err = errors.New("UDM Event Exposure " + op + " rejected")
+	}
+	if err != nil {
+		if rsp.errorCode == 0 {
+			rsp.errorCode = http.StatusInternalServerError
+		}
+		log.Errf("UDM Event Exposure %s Failure. Response Code: %d",
+			op, rsp.errorCode)
+		return rsp, err
+	}
+	log.Infof("UDM Event Exposure %s Success. Response Code: %d", op,
+		rsp.errorCode)
+	return rsp, nil
+}
+
+// nefSBUDMMeSubscribe : This function sends HTTP POST Request to UDM to
+//             create the event exposure subscription of the monitoring event.
+// Input Args:
+//   - meSub: This is the monitoring event subscription
+//   - nefCtx: This is NEF Module Context. This contains the NEF Module Data.
+// Output Args:
+//    - reports: These are the immediate reports returned by the UDM
+//    - rsp: This is Event Exposure Subscribe Response Data
+//    - error: retruns error in case there is failure happened in sending the
+//             request or any failure response is received.
+func nefSBUDMMeSubscribe(meSub *afMeSubscription, nefCtx *nefContext) (
+	reports []MonitoringReport, rsp nefSBRspData, err error) {
+
+	nef := &nefCtx.nef
+
+	cliCtx, cancel := context.WithCancel(nef.ctx)
+	defer cancel()
+
+	notifURI := URI(string(nef.udmNotificationURL) + "/" + meSub.corrID)
+	eeSub := getUdmEeSubscription(meSub.me, notifURI)
+
+	udmSubID, udmResp, err := nef.udmClient.UdmEventExposureSubscribe(
+		cliCtx, meSub.ueIdentity, eeSub)
+	rsp, err = udmMeResponse("Subscribe", udmResp, err)
+	if err != nil {
+		return nil, rsp, err
+	}
+	meSub.udmSubID = udmSubID
+	if udmResp.Ees != nil {
+		reports = udmResp.Ees.EventReports
+	}
+	return reports, rsp, nil
+}
+
+// nefSBUDMMeUnsubscribe : This function sends HTTP DELETE Request to UDM to
+//             remove the event exposure subscription of the monitoring event.
+// Input Args:
+//   - meSub: This is the monitoring event subscription
+//   - nefCtx: This is NEF Module Context. This contains the NEF Module Data.
+// Output Args:
+//    - rsp: This is Event Exposure Unsubscribe Response Data
+//    - error: retruns error in case there is failure happened in sending the
+//             request or any failure response is received.
+func nefSBUDMMeUnsubscribe(meSub *afMeSubscription, nefCtx *nefContext) (
+	rsp nefSBRspData, err error) {
+
+	nef := &nefCtx.nef
+
+	cliCtx, cancel := context.WithCancel(nef.ctx)
+	defer cancel()
+
+	udmResp, err := nef.udmClient.UdmEventExposureUnsubscribe(cliCtx,
+		meSub.ueIdentity, meSub.udmSubID)
+	rsp, err = udmMeResponse("Unsubscribe", udmResp, err)
+
+	// The subscription is already gone in the UDM, e.g. it has expired
+	if err != nil && rsp.errorCode == http.StatusNotFound {
+		return nefSBRspData{errorCode: http.StatusNoContent}, nil
+	}
+	return rsp, err
+}
+
+// Generate the location url prefix for monitoring events
+func getNefLocationURLPrefixMe(cfg *Config) string {
+
+	var uri string
+	// If http2 port is configured use it else http port
+	if cfg.HTTP2Config.Endpoint != "" {
+		uri = "https://" + cfg.NefAPIRoot +
+			cfg.HTTP2Config.Endpoint
+	} else {
+		uri = "http://" + cfg.NefAPIRoot +
+			cfg.HTTPConfig.Endpoint
+	}
+	uri += cfg.LocationPrefixMe
+	return uri
+}
+
+// Generate the notification uri for the UDM, the correlation ID of the
+// subscription is appended to it
+func getNefUdmNotificationURI(cfg *Config) URI {
+
+	var uri string
+	// If http2 port is configured use it else http port
+	if cfg.HTTP2Config.Endpoint != "" {
+		uri = "https://" + cfg.NefAPIRoot +
+			cfg.HTTP2Config.Endpoint
+	} else {
+		uri = "http://" + cfg.NefAPIRoot +
+			cfg.HTTPConfig.Endpoint
+	}
+	uri += cfg.UdmNotificationResURIPath
+	return URI(uri)
+}
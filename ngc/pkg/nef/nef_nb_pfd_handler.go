@@ -28,25 +28,25 @@ func createNewPFDTrans(nefCtx *nefContext, afID string,
 	var af *afData
 	nef := &nefCtx.nef
 
-	af, err = nef.nefGetAf(afID)
-
-	if err != nil {
-		log.Err("NO AF PRESENT CREATE AF")
-		af, err = nef.nefAddAf(nefCtx, afID)
+	for {
+		af, err = nef.nefGetOrAddAf(nefCtx, afID)
 		if err != nil {
 			return loc, rsp, err
 		}
-	} else {
-		log.Infoln("AF PRESENT")
-	}
 
-	loc, rsp, err = af.afAddPFDTransaction(nefCtx, trans)
+		loc, rsp, err = af.afAddPFDTransaction(nefCtx, trans)
 
-	if err != nil {
-		return loc, rsp, err
-	}
+		// The AF got deleted after it was looked up, retry with a new entry
+		if err == errAfDeleted {
+			_ = nef.nefDeleteAf(af)
+			continue
+		}
+		if err != nil {
+			return loc, rsp, err
+		}
 
-	return loc, rsp, nil
+		return loc, rsp, nil
+	}
 }
 
 // ReadAllPFDManagementTransaction : API to read all the PFD Transactions
@@ -197,13 +197,6 @@ func CreatePFDManagementTransaction(w http.ResponseWriter,
 		log.Errf("Write Failed: %v", err)
 		return
 	}
-	// Deleting the PFD reports from the stored transactions once sent
-	afID := vars["scsAsId"]
-	transID := strconv.Itoa((nef.afs[afID].transIDnum) - 1)
-
-	for k := range nef.afs[afID].pfdtrans[transID].pfdManagement.PfdReports {
-		delete(nef.afs[afID].pfdtrans[transID].pfdManagement.PfdReports, k)
-	}
 	logNef(nef)
 }
 
@@ -375,9 +368,21 @@ func DeletePFDManagementTransaction(w http.ResponseWriter,
 
 func (af *afData) afGetPfdTransCount() (afPfdCount int) {
 
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
 	return len(af.pfdtrans)
 }
 
+//Links the UDR SB APIs with the PFD transaction
+func (trans *afPfdTransaction) setUDRCallbacks() {
+
+	trans.NEFSBPfdGet = nefSBUDRPFDGet
+	trans.NEFSBAppPfdPut = nefSBUDRAPPPFDPut
+	trans.NEFSBPfdPut = nefSBUDRPFDPut
+	trans.NEFSBPfdDelete = nefSBUDRPFDDelete
+}
+
 // UpdatePutPFDManagementTransaction updates an existing PFD transaction
 func UpdatePutPFDManagementTransaction(w http.ResponseWriter,
 	r *http.Request) {
@@ -419,7 +424,8 @@ func UpdatePutPFDManagementTransaction(w http.ResponseWriter,
 		resRsp, status := validateAFPfdManagementData(nefCtx, pfdTrans, "PUT")
 		if !status {
 			log.Err(resRsp.result.pd.Title)
-			rsp1 := nefSBRspData{errorCode: resRsp.result.errorCode}
+			rsp1 := nefSBRspData{errorCode: resRsp.result.errorCode,
+				pd: resRsp.result.pd}
 			sendErrorResponseToAF(w, rsp1)
 			return
 		}
@@ -509,17 +515,26 @@ func UpdatePutPFDManagementApplication(w http.ResponseWriter,
 			return
 		}
 
-		for _, pfd := range pfdData.Pfds {
-			rsp, status := validateAFPfdData(pfd)
+		for pfdKey, pfd := range pfdData.Pfds {
+			rsp, status := validateAFPfdData("/pfds/"+pfdKey, pfd)
 			if !status {
 				log.Err(rsp.result.pd.Title)
-				rsp1 := nefSBRspData{errorCode: rsp.result.errorCode}
+				rsp1 := nefSBRspData{errorCode: rsp.result.errorCode,
+					pd: rsp.result.pd}
 				sendErrorResponseToAF(w, rsp1)
 				return
 
 			}
 		}
 
+		if pfdShortDelay(nefCtx, vars["appId"], pfdData, pfdReportList) {
+			rsp1 := nefSBRspData{errorCode: 500}
+			rsp1.pd.Title = pfdAppsFailed
+
+			sendPFDErrorResponseToAF(w, rsp1, "SINGLE_APP", pfdReportList)
+			return
+		}
+
 		rsp, newPfdData, err := af.afUpdatePutPfdApplication(nefCtx,
 			vars["transactionId"], vars["appId"], pfdData, pfdReportList)
 
@@ -604,17 +619,26 @@ func PatchPFDManagementApplication(w http.ResponseWriter,
 			return
 		}
 
-		for _, pfd := range pfdData.Pfds {
-			rsp, status := validateAFPfdData(pfd)
+		for pfdKey, pfd := range pfdData.Pfds {
+			rsp, status := validateAFPfdData("/pfds/"+pfdKey, pfd)
 			if !status {
 				log.Infof("PFD %s is invalid in Application %s", pfd.PfdID,
 					pfdData.ExternalAppID)
-				rsp1 := nefSBRspData{errorCode: rsp.result.errorCode}
+				rsp1 := nefSBRspData{errorCode: rsp.result.errorCode,
+					pd: rsp.result.pd}
 				sendErrorResponseToAF(w, rsp1)
 				return
 			}
 		}
 
+		if pfdShortDelay(nefCtx, vars["appId"], pfdData, pfdReportList) {
+			rsp1 := nefSBRspData{errorCode: 500}
+			rsp1.pd.Title = pfdAppsFailed
+
+			sendPFDErrorResponseToAF(w, rsp1, "SINGLE_APP", pfdReportList)
+			return
+		}
+
 		rsp, newPfdData, err := af.afUpdatePatchPfdApplication(nefCtx,
 			vars["transactionId"], vars["appId"], pfdData, pfdReportList)
 
@@ -668,6 +692,9 @@ func (af *afData) afUpdatePutPfdApplication(nefCtx *nefContext, transID string,
 	pfdReportList map[string]PfdReport) (rsp nefPFDSBRspData, updPfd PfdData,
 	err error) {
 
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
 	pfdTrans, ok := af.pfdtrans[transID]
 
 	if !ok {
@@ -700,7 +727,11 @@ func (af *afData) afUpdatePutPfdApplication(nefCtx *nefContext, transID string,
 	}
 
 	pfdData.Self = trans.Self
-	pfdTrans.pfdManagement.PfdDatas[appID] = pfdData
+	pfdTrans.pfdManagement.PfdDatas[appID] = clonePfdData(pfdData)
+	af.afSchedulePfdExpiry(nefCtx, pfdTrans, appID)
+	nefCtx.nef.nefSavePfdTrans(af, pfdTrans)
+	nefCtx.nef.nefNotifyPfdChange(map[string]PfdData{appID: trans},
+		map[string]PfdData{appID: pfdData})
 
 	updPfd = pfdData
 
@@ -713,6 +744,9 @@ func (af *afData) afUpdatePatchPfdApplication(nefCtx *nefContext,
 	pfdReportList map[string]PfdReport) (rsp nefPFDSBRspData,
 	updPfd PfdData, err error) {
 
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
 	pfdTrans, ok := af.pfdtrans[transID]
 
 	if !ok {
@@ -746,6 +780,8 @@ func (af *afData) afUpdatePatchPfdApplication(nefCtx *nefContext,
 
 	}
 
+	old := clonePfdData(trans)
+
 	// Updating the PFDs present in the
 	for key := range pfdData.Pfds {
 
@@ -758,7 +794,11 @@ func (af *afData) afUpdatePatchPfdApplication(nefCtx *nefContext,
 
 	}
 	pfdData.Self = trans.Self
-	pfdTrans.pfdManagement.PfdDatas[appID] = pfdData
+	pfdTrans.pfdManagement.PfdDatas[appID] = clonePfdData(pfdData)
+	af.afSchedulePfdExpiry(nefCtx, pfdTrans, appID)
+	nefCtx.nef.nefSavePfdTrans(af, pfdTrans)
+	nefCtx.nef.nefNotifyPfdChange(map[string]PfdData{appID: old},
+		map[string]PfdData{appID: pfdData})
 
 	updPfd = pfdData
 
@@ -770,6 +810,9 @@ func (af *afData) afUpdatePutPfdTransaction(nefCtx *nefContext, transID string,
 	trans PfdManagement) (rsp map[string]nefPFDSBRspData, updPfd PfdManagement,
 	err error) {
 
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
 	pfdTrans, ok := af.pfdtrans[transID]
 
 	if !ok {
@@ -806,7 +849,27 @@ func (af *afData) afUpdatePutPfdTransaction(nefCtx *nefContext, transID string,
 		v.Self = pfdTrans.pfdManagement.PfdDatas[key].Self
 		updPfd.PfdDatas[key] = v
 	}
-	pfdTrans.pfdManagement = updPfd
+
+	// Applications which failed are no longer part of the transaction
+	var released []string
+	for key := range pfdTrans.pfdManagement.PfdDatas {
+		if _, ok = updPfd.PfdDatas[key]; !ok {
+			released = append(released, key)
+		}
+	}
+	nefCtx.nef.nefReleasePfdApps(af, transID, released)
+
+	old := pfdTrans.pfdManagement.PfdDatas
+	pfdTrans.pfdManagement = clonePfdManagement(updPfd)
+	pfdTrans.pfdManagement.PfdReports = make(map[string]PfdReport)
+	for appID := range old {
+		af.afStopPfdExpiry(pfdTrans, appID)
+	}
+	for appID := range pfdTrans.pfdManagement.PfdDatas {
+		af.afSchedulePfdExpiry(nefCtx, pfdTrans, appID)
+	}
+	nefCtx.nef.nefSavePfdTrans(af, pfdTrans)
+	nefCtx.nef.nefNotifyPfdChange(old, pfdTrans.pfdManagement.PfdDatas)
 
 	log.Infoln("Update PFD transaction Successful")
 	return rsp, updPfd, err
@@ -815,6 +878,9 @@ func (af *afData) afUpdatePutPfdTransaction(nefCtx *nefContext, transID string,
 func (af *afData) afDeletePfdTransaction(nefCtx *nefContext,
 	pfdTrans string) (rsp nefPFDSBRspData, err error) {
 
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
 	//Check if PFD transaction is already present
 	trans, ok := af.pfdtrans[pfdTrans]
 
@@ -832,7 +898,12 @@ func (af *afData) afDeletePfdTransaction(nefCtx *nefContext,
 	}
 
 	//Delete local entry in map of pfd transactions
+	af.afStopPfdExpiries(trans)
 	delete(af.pfdtrans, pfdTrans)
+	nefCtx.nef.nefReleasePfdApps(af, pfdTrans,
+		pfdAppIDs(trans.pfdManagement))
+	nefCtx.nef.nefRemovePfdTrans(af, pfdTrans)
+	nefCtx.nef.nefNotifyPfdChange(trans.pfdManagement.PfdDatas, nil)
 
 	// TBD check if all trans and sub deleted for AF then delete AF
 
@@ -842,6 +913,9 @@ func (af *afData) afDeletePfdTransaction(nefCtx *nefContext,
 func (af *afData) afGetPfdApplication(nefCtx *nefContext,
 	transID string, appID string) (rsp nefSBRspData, trans PfdData, err error) {
 
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
 	transPfd, ok := af.pfdtrans[transID]
 
 	if !ok {
@@ -859,12 +933,15 @@ func (af *afData) afGetPfdApplication(nefCtx *nefContext,
 	}
 
 	//Return locally
-	return rsp, trans, err
+	return rsp, clonePfdData(trans), err
 }
 
 func (af *afData) afDeletePfdApplication(nefCtx *nefContext,
 	transID string, appID string) (rsp nefSBRspData, err error) {
 
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
 	transPfd, ok := af.pfdtrans[transID]
 
 	if !ok {
@@ -881,19 +958,47 @@ func (af *afData) afDeletePfdApplication(nefCtx *nefContext,
 		return rsp, errors.New(appNotFound)
 	}
 
+	af.afRemovePfdApp(nefCtx, transPfd, appID)
+
+	return rsp, err
+}
+
+//afRemovePfdApp removes the application from the PFD transaction, the
+//transaction is deleted with its last application. The caller must hold
+//af.mu.
+func (af *afData) afRemovePfdApp(nefCtx *nefContext,
+	transPfd *afPfdTransaction, appID string) {
+
+	transID := transPfd.transID
+	app := transPfd.pfdManagement.PfdDatas[appID]
+
+	af.afStopPfdExpiry(transPfd, appID)
 	delete(transPfd.pfdManagement.PfdDatas, appID)
+	nefCtx.nef.nefReleasePfdApps(af, transID, []string{appID})
 
 	// If all apps in trans are deleted, delete the trans
 	if len(transPfd.pfdManagement.PfdDatas) == 0 {
 		delete(af.pfdtrans, transID)
+		nefCtx.nef.nefRemovePfdTrans(af, transID)
+	} else {
+		nefCtx.nef.nefSavePfdTrans(af, transPfd)
 	}
-
-	return rsp, err
+	nefCtx.nef.nefNotifyPfdChange(map[string]PfdData{appID: app}, nil)
 }
 
 func (af *afData) afGetPfdTransaction(nefCtx *nefContext,
 	transID string) (rsp nefPFDSBRspData, trans PfdManagement, err error) {
 
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
+	return af.afGetPfdTransactionLocked(nefCtx, transID)
+}
+
+//afGetPfdTransactionLocked must be called with the AF lock held
+func (af *afData) afGetPfdTransactionLocked(nefCtx *nefContext,
+	transID string) (rsp nefPFDSBRspData, trans PfdManagement, err error) {
+
 	transPfd, ok := af.pfdtrans[transID]
 
 	if !ok {
@@ -905,11 +1010,11 @@ func (af *afData) afGetPfdTransaction(nefCtx *nefContext,
 	_, rsp, err = transPfd.NEFSBPfdGet(transPfd, nefCtx)
 	if err != nil {
 		log.Infoln("Failed to Get PFD transaction")
-		return rsp, transPfd.pfdManagement, err
+		return rsp, clonePfdManagement(transPfd.pfdManagement), err
 	}
 
 	//Return locally
-	return rsp, transPfd.pfdManagement, err
+	return rsp, clonePfdManagement(transPfd.pfdManagement), err
 }
 
 func (af *afData) afGetPfdTransactionList(nefCtx *nefContext) (
@@ -917,11 +1022,14 @@ func (af *afData) afGetPfdTransactionList(nefCtx *nefContext) (
 
 	var transPfd PfdManagement
 
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
 	if len(af.pfdtrans) > 0 {
 
 		for key := range af.pfdtrans {
 
-			rsp, transPfd, err = af.afGetPfdTransaction(nefCtx, key)
+			rsp, transPfd, err = af.afGetPfdTransactionLocked(nefCtx, key)
 
 			if err != nil {
 				return rsp, transList, err
@@ -937,9 +1045,18 @@ func (af *afData) afAddPFDTransaction(nefCtx *nefContext,
 	trans PfdManagement) (loc string, rsp map[string]nefPFDSBRspData,
 	err error) {
 
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
 	rsp = make(map[string]nefPFDSBRspData)
+
+	if af.deleted {
+		return "", rsp, errAfDeleted
+	}
+
 	/*Check if max subscription reached */
-	if len(af.pfdtrans) >= nefCtx.cfg.MaxPfdTransSupport {
+	if len(af.pfdtrans) >=
+		nefCtx.liveConfig().MaxPfdTransSupport {
 
 		return "", rsp, errors.New("MAX TRANS Created")
 	}
@@ -948,17 +1065,29 @@ func (af *afData) afAddPFDTransaction(nefCtx *nefContext,
 	transIDStr := strconv.Itoa(af.transIDnum)
 	af.transIDnum++
 
+	// Reserve the application IDs, the check done during validation can race
+	// with another transaction using the same application ID
+	reserved := pfdAppIDs(trans)
+	dup := nefCtx.nef.nefReservePfdApps(af, transIDStr, reserved)
+	for _, appID := range dup {
+		log.Infof("Application ID %s Duplicate", appID)
+		generatePfdReport(appID, "APP_ID_DUPLICATED", trans.PfdReports)
+		delete(trans.PfdDatas, appID)
+	}
+	if len(trans.PfdDatas) == 0 {
+		nefCtx.nef.nefReleasePfdApps(af, transIDStr, reserved)
+		return "", rsp, errors.New(pfdAppsFailed)
+	}
+
 	//Create PFD transaction data
 	aftrans := afPfdTransaction{transID: transIDStr, pfdManagement: trans}
 
-	aftrans.NEFSBPfdGet = nefSBUDRPFDGet
-	aftrans.NEFSBAppPfdPut = nefSBUDRAPPPFDPut
-	aftrans.NEFSBPfdPut = nefSBUDRPFDPut
-	aftrans.NEFSBPfdDelete = nefSBUDRPFDDelete
+	aftrans.setUDRCallbacks()
 
 	rsp, err = aftrans.NEFSBPfdPut(&aftrans, nefCtx, trans)
 
 	if err != nil {
+		nefCtx.nef.nefReleasePfdApps(af, transIDStr, reserved)
 		//Return fatal  error
 		return "", rsp, err
 	}
@@ -968,27 +1097,46 @@ func (af *afData) afAddPFDTransaction(nefCtx *nefContext,
 	err = updatePfdTransOnRsp(rsp, trans)
 
 	if err != nil {
+		nefCtx.nef.nefReleasePfdApps(af, transIDStr, reserved)
 		return "", rsp, errors.New(pfdAppsFailed)
 	}
 
-	//Link the PFD transaction with the AF
-	af.pfdtrans[transIDStr] = &aftrans
+	// Release the applications which failed at the UDR
+	var failed []string
+	for _, appID := range reserved {
+		if _, ok := trans.PfdDatas[appID]; !ok {
+			failed = append(failed, appID)
+		}
+	}
+	nefCtx.nef.nefReleasePfdApps(af, transIDStr, failed)
 
 	//Create Location URI
 	loc = nefCtx.nef.locationURLPrefixPfd + af.afID + "/transactions/" +
 		transIDStr
 
-	af.pfdtrans[transIDStr].pfdManagement.Self = Link(loc)
+	trans.Self = Link(loc)
 
 	//Also update the self link in each application
-	for k, v := range af.pfdtrans[transIDStr].pfdManagement.PfdDatas {
+	for k, v := range trans.PfdDatas {
 
 		/*Assign the application ID in the link */
 		v.Self = Link(loc) + "/applications/" + Link(k)
 		log.Infof("Application ID is %s", k)
-		af.pfdtrans[transIDStr].pfdManagement.PfdDatas[k] = v
+		trans.PfdDatas[k] = v
+
+	}
+
+	//Link the PFD transaction with the AF. The PFD reports are only sent in
+	//the response and not stored
+	aftrans.pfdManagement = clonePfdManagement(trans)
+	aftrans.pfdManagement.PfdReports = make(map[string]PfdReport)
+	af.pfdtrans[transIDStr] = &aftrans
 
+	for appID := range aftrans.pfdManagement.PfdDatas {
+		af.afSchedulePfdExpiry(nefCtx, &aftrans, appID)
 	}
+	nefCtx.nef.nefSavePfdTrans(af, af.pfdtrans[transIDStr])
+	nefCtx.nef.nefNotifyPfdChange(nil, aftrans.pfdManagement.PfdDatas)
 
 	log.Infoln(" NEW AF PFD transaction added " + transIDStr)
 
@@ -1021,6 +1169,9 @@ PFD Management received from AF
 	- pfdID should be present in PFD
 	- In PFD, only one of the params - FlowDescription, urls or domainnames
 	   should be present
+	- The flowDescriptions should be valid IPFilterRules
+	- The allowedDelay should not be below the configured minimum, else the
+	   application is removed and reported with SHORT_DELAY
 */
 func validateAFPfdManagementData(nefCtx *nefContext,
 	pfdTrans PfdManagement, method string) (rsp nefPFDSBRspData, status bool) {
@@ -1061,8 +1212,15 @@ func validateAFPfdManagementData(nefCtx *nefContext,
 			}
 		}
 
-		for _, pfd := range pfdData.Pfds {
-			rsp1, status := validateAFPfdData(pfd)
+		// Applications whose PFDs cannot be propagated in time are rejected
+		if _, ok := pfdTrans.PfdDatas[key]; ok &&
+			pfdShortDelay(nefCtx, key, pfdData, pfdTrans.PfdReports) {
+			delete(pfdTrans.PfdDatas, key)
+		}
+
+		for pfdKey, pfd := range pfdData.Pfds {
+			rsp1, status := validateAFPfdData("/pfdDatas/"+key+"/pfds/"+
+				pfdKey, pfd)
 			if !status {
 				log.Infof("PFD %s is invalid in Application %s", pfd.PfdID, key)
 				return rsp1, status
@@ -1076,8 +1234,10 @@ func validateAFPfdManagementData(nefCtx *nefContext,
 }
 
 // validateAFPfdData Function to validate mandatory parameters of
-// PFD  received from AF
-func validateAFPfdData(pfd Pfd) (rsp nefPFDSBRspData,
+// PFD  received from AF. The flow descriptions are normalized in place and
+// the invalid ones are reported as invalid parameters of param, the JSON
+// pointer of the PFD.
+func validateAFPfdData(param string, pfd Pfd) (rsp nefPFDSBRspData,
 	status bool) {
 
 	if len(pfd.PfdID) == 0 {
@@ -1094,6 +1254,15 @@ func validateAFPfdData(pfd Pfd) (rsp nefPFDSBRspData,
 		log.Info(rsp.result.pd.Title)
 		return rsp, false
 	}
+	invalid := normalizeFlowDescs(param+"/flowDescriptions",
+		pfd.FlowDescriptions)
+	if len(invalid) != 0 {
+		rsp.result.errorCode = 400
+		rsp.result.pd.Title = "Invalid flowDescriptions attribute"
+		rsp.result.pd.InvalidParams = invalid
+		log.Info(rsp.result.pd.Title)
+		return rsp, false
+	}
 	return rsp, true
 }
 
@@ -1111,6 +1280,11 @@ func nefSBUDRAPPPFDGet(transData *afPfdTransaction, nefCtx *nefContext,
 
 		return appPfd, rsp, e
 	}
+	if r.AppPfd == nil {
+		rsp.result.errorCode = 404
+		rsp.result.pd.Title = appNotFound
+		return appPfd, rsp, errors.New(appNotFound)
+	}
 	appPfd.ExternalAppID = string(r.AppPfd.AppID)
 
 	appPfd.Pfds = make(map[string]Pfd)
@@ -1166,19 +1340,12 @@ func nefSBUDRAPPPFDPut(transData *afPfdTransaction, nefCtx *nefContext,
 	if app.CachingTime != nil {
 
 		i := time.Duration(*app.CachingTime)
-		timeLater := DateTime(time.Now().Add(time.Second * i).String())
+		timeLater := DateTime(time.Now().Add(time.Second * i).UTC().Format(
+			time.RFC3339))
 		pfdApp.CachingTime = &timeLater
 	}
 
-	for _, pfdv := range app.Pfds {
-
-		var c PfdContent
-		c.PfdID = pfdv.PfdID
-		c.DomainNames = pfdv.DomainNames
-		c.FlowDescriptions = pfdv.FlowDescriptions
-		c.Urls = pfdv.Urls
-		pfdApp.Pfds = append(pfdApp.Pfds, c)
-	}
+	pfdApp.Pfds = pfdContents(app)
 
 	_, e := nef.udrPfdClient.UdrPfdDataCreate(cliCtx, pfdApp)
 
@@ -1323,7 +1490,20 @@ func generatePfdReport(appID string,
 		log.Infoln("RESOURCE_LIMITATION failure code not handled")
 
 	case "SHORT_DELAY":
-		log.Infoln("SHORT_DELAY failure code is not handled")
+		if _, ok := pfdReportList[failureReason]; !ok {
+			// Create the first PFD report
+			var appIds []string
+			appIds = append(appIds, appID)
+			pfdReport := PfdReport{ExternalAppIds: appIds,
+				FailureCode: ShortDelay}
+			pfdReportList[failureReason] = pfdReport
+
+		} else {
+			pfdReport := pfdReportList[failureReason]
+			pfdReport.ExternalAppIds = append(pfdReport.ExternalAppIds,
+				appID)
+			pfdReportList[failureReason] = pfdReport
+		}
 	}
 
 }
@@ -1376,17 +1556,51 @@ func sendPFDErrorResponseToAF(w http.ResponseWriter,
 
 func nefCheckPfdAppIDExists(appID string, nefCtx *nefContext) bool {
 
-	nef := &nefCtx.nef
-	for _, v := range nef.afs {
-		for _, trans := range v.pfdtrans {
-			for key := range trans.pfdManagement.PfdDatas {
-				if key == appID {
-					return true
-				}
+	return nefCtx.nef.nefPfdAppExists(appID)
+}
 
-			}
-		}
+// pfdAppIDs returns the application IDs of the PFD transaction
+func pfdAppIDs(trans PfdManagement) []string {
+
+	appIDs := make([]string, 0, len(trans.PfdDatas))
+	for key := range trans.PfdDatas {
+		appIDs = append(appIDs, key)
+	}
+	return appIDs
+}
+
+// clonePfdData returns a copy of the PFD data which does not share the PFD
+// map with the original
+func clonePfdData(pfdData PfdData) PfdData {
+
+	if pfdData.Pfds == nil {
+		return pfdData
 	}
-	return false
+	pfds := make(map[string]Pfd, len(pfdData.Pfds))
+	for k, v := range pfdData.Pfds {
+		pfds[k] = v
+	}
+	pfdData.Pfds = pfds
+	return pfdData
+}
 
+// clonePfdManagement returns a copy of the PFD transaction which does not
+// share any map with the original
+func clonePfdManagement(trans PfdManagement) PfdManagement {
+
+	if trans.PfdDatas != nil {
+		pfdDatas := make(map[string]PfdData, len(trans.PfdDatas))
+		for k, v := range trans.PfdDatas {
+			pfdDatas[k] = clonePfdData(v)
+		}
+		trans.PfdDatas = pfdDatas
+	}
+	if trans.PfdReports != nil {
+		pfdReports := make(map[string]PfdReport, len(trans.PfdReports))
+		for k, v := range trans.PfdReports {
+			pfdReports[k] = v
+		}
+		trans.PfdReports = pfdReports
+	}
+	return trans
 }
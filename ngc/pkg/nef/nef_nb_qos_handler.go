@@ -0,0 +1,752 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+/* Handlers of the AS session with QoS API (3GPP TS 29.122). The QoS of the
+AS session is requested from the PCF with a Policy Authorization carrying the
+flows of the application as media subcomponents and the QoS reference. */
+
+package ngcnef
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// Media component number used for the flows of an AS session with QoS
+const qosMedCompN = 1
+
+func createNewQosSub(nefCtx *nefContext, afID string,
+	qos AsSessionWithQoSSubscription) (loc string, rsp nefSBRspData,
+	err error) {
+
+	var af *afData
+	nef := &nefCtx.nef
+
+	for {
+		af, err = nef.nefGetOrAddAf(nefCtx, afID)
+		if err != nil {
+			return loc, rsp, err
+		}
+
+		loc, rsp, err = af.afAddQosSubscription(nefCtx, qos)
+
+		// The AF got deleted after it was looked up, retry with a new entry
+		if err == errAfDeleted {
+			_ = nef.nefDeleteAf(af)
+			continue
+		}
+		return loc, rsp, err
+	}
+}
+
+// ReadAllAsSessionWithQoSSubscription : API to read all the AS session with
+// QoS subscriptions of the AF
+func ReadAllAsSessionWithQoSSubscription(w http.ResponseWriter,
+	r *http.Request) {
+
+	var subslist []AsSessionWithQoSSubscription
+
+	nefCtx := r.Context().Value(nefCtxKey("nefCtx")).(*nefContext)
+	nef := &nefCtx.nef
+
+	vars := mux.Vars(r)
+	log.Infof(" SCSASID : %s", vars["scsAsId"])
+
+	af, err := nef.nefGetAf(vars["scsAsId"])
+	if err != nil {
+		/* No subscription data will be returned to AF */
+		log.Infoln(err)
+	} else {
+		subslist = af.afGetQosSubscriptionList()
+	}
+
+	mdata, err := json.Marshal(subslist)
+	if err != nil {
+		sendCustomeErrorRspToAF(w, 400, "Failed to MARSHAL Subscription data ")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(mdata)
+	if err != nil {
+		log.Errf("Write Failed: %v", err)
+		return
+	}
+
+	log.Infof("HTTP Response sent: %d", http.StatusOK)
+}
+
+// CreateAsSessionWithQoSSubscription : Handles the AS session with QoS
+// requested by AF
+func CreateAsSessionWithQoSSubscription(w http.ResponseWriter,
+	r *http.Request) {
+
+	nefCtx := r.Context().Value(nefCtxKey("nefCtx")).(*nefContext)
+
+	vars := mux.Vars(r)
+	log.Infof(" SCSASID : %s", vars["scsAsId"])
+
+	b, err := ioutil.ReadAll(r.Body)
+	defer closeReqBody(r)
+
+	if err != nil {
+		sendCustomeErrorRspToAF(w, 400, "Failed to read HTTP POST Body")
+		return
+	}
+
+	qosBody := AsSessionWithQoSSubscription{}
+	if err = json.Unmarshal(b, &qosBody); err != nil {
+		log.Err(err)
+		sendCustomeErrorRspToAF(w, 400, "Failed UnMarshal POST data")
+		return
+	}
+
+	//validate the mandatory parameters
+	resRsp, status := validateAFQosData(qosBody)
+	if !status {
+		log.Err(resRsp.pd.Title)
+		sendErrorResponseToAF(w, resRsp)
+		return
+	}
+
+	loc, rsp, err := createNewQosSub(nefCtx, vars["scsAsId"], qosBody)
+	if err != nil {
+		log.Err(err)
+		// we return bad request here since the PCF rejected the session
+		// or we have reached the max
+		rsp.errorCode = 400
+		sendErrorResponseToAF(w, rsp)
+		return
+	}
+	log.Infoln(loc)
+
+	qosBody.Self = Link(loc)
+	mdata, err := json.Marshal(qosBody)
+	if err != nil {
+		log.Err(err)
+		sendCustomeErrorRspToAF(w, 400, "Failed to Marshal POST response data")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set("Location", loc)
+	w.WriteHeader(http.StatusCreated)
+	log.Infof("CreateAsSessionWithQoSSubscription responses => %d",
+		http.StatusCreated)
+	_, err = w.Write(mdata)
+	if err != nil {
+		log.Errf("Write Failed: %v", err)
+		return
+	}
+	logNef(&nefCtx.nef)
+}
+
+// ReadAsSessionWithQoSSubscription : Read a particular AS session with QoS
+// subscription
+func ReadAsSessionWithQoSSubscription(w http.ResponseWriter,
+	r *http.Request) {
+
+	nefCtx := r.Context().Value(nefCtxKey("nefCtx")).(*nefContext)
+	nef := &nefCtx.nef
+
+	vars := mux.Vars(r)
+	log.Infof(" SCSASID : %s", vars["scsAsId"])
+	log.Infof(" SUBSCRIPTION ID  : %s", vars["subscriptionId"])
+
+	af, err := nef.nefGetAf(vars["scsAsId"])
+	if err != nil {
+		sendCustomeErrorRspToAF(w, 404, "Failed to find AF records")
+		return
+	}
+
+	rsp, qos, err := af.afGetQosSubscription(vars["subscriptionId"])
+	if err != nil {
+		log.Err(err)
+		sendErrorResponseToAF(w, rsp)
+		return
+	}
+
+	sendQosSubToAF(w, qos)
+}
+
+// UpdatePutAsSessionWithQoSSubscription : Replaces an AS session with QoS
+// subscription created earlier (PUT Req)
+func UpdatePutAsSessionWithQoSSubscription(w http.ResponseWriter,
+	r *http.Request) {
+
+	nefCtx := r.Context().Value(nefCtxKey("nefCtx")).(*nefContext)
+	nef := &nefCtx.nef
+
+	vars := mux.Vars(r)
+	log.Infof(" SCSASID : %s", vars["scsAsId"])
+	log.Infof(" SUBSCRIPTION ID  : %s", vars["subscriptionId"])
+
+	af, err := nef.nefGetAf(vars["scsAsId"])
+	if err != nil {
+		log.Infoln(err)
+		sendCustomeErrorRspToAF(w, 404, "Failed to find AF records")
+		return
+	}
+
+	b, err := ioutil.ReadAll(r.Body)
+	defer closeReqBody(r)
+
+	if err != nil {
+		log.Err(err)
+		sendCustomeErrorRspToAF(w, 400, "Failed to read HTTP PUT Body")
+		return
+	}
+
+	qosBody := AsSessionWithQoSSubscription{}
+	if err = json.Unmarshal(b, &qosBody); err != nil {
+		log.Err(err)
+		sendCustomeErrorRspToAF(w, 400, "Failed UnMarshal PUT data")
+		return
+	}
+
+	resRsp, status := validateAFQosData(qosBody)
+	if !status {
+		log.Err(resRsp.pd.Title)
+		sendErrorResponseToAF(w, resRsp)
+		return
+	}
+
+	rsp, qos, err := af.afUpdateQosSubscription(nefCtx,
+		vars["subscriptionId"], qosBody)
+	if err != nil {
+		log.Err(err)
+		sendErrorResponseToAF(w, rsp)
+		return
+	}
+
+	sendQosSubToAF(w, qos)
+}
+
+// UpdatePatchAsSessionWithQoSSubscription : Updates an AS session with QoS
+// subscription created earlier (PATCH Req)
+func UpdatePatchAsSessionWithQoSSubscription(w http.ResponseWriter,
+	r *http.Request) {
+
+	nefCtx := r.Context().Value(nefCtxKey("nefCtx")).(*nefContext)
+	nef := &nefCtx.nef
+
+	vars := mux.Vars(r)
+	log.Infof(" SCSASID : %s", vars["scsAsId"])
+	log.Infof(" SUBSCRIPTION ID  : %s", vars["subscriptionId"])
+
+	af, err := nef.nefGetAf(vars["scsAsId"])
+	if err != nil {
+		log.Infoln(err)
+		sendCustomeErrorRspToAF(w, 404, "Failed to find AF records")
+		return
+	}
+
+	b, err := ioutil.ReadAll(r.Body)
+	defer closeReqBody(r)
+
+	if err != nil {
+		log.Err(err)
+		sendCustomeErrorRspToAF(w, 400, "Failed to read HTTP PATCH Body")
+		return
+	}
+
+	qosPatch := AsSessionWithQoSSubscriptionPatch{}
+	if err = json.Unmarshal(b, &qosPatch); err != nil {
+		log.Err(err)
+		sendCustomeErrorRspToAF(w, 400, "Failed UnMarshal PATCH data")
+		return
+	}
+
+	rsp, qos, err := af.afPartialUpdateQosSubscription(nefCtx,
+		vars["subscriptionId"], qosPatch)
+	if err != nil {
+		log.Err(err)
+		sendErrorResponseToAF(w, rsp)
+		return
+	}
+
+	sendQosSubToAF(w, qos)
+}
+
+// DeleteAsSessionWithQoSSubscription : Deletes an AS session with QoS
+// subscription created by AF
+func DeleteAsSessionWithQoSSubscription(w http.ResponseWriter,
+	r *http.Request) {
+
+	nefCtx := r.Context().Value(nefCtxKey("nefCtx")).(*nefContext)
+	nef := &nefCtx.nef
+
+	vars := mux.Vars(r)
+	log.Infof(" SCSASID : %s", vars["scsAsId"])
+	log.Infof(" SUBSCRIPTION ID  : %s", vars["subscriptionId"])
+
+	af, err := nef.nefGetAf(vars["scsAsId"])
+	if err != nil {
+		log.Err(err)
+		sendCustomeErrorRspToAF(w, 404, "Failed to find AF entry")
+		return
+	}
+
+	rsp, err := af.afDeleteQosSubscription(nefCtx, vars["subscriptionId"])
+	if err != nil {
+		log.Err(err)
+		sendErrorResponseToAF(w, rsp)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	log.Infof("HTTP Response sent: %d", http.StatusNoContent)
+
+	nef.nefCheckDeleteAf(vars["scsAsId"])
+	logNef(nef)
+}
+
+// sendQosSubToAF sends the AS session with QoS subscription in a 200 response
+func sendQosSubToAF(w http.ResponseWriter, qos AsSessionWithQoSSubscription) {
+
+	mdata, err := json.Marshal(qos)
+	if err != nil {
+		log.Err(err)
+		sendCustomeErrorRspToAF(w, 400, "Failed to Marshal response data")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(mdata)
+	if err != nil {
+		log.Errf("Write Failed: %v", err)
+		return
+	}
+	log.Infof("HTTP Response sent: %d", http.StatusOK)
+}
+
+//validateAFQosData: Function to validate mandatory parameters of the AS
+//session with QoS received from AF
+func validateAFQosData(qos AsSessionWithQoSSubscription) (rsp nefSBRspData,
+	status bool) {
+
+	rsp.errorCode = 400
+
+	if len(qos.NotificationDestination) == 0 {
+		rsp.pd.Title = "Missing notificationDestination attribute"
+		return rsp, false
+	}
+
+	ueAddrs := 0
+	for _, addr := range []string{string(qos.UeIpv4Addr),
+		string(qos.UeIpv6Addr), string(qos.MacAddr)} {
+		if len(addr) > 0 {
+			ueAddrs++
+		}
+	}
+	if ueAddrs != 1 {
+		rsp.pd.Title = "One of ueIpv4Addr, ueIpv6Addr, macAddr is required"
+		return rsp, false
+	}
+
+	if len(qos.MacAddr) > 0 {
+		if len(qos.EthFlowInfo) == 0 || len(qos.FlowInfo) > 0 {
+			rsp.pd.Title = "ethFlowInfo is required with macAddr"
+			return rsp, false
+		}
+	} else if len(qos.FlowInfo) == 0 || len(qos.EthFlowInfo) > 0 {
+		rsp.pd.Title = "flowInfo is required with ueIpv4Addr or ueIpv6Addr"
+		return rsp, false
+	}
+
+	// Only pre-defined QoS information is supported
+	if len(qos.QosReference) == 0 {
+		rsp.pd.Title = "Missing qosReference attribute"
+		return rsp, false
+	}
+
+	rsp.errorCode = 0
+	return rsp, true
+}
+
+//Creates a new AS session with QoS subscription
+func (af *afData) afAddQosSubscription(nefCtx *nefContext,
+	qos AsSessionWithQoSSubscription) (loc string, rsp nefSBRspData,
+	err error) {
+
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
+	if af.deleted {
+		return "", rsp, errAfDeleted
+	}
+
+	/*Check if max subscription reached */
+	if af.afSubTotal() >= nefCtx.liveConfig().MaxSubSupport {
+		rsp.errorCode = 400
+		rsp.pd.Title = "MAX Subscription Reached"
+		return "", rsp, errors.New("MAX SUBS Created")
+	}
+
+	//Generate a unique subscription ID string
+	subIDStr := strconv.Itoa(af.subIDnum)
+	af.subIDnum++
+
+	afsub := afQosSubscription{subid: subIDStr, qos: qos}
+
+	rsp, err = nefSBPCFQosPost(&afsub, nefCtx, qos)
+	if err != nil {
+		return "", rsp, err
+	}
+
+	//Link the subscription with the AF
+	af.qosSubs[subIDStr] = &afsub
+
+	//Create Location URI
+	loc = nefCtx.nef.locationURLPrefixQos + af.afID + "/subscriptions/" +
+		subIDStr
+
+	afsub.qos.Self = Link(loc)
+	nefCtx.nef.nefSaveQosSub(af, &afsub)
+
+	log.Infoln(" NEW AF QoS Subscription added " + subIDStr)
+
+	return loc, rsp, nil
+}
+
+func (af *afData) afUpdateQosSubscription(nefCtx *nefContext, subID string,
+	qos AsSessionWithQoSSubscription) (rsp nefSBRspData,
+	updtQos AsSessionWithQoSSubscription, err error) {
+
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
+	sub, ok := af.qosSubs[subID]
+	if !ok {
+		rsp.errorCode = 404
+		rsp.pd.Title = subNotFound
+		return rsp, updtQos, errors.New(subNotFound)
+	}
+
+	// The app session context of the PCF is bound to the UE
+	if qos.UeIpv4Addr != sub.qos.UeIpv4Addr ||
+		qos.UeIpv6Addr != sub.qos.UeIpv6Addr ||
+		qos.MacAddr != sub.qos.MacAddr {
+		rsp.errorCode = 400
+		rsp.pd.Title = "UE address can not be changed"
+		return rsp, updtQos, errors.New(rsp.pd.Title)
+	}
+
+	rsp, err = nefSBPCFQosPatch(sub, nefCtx, qos)
+	if err != nil {
+		log.Err("Failed to Update QoS Subscription")
+		return rsp, updtQos, err
+	}
+
+	updtQos = qos
+	updtQos.Self = sub.qos.Self
+	sub.qos = updtQos
+	nefCtx.nef.nefSaveQosSub(af, sub)
+
+	log.Infoln("Update QoS Subscription Successful")
+	return rsp, updtQos, nil
+}
+
+//Applies the patch to the AS session with QoS subscription
+func updateQosFromPatch(qos *AsSessionWithQoSSubscription,
+	qosp AsSessionWithQoSSubscriptionPatch) {
+
+	if qosp.FlowInfo != nil {
+		log.Infoln("Updating FlowInfo")
+		qos.FlowInfo = qosp.FlowInfo
+	}
+	if qosp.EthFlowInfo != nil {
+		log.Infoln("Updating EthFlowInfo")
+		qos.EthFlowInfo = qosp.EthFlowInfo
+	}
+	if qosp.QosReference != "" {
+		log.Infoln("Updating QosReference")
+		qos.QosReference = qosp.QosReference
+	}
+	if qosp.AltQoSReferences != nil {
+		log.Infoln("Updating AltQoSReferences")
+		qos.AltQoSReferences = qosp.AltQoSReferences
+	}
+	if qosp.NotificationDestination != "" {
+		log.Infoln("Updating NotificationDestination")
+		qos.NotificationDestination = qosp.NotificationDestination
+	}
+}
+
+func (af *afData) afPartialUpdateQosSubscription(nefCtx *nefContext,
+	subID string, qosp AsSessionWithQoSSubscriptionPatch) (
+	rsp nefSBRspData, qos AsSessionWithQoSSubscription, err error) {
+
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
+	sub, ok := af.qosSubs[subID]
+	if !ok {
+		rsp.errorCode = 404
+		rsp.pd.Title = subNotFound
+		return rsp, qos, errors.New(subNotFound)
+	}
+
+	qos = sub.qos
+	updateQosFromPatch(&qos, qosp)
+
+	rsp, status := validateAFQosData(qos)
+	if !status {
+		return rsp, AsSessionWithQoSSubscription{}, errors.New(rsp.pd.Title)
+	}
+
+	rsp, err = nefSBPCFQosPatch(sub, nefCtx, qos)
+	if err != nil {
+		log.Err("Failed to Patch QoS Subscription")
+		return rsp, AsSessionWithQoSSubscription{}, err
+	}
+
+	sub.qos = qos
+	nefCtx.nef.nefSaveQosSub(af, sub)
+
+	return rsp, sub.qos, nil
+}
+
+func (af *afData) afGetQosSubscription(subID string) (rsp nefSBRspData,
+	qos AsSessionWithQoSSubscription, err error) {
+
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
+	sub, ok := af.qosSubs[subID]
+	if !ok {
+		rsp.errorCode = 404
+		rsp.pd.Title = subNotFound
+		return rsp, qos, errors.New(subNotFound)
+	}
+
+	//Return locally
+	return rsp, sub.qos, nil
+}
+
+func (af *afData) afGetQosSubscriptionList() (
+	subsList []AsSessionWithQoSSubscription) {
+
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
+	//Return locally
+	for _, sub := range af.qosSubs {
+		subsList = append(subsList, sub.qos)
+	}
+	return subsList
+}
+
+func (af *afData) afDeleteQosSubscription(nefCtx *nefContext,
+	subID string) (rsp nefSBRspData, err error) {
+
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
+	sub, ok := af.qosSubs[subID]
+	if !ok {
+		rsp.errorCode = 404
+		rsp.pd.Title = subNotFound
+		return rsp, errors.New(subNotFound)
+	}
+
+	rsp, err = nefSBPCFQosDelete(sub, nefCtx)
+	if err != nil {
+		log.Err("Failed to Delete QoS Subscription")
+		return rsp, err
+	}
+
+	//Delete local entry in map
+	delete(af.qosSubs, subID)
+	nefCtx.nef.nefRemoveQosSub(af, subID)
+
+	return rsp, nil
+}
+
+// Generate the location url prefix for AS session with QoS
+func getNefLocationURLPrefixQos(cfg *Config) string {
+
+	var uri string
+	// If http2 port is configured use it else http port
+	if cfg.HTTP2Config.Endpoint != "" {
+		uri = "https://" + cfg.NefAPIRoot +
+			cfg.HTTP2Config.Endpoint
+	} else {
+		uri = "http://" + cfg.NefAPIRoot +
+			cfg.HTTPConfig.Endpoint
+	}
+	uri += cfg.LocationPrefixQos
+	return uri
+}
+
+// getQosMediaComponents : Maps the flows and the QoS reference of the AS
+// session onto a media component. The flows of the old subscription which are
+// not part of the new one are marked as removed.
+func getQosMediaComponents(qos AsSessionWithQoSSubscription,
+	old *AsSessionWithQoSSubscription) map[string]MediaComponent {
+
+	medComp := MediaComponent{MedCompN: qosMedCompN,
+		QosReference: qos.QosReference, AltSerReqs: qos.AltQoSReferences,
+		FStatus:     FlowStatusEnabled,
+		MedSubComps: qosMediaSubComponents(qos, FlowStatusEnabled)}
+
+	if old != nil {
+		for fNum, subComp := range qosMediaSubComponents(*old,
+			FlowStatusRemoved) {
+			if _, ok := medComp.MedSubComps[fNum]; !ok {
+				medComp.MedSubComps[fNum] = MediaSubComponent{
+					FNum: subComp.FNum, FStatus: FlowStatusRemoved}
+			}
+		}
+	}
+	return map[string]MediaComponent{strconv.Itoa(qosMedCompN): medComp}
+}
+
+// qosMediaSubComponents returns a media subcomponent per flow of the AS
+// session. The ethernet flows are numbered in the order they are listed.
+func qosMediaSubComponents(qos AsSessionWithQoSSubscription,
+	fStatus FlowStatus) map[string]MediaSubComponent {
+
+	subComps := make(map[string]MediaSubComponent)
+	for _, fi := range qos.FlowInfo {
+		subComp := MediaSubComponent{FNum: fi.FlowID, FStatus: fStatus}
+		for _, fd := range fi.FlowDescriptions {
+			subComp.FDescs = append(subComp.FDescs, FlowDescription(fd))
+		}
+		subComps[strconv.Itoa(int(fi.FlowID))] = subComp
+	}
+	for i, efd := range qos.EthFlowInfo {
+		fNum := int32(i + 1)
+		subComps[strconv.Itoa(int(fNum))] = MediaSubComponent{FNum: fNum,
+			EthfDescs: []EthFlowDescription{efd}, FStatus: fStatus}
+	}
+	return subComps
+}
+
+// pcfQosResponse : Converts the PCF response, any failure response is
+// returned as error
+func pcfQosResponse(op string, pcfPolicyResp PcfPolicyResponse,
+	err error) (rsp nefSBRspData, _ error) {
+
+	rsp.errorCode = int(pcfPolicyResp.ResponseCode)
+	if pcfPolicyResp.Pd != nil {
+		rsp.pd = *pcfPolicyResp.Pd
+	}
+	if err == nil && rsp.errorCode >= 300 {
+		err = errors.New("PCF Policy Authorization " + op + " rejected")
+	}
+	if err != nil {
+		if rsp.errorCode == 0 {
+			rsp.errorCode = http.StatusInternalServerError
+		}
+		log.Errf("PCF Policy Authorization %s Failure. Response Code: %d",
+			op, rsp.errorCode)
+		return rsp, err
+	}
+	log.Infof("PCF Policy Authorization %s Success. Response Code: %d", op,
+		rsp.errorCode)
+	return rsp, nil
+}
+
+// nefSBPCFQosPost : This function sends HTTP POST Request to PCF to create a
+//             Policy Authorization with the QoS of the AS session.
+// Input Args:
+//   - qosSub: This is the AS session with QoS subscription
+//   - nefCtx: This is NEF Module Context. This contains the NEF Module Data.
+//   - qos: This is AS session with QoS Subscription Data.
+// Output Args:
+//    - rsp: This is Policy Authorization Create Response Data
+//    - error: retruns error in case there is failure happened in sending the
+//             request or any failure response is received.
+func nefSBPCFQosPost(qosSub *afQosSubscription, nefCtx *nefContext,
+	qos AsSessionWithQoSSubscription) (rsp nefSBRspData, err error) {
+
+	nef := &nefCtx.nef
+
+	cliCtx, cancel := context.WithCancel(nef.ctx)
+	defer cancel()
+
+	appSessCtx := AppSessionContext{}
+	appSessCtx.AscReqData.Dnn = qos.Dnn
+	if qos.Snssai != nil {
+		appSessCtx.AscReqData.SliceInfo = *qos.Snssai
+	}
+	appSessCtx.AscReqData.SuppFeat = qos.SupportedFeatures
+	appSessCtx.AscReqData.UeIpv4 = qos.UeIpv4Addr
+	appSessCtx.AscReqData.UeIpv6 = qos.UeIpv6Addr
+	appSessCtx.AscReqData.UeMac = qos.MacAddr
+	appSessCtx.AscReqData.MedComponents = getQosMediaComponents(qos, nil)
+
+	appSessID, pcfPolicyResp, err :=
+		nef.pcfClient.PolicyAuthorizationCreate(cliCtx, appSessCtx)
+	rsp, err = pcfQosResponse("Create", pcfPolicyResp, err)
+	if err != nil {
+		return rsp, err
+	}
+	qosSub.appSessionID = appSessID
+	return rsp, nil
+}
+
+// nefSBPCFQosPatch : This function sends HTTP PATCH Request to PCF to update
+//             the QoS of the AS session using App Session Context Key.
+// Input Args:
+//   - qosSub: This is the AS session with QoS subscription
+//   - nefCtx: This is NEF Module Context. This contains the NEF Module Data.
+//   - qos: This is the updated AS session with QoS Subscription Data.
+// Output Args:
+//    - rsp: This is Policy Authorization Update Response Data
+//    - error: retruns error in case there is failure happened in sending the
+//             request or any failure response is received.
+func nefSBPCFQosPatch(qosSub *afQosSubscription, nefCtx *nefContext,
+	qos AsSessionWithQoSSubscription) (rsp nefSBRspData, err error) {
+
+	nef := &nefCtx.nef
+
+	cliCtx, cancel := context.WithCancel(nef.ctx)
+	defer cancel()
+
+	appSessCtxUpdtData := AppSessionContextUpdateData{}
+	appSessCtxUpdtData.MedComponents = getQosMediaComponents(qos,
+		&qosSub.qos)
+
+	pcfPolicyResp, err := nef.pcfClient.PolicyAuthorizationUpdate(cliCtx,
+		appSessCtxUpdtData, qosSub.appSessionID)
+	return pcfQosResponse("Update", pcfPolicyResp, err)
+}
+
+// nefSBPCFQosDelete : This function sends HTTP DELETE Request to PCF to delete
+//             the Policy Authorization of the AS session.
+// Input Args:
+//   - qosSub: This is the AS session with QoS subscription
+//   - nefCtx: This is NEF Module Context. This contains the NEF Module Data.
+// Output Args:
+//    - rsp: This is Policy Authorization Delete Response Data
+//    - error: retruns error in case there is failure happened in sending the
+//             request or any failure response is received.
+func nefSBPCFQosDelete(qosSub *afQosSubscription, nefCtx *nefContext) (
+	rsp nefSBRspData, err error) {
+
+	nef := &nefCtx.nef
+
+	cliCtx, cancel := context.WithCancel(nef.ctx)
+	defer cancel()
+
+	pcfPolicyResp, err :=
+		nef.pcfClient.PolicyAuthorizationDelete(cliCtx, qosSub.appSessionID)
+	rsp, err = pcfQosResponse("Delete", pcfPolicyResp, err)
+
+	// The app session context is already gone in the PCF
+	if err != nil && rsp.errorCode == http.StatusNotFound {
+		return nefSBRspData{errorCode: http.StatusNoContent}, nil
+	}
+	return rsp, err
+}
@@ -11,7 +11,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strconv"
-	"strings"
+	"time"
 
 	//"strconv"
 
@@ -24,25 +24,25 @@ func createNewSub(nefCtx *nefContext, afID string,
 	var af *afData
 	nef := &nefCtx.nef
 
-	af, err = nef.nefGetAf(afID)
-
-	if err != nil {
-		log.Err("NO AF PRESENT CREATE AF")
-		af, err = nef.nefAddAf(nefCtx, afID)
+	for {
+		af, err = nef.nefGetOrAddAf(nefCtx, afID)
 		if err != nil {
 			return loc, rsp, err
 		}
-	} else {
-		log.Infoln("AF PRESENT")
-	}
 
-	loc, rsp, err = af.afAddSubscription(nefCtx, ti)
+		loc, rsp, err = af.afAddSubscription(nefCtx, ti)
 
-	if err != nil {
-		return loc, rsp, err
-	}
+		// The AF got deleted after it was looked up, retry with a new entry
+		if err == errAfDeleted {
+			_ = nef.nefDeleteAf(af)
+			continue
+		}
+		if err != nil {
+			return loc, rsp, err
+		}
 
-	return loc, rsp, nil
+		return loc, rsp, nil
+	}
 }
 
 // ReadAllTrafficInfluenceSubscription : API to read all the subscritions
@@ -131,18 +131,52 @@ func CreateTrafficInfluenceSubscription(w http.ResponseWriter,
 		return
 	}
 
+	resRsp, status = nefCtx.nef.geoZones.validate("validGeoZoneIds",
+		trInBody.ValidGeoZoneIDs)
+	if !status {
+		log.Err(resRsp.pd.Title)
+		sendErrorResponseToAF(w, resRsp)
+		return
+	}
+
+	resRsp, status = validateTempValidities(trInBody.TempValidities)
+	if !status {
+		log.Err(resRsp.pd.Title)
+		sendErrorResponseToAF(w, resRsp)
+		return
+	}
+
+	resRsp, status = validateTrafficFilters(trInBody.TrafficFilters,
+		trInBody.EthTrafficFilters)
+	if !status {
+		log.Err(resRsp.pd.Title)
+		sendErrorResponseToAF(w, resRsp)
+		return
+	}
+
+	resRsp, status = nefCtx.nef.afServices.validate(trInBody)
+	if !status {
+		log.Err(resRsp.pd.Title)
+		sendErrorResponseToAF(w, resRsp)
+		return
+	}
+
 	loc, rsp, err3 := createNewSub(nefCtx, vars["afId"], trInBody)
 
 	if err3 != nil {
 		log.Err(err3)
-		// we return bad request here since we have reached the max
-		rsp.errorCode = 400
+		// we return bad request here since we have reached the max, the UE
+		// not known by the UDM and the UDM failures are reported as is
+		if err3 != errGpsiNotTranslated && err3 != errUdmFailure {
+			rsp.errorCode = 400
+		}
 		sendErrorResponseToAF(w, rsp)
 		return
 	}
 	log.Infoln(loc)
 
 	trInBody.Self = Link(loc)
+	setTiWebsocketURI(&trInBody)
 
 	//Martshal data and send into the body
 	mdata, err2 := json.Marshal(trInBody)
@@ -168,6 +202,22 @@ func CreateTrafficInfluenceSubscription(w http.ResponseWriter,
 	nef := &nefCtx.nef
 	logNef(nef)
 
+	//The test notification is sent once the websocket is connected if the
+	//websocket delivery is requested
+	if trInBody.RequestTestNotification &&
+		!trInBody.WebsockNotifConfig.RequestWebsocketURI {
+		nef.afNotifier.dispatchUpfEvent(
+			URI(trInBody.NotificationDestination),
+			tiTestNotification(trInBody))
+	}
+}
+
+//tiTestNotification returns the test notification of the subscription
+//requested by the AF with requestTestNotification
+func tiTestNotification(ti TrafficInfluSub) EventNotification {
+
+	return EventNotification{AfTransID: ti.AfTransID,
+		SubscribedEvent: TestNotification}
 }
 
 // ReadTrafficInfluenceSubscription : Read a particular subscription details
@@ -248,6 +298,24 @@ func UpdatePutTrafficInfluenceSubscription(w http.ResponseWriter,
 			return
 		}
 
+		rsp, status := nef.geoZones.validate("validGeoZoneIds",
+			trInBody.ValidGeoZoneIDs)
+		if status {
+			rsp, status = validateTempValidities(trInBody.TempValidities)
+		}
+		if status {
+			rsp, status = validateTrafficFilters(trInBody.TrafficFilters,
+				trInBody.EthTrafficFilters)
+		}
+		if status {
+			rsp, status = nef.afServices.validate(trInBody)
+		}
+		if !status {
+			log.Err(rsp.pd.Title)
+			sendErrorResponseToAF(w, rsp)
+			return
+		}
+
 		rsp, newTI, err := af.afUpdateSubscription(nefCtx,
 			vars["subscriptionId"], trInBody)
 
@@ -316,6 +384,21 @@ func UpdatePatchTrafficInfluenceSubscription(w http.ResponseWriter,
 			return
 		}
 
+		rsp, status := nef.geoZones.validate("validGeoZoneIds",
+			TrInSPBody.ValidGeoZoneIDs)
+		if status {
+			rsp, status = validateTempValidities(TrInSPBody.TempValidities)
+		}
+		if status {
+			rsp, status = validateTrafficFilters(TrInSPBody.TrafficFilters,
+				TrInSPBody.EthTrafficFilters)
+		}
+		if !status {
+			log.Err(rsp.pd.Title)
+			sendErrorResponseToAF(w, rsp)
+			return
+		}
+
 		rsp, ti, err := af.afPartialUpdateSubscription(nefCtx,
 			vars["subscriptionId"], TrInSPBody)
 
@@ -379,10 +462,7 @@ func DeleteTrafficInfluenceSubscription(w http.ResponseWriter,
 
 	log.Infof("HTTP Response sent: %d", http.StatusNoContent)
 
-	if af.afGetSubCount() == 0 {
-
-		_ = nef.nefDeleteAf(vars["afId"])
-	}
+	nef.nefCheckDeleteAf(vars["afId"])
 
 	logNef(nef)
 }
@@ -470,33 +550,36 @@ func NotifySmfUPFEvent(w http.ResponseWriter,
 
 	w.WriteHeader(http.StatusOK)
 
-	// Send the request towards AF
-	var afClient AfNotification = NewAfClient(&nefCtx.cfg)
-	err := afClient.AfNotificationUpfEvent(r.Context(), afURL, ev)
-	if err != nil {
-		log.Errf("NotifySmfUPFEvent sending to AF failed : %s",
-			err.Error())
+	// Send through the websocket of the AF if connected else POST
+	if afSubs.sendWebsocketNotif(ev) {
+		return
 	}
+
+	// Queue the request towards AF, it is retried if the AF is unreachable
+	nefCtx.nef.afNotifier.dispatchUpfEvent(afURL, ev)
 }
 
+//getSubFromCorrID returns a copy of the subscription linked with the
+//notification correlation ID
 func getSubFromCorrID(nefCtx *nefContext, corrID string) (sub *afSubscription,
 	err error) {
 
 	nef := &nefCtx.nef
 
-	/*Search across all the AF registered */
-	for _, value := range nef.afs {
+	ref, ok := nef.nefLookupCorrID(corrID)
+	if !ok {
+		return sub, errors.New(subNotFound)
+	}
 
-		/*Search across all the Subscription*/
-		for _, vs := range value.subs {
+	ref.af.mu.Lock()
+	defer ref.af.mu.Unlock()
 
-			if vs.NotifCorreID == corrID {
-				/*Match found return sub handle*/
-				return vs, nil
-			}
-		}
+	vs, ok := ref.af.subs[ref.subID]
+	if !ok || vs.NotifCorreID != corrID {
+		return sub, errors.New(subNotFound)
 	}
-	return sub, errors.New("Subscription Not Found")
+	subCopy := *vs
+	return &subCopy, nil
 }
 
 //validateAFTrafficInfluenceData: Function to validate mandatory parameters of
@@ -532,8 +615,15 @@ func validateAFTrafficInfluenceData(ti TrafficInfluSub) (rsp nefSBRspData,
 func (af *afData) afAddSubscription(nefCtx *nefContext,
 	ti TrafficInfluSub) (loc string, rsp nefSBRspData, err error) {
 
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
+	if af.deleted {
+		return "", rsp, errAfDeleted
+	}
+
 	/*Check if max subscription reached */
-	if len(af.subs) >= nefCtx.cfg.MaxSubSupport {
+	if af.afSubTotal() >= nefCtx.liveConfig().MaxSubSupport {
 
 		rsp.errorCode = 400
 		rsp.pd.Title = "MAX Subscription Reached"
@@ -548,11 +638,16 @@ func (af *afData) afAddSubscription(nefCtx *nefContext,
 	afsub := afSubscription{subid: subIDStr, ti: ti, appSessionID: "",
 		NotifCorreID: "", iid: ""}
 
-	if len(ti.Gpsi) > 0 || len(ti.Ipv4Addr) > 0 || len(ti.Ipv6Addr) > 0 {
+	//The policy is installed only inside the temporal validities
+	afsub.active, _ = tempValidityState(ti.TempValidities, time.Now())
+
+	if isSingleUESub(ti) {
 
 		//Applicable to single UE, PCF case
 
-		rsp, err = nefSBPCFPost(&afsub, nefCtx, ti)
+		if afsub.active {
+			rsp, err = nefSBPCFPost(&afsub, nefCtx, ti)
+		}
 
 		if err != nil {
 
@@ -561,16 +656,17 @@ func (af *afData) afAddSubscription(nefCtx *nefContext,
 		}
 		//Store Notification Destination URI
 		afsub.afNotificationDestination = ti.NotificationDestination
-		afsub.NEFSBGet = nefSBPCFGet
-		afsub.NEFSBPut = nefSBPCFPut
-		afsub.NEFSBPatch = nefSBPCFPatch
-		afsub.NEFSBDelete = nefSBPCFDelete
+		afsub.setPCFCallbacks()
 
 	} else if len(ti.ExternalGroupID) > 0 || ti.AnyUeInd {
 
 		//Applicable to Any UE, UDR case
+		//The influence data is stored in UDR with an id unique in the NEF
+		afsub.iid = InfluenceID(af.afID + "-" + subIDStr)
 
-		rsp, err = nefSBUDRPost(&afsub, nefCtx, ti)
+		if afsub.active {
+			rsp, err = nefSBUDRPost(&afsub, nefCtx, ti)
+		}
 
 		if err != nil {
 
@@ -579,11 +675,7 @@ func (af *afData) afAddSubscription(nefCtx *nefContext,
 		}
 		//Store Notification Destination URI
 		afsub.afNotificationDestination = ti.NotificationDestination
-
-		afsub.NEFSBGet = nefSBUDRGet
-		afsub.NEFSBPut = nefSBUDRPut
-		afsub.NEFSBPatch = nefSBUDRPatch
-		afsub.NEFSBDelete = nefSBUDRDelete
+		afsub.setUDRCallbacks()
 
 	} else {
 		//Invalid case. Return Error
@@ -600,6 +692,10 @@ func (af *afData) afAddSubscription(nefCtx *nefContext,
 		subIDStr
 
 	afsub.ti.Self = Link(loc)
+	setTiWebsocketURI(&afsub.ti)
+	nefCtx.nef.nefIndexSub(af, &afsub, "")
+	nefCtx.nef.nefSaveSub(af, &afsub)
+	af.afScheduleTempValidity(nefCtx, &afsub)
 
 	log.Infoln(" NEW AF Subscription added " + subIDStr)
 
@@ -609,6 +705,9 @@ func (af *afData) afAddSubscription(nefCtx *nefContext,
 func (af *afData) afUpdateSubscription(nefCtx *nefContext, subID string,
 	ti TrafficInfluSub) (rsp nefSBRspData, updtTI TrafficInfluSub, err error) {
 
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
 	sub, ok := af.subs[subID]
 
 	if !ok {
@@ -618,16 +717,26 @@ func (af *afData) afUpdateSubscription(nefCtx *nefContext, subID string,
 		return rsp, updtTI, errors.New(subNotFound)
 	}
 
-	rsp, err = sub.NEFSBPut(sub, nefCtx, ti)
+	oldCorrID := sub.NotifCorreID
+	rsp, active, err := af.afSBApply(nefCtx, sub, ti,
+		func() (nefSBRspData, error) { return sub.NEFSBPut(sub, nefCtx, ti) })
 
 	if err != nil {
 		log.Err("Failed to Update Subscription")
+		sub.NotifCorreID = oldCorrID
 		return rsp, updtTI, err
 	}
 
 	updtTI = ti
 	updtTI.Self = sub.ti.Self
+	setTiWebsocketURI(&updtTI)
+	if !updtTI.WebsockNotifConfig.RequestWebsocketURI {
+		sub.afSetWebsocket(nil)
+	}
 	sub.ti = updtTI
+	af.afSetActive(nefCtx, sub, active, oldCorrID)
+	nefCtx.nef.nefSaveSub(af, sub)
+	af.afScheduleTempValidity(nefCtx, sub)
 
 	log.Infoln("Update Subscription Successful")
 	return rsp, updtTI, err
@@ -669,6 +778,9 @@ func (af *afData) afPartialUpdateSubscription(nefCtx *nefContext, subID string,
 	tisp TrafficInfluSubPatch) (rsp nefSBRspData, ti TrafficInfluSub,
 	err error) {
 
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
 	sub, ok := af.subs[subID]
 
 	if !ok {
@@ -677,13 +789,29 @@ func (af *afData) afPartialUpdateSubscription(nefCtx *nefContext, subID string,
 		return rsp, ti, errors.New(subNotFound)
 	}
 
-	rsp, err = sub.NEFSBPatch(sub, nefCtx, tisp)
+	ti = sub.ti
+	updateTiFromTisp(&ti, tisp)
+
+	// The traffic routes may not be allowed for the AF service
+	if rsp, ok = nefCtx.nef.afServices.validate(ti); !ok {
+		return rsp, TrafficInfluSub{}, errors.New(rsp.pd.Title)
+	}
+
+	oldCorrID := sub.NotifCorreID
+	rsp, active, err := af.afSBApply(nefCtx, sub, ti,
+		func() (nefSBRspData, error) {
+			return sub.NEFSBPatch(sub, nefCtx, tisp)
+		})
 
 	if err != nil {
 		log.Err("Failed to Patch Subscription")
-		return rsp, ti, err
+		sub.NotifCorreID = oldCorrID
+		return rsp, TrafficInfluSub{}, err
 	}
-	updateTiFromTisp(&sub.ti, tisp)
+	sub.ti = ti
+	af.afSetActive(nefCtx, sub, active, oldCorrID)
+	nefCtx.nef.nefSaveSub(af, sub)
+	af.afScheduleTempValidity(nefCtx, sub)
 
 	return rsp, sub.ti, err
 
@@ -692,6 +820,9 @@ func (af *afData) afPartialUpdateSubscription(nefCtx *nefContext, subID string,
 func (af *afData) afGetSubscription(nefCtx *nefContext,
 	subID string) (rsp nefSBRspData, ti TrafficInfluSub, err error) {
 
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
 	sub, ok := af.subs[subID]
 
 	if !ok {
@@ -712,25 +843,22 @@ func (af *afData) afGetSubscription(nefCtx *nefContext,
 	*/
 
 	//Return locally
-	return rsp, sub.ti, err
+	ti = sub.ti
+	ti.ActivationState = sub.activationState()
+	return rsp, ti, err
 }
 
 func (af *afData) afGetSubscriptionList(nefCtx *nefContext) (rsp nefSBRspData,
 	subsList []TrafficInfluSub, err error) {
 
-	var ti TrafficInfluSub
-
-	if len(af.subs) > 0 {
+	af.mu.Lock()
+	defer af.mu.Unlock()
 
-		for key := range af.subs {
-
-			rsp, ti, err = af.afGetSubscription(nefCtx, key)
-
-			if err != nil {
-				return rsp, subsList, err
-			}
-			subsList = append(subsList, ti)
-		}
+	//Return locally
+	for _, sub := range af.subs {
+		ti := sub.ti
+		ti.ActivationState = sub.activationState()
+		subsList = append(subsList, ti)
 	}
 	return rsp, subsList, err
 }
@@ -738,6 +866,9 @@ func (af *afData) afGetSubscriptionList(nefCtx *nefContext) (rsp nefSBRspData,
 func (af *afData) afDeleteSubscription(nefCtx *nefContext,
 	subID string) (rsp nefSBRspData, err error) {
 
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
 	//Check if AF is already present
 	sub, ok := af.subs[subID]
 
@@ -747,7 +878,12 @@ func (af *afData) afDeleteSubscription(nefCtx *nefContext,
 		return rsp, errors.New(subNotFound)
 	}
 
-	rsp, err = sub.NEFSBDelete(sub, nefCtx)
+	//The policy is not installed outside the temporal validities
+	if sub.active {
+		rsp, err = sub.NEFSBDelete(sub, nefCtx)
+	} else {
+		rsp.errorCode = http.StatusNoContent
+	}
 
 	if err != nil {
 		log.Err("Failed to Delete Subscription")
@@ -755,17 +891,50 @@ func (af *afData) afDeleteSubscription(nefCtx *nefContext,
 	}
 
 	//Delete local entry in map
+	af.afStopTempValidity(sub)
+	sub.afSetWebsocket(nil)
 	delete(af.subs, subID)
 	//af.subIDnum--
+	nefCtx.nef.nefUnindexSub(sub)
+	nefCtx.nef.nefRemoveSub(af, subID)
 
 	return rsp, err
 }
 
 func (af *afData) afGetSubCount() (afCount int) {
 
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
 	return len(af.subs)
 }
 
+//Checks if the subscription is for a single UE which is handled by the PCF
+func isSingleUESub(ti TrafficInfluSub) bool {
+
+	return len(ti.Gpsi) > 0 || len(ti.Ipv4Addr) > 0 || len(ti.Ipv6Addr) > 0
+}
+
+//Links the PCF SB APIs with the subscription
+func (sub *afSubscription) setPCFCallbacks() {
+
+	sub.NEFSBPost = nefSBPCFPost
+	sub.NEFSBGet = nefSBPCFGet
+	sub.NEFSBPut = nefSBPCFPut
+	sub.NEFSBPatch = nefSBPCFPatch
+	sub.NEFSBDelete = nefSBPCFDelete
+}
+
+//Links the UDR SB APIs with the subscription
+func (sub *afSubscription) setUDRCallbacks() {
+
+	sub.NEFSBPost = nefSBUDRPost
+	sub.NEFSBGet = nefSBUDRGet
+	sub.NEFSBPut = nefSBUDRPut
+	sub.NEFSBPatch = nefSBUDRPatch
+	sub.NEFSBDelete = nefSBUDRDelete
+}
+
 /* unused function
 func (af *afData) afDestroy(afid string) error {
 
@@ -824,8 +993,7 @@ func nefSBPCFPost(pcfSub *afSubscription, nefCtx *nefContext,
 	cliCtx, cancel := context.WithCancel(nef.ctx)
 	defer cancel()
 
-	pcfSub.NotifCorreID = strconv.Itoa(int(nef.corrID))
-	nef.corrID++
+	pcfSub.NotifCorreID = nef.nefNextCorrID()
 
 	appSessCtx := AppSessionContext{}
 	pcfPolicyResp := PcfPolicyResponse{}
@@ -863,8 +1031,8 @@ func nefSBPCFPost(pcfSub *afSubscription, nefCtx *nefContext,
 	_ = copy(appSessCtx.AscReqData.AfRoutReq.TempVals, ti.TempValidities)
 
 	//Populating Spatial Validity in App Session Context
-	_ = getSpatialValidityData(cliCtx, nefCtx,
-		&appSessCtx.AscReqData.AfRoutReq.SpVal)
+	appSessCtx.AscReqData.AfRoutReq.SpVal = getSpatialValidityData(nefCtx,
+		ti.ValidGeoZoneIDs)
 
 	//Populating IP and Mac Addresses in App Session Context
 	appSessCtx.AscReqData.UeIpv4 = ti.Ipv4Addr
@@ -872,18 +1040,17 @@ func nefSBPCFPost(pcfSub *afSubscription, nefCtx *nefContext,
 	appSessCtx.AscReqData.UeMac = ti.MacAddr
 
 	//Populating DNN and NW Slice Info and SUPI in App Session Context
-	for _, afServIdcounter := range nefCtx.cfg.AfServiceIDs {
-		afServiceID := afServIdcounter.(map[string]interface{})
-		if 0 == strings.Compare(ti.AfServiceID, afServiceID["id"].(string)) {
-			appSessCtx.AscReqData.Dnn = Dnn(afServiceID["dnn"].(string))
-			appSessCtx.AscReqData.SliceInfo.Sd = afServiceID["snssai"].(string)
-			appSessCtx.AscReqData.SliceInfo.Sst =
-				uint8(len(appSessCtx.AscReqData.SliceInfo.Sd))
-		}
-	}
+	appSessCtx.AscReqData.Dnn, appSessCtx.AscReqData.SliceInfo =
+		nefCtx.nef.afServices.resolve(ti)
 
 	//Populating SUPI in App Session Context
-	_ = getSupiData(cliCtx, nefCtx, &appSessCtx.AscReqData.Supi)
+	if ti.Gpsi != "" {
+		appSessCtx.AscReqData.Supi, rsp, err = getSupiData(cliCtx, nefCtx,
+			ti.Gpsi)
+		if err != nil {
+			return rsp, err
+		}
+	}
 
 	appSessID, pcfPolicyResp, err =
 		nef.pcfClient.PolicyAuthorizationCreate(cliCtx, appSessCtx)
@@ -1014,8 +1181,8 @@ func nefSBPCFPatch(pcfSub *afSubscription, nefCtx *nefContext,
 	_ = copy(appSessCtxUpdtData.AfRoutReq.TempVals, tisp.TempValidities)
 
 	//Populating Spatial Validity in App Session Context
-	_ = getSpatialValidityData(cliCtx, nefCtx,
-		&appSessCtxUpdtData.AfRoutReq.SpVal)
+	appSessCtxUpdtData.AfRoutReq.SpVal = getSpatialValidityData(nefCtx,
+		tisp.ValidGeoZoneIDs)
 
 	pcfPolicyResp, err := nef.pcfClient.PolicyAuthorizationUpdate(cliCtx,
 		appSessCtxUpdtData, pcfSub.appSessionID)
@@ -1097,7 +1264,21 @@ func nefSBPCFDelete(pcfSub *afSubscription, nefCtx *nefContext) (
 func nefSBUDRPost(udrSub *afSubscription, nefCtx *nefContext,
 	ti TrafficInfluSub) (rsp nefSBRspData, err error) {
 
-	rsp, err = nefSBUDRPut(udrSub, nefCtx, ti)
+	rsp, err = nefSBUDRInfluenceDataPut(udrSub, nefCtx, ti)
+	if err != nil || rsp.errorCode >= 300 {
+		return rsp, err
+	}
+
+	smfRsp, err := nefSBSMFUpdate(udrSub, nefCtx, ti, false)
+	if err != nil {
+		// Remove the influence data as the subscription is not created
+		nef := &nefCtx.nef
+		_, err1 := nef.udrClient.UdrInfluenceDataDelete(nef.ctx, udrSub.iid)
+		if err1 != nil {
+			log.Errf("UDR Traffic Influence Data rollback failed: %v", err1)
+		}
+		return smfRsp, err
+	}
 	return rsp, err
 
 }
@@ -1146,8 +1327,8 @@ func nefSBUDRGet(udrSub *afSubscription, nefCtx *nefContext) (
 	return sub, rsp, err
 }
 
-// nefSBUDRPut : This function sends HTTP PUT Request to UDR to create Traffic
-//            Influence Data.
+// nefSBUDRPut : This function sends HTTP PUT Request to UDR to update Traffic
+//            Influence Data and updates the SMF event exposure subscription.
 // Input Args:
 //   - nefCtx: This is NEF Module Context. This contains the NEF Module Data.
 //   - ti: This is Traffic Influence Subscription Data.
@@ -1158,6 +1339,30 @@ func nefSBUDRGet(udrSub *afSubscription, nefCtx *nefContext) (
 func nefSBUDRPut(udrSub *afSubscription, nefCtx *nefContext,
 	ti TrafficInfluSub) (rsp nefSBRspData, err error) {
 
+	rsp, err = nefSBUDRInfluenceDataPut(udrSub, nefCtx, ti)
+	if err != nil || rsp.errorCode >= 300 {
+		return rsp, err
+	}
+
+	smfRsp, err := nefSBSMFUpdate(udrSub, nefCtx, ti, false)
+	if err != nil {
+		return smfRsp, err
+	}
+	return rsp, err
+}
+
+// nefSBUDRInfluenceDataPut : This function sends HTTP PUT Request to UDR to
+//            create Traffic Influence Data.
+// Input Args:
+//   - nefCtx: This is NEF Module Context. This contains the NEF Module Data.
+//   - ti: This is Traffic Influence Subscription Data.
+// Output Args:
+//    - rsp: This is Traffic Influence Data Create Response Data
+//    - error: retruns error in case there is failure happened in sending the
+//             request.
+func nefSBUDRInfluenceDataPut(udrSub *afSubscription, nefCtx *nefContext,
+	ti TrafficInfluSub) (rsp nefSBRspData, err error) {
+
 	nef := &nefCtx.nef
 
 	cliCtx, cancel := context.WithCancel(nef.ctx)
@@ -1169,26 +1374,29 @@ func nefSBUDRPut(udrSub *afSubscription, nefCtx *nefContext,
 	trafficInfluData.AfAppID = ti.AfAppID
 
 	//Populating DNN and NW Slice Info in Traffic Influence Data
-	for _, afServIdcounter := range nefCtx.cfg.AfServiceIDs {
-		afServiceID := afServIdcounter.(map[string]interface{})
-		if 0 == strings.Compare(ti.AfServiceID, afServiceID["id"].(string)) {
-			trafficInfluData.Dnn = Dnn(afServiceID["dnn"].(string))
-			trafficInfluData.Snssai.Sd = afServiceID["snssai"].(string)
-			trafficInfluData.Snssai.Sst = uint8(len(trafficInfluData.Snssai.Sd))
-		}
-	}
+	trafficInfluData.Dnn, trafficInfluData.Snssai =
+		nefCtx.nef.afServices.resolve(ti)
 
 	trafficInfluData.AppReloInd = ti.AppReloInd
 	trafficInfluData.InterGroupID = string(ti.ExternalGroupID)
 
+	//Populating SUPI in Traffic Influence Data
+	if ti.Gpsi != "" {
+		trafficInfluData.Supi, rsp, err = getSupiData(cliCtx, nefCtx,
+			ti.Gpsi)
+		if err != nil {
+			return rsp, err
+		}
+	}
+
 	//Populating UP Path Chnage Subbscription Data in Traffic Influence Data
 	trafficInfluData.UpPathChgNotifURI = nefCtx.nef.upfNotificationURL
 
-	if len(ti.SubscribedEvents) > 0 &&
-		0 == strings.Compare(string(ti.SubscribedEvents[0]), "UP_PATH_CHANGE") {
-		udrSub.NotifCorreID = strconv.Itoa(int(nef.corrID))
-		nef.corrID++
+	if hasUpPathChangeEvent(ti) {
+		udrSub.NotifCorreID = nef.nefNextCorrID()
 		trafficInfluData.UpPathChgNotifCorreID = udrSub.NotifCorreID
+	} else {
+		udrSub.NotifCorreID = ""
 	}
 
 	//Populating Traffic Filters in Traffic Influence Data
@@ -1215,7 +1423,8 @@ func nefSBUDRPut(udrSub *afSubscription, nefCtx *nefContext,
 	}
 
 	//Populating Spatial Validity in Traffic Influence Data
-	_ = getNetworkAreaInfo(cliCtx, nefCtx, &trafficInfluData.NwAreaInfo)
+	trafficInfluData.NwAreaInfo = getNetworkAreaInfo(nefCtx,
+		ti.ValidGeoZoneIDs)
 
 	udrInfluenceResp, err := nef.udrClient.UdrInfluenceDataCreate(
 		cliCtx, trafficInfluData, udrSub.iid)
@@ -1287,6 +1496,10 @@ func nefSBUDRPatch(udrSub *afSubscription, nefCtx *nefContext,
 			DateTime(tisp.TempValidities[0].StopTime)
 	}
 
+	//Populating Spatial Validity in Traffic Influence Data
+	trafficInfluDataPatch.NwAreaInfo = getNetworkAreaInfo(nefCtx,
+		tisp.ValidGeoZoneIDs)
+
 	udrInfluenceResp, err := nef.udrClient.UdrInfluenceDataUpdate(
 		cliCtx, trafficInfluDataPatch, udrSub.iid)
 	if err != nil {
@@ -1306,9 +1519,14 @@ func nefSBUDRPatch(udrSub *afSubscription, nefCtx *nefContext,
 		}
 		log.Errf("UDR Traffic Influence Data Update Failure. Response Code: %d",
 			rsp.errorCode)
-	} else {
-		log.Infof("UDR Traffic Influence Data Update Success.Response Code: %d",
-			rsp.errorCode)
+		return rsp, err
+	}
+	log.Infof("UDR Traffic Influence Data Update Success.Response Code: %d",
+		rsp.errorCode)
+
+	smfRsp, err := nefSBSMFUpdate(udrSub, nefCtx, udrSub.ti, false)
+	if err != nil {
+		return smfRsp, err
 	}
 	return rsp, err
 }
@@ -1329,6 +1547,10 @@ func nefSBUDRDelete(udrSub *afSubscription, nefCtx *nefContext) (
 	cliCtx, cancel := context.WithCancel(nef.ctx)
 	defer cancel()
 
+	if rsp, err = nefSBSMFUpdate(udrSub, nefCtx, udrSub.ti, true); err != nil {
+		return rsp, err
+	}
+
 	udrInfluenceResp, err := nef.udrClient.UdrInfluenceDataDelete(cliCtx,
 		udrSub.iid)
 	if err != nil {
@@ -1356,64 +1578,160 @@ func nefSBUDRDelete(udrSub *afSubscription, nefCtx *nefContext) (
 	return rsp, err
 }
 
-func getSpatialValidityData(cliCtx context.Context, nefCtx *nefContext,
-	spVal *SpatialValidity) error {
-	_ = cliCtx
-	_ = nefCtx
-
-	spVal.PresenceInfoList.PraID = "PRA_01"
-	spVal.PresenceInfoList.PresenceState = "IN_AREA"
-	spVal.PresenceInfoList.EcgiList = make([]Ecgi, 1)
-	spVal.PresenceInfoList.EcgiList[0].EutraCellID = "EUTRACELL_01"
-	spVal.PresenceInfoList.EcgiList[0].PlmnID.Mcc = "634"
-	spVal.PresenceInfoList.EcgiList[0].PlmnID.Mnc = "635"
-	spVal.PresenceInfoList.NcgiList = make([]Ncgi, 1)
-	spVal.PresenceInfoList.NcgiList[0].NrCellID = "NRCELL_01"
-	spVal.PresenceInfoList.NcgiList[0].PlmnID.Mcc = "834"
-	spVal.PresenceInfoList.NcgiList[0].PlmnID.Mnc = "835"
-	spVal.PresenceInfoList.GlobalRanNodeIDList = make([]GlobalRanNodeID, 1)
-	spVal.PresenceInfoList.GlobalRanNodeIDList[0].PlmnID.Mcc = "934"
-	spVal.PresenceInfoList.GlobalRanNodeIDList[0].PlmnID.Mnc = "935"
-	spVal.PresenceInfoList.GlobalRanNodeIDList[0].N3IwfID = "IWF_01"
-	spVal.PresenceInfoList.GlobalRanNodeIDList[0].GNbID.BitLength = 48
-	spVal.PresenceInfoList.GlobalRanNodeIDList[0].GNbID.GNBValue = "GNB_01"
-	spVal.PresenceInfoList.GlobalRanNodeIDList[0].NgeNbID = "NB_01"
-
-	return nil
+// nefSBSMFUpdate : This function creates, updates or deletes the SMF event
+//             exposure subscription for the UP path change event of the
+//             Traffic Influence Subscription.
+// Input Args:
+//   - nefCtx: This is NEF Module Context. This contains the NEF Module Data.
+//   - ti: This is Traffic Influence Subscription Data.
+//   - remove: The SMF subscription is deleted if set
+// Output Args:
+//    - rsp: This is SMF Event Exposure Response Data
+//    - error: retruns error in case there is failure happened in sending the
+//             request or any failure response is received.
+func nefSBSMFUpdate(udrSub *afSubscription, nefCtx *nefContext,
+	ti TrafficInfluSub, remove bool) (rsp nefSBRspData, err error) {
+
+	var smfRsp SmfEventExposureResponse
+	nef := &nefCtx.nef
+
+	cliCtx, cancel := context.WithCancel(nef.ctx)
+	defer cancel()
+
+	subscribe := !remove && hasUpPathChangeEvent(ti)
+
+	switch {
+	case subscribe && udrSub.smfSubID == "":
+		var subID SmfSubscriptionID
+		subID, smfRsp, err = nef.smfClient.SmfEventExposureSubscribe(cliCtx,
+			getSmfEventExposureData(nefCtx, udrSub, ti))
+		if err == nil && smfRsp.ResponseCode < 300 {
+			udrSub.smfSubID = subID
+		}
+	case subscribe:
+		smfRsp, err = nef.smfClient.SmfEventExposureUpdate(cliCtx,
+			getSmfEventExposureData(nefCtx, udrSub, ti), udrSub.smfSubID)
+	case udrSub.smfSubID != "":
+		smfRsp, err = nef.smfClient.SmfEventExposureUnsubscribe(cliCtx,
+			udrSub.smfSubID)
+		// Subscription not found in SMF is already removed
+		if err == nil && (smfRsp.ResponseCode < 300 ||
+			smfRsp.ResponseCode == http.StatusNotFound) {
+			udrSub.smfSubID = ""
+			smfRsp.ResponseCode = http.StatusNoContent
+		}
+	default:
+		return rsp, nil
+	}
+
+	rsp.errorCode = int(smfRsp.ResponseCode)
+	if smfRsp.Pd != nil {
+		rsp.pd = *smfRsp.Pd
+	}
+	if err == nil && rsp.errorCode >= 300 {
+		err = errors.New("SMF Event Exposure failure")
+	}
+	if err != nil {
+		log.Errf("SMF Event Exposure Failure. Response Code: %d",
+			rsp.errorCode)
+		return rsp, err
+	}
+	log.Infof("SMF Event Exposure Success. Response Code: %d", rsp.errorCode)
+	return rsp, nil
+}
+
+//hasUpPathChangeEvent returns true if the AF subscribed to the UP path change
+//event
+func hasUpPathChangeEvent(ti TrafficInfluSub) bool {
+
+	for _, ev := range ti.SubscribedEvents {
+		if ev == SubscribedEvent("UP_PATH_CHANGE") {
+			return true
+		}
+	}
+	return false
 }
 
+//getSmfEventExposureData returns the SMF event exposure subscription for the
+//UP path change event of the Traffic Influence Subscription
+func getSmfEventExposureData(nefCtx *nefContext, udrSub *afSubscription,
+	ti TrafficInfluSub) (nee NsmfEventExposure) {
+
+	nee.NotifID = udrSub.NotifCorreID
+	nee.NotifURI = nefCtx.nef.upfNotificationURL
+	nee.EventSubs = []EventSubscription{{Event: SmfEventUpPathCh,
+		DnaiChgType: ti.DnaiChgType}}
+
+	if ti.AnyUeInd {
+		nee.AnyUeInd = true
+	} else {
+		nee.GroupID = string(ti.ExternalGroupID)
+	}
+
+	//Populating DNN and NW Slice Info
+	dnn, snssai := nefCtx.nef.afServices.resolve(ti)
+	nee.Dnn = dnn
+	if snssai != (Snssai{}) {
+		nee.Snssai = &snssai
+	}
+	return nee
+}
+
+//Returns the spatial validity of the geo zones, nil if there is none
+func getSpatialValidityData(nefCtx *nefContext,
+	zoneIDs []string) *SpatialValidity {
+
+	return nefCtx.nef.geoZones.spatialValidity(zoneIDs)
+}
+
+//Translates the GPSI of the UE into the SUPI using the UDM. The GPSI not
+//known by the UDM is reported with errGpsiNotTranslated and a 404 response,
+//the other failures with errUdmFailure and a 5xx response with the
+//ProblemDetails of the UDM.
 func getSupiData(cliCtx context.Context, nefCtx *nefContext,
-	supi *Supi) error {
-	_ = cliCtx
-	_ = nefCtx
-	*supi = "imsi-8"
-	return nil
+	gpsi Gpsi) (supi Supi, rsp nefSBRspData, err error) {
+
+	udmRsp, err := nefCtx.nef.udmSdmClient.UdmIDTranslationGet(cliCtx,
+		string(gpsi))
+	if err == nil && udmRsp.ResponseCode < 300 && udmRsp.Result != nil {
+		log.Infof("UDM ID Translation Success [Gpsi,Supi] => [%s,%s]",
+			string(gpsi), string(udmRsp.Result.Supi))
+		return udmRsp.Result.Supi, rsp, nil
+	}
+
+	log.Errf("UDM ID Translation Failure. Response Code: %d",
+		udmRsp.ResponseCode)
+	if udmRsp.Pd != nil {
+		rsp.pd = *udmRsp.Pd
+	}
+	if rsp.pd.Title == "" {
+		rsp.pd.Title = "GPSI translation failed"
+	}
+
+	if err == nil && udmRsp.ResponseCode == http.StatusNotFound {
+		rsp.errorCode = http.StatusNotFound
+		rsp.pd.Status = http.StatusNotFound
+		rsp.pd.InvalidParams = append(rsp.pd.InvalidParams,
+			InvalidParam{Param: "/gpsi",
+				Reason: "Unknown UE identity " + string(gpsi)})
+		return "", rsp, errGpsiNotTranslated
+	}
+
+	if err != nil {
+		log.Err(err)
+	}
+	//The failures of the UDM are not caused by the request of the AF
+	rsp.errorCode = int(udmRsp.ResponseCode)
+	if rsp.errorCode < 500 {
+		rsp.errorCode = http.StatusInternalServerError
+	}
+	rsp.pd.Status = int32(rsp.errorCode)
+	return "", rsp, errUdmFailure
 }
 
-func getNetworkAreaInfo(cliCtx context.Context, nefCtx *nefContext,
-	nwAreaInfo *NetworkAreaInfo) error {
-	_ = cliCtx
-	_ = nefCtx
-
-	nwAreaInfo.Ecgis = make([]Ecgi, 1)
-	nwAreaInfo.Ecgis[0].EutraCellID = "EUTRACELL_01"
-	nwAreaInfo.Ecgis[0].PlmnID.Mcc = "634"
-	nwAreaInfo.Ecgis[0].PlmnID.Mnc = "635"
-	nwAreaInfo.Ncgis = make([]Ncgi, 1)
-	nwAreaInfo.Ncgis[0].NrCellID = "NRCELL_01"
-	nwAreaInfo.Ncgis[0].PlmnID.Mcc = "834"
-	nwAreaInfo.Ncgis[0].PlmnID.Mnc = "835"
-	nwAreaInfo.GRanNodeIds = make([]GlobalRanNodeID, 1)
-	nwAreaInfo.GRanNodeIds[0].PlmnID.Mcc = "934"
-	nwAreaInfo.GRanNodeIds[0].PlmnID.Mnc = "935"
-	nwAreaInfo.GRanNodeIds[0].N3IwfID = "IWF_01"
-	nwAreaInfo.GRanNodeIds[0].GNbID.BitLength = 48
-	nwAreaInfo.GRanNodeIds[0].GNbID.GNBValue = "GNB_01"
-	nwAreaInfo.GRanNodeIds[0].NgeNbID = "NB_01"
-	nwAreaInfo.Tais = make([]Tai, 1)
-	nwAreaInfo.Tais[0].PlmnID.Mcc = "734"
-	nwAreaInfo.Tais[0].PlmnID.Mnc = "735"
-	nwAreaInfo.Tais[0].Tac = "TAC_01"
-
-	return nil
+//Returns the network area of the geo zones, nil if there is none
+func getNetworkAreaInfo(nefCtx *nefContext,
+	zoneIDs []string) *NetworkAreaInfo {
+
+	return nefCtx.nef.geoZones.networkAreaInfo(zoneIDs)
 }
@@ -0,0 +1,434 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+/* Client implementation of the Nnrf_NFManagement and Nnrf_NFDiscovery
+services (3GPP TS 29.510) */
+
+package ngcnef
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const nrfNfInstancesPath = "/nnrf-nfm/v1/nf-instances"
+const nrfDiscoveryPath = "/nnrf-disc/v1/nf-instances"
+
+const nrfDefaultHeartBeatTimer = 60
+const nrfDefaultCacheTime = 300
+
+// Timeout for the deregistration when the NEF is stopped
+const nrfDeregisterTimeout = 5 * time.Second
+
+// Services provided by the NEF and used from the other network functions
+const (
+	nefTrafficInfluenceService = "nnef-trafficinfluence"
+	nefPfdManagementService    = "nnef-pfdmanagement"
+	nefAfSessionWithQosService = "nnef-afsessionwithqos"
+	pcfPolicyAuthService       = "npcf-policyauthorization"
+	udrDataRepositoryService   = "nudr-dr"
+	smfEventExposureService    = "nsmf-event-exposure"
+	udmEventExposureService    = "nudm-ee"
+	udmSdmService              = "nudm-sdm"
+)
+
+// nrfClient registers the NEF in the NRF and discovers the network functions
+// used by the NEF
+type nrfClient struct {
+	sb        *sbClient
+	profile   NFProfile
+	heartBeat time.Duration
+	cacheTime time.Duration
+	// done is closed when the registration go routine exits
+	done chan struct{}
+
+	// mu guards registered and cache
+	mu         sync.Mutex
+	registered bool
+	// discovery results per NF type, service, DNN and S-NSSAI
+	cache map[string]nrfCacheEntry
+}
+
+// nrfCacheEntry is a discovery result cached until the expiry
+type nrfCacheEntry struct {
+	apiRoots []string
+	expiry   time.Time
+	// next instance used, the requests are distributed on the instances
+	next int
+}
+
+// newNRFClient creates the NRF client and the NF profile of the NEF
+func newNRFClient(cfg *Config) (*nrfClient, error) {
+
+	sb, err := newSBClient(&cfg.NRFConfig.SBClientConfig, cfg.UserAgent)
+	if err != nil {
+		log.Errf("NRF Client creation failed: %v", err)
+		return nil, err
+	}
+
+	nrf := &nrfClient{sb: sb}
+	nrf.cache = make(map[string]nrfCacheEntry)
+
+	heartBeat := cfg.NRFConfig.HeartBeatTimer
+	if heartBeat <= 0 {
+		heartBeat = nrfDefaultHeartBeatTimer
+	}
+	nrf.heartBeat = time.Duration(heartBeat) * time.Second
+
+	cacheTime := cfg.NRFConfig.CacheTime
+	if cacheTime <= 0 {
+		cacheTime = nrfDefaultCacheTime
+	}
+	nrf.cacheTime = time.Duration(cacheTime) * time.Second
+
+	nrf.profile, err = nefProfile(cfg, heartBeat)
+	if err != nil {
+		log.Errf("NRF Client creation failed: %v", err)
+		return nil, err
+	}
+	log.Infof("NRF Client created for %s, NF Instance ID %s", sb.apiRoot,
+		nrf.profile.NfInstanceID)
+	return nrf, nil
+}
+
+// newUUID returns a random (version 4) UUID
+func newUUID() (string, error) {
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10],
+		b[10:]), nil
+}
+
+// nefProfile returns the NF profile of the NEF with the traffic influence and
+// PFD management services
+func nefProfile(cfg *Config, heartBeat int) (NFProfile, error) {
+
+	var err error
+	profile := NFProfile{NfType: NFTypeNEF, NfStatus: NFStatusRegistered,
+		HeartBeatTimer: heartBeat}
+
+	profile.NfInstanceID = cfg.NRFConfig.NfInstanceID
+	if profile.NfInstanceID == "" {
+		if profile.NfInstanceID, err = newUUID(); err != nil {
+			return profile, err
+		}
+	}
+
+	if ip := net.ParseIP(cfg.NefAPIRoot); ip != nil && ip.To4() != nil {
+		profile.Ipv4Addresses = []string{cfg.NefAPIRoot}
+	} else {
+		profile.Fqdn = cfg.NefAPIRoot
+	}
+
+	// The NEF serves the slices of the AF services
+	seen := make(map[Snssai]bool)
+	for _, m := range cfg.AfServiceIDs {
+		if m.Snssai != (Snssai{}) && !seen[m.Snssai] {
+			seen[m.Snssai] = true
+			profile.SNssais = append(profile.SNssais, m.Snssai)
+		}
+	}
+
+	scheme, endpoint := "https", cfg.HTTP2Config.Endpoint
+	if endpoint == "" {
+		scheme, endpoint = "http", cfg.HTTPConfig.Endpoint
+	}
+	port := 0
+	if _, p, err := net.SplitHostPort(endpoint); err == nil {
+		port, _ = strconv.Atoi(p)
+	}
+
+	services := []struct {
+		name   string
+		prefix string
+	}{
+		{nefTrafficInfluenceService, cfg.LocationPrefix},
+		{nefPfdManagementService, cfg.LocationPrefixPfd},
+		{nefAfSessionWithQosService, cfg.LocationPrefixQos},
+	}
+	for i, s := range services {
+		service := NFService{ServiceInstanceID: strconv.Itoa(i + 1),
+			ServiceName: s.name, Scheme: scheme,
+			NfServiceStatus: NFStatusRegistered,
+			Versions: []NFServiceVersion{{APIVersionInURI: "v1",
+				APIFullVersion: "1.0.0"}}}
+		// The API prefix is the location prefix without the API name and
+		// version ex: "/3gpp-traffic-influence/v1/"
+		prefix := strings.Trim(s.prefix, "/")
+		if idx := strings.Index(prefix, "/3gpp-"); idx > 0 {
+			service.APIPrefix = "/" + prefix[:idx]
+		}
+		if port != 0 {
+			service.IPEndPoints = []IPEndPoint{{Port: port}}
+			if profile.Ipv4Addresses != nil {
+				service.IPEndPoints[0].Ipv4Address = cfg.NefAPIRoot
+			}
+		}
+		profile.NfServices = append(profile.NfServices, service)
+	}
+	return profile, nil
+}
+
+// nfInstanceURI returns the uri of the NEF instance in the NRF
+func (nrf *nrfClient) nfInstanceURI() string {
+	return nrf.sb.apiRoot + nrfNfInstancesPath + "/" +
+		url.PathEscape(nrf.profile.NfInstanceID)
+}
+
+// register sends PUT to the NF instance to register the NEF profile
+// Successful response : 201 or 200 and body contains NFProfile
+func (nrf *nrfClient) register(ctx context.Context) error {
+
+	log.Infof("NRF Register Entered for %s", nrf.profile.NfInstanceID)
+
+	rsp, err := nrf.sb.send(ctx, http.MethodPut, nrf.nfInstanceURI(),
+		"application/json", nrf.profile)
+	if err != nil {
+		return err
+	}
+	if rsp.code != http.StatusCreated && rsp.code != http.StatusOK {
+		return errors.New("NRF Register failure response " +
+			strconv.Itoa(rsp.code))
+	}
+
+	// The NRF may change the heart-beat timer
+	profile := NFProfile{}
+	if len(rsp.body) > 0 && json.Unmarshal(rsp.body, &profile) == nil &&
+		profile.HeartBeatTimer > 0 {
+		nrf.heartBeat = time.Duration(profile.HeartBeatTimer) * time.Second
+	}
+
+	nrf.mu.Lock()
+	nrf.registered = true
+	nrf.mu.Unlock()
+	log.Infof("NRF Register Success. Heart-beat %v", nrf.heartBeat)
+	return nil
+}
+
+// heartBeatUpdate sends PATCH to the NF instance with the NF status. The NEF
+// is registered again if the NRF does not know it.
+// Successful response : 204 or 200 and body contains NFProfile
+func (nrf *nrfClient) heartBeatUpdate(ctx context.Context) error {
+
+	patch := []PatchItem{{Op: "replace", Path: "/nfStatus",
+		Value: NFStatusRegistered}}
+	rsp, err := nrf.sb.send(ctx, http.MethodPatch, nrf.nfInstanceURI(),
+		"application/json-patch+json", patch)
+	if err != nil {
+		return err
+	}
+	switch {
+	case rsp.code == http.StatusNotFound:
+		log.Infof("NRF Heart-beat NF Instance not found, registering")
+		return nrf.register(ctx)
+	case rsp.code >= 300:
+		return errors.New("NRF Heart-beat failure response " +
+			strconv.Itoa(rsp.code))
+	}
+	return nil
+}
+
+// deregister sends DELETE to the NF instance
+// Successful response : 204
+func (nrf *nrfClient) deregister(ctx context.Context) error {
+
+	nrf.mu.Lock()
+	registered := nrf.registered
+	nrf.registered = false
+	nrf.mu.Unlock()
+	if !registered {
+		return nil
+	}
+
+	log.Infof("NRF Deregister Entered for %s", nrf.profile.NfInstanceID)
+
+	rsp, err := nrf.sb.send(ctx, http.MethodDelete, nrf.nfInstanceURI(), "",
+		nil)
+	if err != nil {
+		return err
+	}
+	if rsp.code >= 300 && rsp.code != http.StatusNotFound {
+		return errors.New("NRF Deregister failure response " +
+			strconv.Itoa(rsp.code))
+	}
+	log.Infof("NRF Deregister Success")
+	return nil
+}
+
+// start registers the NEF and sends the heart-beats in a go routine until the
+// context is cancelled. The registration is retried every heart-beat period.
+func (nrf *nrfClient) start(ctx context.Context) {
+
+	nrf.done = make(chan struct{})
+	go func() {
+		defer close(nrf.done)
+		for {
+			nrf.mu.Lock()
+			registered := nrf.registered
+			nrf.mu.Unlock()
+
+			var err error
+			if registered {
+				err = nrf.heartBeatUpdate(ctx)
+			} else {
+				err = nrf.register(ctx)
+			}
+			if err != nil && ctx.Err() == nil {
+				log.Errf("NRF Registration failed: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(nrf.heartBeat):
+			}
+		}
+	}()
+}
+
+// stop waits for the registration go routine to exit and deregisters the
+// NEF. The context given to start shall be cancelled before.
+func (nrf *nrfClient) stop() {
+
+	if nrf.done != nil {
+		<-nrf.done
+	}
+	ctx, cancel := context.WithTimeout(context.Background(),
+		nrfDeregisterTimeout)
+	defer cancel()
+	if err := nrf.deregister(ctx); err != nil {
+		log.Errf("NRF Deregister failed: %v", err)
+	}
+}
+
+// discover returns the API root of an instance of the network function
+// providing the service for the DNN and S-NSSAI (if not empty). The results
+// are cached for the validity period given by the NRF and the instances are
+// used in turn.
+func (nrf *nrfClient) discover(ctx context.Context, nfType NFType,
+	service string, dnn Dnn, snssai Snssai) (string, error) {
+
+	key := string(nfType) + "|" + service + "|" + string(dnn) + "|" +
+		strconv.Itoa(int(snssai.Sst)) + "|" + snssai.Sd
+
+	nrf.mu.Lock()
+	entry, ok := nrf.cache[key]
+	if ok && time.Now().Before(entry.expiry) {
+		apiRoot := entry.apiRoots[entry.next%len(entry.apiRoots)]
+		entry.next++
+		nrf.cache[key] = entry
+		nrf.mu.Unlock()
+		return apiRoot, nil
+	}
+	nrf.mu.Unlock()
+
+	query := url.Values{}
+	query.Set("target-nf-type", string(nfType))
+	query.Set("requester-nf-type", string(NFTypeNEF))
+	query.Set("requester-nf-instance-id", nrf.profile.NfInstanceID)
+	query.Set("service-names", service)
+	if dnn != "" {
+		query.Set("dnn", string(dnn))
+	}
+	if snssai != (Snssai{}) {
+		b, err := json.Marshal([]Snssai{snssai})
+		if err != nil {
+			return "", err
+		}
+		query.Set("snssais", string(b))
+	}
+
+	rsp, err := nrf.sb.send(ctx, http.MethodGet,
+		nrf.sb.apiRoot+nrfDiscoveryPath+"?"+query.Encode(), "", nil)
+	if err != nil {
+		return "", err
+	}
+	if rsp.code != http.StatusOK {
+		return "", errors.New("NRF Discovery failure response " +
+			strconv.Itoa(rsp.code))
+	}
+
+	result := SearchResult{}
+	if err = json.Unmarshal(rsp.body, &result); err != nil {
+		return "", err
+	}
+
+	entry = nrfCacheEntry{expiry: time.Now().Add(nrf.cacheTime), next: 1}
+	if result.ValidityPeriod > 0 {
+		entry.expiry = time.Now().Add(
+			time.Duration(result.ValidityPeriod) * time.Second)
+	}
+	for _, profile := range result.NfInstances {
+		if apiRoot := serviceAPIRoot(profile, service); apiRoot != "" {
+			entry.apiRoots = append(entry.apiRoots, apiRoot)
+		}
+	}
+	if len(entry.apiRoots) == 0 {
+		return "", errors.New("No " + string(nfType) + " instance found")
+	}
+
+	nrf.mu.Lock()
+	nrf.cache[key] = entry
+	nrf.mu.Unlock()
+	log.Infof("NRF Discovery %s: %v", key, entry.apiRoots)
+	return entry.apiRoots[0], nil
+}
+
+// serviceAPIRoot returns the API root of the service provided by the network
+// function instance or an empty string if the service is not available
+func serviceAPIRoot(profile NFProfile, service string) string {
+
+	if profile.NfStatus != "" && profile.NfStatus != NFStatusRegistered {
+		return ""
+	}
+	for _, s := range profile.NfServices {
+		if s.ServiceName != service || (s.NfServiceStatus != "" &&
+			s.NfServiceStatus != NFStatusRegistered) {
+			continue
+		}
+
+		host := s.Fqdn
+		port := 0
+		if len(s.IPEndPoints) > 0 {
+			if host == "" {
+				host = s.IPEndPoints[0].Ipv4Address
+			}
+			port = s.IPEndPoints[0].Port
+		}
+		if host == "" {
+			host = profile.Fqdn
+		}
+		if host == "" && len(profile.Ipv4Addresses) > 0 {
+			host = profile.Ipv4Addresses[0]
+		}
+		if host == "" {
+			return ""
+		}
+		if port != 0 {
+			host = net.JoinHostPort(host, strconv.Itoa(port))
+		}
+
+		scheme := s.Scheme
+		if scheme == "" {
+			scheme = "https"
+		}
+		return scheme + "://" + host + strings.TrimSuffix(s.APIPrefix, "/")
+	}
+	return ""
+}
@@ -0,0 +1,285 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	ngcnef "github.com/open-ness/epcforedge/ngc/pkg/nef"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+const fakeNRFInstances = "/nnrf-nfm/v1/nf-instances/"
+const fakeNRFDiscovery = "/nnrf-disc/v1/nf-instances"
+
+const testNefInstanceID = "8e2ee9e6-5a56-4a1b-9e41-a63c6e0c7e3c"
+
+// fakeNRF is a local NRF server providing the Nnrf_NFManagement and
+// Nnrf_NFDiscovery services over HTTP/2 clear text
+type fakeNRF struct {
+	server *httptest.Server
+	mu     sync.Mutex
+	// registered NF profiles
+	profiles map[string]ngcnef.NFProfile
+	// NF profiles returned by the discovery per target NF type
+	instances  map[string][]ngcnef.NFProfile
+	heartBeats int
+	queries    []url.Values
+}
+
+func newFakeNRF() *fakeNRF {
+
+	nrf := &fakeNRF{profiles: map[string]ngcnef.NFProfile{},
+		instances: map[string][]ngcnef.NFProfile{}}
+	nrf.server = httptest.NewServer(h2c.NewHandler(
+		http.HandlerFunc(nrf.serveHTTP), &http2.Server{}))
+	return nrf
+}
+
+// addInstance adds an instance of the network function providing the service
+// at the API root to the discovery results
+func (nrf *fakeNRF) addInstance(nfType ngcnef.NFType, service string,
+	apiRoot string) {
+
+	u, _ := url.Parse(apiRoot)
+	profile := ngcnef.NFProfile{NfInstanceID: string(nfType) + "-1",
+		NfType: nfType, NfStatus: ngcnef.NFStatusRegistered,
+		NfServices: []ngcnef.NFService{{ServiceInstanceID: "1",
+			ServiceName: service, Scheme: u.Scheme, Fqdn: u.Host,
+			NfServiceStatus: ngcnef.NFStatusRegistered}}}
+
+	nrf.mu.Lock()
+	defer nrf.mu.Unlock()
+	nrf.instances[string(nfType)] = append(nrf.instances[string(nfType)],
+		profile)
+}
+
+func (nrf *fakeNRF) profile(id string) (ngcnef.NFProfile, bool) {
+	nrf.mu.Lock()
+	defer nrf.mu.Unlock()
+	profile, ok := nrf.profiles[id]
+	return profile, ok
+}
+
+func (nrf *fakeNRF) heartBeatCount() int {
+	nrf.mu.Lock()
+	defer nrf.mu.Unlock()
+	return nrf.heartBeats
+}
+
+// discoveries returns the discovery queries for the NF type
+func (nrf *fakeNRF) discoveries(nfType ngcnef.NFType) []url.Values {
+	nrf.mu.Lock()
+	defer nrf.mu.Unlock()
+	queries := []url.Values{}
+	for _, q := range nrf.queries {
+		if q.Get("target-nf-type") == string(nfType) {
+			queries = append(queries, q)
+		}
+	}
+	return queries
+}
+
+func (nrf *fakeNRF) deregisterAll() {
+	nrf.mu.Lock()
+	defer nrf.mu.Unlock()
+	nrf.profiles = map[string]ngcnef.NFProfile{}
+}
+
+func (nrf *fakeNRF) serveHTTP(w http.ResponseWriter, r *http.Request) {
+
+	nrf.mu.Lock()
+	defer nrf.mu.Unlock()
+
+	if r.URL.Path == fakeNRFDiscovery && r.Method == http.MethodGet {
+		nrf.queries = append(nrf.queries, r.URL.Query())
+		result := ngcnef.SearchResult{ValidityPeriod: 60,
+			NfInstances: nrf.instances[r.URL.Query().Get("target-nf-type")]}
+		b, _ := json.Marshal(result)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(b)
+		return
+	}
+
+	if !strings.HasPrefix(r.URL.Path, fakeNRFInstances) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, fakeNRFInstances)
+	_, ok := nrf.profiles[id]
+
+	switch r.Method {
+	case http.MethodPut:
+		profile := ngcnef.NFProfile{}
+		if err := json.NewDecoder(r.Body).Decode(&profile); err != nil ||
+			profile.NfInstanceID != id {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		nrf.profiles[id] = profile
+		b, _ := json.Marshal(profile)
+		w.Header().Set("Content-Type", "application/json")
+		if ok {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusCreated)
+		}
+		_, _ = w.Write(b)
+	case http.MethodPatch:
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		nrf.heartBeats++
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(nrf.profiles, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func createNRFCfg(dir string, nrfRoot string, pcfRoot string) string {
+
+	cfg := map[string]interface{}{
+		"NRFConfig": map[string]interface{}{"apiRoot": nrfRoot,
+			"nfInstanceId": testNefInstanceID, "heartBeatTimer": 1}}
+	if pcfRoot != "" {
+		cfg["PCFConfig"] = sbConfig(pcfRoot)
+	}
+	return createNefCfg(dir, cfg)
+}
+
+var _ = Describe("NEF NRF Client", func() {
+
+	var (
+		nrf *fakeNRF
+		pcf *fakePCF
+		udm *fakeUDM
+		dir string
+	)
+
+	BeforeEach(func() {
+		var err error
+		nrf = newFakeNRF()
+		pcf = newFakePCF()
+		// The UDM translates the GPSI of the single UE subscriptions
+		udm = newFakeUDM()
+		udm.supis["msisdn-1234567890"] = "imsi-634635123456789"
+		nrf.addInstance(ngcnef.NFTypeUDM, "nudm-sdm", udm.server.URL)
+		dir, err = ioutil.TempDir("", "nefnrf")
+		Expect(err).Should(BeNil())
+	})
+
+	AfterEach(func() {
+		nrf.server.Close()
+		pcf.server.Close()
+		udm.server.Close()
+		os.RemoveAll(dir)
+	})
+
+	It("Registers, sends heart-beats and deregisters the NEF", func() {
+
+		_, cancel := startNefWithCfg(createNRFCfg(dir, nrf.server.URL, ""))
+
+		profile, ok := nrf.profile(testNefInstanceID)
+		Expect(ok).Should(BeTrue())
+		Expect(profile.NfType).Should(Equal(ngcnef.NFTypeNEF))
+		Expect(profile.NfStatus).Should(Equal(ngcnef.NFStatusRegistered))
+		Expect(profile.Fqdn).Should(Equal("localhost"))
+		Expect(len(profile.NfServices)).Should(Equal(3))
+		Expect(profile.NfServices[0].ServiceName).Should(
+			Equal("nnef-trafficinfluence"))
+		Expect(profile.NfServices[0].IPEndPoints[0].Port).Should(
+			Equal(8090))
+		Eventually(nrf.heartBeatCount, 3*time.Second).Should(
+			BeNumerically(">", 0))
+
+		// The NEF registers again if the NRF lost the registration
+		nrf.deregisterAll()
+		Eventually(func() bool {
+			_, ok := nrf.profile(testNefInstanceID)
+			return ok
+		}, 3*time.Second).Should(BeTrue())
+
+		cancel()
+		Eventually(func() bool {
+			_, ok := nrf.profile(testNefInstanceID)
+			return ok
+		}, 3*time.Second).Should(BeFalse())
+	})
+
+	It("Discovers the PCF from the NRF", func() {
+
+		nrf.addInstance(ngcnef.NFTypePCF, "npcf-policyauthorization",
+			pcf.server.URL)
+		ctx, cancel := startNefWithCfg(createNRFCfg(dir, nrf.server.URL,
+			""))
+		defer cancel()
+
+		postbody, _ := ioutil.ReadFile(testJSONPath + "AF_NEF_POST_01.json")
+
+		rr, req := CreateReqForNEF(ctx, "POST", "", postbody)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+		rr, req = CreateReqForNEF(ctx, "POST", "", postbody)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+		Expect(pcf.sessions()).Should(Equal(2))
+
+		// The discovery result is cached
+		queries := nrf.discoveries(ngcnef.NFTypePCF)
+		Expect(len(queries)).Should(Equal(1))
+		Expect(queries[0].Get("requester-nf-type")).Should(Equal("NEF"))
+		Expect(queries[0].Get("service-names")).Should(
+			Equal("npcf-policyauthorization"))
+
+		rr, req = CreateReqForNEF(ctx, "DELETE", "11111", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusNoContent))
+		Expect(pcf.sessions()).Should(Equal(1))
+	})
+
+	It("Uses the configured PCF and UDR if none is discovered", func() {
+
+		ctx, cancel := startNefWithCfg(createNRFCfg(dir, nrf.server.URL,
+			pcf.server.URL))
+		defer cancel()
+
+		postbody, _ := ioutil.ReadFile(testJSONPath + "AF_NEF_POST_01.json")
+		rr, req := CreateReqForNEF(ctx, "POST", "", postbody)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+		Expect(pcf.sessions()).Should(Equal(1))
+		Expect(len(nrf.discoveries(ngcnef.NFTypePCF))).Should(Equal(1))
+
+		count := fakeUDRG.influenceData()
+		postbody, _ = ioutil.ReadFile(testJSONPath +
+			"AF_NEF_POST_UDR_01.json")
+		rr, req = CreateReqForNEF(ctx, "POST", "", postbody)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusBadRequest))
+		// The SMF is neither discovered nor configured
+		Expect(fakeUDRG.influenceData()).Should(Equal(count))
+		Expect(len(nrf.discoveries(ngcnef.NFTypeUDR))).Should(
+			BeNumerically(">", 0))
+	})
+})
@@ -0,0 +1,201 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	ngcnef "github.com/open-ness/epcforedge/ngc/pkg/nef"
+	"github.com/open-ness/epcforedge/ngc/pkg/oauth2"
+)
+
+// createOAuth2Cfg creates a configuration with OAuth2 enabled and the OAuth2
+// configuration used to sign and validate the tokens
+func createOAuth2Cfg(dir string) string {
+
+	oauth2Path := filepath.Join(dir, "oauth2.json")
+	Expect(ioutil.WriteFile(oauth2Path,
+		[]byte(`{"signingkey": "TESTKEY", "algorithm": "HS256",
+			"expiration": 600,
+			"clients": [{"clientId": "AF_01", "clientSecret": "TESTSECRET",
+			"scopes": ["3gpp-traffic-influence"]}]}`),
+		0600)).Should(BeNil())
+	oauth2.CfgPath = oauth2Path
+
+	return createNefCfg(dir, map[string]interface{}{"OAuth2Support": true})
+}
+
+var _ = Describe("Test NEF OAuth2 Authorization", func() {
+
+	var (
+		dir        string
+		oauth2Path string
+	)
+
+	// token returns a token of the AF granting the scopes
+	token := func(afID string, scopes ...string) string {
+		t, err := oauth2.GetNEFAccessTokenFromNRF(oauth2.AccessTokenReq{
+			NfInstanceID: afID, Scope: strings.Join(scopes, " ")})
+		Expect(err).Should(BeNil())
+		return "Bearer " + t
+	}
+
+	// problem returns the problem details of the response
+	problem := func(body []byte) ngcnef.ProblemDetails {
+		var pd ngcnef.ProblemDetails
+		Expect(json.Unmarshal(body, &pd)).Should(BeNil())
+		return pd
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "nefoauth2")
+		Expect(err).Should(BeNil())
+		oauth2Path = oauth2.CfgPath
+	})
+
+	AfterEach(func() {
+		oauth2.CfgPath = oauth2Path
+		os.RemoveAll(dir)
+	})
+
+	It("Enforces the scope of the API and the AF of the token", func() {
+
+		ctx, cancel := startNefWithCfg(createOAuth2Cfg(dir))
+		defer cancel()
+
+		rr, req := CreateReqForNEF(ctx, "GET", "", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusUnauthorized))
+
+		rr, req = CreateReqForNEF(ctx, "GET", "", nil)
+		req.Header.Set("Authorization", "Bearer invalid.token.value")
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).ShouldNot(Equal(http.StatusOK))
+
+		rr, req = CreateReqForNEF(ctx, "GET", "", nil)
+		req.Header.Set("Authorization",
+			token("AF_01", oauth2.ScopeTrafficInfluence))
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+
+		// Missing scope of the traffic influence API
+		rr, req = CreateReqForNEF(ctx, "GET", "", nil)
+		req.Header.Set("Authorization",
+			token("AF_01", oauth2.ScopePfdManagement))
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusForbidden))
+		Expect(problem(rr.Body.Bytes()).Title).Should(
+			Equal("Insufficient scope"))
+		Expect(rr.Header().Get("WWW-Authenticate")).Should(
+			ContainSubstring(`error="insufficient_scope"`))
+
+		// Token of another AF
+		rr, req = CreateReqForNEF(ctx, "GET", "", nil)
+		req.Header.Set("Authorization",
+			token("AF_02", oauth2.ScopeTrafficInfluence))
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusForbidden))
+		Expect(problem(rr.Body.Bytes()).Title).Should(
+			Equal("AF not authorized"))
+
+		// The PFD management API requires its own scope
+		rr, req = CreatePFDReqForNEF(ctx, "GET", "", "", nil)
+		req.Header.Set("Authorization",
+			token("AF_01", oauth2.ScopeTrafficInfluence))
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusForbidden))
+
+		rr, req = CreatePFDReqForNEF(ctx, "GET", "", "", nil)
+		req.Header.Set("Authorization", token("AF_01", oauth2.AFScopes...))
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+
+		// The admin API is not granted to the AFs
+		rr, req = CreateGeoZoneReqForNEF(ctx, "GET", "", nil)
+		req.Header.Set("Authorization", token("AF_01", oauth2.AFScopes...))
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusForbidden))
+
+		rr, req = CreateGeoZoneReqForNEF(ctx, "GET", "", nil)
+		req.Header.Set("Authorization",
+			token("operator", oauth2.ScopeNefAdmin))
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+	})
+
+	It("Refuses the notifications of the 5GC to the AFs", func() {
+
+		ctx, cancel := startNefWithCfg(createOAuth2Cfg(dir))
+		defer cancel()
+
+		// notify sends an empty notification with the token to the path
+		notify := func(path string, auth string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("POST", "http://localhost:8091"+path,
+				strings.NewReader("{}"))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", auth)
+			rr := httptest.NewRecorder()
+			ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+			return rr
+		}
+
+		for _, path := range []string{
+			"/3gpp-traffic-influence/v1/notification/upf",
+			"/3gpp-monitoring-event/v1/notification/udm/1"} {
+
+			// Traffic influence token of an AF
+			rr := notify(path, token("AF_01", oauth2.AFScopes...))
+			Expect(rr.Code).Should(Equal(http.StatusForbidden))
+			Expect(problem(rr.Body.Bytes()).Title).Should(
+				Equal("Insufficient scope"))
+
+			// The AF clients are refused even with the 5GC scope
+			rr = notify(path, token("AF_01", oauth2.Scope5gcNotification))
+			Expect(rr.Code).Should(Equal(http.StatusForbidden))
+			Expect(problem(rr.Body.Bytes()).Title).Should(
+				Equal("AF not authorized"))
+
+			rr = notify(path, token("SMF", oauth2.Scope5gcNotification))
+			Expect(rr.Code).ShouldNot(Equal(http.StatusForbidden))
+			Expect(rr.Code).ShouldNot(Equal(http.StatusUnauthorized))
+		}
+	})
+
+	It("Issues the AF tokens at the token endpoint", func() {
+
+		ctx, cancel := startNefWithCfg(createOAuth2Cfg(dir))
+		defer cancel()
+
+		form := url.Values{"grant_type": {"client_credentials"},
+			"nfInstanceId": {"AF_01"},
+			"scope":        {oauth2.ScopeTrafficInfluence}}
+		req, _ := http.NewRequest("POST",
+			"http://localhost:8091"+oauth2.TokenURLPath,
+			strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth("AF_01", "TESTSECRET")
+		rr := httptest.NewRecorder()
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+		var rsp oauth2.AccessTokenRsp
+		Expect(json.Unmarshal(rr.Body.Bytes(), &rsp)).Should(BeNil())
+		Expect(rsp.ExpiresIn).Should(Equal(int64(600)))
+
+		rr, req = CreateReqForNEF(ctx, "GET", "", nil)
+		req.Header.Set("Authorization", "Bearer "+rsp.AccessToken)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+	})
+})
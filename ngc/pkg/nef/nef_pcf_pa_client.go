@@ -10,12 +10,15 @@ import (
 	"context"
 	"math/rand"
 	"strconv"
+	"sync"
 )
 
 // PcfClientStub is an implementation of the Pcf Authorization
 type PcfClientStub struct {
 	pcf       string
 	initialID int
+	// mu guards the database as requests are handled concurrently
+	mu sync.Mutex
 	// database to store the contents of the app session contexts created
 	paDb map[int]AppSessionContext
 }
@@ -57,6 +60,8 @@ func (pcf *PcfClientStub) PolicyAuthorizationCreate(ctx context.Context,
 
 	log.Infof("PCFs PolicyAuthorizationCreate Entered")
 	_ = ctx
+	pcf.mu.Lock()
+	defer pcf.mu.Unlock()
 
 	var err error
 	pcfPr := PcfPolicyResponse{}
@@ -86,6 +91,8 @@ func (pcf *PcfClientStub) PolicyAuthorizationUpdate(ctx context.Context,
 	log.Infof("PCFs PolicyAuthorizationUpdate Entered for AppSessionID %s",
 		string(appSessionID))
 	_ = ctx
+	pcf.mu.Lock()
+	defer pcf.mu.Unlock()
 
 	var err error
 	pcfPr := PcfPolicyResponse{}
@@ -122,6 +129,8 @@ func (pcf *PcfClientStub) PolicyAuthorizationDelete(ctx context.Context,
 	log.Infof("PCFs PolicyAuthorizationDelete Entered for AppSessionID %s",
 		string(appSessionID))
 	_ = ctx
+	pcf.mu.Lock()
+	defer pcf.mu.Unlock()
 
 	var err error
 	pcfPr := PcfPolicyResponse{}
@@ -154,6 +163,8 @@ func (pcf *PcfClientStub) PolicyAuthorizationGet(ctx context.Context,
 	log.Infof("PCFs PolicyAuthorizationGet Entered for AppSessionID %s",
 		string(appSessionID))
 	_ = ctx
+	pcf.mu.Lock()
+	defer pcf.mu.Unlock()
 
 	var err error
 	pcfPr := PcfPolicyResponse{}
@@ -0,0 +1,173 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+/* Client implementation of the Npcf_PolicyAuthorization service
+(3GPP TS 29.514) */
+
+package ngcnef
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+const pcfAppSessionsPath = "/npcf-policyauthorization/v1/app-sessions"
+
+// PcfClient is an HTTP/2 implementation of the Pcf Authorization
+type PcfClient struct {
+	sb *sbClient
+}
+
+// NewPCFHTTPClient creates a new PCF Client sending the requests to the
+// PCF configured in PCFConfig
+func NewPCFHTTPClient(cfg *Config) (*PcfClient, error) {
+
+	sb, err := newSBClient(&cfg.PCFConfig, cfg.UserAgent)
+	if err != nil {
+		log.Errf("PCF Client creation failed: %v", err)
+		return nil, err
+	}
+	log.Infof("PCF Client created for %s", sb.apiRoot)
+	return &PcfClient{sb: sb}, nil
+}
+
+// appSessionURI returns the uri of the app session resource on the PCF
+// instance where it was created
+func (pcf *PcfClient) appSessionURI(ctx context.Context,
+	appSessionID AppSessionID) (string, error) {
+
+	apiRoot, err := pcf.sb.resourceRoot(ctx, string(appSessionID))
+	if err != nil {
+		return "", err
+	}
+	return apiRoot + pcfAppSessionsPath + "/" +
+		url.PathEscape(string(appSessionID)), nil
+}
+
+// policyResponse converts the response received from the PCF. The
+// AppSessionContext is only decoded for the success code provided.
+func policyResponse(rsp sbResponse, err error,
+	successCode int) (PcfPolicyResponse, error) {
+
+	pcfPr := PcfPolicyResponse{}
+	if err != nil {
+		log.Errf("PCF request failed: %v", err)
+		pcfPr.ResponseCode = http.StatusServiceUnavailable
+		pcfPr.Pd = sbUnreachableProblem("PCF", err)
+		return pcfPr, err
+	}
+
+	pcfPr.ResponseCode = uint16(rsp.code)
+	if rsp.code >= 300 {
+		pcfPr.Pd = sbProblemDetails(rsp)
+		return pcfPr, nil
+	}
+
+	if rsp.code == successCode && len(rsp.body) > 0 {
+		asc := AppSessionContext{}
+		if err = json.Unmarshal(rsp.body, &asc); err != nil {
+			log.Errf("PCF response decode failed: %v", err)
+			pcfPr.ResponseCode = http.StatusInternalServerError
+			pcfPr.Pd = &ProblemDetails{Title: "Invalid PCF response",
+				Status: http.StatusInternalServerError}
+			return pcfPr, err
+		}
+		pcfPr.Asc = &asc
+	}
+	return pcfPr, nil
+}
+
+// PolicyAuthorizationCreate sends POST to the app-sessions collection
+// Successful response : 201, Location header and body contains
+// AppSessionContext
+func (pcf *PcfClient) PolicyAuthorizationCreate(ctx context.Context,
+	body AppSessionContext) (AppSessionID, PcfPolicyResponse, error) {
+
+	log.Infof("PCF PolicyAuthorizationCreate Entered")
+
+	apiRoot, err := pcf.sb.root(ctx, body.AscReqData.Dnn,
+		body.AscReqData.SliceInfo)
+	if err != nil {
+		pcfPr, err := policyResponse(sbResponse{}, err, http.StatusCreated)
+		return "", pcfPr, err
+	}
+	rsp, err := pcf.sb.send(ctx, http.MethodPost,
+		apiRoot+pcfAppSessionsPath,
+		"application/json", body)
+	pcfPr, err := policyResponse(rsp, err, http.StatusCreated)
+	if err != nil || pcfPr.ResponseCode != http.StatusCreated {
+		return "", pcfPr, err
+	}
+
+	id, err := sbResourceID(rsp, pcfAppSessionsPath+"/")
+	if err != nil {
+		log.Errf("PCF PolicyAuthorizationCreate: %v", err)
+		pcfPr.ResponseCode = http.StatusInternalServerError
+		pcfPr.Pd = &ProblemDetails{Title: "Invalid PCF response",
+			Detail: err.Error(), Status: http.StatusInternalServerError}
+		return "", pcfPr, err
+	}
+
+	pcf.sb.setResourceRoot(id, apiRoot)
+	log.Infof("PCF PolicyAuthorizationCreate Exited successfully with "+
+		"sessid: %s", id)
+	return AppSessionID(id), pcfPr, nil
+}
+
+// PolicyAuthorizationUpdate sends PATCH to the app session
+// Successful response : 200 and body contains AppSessionContext or 204
+func (pcf *PcfClient) PolicyAuthorizationUpdate(ctx context.Context,
+	body AppSessionContextUpdateData,
+	appSessionID AppSessionID) (PcfPolicyResponse, error) {
+
+	log.Infof("PCF PolicyAuthorizationUpdate Entered for AppSessionID %s",
+		string(appSessionID))
+
+	uri, err := pcf.appSessionURI(ctx, appSessionID)
+	if err != nil {
+		return policyResponse(sbResponse{}, err, http.StatusOK)
+	}
+	rsp, err := pcf.sb.send(ctx, http.MethodPatch, uri,
+		"application/merge-patch+json", body)
+	return policyResponse(rsp, err, http.StatusOK)
+}
+
+// PolicyAuthorizationDelete sends POST to the delete custom operation of the
+// app session
+// Successful response : 204 and empty body or 200
+func (pcf *PcfClient) PolicyAuthorizationDelete(ctx context.Context,
+	appSessionID AppSessionID) (PcfPolicyResponse, error) {
+
+	log.Infof("PCF PolicyAuthorizationDelete Entered for AppSessionID %s",
+		string(appSessionID))
+
+	uri, err := pcf.appSessionURI(ctx, appSessionID)
+	if err != nil {
+		return policyResponse(sbResponse{}, err, 0)
+	}
+	rsp, err := pcf.sb.send(ctx, http.MethodPost, uri+"/delete", "", nil)
+	if err == nil && (rsp.code < 300 || rsp.code == http.StatusNotFound) {
+		pcf.sb.deleteResourceRoot(string(appSessionID))
+	}
+	// 200 contains EventsNotification which is not used
+	return policyResponse(rsp, err, 0)
+}
+
+// PolicyAuthorizationGet sends GET to the app session
+// Successful response : 200 and body contains AppSessionContext
+func (pcf *PcfClient) PolicyAuthorizationGet(ctx context.Context,
+	appSessionID AppSessionID) (PcfPolicyResponse, error) {
+
+	log.Infof("PCF PolicyAuthorizationGet Entered for AppSessionID %s",
+		string(appSessionID))
+
+	uri, err := pcf.appSessionURI(ctx, appSessionID)
+	if err != nil {
+		return policyResponse(sbResponse{}, err, http.StatusOK)
+	}
+	rsp, err := pcf.sb.send(ctx, http.MethodGet, uri, "", nil)
+	return policyResponse(rsp, err, http.StatusOK)
+}
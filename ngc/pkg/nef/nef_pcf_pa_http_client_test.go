@@ -0,0 +1,239 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	ngcnef "github.com/open-ness/epcforedge/ngc/pkg/nef"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+const fakePCFAppSessions = "/npcf-policyauthorization/v1/app-sessions"
+
+// fakePCF is a local Npcf_PolicyAuthorization server used for testing the
+// PCF client over HTTP/2 clear text
+type fakePCF struct {
+	server *httptest.Server
+	mu     sync.Mutex
+	nextID int
+	// non HTTP/2 requests received
+	http1 int
+	ascs  map[string]ngcnef.AppSessionContext
+}
+
+func newFakePCF() *fakePCF {
+
+	pcf := &fakePCF{nextID: 1, ascs: map[string]ngcnef.AppSessionContext{}}
+	pcf.server = httptest.NewServer(h2c.NewHandler(
+		http.HandlerFunc(pcf.serveHTTP), &http2.Server{}))
+	return pcf
+}
+
+func (pcf *fakePCF) sessions() int {
+	pcf.mu.Lock()
+	defer pcf.mu.Unlock()
+	return len(pcf.ascs)
+}
+
+// appSessions returns the app session contexts created in the PCF
+func (pcf *fakePCF) appSessions() []ngcnef.AppSessionContext {
+	pcf.mu.Lock()
+	defer pcf.mu.Unlock()
+	ascs := []ngcnef.AppSessionContext{}
+	for _, asc := range pcf.ascs {
+		ascs = append(ascs, asc)
+	}
+	return ascs
+}
+
+func (pcf *fakePCF) serveHTTP(w http.ResponseWriter, r *http.Request) {
+
+	pcf.mu.Lock()
+	defer pcf.mu.Unlock()
+
+	if r.ProtoMajor != 2 {
+		pcf.http1++
+	}
+
+	if r.URL.Path == fakePCFAppSessions && r.Method == http.MethodPost {
+		asc := ngcnef.AppSessionContext{}
+		if err := json.NewDecoder(r.Body).Decode(&asc); err != nil {
+			problem(w, http.StatusBadRequest, "")
+			return
+		}
+		id := strconv.Itoa(pcf.nextID)
+		pcf.nextID++
+		pcf.ascs[id] = asc
+		w.Header().Set("Location",
+			pcf.server.URL+fakePCFAppSessions+"/"+id)
+		writeJSON(w, http.StatusCreated, "application/json", asc)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, fakePCFAppSessions+"/")
+	id := strings.TrimSuffix(path, "/delete")
+	asc, ok := pcf.ascs[id]
+	if !ok {
+		problem(w, http.StatusNotFound,
+			"APPLICATION_SESSION_CONTEXT_NOT_FOUND")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, "application/json", asc)
+	case r.Method == http.MethodPatch:
+		upd := ngcnef.AppSessionContextUpdateData{}
+		if r.Header.Get("Content-Type") != "application/merge-patch+json" ||
+			json.NewDecoder(r.Body).Decode(&upd) != nil {
+			problem(w, http.StatusBadRequest, "")
+			return
+		}
+		asc.AscReqData.AfRoutReq = upd.AfRoutReq
+		if upd.MedComponents != nil {
+			asc.AscReqData.MedComponents = upd.MedComponents
+		}
+		pcf.ascs[id] = asc
+		writeJSON(w, http.StatusOK, "application/json", asc)
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/delete"):
+		delete(pcf.ascs, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// createPCFCfg writes a copy of the valid NEF configuration using the PCF
+// at apiRoot and returns its path
+func createPCFCfg(dir string, apiRoot string) string {
+	return createNefCfg(dir, map[string]interface{}{
+		"PCFConfig": sbConfig(apiRoot)})
+}
+
+var _ = Describe("NEF PCF Client", func() {
+
+	var (
+		pcf *fakePCF
+		cfg ngcnef.Config
+	)
+
+	BeforeEach(func() {
+		pcf = newFakePCF()
+		cfg = ngcnef.Config{UserAgent: "NEF-OPENNESS-1912"}
+		cfg.PCFConfig.APIRoot = pcf.server.URL
+	})
+
+	AfterEach(func() {
+		pcf.server.Close()
+	})
+
+	It("Create, get, update and delete an app session", func() {
+
+		client, err := ngcnef.NewPCFHTTPClient(&cfg)
+		Expect(err).Should(BeNil())
+
+		asc := ngcnef.AppSessionContext{}
+		asc.AscReqData.AfAppID = "app1"
+		asc.AscReqData.SuppFeat = "1"
+		ctx := context.Background()
+
+		id, rsp, err := client.PolicyAuthorizationCreate(ctx, asc)
+		Expect(err).Should(BeNil())
+		Expect(rsp.ResponseCode).Should(Equal(uint16(http.StatusCreated)))
+		Expect(id).Should(Equal(ngcnef.AppSessionID("1")))
+		Expect(rsp.Asc.AscReqData.AfAppID).Should(Equal(ngcnef.AfAppID("app1")))
+
+		rsp, err = client.PolicyAuthorizationGet(ctx, id)
+		Expect(err).Should(BeNil())
+		Expect(rsp.ResponseCode).Should(Equal(uint16(http.StatusOK)))
+		Expect(rsp.Asc).ShouldNot(BeNil())
+
+		upd := ngcnef.AppSessionContextUpdateData{}
+		upd.AfRoutReq.AppReloc = true
+		rsp, err = client.PolicyAuthorizationUpdate(ctx, upd, id)
+		Expect(err).Should(BeNil())
+		Expect(rsp.ResponseCode).Should(Equal(uint16(http.StatusOK)))
+		Expect(rsp.Asc.AscReqData.AfRoutReq.AppReloc).Should(BeTrue())
+
+		rsp, err = client.PolicyAuthorizationDelete(ctx, id)
+		Expect(err).Should(BeNil())
+		Expect(rsp.ResponseCode).Should(Equal(uint16(http.StatusNoContent)))
+		Expect(pcf.sessions()).Should(Equal(0))
+		Expect(pcf.http1).Should(Equal(0))
+	})
+
+	It("Maps the ProblemDetails of a failure response", func() {
+
+		client, err := ngcnef.NewPCFHTTPClient(&cfg)
+		Expect(err).Should(BeNil())
+
+		rsp, err := client.PolicyAuthorizationGet(context.Background(), "10")
+		Expect(err).Should(BeNil())
+		Expect(rsp.ResponseCode).Should(Equal(uint16(http.StatusNotFound)))
+		Expect(rsp.Pd).ShouldNot(BeNil())
+		Expect(rsp.Pd.Cause).Should(
+			Equal("APPLICATION_SESSION_CONTEXT_NOT_FOUND"))
+	})
+
+	It("Returns an error when the PCF is not reachable", func() {
+
+		client, err := ngcnef.NewPCFHTTPClient(&cfg)
+		Expect(err).Should(BeNil())
+		pcf.server.Close()
+
+		_, rsp, err := client.PolicyAuthorizationCreate(context.Background(),
+			ngcnef.AppSessionContext{})
+		Expect(err).ShouldNot(BeNil())
+		Expect(rsp.ResponseCode).Should(
+			Equal(uint16(http.StatusServiceUnavailable)))
+	})
+
+	It("Fails for an unsupported url scheme", func() {
+
+		cfg.PCFConfig.APIRoot = "ftp://localhost"
+		_, err := ngcnef.NewPCFHTTPClient(&cfg)
+		Expect(err).ShouldNot(BeNil())
+	})
+
+	It("Traffic influence subscription for a single UE uses the PCF", func() {
+
+		dir, err := ioutil.TempDir("", "nefpcf")
+		Expect(err).Should(BeNil())
+		defer os.RemoveAll(dir)
+
+		ctx, cancel := startNefWithCfg(createPCFCfg(dir, pcf.server.URL))
+		defer cancel()
+
+		postbody, _ := ioutil.ReadFile(testJSONPath + "AF_NEF_POST_01.json")
+		patchbody, _ := ioutil.ReadFile(testJSONPath +
+			"AF_NEF_PATCH_01.json")
+
+		rr, req := CreateReqForNEF(ctx, "POST", "", postbody)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+		Expect(pcf.sessions()).Should(Equal(1))
+
+		rr, req = CreateReqForNEF(ctx, "PATCH", "11111", patchbody)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+
+		rr, req = CreateReqForNEF(ctx, "DELETE", "11111", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusNoContent))
+		Expect(pcf.sessions()).Should(Equal(0))
+	})
+})
@@ -0,0 +1,188 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef
+
+import "time"
+
+// pfdCacheTimer is the caching time timer of a PFD application
+type pfdCacheTimer struct {
+	timer *time.Timer
+	// Time at which the caching time elapses, saved in the store
+	expiry time.Time
+}
+
+// pfdShortDelay returns true and adds the application to the SHORT_DELAY PFD
+// report if its allowedDelay is below the configured minimum. The caching
+// time of the report is the minimum delay the NEF can meet.
+func pfdShortDelay(nefCtx *nefContext, appID string, pfdData PfdData,
+	pfdReports map[string]PfdReport) bool {
+
+	minDelay := nefCtx.liveConfig().PfdConfig.MinAllowedDelay
+	if minDelay <= 0 || pfdData.AllowedDelay == nil ||
+		*pfdData.AllowedDelay >= DurationSecRm(minDelay) {
+		return false
+	}
+
+	log.Infof("Allowed delay %d of application %s is below %d seconds",
+		*pfdData.AllowedDelay, appID, minDelay)
+	generatePfdReport(appID, "SHORT_DELAY", pfdReports)
+	report := pfdReports["SHORT_DELAY"]
+	cachingTime := DurationSec(minDelay)
+	report.CachingTime = &cachingTime
+	pfdReports["SHORT_DELAY"] = report
+	return true
+}
+
+// afSchedulePfdExpiry arms the caching time timer of the application, the
+// PFDs are removed when it fires unless the application is updated before.
+// The caller must hold af.mu and save the transaction afterwards.
+func (af *afData) afSchedulePfdExpiry(nefCtx *nefContext,
+	trans *afPfdTransaction, appID string) {
+
+	af.afStopPfdExpiry(trans, appID)
+
+	app, ok := trans.pfdManagement.PfdDatas[appID]
+	if !ok || app.CachingTime == nil || *app.CachingTime == 0 {
+		return
+	}
+	af.afArmPfdExpiry(nefCtx, trans, appID,
+		time.Now().Add(time.Duration(*app.CachingTime)*time.Second))
+}
+
+// afArmPfdExpiry arms the caching time timer of the application to fire at
+// expiry, at once if it has passed. The caller must hold af.mu.
+func (af *afData) afArmPfdExpiry(nefCtx *nefContext,
+	trans *afPfdTransaction, appID string, expiry time.Time) {
+
+	af.afStopPfdExpiry(trans, appID)
+
+	if trans.cacheTimers == nil {
+		trans.cacheTimers = make(map[string]*pfdCacheTimer)
+	}
+
+	ct := &pfdCacheTimer{expiry: expiry}
+	transID := trans.transID
+	ct.timer = time.AfterFunc(time.Until(expiry), func() {
+		af.afPfdExpired(nefCtx, transID, appID, ct)
+	})
+	trans.cacheTimers[appID] = ct
+}
+
+// pfdCacheExpiries returns the caching time expiries of the applications of
+// the PFD transaction, the caller must hold af.mu
+func (trans *afPfdTransaction) pfdCacheExpiries() map[string]time.Time {
+
+	if len(trans.cacheTimers) == 0 {
+		return nil
+	}
+	expiries := make(map[string]time.Time, len(trans.cacheTimers))
+	for appID, ct := range trans.cacheTimers {
+		expiries[appID] = ct.expiry
+	}
+	return expiries
+}
+
+// afStopPfdExpiry stops the caching time timer of the application, the caller
+// must hold af.mu
+func (af *afData) afStopPfdExpiry(trans *afPfdTransaction, appID string) {
+
+	if ct, ok := trans.cacheTimers[appID]; ok {
+		ct.timer.Stop()
+		delete(trans.cacheTimers, appID)
+	}
+}
+
+// afStopPfdExpiries stops the caching time timers of all the applications of
+// the PFD transaction, the caller must hold af.mu
+func (af *afData) afStopPfdExpiries(trans *afPfdTransaction) {
+
+	for appID := range trans.cacheTimers {
+		af.afStopPfdExpiry(trans, appID)
+	}
+}
+
+// afPfdExpired removes the PFDs of the application from the UDR and the PFD
+// transaction when its caching time elapses and reports it to the AF
+func (af *afData) afPfdExpired(nefCtx *nefContext, transID string,
+	appID string, ct *pfdCacheTimer) {
+
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
+	trans, ok := af.pfdtrans[transID]
+	// A timer already fired waiting for af.mu is ignored
+	if af.deleted || !ok || trans.cacheTimers[appID] != ct ||
+		nefCtx.nef.ctx.Err() != nil {
+		return
+	}
+	delete(trans.cacheTimers, appID)
+
+	app, ok := trans.pfdManagement.PfdDatas[appID]
+	if !ok {
+		return
+	}
+	log.Infof("Caching time of PFD application %s expired", appID)
+
+	_, err := nefSBUDRAPPPFDDelete(trans, nefCtx,
+		ApplicationID(app.ExternalAppID))
+	if err != nil {
+		log.Errf("Failed to delete expired PFD application %s from the "+
+			"UDR: %v", appID, err)
+	}
+	af.afRemovePfdApp(nefCtx, trans, appID)
+
+	dest := trans.pfdManagement.NotificationDestination
+	if dest != "" {
+		nefCtx.nef.afNotifier.dispatchPfdReports(URI(dest), []PfdReport{{
+			ExternalAppIds: []string{appID}, FailureCode: PfdExpired}})
+	}
+}
+
+// nefStartPfdExpiry arms the caching time timers of the PFD applications
+// restored from the store for the rest of their caching time. The ones saved
+// without expiry start their caching time again and are saved with it.
+func (nef *nefData) nefStartPfdExpiry(nefCtx *nefContext) {
+
+	nef.mu.RLock()
+	defer nef.mu.RUnlock()
+
+	for _, af := range nef.afs {
+		af.mu.Lock()
+		for _, trans := range af.pfdtrans {
+			rearmed := false
+			for appID := range trans.pfdManagement.PfdDatas {
+				expiry, ok := trans.restoredExpiries[appID]
+				if ok {
+					af.afArmPfdExpiry(nefCtx, trans, appID, expiry)
+					continue
+				}
+				af.afSchedulePfdExpiry(nefCtx, trans, appID)
+				if _, ok = trans.cacheTimers[appID]; ok {
+					rearmed = true
+				}
+			}
+			trans.restoredExpiries = nil
+			if rearmed {
+				nef.nefSavePfdTrans(af, trans)
+			}
+		}
+		af.mu.Unlock()
+	}
+}
+
+// nefStopPfdExpiry stops the caching time timers of all the PFD applications
+func (nef *nefData) nefStopPfdExpiry() {
+
+	nef.mu.RLock()
+	defer nef.mu.RUnlock()
+
+	for _, af := range nef.afs {
+		af.mu.Lock()
+		for _, trans := range af.pfdtrans {
+			af.afStopPfdExpiries(trans)
+		}
+		af.mu.Unlock()
+	}
+}
@@ -0,0 +1,192 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	ngcnef "github.com/open-ness/epcforedge/ngc/pkg/nef"
+)
+
+// createPfdCfg creates a configuration with the minimum allowed delay of the
+// PFDs
+func createPfdCfg(dir string, minAllowedDelay int) string {
+	return createNefCfg(dir, map[string]interface{}{
+		"PfdConfig": map[string]int{"minAllowedDelay": minAllowedDelay}})
+}
+
+var _ = Describe("Test NEF PFD Caching Time and Allowed Delay", func() {
+
+	var dir string
+
+	postbody, _ := ioutil.ReadFile(testJSONPFDPath +
+		"AF_NEF_PFD_POST_001.json")
+	putappbody, _ := ioutil.ReadFile(testJSONPFDPath +
+		"AF_NEF_PFD_APP_PUT_001.json")
+
+	pfdTrans := func() ngcnef.PfdManagement {
+		var trans ngcnef.PfdManagement
+		Expect(json.Unmarshal(postbody, &trans)).Should(BeNil())
+		return trans
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "nefpfdcaching")
+		Expect(err).Should(BeNil())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("Rejects the applications with a too short allowed delay", func() {
+
+		ctx, cancel := startNefWithCfg(createPfdCfg(dir, 10))
+		defer cancel()
+
+		short := ngcnef.DurationSecRm(5)
+		long := ngcnef.DurationSecRm(20)
+		trans := pfdTrans()
+		app1 := trans.PfdDatas["app1"]
+		app1.AllowedDelay = &short
+		trans.PfdDatas["app1"] = app1
+		app2 := trans.PfdDatas["app2"]
+		app2.AllowedDelay = &long
+		trans.PfdDatas["app2"] = app2
+		b, _ := json.Marshal(trans)
+
+		rr, req := CreatePFDReqForNEF(ctx, "POST", "", "", b)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+
+		var rsp ngcnef.PfdManagement
+		Expect(json.Unmarshal(rr.Body.Bytes(), &rsp)).Should(BeNil())
+		Expect(rsp.PfdDatas).Should(HaveKey("app2"))
+		Expect(rsp.PfdDatas).ShouldNot(HaveKey("app1"))
+		report := rsp.PfdReports["SHORT_DELAY"]
+		Expect(report.ExternalAppIds).Should(Equal([]string{"app1"}))
+		Expect(report.FailureCode).Should(
+			Equal(ngcnef.FailureCode(ngcnef.ShortDelay)))
+		Expect(*report.CachingTime).Should(Equal(ngcnef.DurationSec(10)))
+
+		// Same for the update of a single application
+		var app ngcnef.PfdData
+		Expect(json.Unmarshal(putappbody, &app)).Should(BeNil())
+		app.ExternalAppID = "app2"
+		app.AllowedDelay = &short
+		b, _ = json.Marshal(app)
+		rr, req = CreatePFDReqForNEF(ctx, "PUT", "10000", "app2", b)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusInternalServerError))
+		var appReport ngcnef.PfdReport
+		Expect(json.Unmarshal(rr.Body.Bytes(), &appReport)).Should(BeNil())
+		Expect(appReport.ExternalAppIds).Should(Equal([]string{"app2"}))
+
+		rr, req = CreatePFDReqForNEF(ctx, "DELETE", "10000", "", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusNoContent))
+	})
+
+	It("Removes the PFDs after their caching time", func() {
+
+		af := newFakeAF(http.StatusNoContent)
+		defer af.server.Close()
+
+		ctx, cancel := startNefWithCfg(createPfdCfg(dir, 0))
+		defer cancel()
+
+		cachingTime := ngcnef.DurationSecRo(1)
+		trans := pfdTrans()
+		trans.NotificationDestination = ngcnef.Link(af.server.URL)
+		app1 := trans.PfdDatas["app1"]
+		app1.CachingTime = &cachingTime
+		trans.PfdDatas["app1"] = app1
+		app2 := trans.PfdDatas["app2"]
+		app2.CachingTime = nil
+		trans.PfdDatas["app2"] = app2
+		b, _ := json.Marshal(trans)
+
+		rr, req := CreatePFDReqForNEF(ctx, "POST", "", "", b)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+
+		Eventually(af.received, 3*time.Second,
+			10*time.Millisecond).Should(Equal(1))
+		var reports []ngcnef.PfdReport
+		af.mu.Lock()
+		Expect(json.Unmarshal(af.bodies[0], &reports)).Should(BeNil())
+		af.mu.Unlock()
+		Expect(reports).Should(Equal([]ngcnef.PfdReport{{
+			ExternalAppIds: []string{"app1"},
+			FailureCode:    ngcnef.PfdExpired}}))
+
+		rr, req = CreatePFDReqForNEF(ctx, "GET", "10000", "app1", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusNotFound))
+		rr, req = CreatePFDReqForNEF(ctx, "GET", "10000", "app2", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+
+		rr, req = CreatePFDReqForNEF(ctx, "DELETE", "10000", "", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusNoContent))
+	})
+
+	It("Keeps the caching time of the restored PFDs", func() {
+
+		af := newFakeAF(http.StatusNoContent)
+		defer af.server.Close()
+
+		cfgPath := createNefCfg(dir, map[string]interface{}{
+			"PfdConfig": map[string]int{"minAllowedDelay": 0},
+			"StoreConfig": map[string]string{
+				"path": filepath.Join(dir, "nef.journal")}})
+		ctx, cancel := startNefWithCfg(cfgPath)
+
+		cachingTime := ngcnef.DurationSecRo(3)
+		trans := pfdTrans()
+		trans.NotificationDestination = ngcnef.Link(af.server.URL)
+		app1 := trans.PfdDatas["app1"]
+		app1.CachingTime = &cachingTime
+		trans.PfdDatas["app1"] = app1
+		app2 := trans.PfdDatas["app2"]
+		app2.CachingTime = nil
+		trans.PfdDatas["app2"] = app2
+		b, _ := json.Marshal(trans)
+
+		rr, req := CreatePFDReqForNEF(ctx, "POST", "", "", b)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+
+		// The caching time elapses while the NEF is stopped
+		cancel()
+		time.Sleep(3 * time.Second)
+		Expect(af.received()).Should(Equal(0))
+
+		// Expired at once, not after another caching time
+		ctx, cancel = startNefWithCfg(cfgPath)
+		defer cancel()
+
+		Expect(af.received()).Should(Equal(1))
+		rr, req = CreatePFDReqForNEF(ctx, "GET", "10000", "app1", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusNotFound))
+		rr, req = CreatePFDReqForNEF(ctx, "GET", "10000", "app2", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+
+		rr, req = CreatePFDReqForNEF(ctx, "DELETE", "10000", "", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusNoContent))
+	})
+})
@@ -0,0 +1,319 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// nnefPfdMgmtPrefix is the API prefix of the Nnef_PFDManagement service
+// offered to the SMFs, refer 3GPP TS 29.551
+const nnefPfdMgmtPrefix = "/nnef-pfdmanagement/v1"
+
+// pfdSubRegistry keeps the subscriptions of the NFs to the PFD changes. They
+// are only kept in memory, the NFs subscribe again after a NEF restart.
+type pfdSubRegistry struct {
+	//mu guards subIDnum and subs
+	mu       sync.Mutex
+	subIDnum int
+	subs     map[string]PfdSubscription
+}
+
+// pfdSubNotif holds the PFD change notifications of a subscription
+type pfdSubNotif struct {
+	uri    URI
+	notifs []PfdChangeNotification
+}
+
+func newPfdSubRegistry() *pfdSubRegistry {
+
+	return &pfdSubRegistry{subIDnum: 1,
+		subs: make(map[string]PfdSubscription)}
+}
+
+// add stores the subscription and returns its ID
+func (p *pfdSubRegistry) add(sub PfdSubscription) string {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	subID := strconv.Itoa(p.subIDnum)
+	p.subIDnum++
+	p.subs[subID] = sub
+	return subID
+}
+
+// delete removes the subscription, false is returned if it is not found
+func (p *pfdSubRegistry) delete(subID string) bool {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.subs[subID]; !ok {
+		return false
+	}
+	delete(p.subs, subID)
+	return true
+}
+
+// match returns the notifications to send to each subscription interested
+// in the changes
+func (p *pfdSubRegistry) match(
+	changes []PfdChangeNotification) (res []pfdSubNotif) {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, sub := range p.subs {
+		var notifs []PfdChangeNotification
+		for _, c := range changes {
+			if len(sub.ApplicationIds) == 0 ||
+				containsAppID(sub.ApplicationIds, c.ApplicationID) {
+				notifs = append(notifs, c)
+			}
+		}
+		if len(notifs) != 0 {
+			res = append(res, pfdSubNotif{uri: sub.NotifyURI,
+				notifs: notifs})
+		}
+	}
+	return res
+}
+
+func containsAppID(appIDs []ApplicationID, appID ApplicationID) bool {
+
+	for _, id := range appIDs {
+		if id == appID {
+			return true
+		}
+	}
+	return false
+}
+
+// pfdContents returns the PFDs of the application ordered by PFD ID as sent
+// to the UDR and the SMF
+func pfdContents(app PfdData) []PfdContent {
+
+	pfds := make([]PfdContent, 0, len(app.Pfds))
+	for _, pfd := range app.Pfds {
+		pfds = append(pfds, PfdContent{PfdID: pfd.PfdID,
+			FlowDescriptions: pfd.FlowDescriptions, Urls: pfd.Urls,
+			DomainNames: pfd.DomainNames})
+	}
+	sort.Slice(pfds, func(i, j int) bool {
+		return pfds[i].PfdID < pfds[j].PfdID
+	})
+	return pfds
+}
+
+// pfdChanges returns the notifications of the applications whose PFDs
+// differ between old and upd. The applications missing in upd are removed.
+func pfdChanges(old map[string]PfdData,
+	upd map[string]PfdData) (changes []PfdChangeNotification) {
+
+	appIDs := make([]string, 0, len(old)+len(upd))
+	for appID := range upd {
+		appIDs = append(appIDs, appID)
+	}
+	for appID := range old {
+		if _, ok := upd[appID]; !ok {
+			appIDs = append(appIDs, appID)
+		}
+	}
+	sort.Strings(appIDs)
+
+	for _, appID := range appIDs {
+		u, ok := upd[appID]
+		if !ok {
+			changes = append(changes, PfdChangeNotification{
+				ApplicationID: ApplicationID(appID), RemovalFlag: true})
+			continue
+		}
+		pfds := pfdContents(u)
+		if o, ok := old[appID]; ok &&
+			reflect.DeepEqual(pfdContents(o), pfds) {
+			continue
+		}
+		changes = append(changes, PfdChangeNotification{
+			ApplicationID: ApplicationID(appID), Pfds: pfds})
+	}
+	return changes
+}
+
+//Sends the changes of the PFDs between old and upd to the subscribed NFs.
+//The notifications are queued so the AF lock can be held by the caller.
+func (nef *nefData) nefNotifyPfdChange(old map[string]PfdData,
+	upd map[string]PfdData) {
+
+	changes := pfdChanges(old, upd)
+	if len(changes) == 0 {
+		return
+	}
+	for _, n := range nef.pfdSubs.match(changes) {
+		nef.afNotifier.dispatchPfdChange(n.uri, n.notifs)
+	}
+}
+
+//Returns a copy of the PFD data of the application from the PFD transaction
+//it belongs to
+func (nef *nefData) nefGetPfdApp(appID string) (app PfdData, found bool) {
+
+	nef.idxMu.Lock()
+	ref, ok := nef.pfdAppIdx[appID]
+	nef.idxMu.Unlock()
+	if !ok {
+		return app, false
+	}
+
+	ref.af.mu.Lock()
+	defer ref.af.mu.Unlock()
+
+	trans, ok := ref.af.pfdtrans[ref.transID]
+	if !ok {
+		return app, false
+	}
+	app, ok = trans.pfdManagement.PfdDatas[appID]
+	if !ok {
+		return app, false
+	}
+	return clonePfdData(app), true
+}
+
+// Generate the location uri prefix of the PFD subscriptions
+func getNefPfdSubURLPrefix(cfg *Config) string {
+
+	var uri string
+	// If http2 port is configured use it else http port
+	if cfg.HTTP2Config.Endpoint != "" {
+		uri = "https://" + cfg.NefAPIRoot +
+			cfg.HTTP2Config.Endpoint
+	} else {
+		uri = "http://" + cfg.NefAPIRoot +
+			cfg.HTTPConfig.Endpoint
+	}
+	uri += nnefPfdMgmtPrefix + "/subscriptions/"
+	return uri
+}
+
+func sendPfdMgmtRsp(w http.ResponseWriter, code int, data interface{}) {
+
+	mdata, err := json.Marshal(data)
+	if err != nil {
+		log.Err(err)
+		sendCustomeErrorRspToAF(w, 500, "Failed to Marshal PFD data")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(code)
+	if _, err = w.Write(mdata); err != nil {
+		log.Errf("Write Failed: %v", err)
+		return
+	}
+	log.Infof("HTTP Response sent: %d", code)
+}
+
+// FetchPfdApplications : Returns the PFDs of the applications listed in the
+// application-ids query parameter
+func FetchPfdApplications(w http.ResponseWriter, r *http.Request) {
+
+	nefCtx := r.Context().Value(nefCtxKey("nefCtx")).(*nefContext)
+
+	ids := r.URL.Query().Get("application-ids")
+	if ids == "" {
+		sendCustomeErrorRspToAF(w, 400, "Missing application-ids")
+		return
+	}
+
+	apps := []PfdDataForApp{}
+	for _, appID := range strings.Split(ids, ",") {
+		app, ok := nefCtx.nef.nefGetPfdApp(appID)
+		if !ok {
+			log.Infof("PFDs of application %s not found", appID)
+			continue
+		}
+		apps = append(apps, PfdDataForApp{AppID: ApplicationID(appID),
+			Pfds: pfdContents(app)})
+	}
+	if len(apps) == 0 {
+		sendCustomeErrorRspToAF(w, 404, appNotFound)
+		return
+	}
+	sendPfdMgmtRsp(w, http.StatusOK, apps)
+}
+
+// FetchPfdApplication : Returns the PFDs of an application
+func FetchPfdApplication(w http.ResponseWriter, r *http.Request) {
+
+	nefCtx := r.Context().Value(nefCtxKey("nefCtx")).(*nefContext)
+
+	vars := mux.Vars(r)
+	log.Infof(" APPLICATION ID : %s", vars["appId"])
+
+	app, ok := nefCtx.nef.nefGetPfdApp(vars["appId"])
+	if !ok {
+		sendCustomeErrorRspToAF(w, 404, appNotFound)
+		return
+	}
+	sendPfdMgmtRsp(w, http.StatusOK, PfdDataForApp{
+		AppID: ApplicationID(vars["appId"]), Pfds: pfdContents(app)})
+}
+
+// CreatePfdSubscription : Subscribes the NF to the changes of the PFDs
+func CreatePfdSubscription(w http.ResponseWriter, r *http.Request) {
+
+	nefCtx := r.Context().Value(nefCtxKey("nefCtx")).(*nefContext)
+
+	b, err := ioutil.ReadAll(r.Body)
+	defer closeReqBody(r)
+	if err != nil {
+		sendCustomeErrorRspToAF(w, 400, "Failed to read HTTP POST Body")
+		return
+	}
+
+	sub := PfdSubscription{}
+	if err = json.Unmarshal(b, &sub); err != nil {
+		log.Err(err)
+		sendCustomeErrorRspToAF(w, 400, "Failed UnMarshal POST data")
+		return
+	}
+	if sub.NotifyURI == "" {
+		sendCustomeErrorRspToAF(w, 400, "Missing notifyUri")
+		return
+	}
+
+	subID := nefCtx.nef.pfdSubs.add(sub)
+	loc := nefCtx.nef.pfdSubURLPrefix + subID
+	log.Infof("PFD subscription %s created for %s", subID, sub.NotifyURI)
+
+	w.Header().Set("Location", loc)
+	sendPfdMgmtRsp(w, http.StatusCreated, sub)
+}
+
+// DeletePfdSubscription : Removes the subscription of the NF to the changes
+// of the PFDs
+func DeletePfdSubscription(w http.ResponseWriter, r *http.Request) {
+
+	nefCtx := r.Context().Value(nefCtxKey("nefCtx")).(*nefContext)
+
+	vars := mux.Vars(r)
+	log.Infof(" PFD SUBSCRIPTION ID : %s", vars["subscriptionId"])
+
+	if !nefCtx.nef.pfdSubs.delete(vars["subscriptionId"]) {
+		sendCustomeErrorRspToAF(w, 404, subNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+	log.Infof("HTTP Response sent: %d", http.StatusNoContent)
+}
@@ -0,0 +1,130 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	ngcnef "github.com/open-ness/epcforedge/ngc/pkg/nef"
+)
+
+const basePfdMgmtURL = "http://localhost:8091/nnef-pfdmanagement/v1"
+
+// pfdChanges returns the PFD change notifications of the i-th request
+func (af *fakeAF) pfdChanges(i int) []ngcnef.PfdChangeNotification {
+	var notifs []ngcnef.PfdChangeNotification
+	af.mu.Lock()
+	defer af.mu.Unlock()
+	Expect(json.Unmarshal(af.bodies[i], &notifs)).Should(BeNil())
+	return notifs
+}
+
+var _ = Describe("Test NEF PFD Management Service for the SMF", func() {
+
+	postbody, _ := ioutil.ReadFile(testJSONPFDPath +
+		"AF_NEF_PFD_POST_001.json")
+	putappbody, _ := ioutil.ReadFile(testJSONPFDPath +
+		"AF_NEF_PFD_APP_PUT_001.json")
+
+	serve := func(ctx context.Context, method string, url string,
+		body []byte) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, url, bytes.NewBuffer(body))
+		rr := httptest.NewRecorder()
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		return rr
+	}
+
+	It("Fetches the PFDs and notifies their changes to the SMF", func() {
+
+		smf := newFakeAF(http.StatusNoContent)
+		defer smf.server.Close()
+
+		ctx, cancel := startNefWithCfg(NefTestCfgBasepath + "valid.json")
+		defer cancel()
+
+		rr := serve(ctx, "GET", basePfdMgmtURL+"/applications/app1", nil)
+		Expect(rr.Code).Should(Equal(http.StatusNotFound))
+
+		sub, _ := json.Marshal(ngcnef.PfdSubscription{
+			ApplicationIds: []ngcnef.ApplicationID{"app1"},
+			NotifyURI:      ngcnef.URI(smf.server.URL)})
+		rr = serve(ctx, "POST", basePfdMgmtURL+"/subscriptions", sub)
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+		Expect(strings.HasSuffix(rr.Header().Get("Location"),
+			"/nnef-pfdmanagement/v1/subscriptions/1")).Should(BeTrue())
+
+		// Only the subscribed application is notified
+		rr, req := CreatePFDReqForNEF(ctx, "POST", "", "", postbody)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+		Eventually(smf.received, 2*time.Second,
+			10*time.Millisecond).Should(Equal(1))
+		notifs := smf.pfdChanges(0)
+		Expect(len(notifs)).Should(Equal(1))
+		Expect(notifs[0].ApplicationID).Should(
+			Equal(ngcnef.ApplicationID("app1")))
+		Expect(notifs[0].RemovalFlag).Should(BeFalse())
+		Expect(len(notifs[0].Pfds)).Should(Equal(2))
+		Expect(notifs[0].Pfds[0].PfdID).Should(Equal("pfd1"))
+
+		var apps []ngcnef.PfdDataForApp
+		rr = serve(ctx, "GET", basePfdMgmtURL+
+			"/applications?application-ids=app1,app2,app3", nil)
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+		Expect(json.Unmarshal(rr.Body.Bytes(), &apps)).Should(BeNil())
+		Expect(len(apps)).Should(Equal(2))
+		Expect(apps[1].AppID).Should(Equal(ngcnef.ApplicationID("app2")))
+		Expect(apps[1].Pfds[0].FlowDescriptions).Should(Equal(
+			[]string{"permit in ip from 10.11.12.124 80 to any"}))
+
+		rr = serve(ctx, "GET", basePfdMgmtURL+"/applications", nil)
+		Expect(rr.Code).Should(Equal(http.StatusBadRequest))
+
+		// Update of the application
+		rr, req = CreatePFDReqForNEF(ctx, "PUT", "10000", "app1", putappbody)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+		Eventually(smf.received, 2*time.Second,
+			10*time.Millisecond).Should(Equal(2))
+		notifs = smf.pfdChanges(1)
+		Expect(notifs[0].Pfds[0].FlowDescriptions).Should(Equal(
+			[]string{"permit in ip from 10.11.12.125 443 to any"}))
+
+		var app ngcnef.PfdDataForApp
+		rr = serve(ctx, "GET", basePfdMgmtURL+"/applications/app1", nil)
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+		Expect(json.Unmarshal(rr.Body.Bytes(), &app)).Should(BeNil())
+		Expect(app.Pfds).Should(Equal(notifs[0].Pfds))
+
+		// Removal of the application
+		rr, req = CreatePFDReqForNEF(ctx, "DELETE", "10000", "app1", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusNoContent))
+		Eventually(smf.received, 2*time.Second,
+			10*time.Millisecond).Should(Equal(3))
+		notifs = smf.pfdChanges(2)
+		Expect(notifs).Should(Equal([]ngcnef.PfdChangeNotification{{
+			ApplicationID: "app1", RemovalFlag: true}}))
+
+		rr = serve(ctx, "DELETE", basePfdMgmtURL+"/subscriptions/1", nil)
+		Expect(rr.Code).Should(Equal(http.StatusNoContent))
+		rr = serve(ctx, "DELETE", basePfdMgmtURL+"/subscriptions/1", nil)
+		Expect(rr.Code).Should(Equal(http.StatusNotFound))
+
+		rr, req = CreatePFDReqForNEF(ctx, "DELETE", "10000", "", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusNoContent))
+		Consistently(smf.received, 200*time.Millisecond).Should(Equal(3))
+	})
+})
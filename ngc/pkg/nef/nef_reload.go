@@ -0,0 +1,182 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	logtool "github.com/open-ness/common/log"
+	"github.com/open-ness/epcforedge/ngc/pkg/config"
+)
+
+// ConfigReloadResult reports the settings changed by a reload of the NEF
+// configuration file
+type ConfigReloadResult struct {
+	// Settings applied to the running NEF
+	Applied []string `json:"applied"`
+	// Settings changed in the file which need a restart of the NEF
+	RestartRequired []string `json:"restartRequired"`
+}
+
+// nefLiveSettings are the settings of Config applied by a reload, the
+// server certificate only if the HTTP2 server is running and the
+// certificates of the AF notification client only if they were loaded at
+// start
+var nefLiveSettings = map[string]bool{
+	"MaxSubSupport":                true,
+	"MaxPfdTransSupport":           true,
+	"MaxAFSupport":                 true,
+	"PfdConfig.MinAllowedDelay":    true,
+	"OAuth2Support":                true,
+	"LogLevel":                     true,
+	"HTTP2Config.AfCertIdentities": true,
+	"HTTP2Config.NefServerCert":    true,
+	"HTTP2Config.NefServerKey":     true,
+	"HTTP2Config.AfClientCert":     true,
+	"AfNotifConfig.ClientCert":     true,
+	"AfNotifConfig.ClientKey":      true,
+}
+
+// nefAfClientSetting returns true if the setting is a certificate of the AF
+// notification client
+func nefAfClientSetting(f string) bool {
+	return f == "HTTP2Config.AfClientCert" ||
+		strings.HasPrefix(f, "AfNotifConfig.Client")
+}
+
+// liveConfig returns the configuration of the NEF, to be used for the
+// settings applied by a reload
+func (nefCtx *nefContext) liveConfig() Config {
+
+	nefCtx.cfgMu.RLock()
+	defer nefCtx.cfgMu.RUnlock()
+	return nefCtx.cfg
+}
+
+// validateConfig checks the settings of a reloaded configuration
+func validateConfig(cfg Config) error {
+
+	if cfg.MaxSubSupport <= 0 || cfg.MaxPfdTransSupport <= 0 ||
+		cfg.MaxAFSupport <= 0 {
+		return errors.New("Invalid maxSubSupport, maxPfdTransSupport or " +
+			"maxAFSupport")
+	}
+	if cfg.PfdConfig.MinAllowedDelay < 0 {
+		return errors.New("Invalid PfdConfig minAllowedDelay")
+	}
+	if cfg.LogLevel != "" {
+		if _, err := logtool.ParseLevel(cfg.LogLevel); err != nil {
+			return err
+		}
+	}
+	_, err := nefServerTLSConfig(cfg.HTTP2Config)
+	return err
+}
+
+// setLogLevel sets the level of the logger if configured
+func setLogLevel(level string) error {
+
+	if level == "" {
+		return nil
+	}
+	lvl, err := logtool.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	logtool.SetLevel(lvl)
+	return nil
+}
+
+// reloadConfig reads the configuration file again and applies the limits,
+// the log level, the OAuth2 support, the AF certificate identities, the
+// server certificate and the certificates of the AF notification client.
+// The other settings changed in the file need a restart. Nothing is applied
+// if the configuration is invalid.
+func (nefCtx *nefContext) reloadConfig() (ConfigReloadResult, error) {
+
+	var cfg Config
+
+	res := ConfigReloadResult{Applied: []string{},
+		RestartRequired: []string{}}
+
+	nefCtx.reloadMu.Lock()
+	defer nefCtx.reloadMu.Unlock()
+
+	if err := loadJSONConfig(nefCtx.cfgPath, &cfg); err != nil {
+		return res, err
+	}
+	if err := validateConfig(cfg); err != nil {
+		return res, err
+	}
+	if nefCtx.certs != nil {
+		if err := nefCtx.certs.Reload(cfg.HTTP2Config.NefServerCert,
+			cfg.HTTP2Config.NefServerKey); err != nil {
+			return res, err
+		}
+	}
+	afClient := nefCtx.nef.afClient
+	afTLS := afClient != nil && afClient.tlsLoaded()
+	if afTLS {
+		if err := afClient.reloadTLS(&cfg); err != nil {
+			return res, err
+		}
+	}
+	if err := setLogLevel(cfg.LogLevel); err != nil {
+		return res, err
+	}
+
+	nefCtx.cfgMu.Lock()
+	for _, f := range config.ChangedFields(nefCtx.cfg, cfg) {
+		if nefLiveSettings[f] && (nefCtx.certs != nil ||
+			!strings.HasPrefix(f, "HTTP2Config.NefServer")) &&
+			(afTLS || !nefAfClientSetting(f)) {
+			res.Applied = append(res.Applied, f)
+		} else {
+			res.RestartRequired = append(res.RestartRequired, f)
+		}
+	}
+	nefCtx.cfg.MaxSubSupport = cfg.MaxSubSupport
+	nefCtx.cfg.MaxPfdTransSupport = cfg.MaxPfdTransSupport
+	nefCtx.cfg.MaxAFSupport = cfg.MaxAFSupport
+	nefCtx.cfg.PfdConfig.MinAllowedDelay = cfg.PfdConfig.MinAllowedDelay
+	nefCtx.cfg.OAuth2Support = cfg.OAuth2Support
+	nefCtx.cfg.LogLevel = cfg.LogLevel
+	nefCtx.cfg.HTTP2Config.AfCertIdentities = cfg.HTTP2Config.AfCertIdentities
+	if nefCtx.certs != nil {
+		nefCtx.cfg.HTTP2Config.NefServerCert = cfg.HTTP2Config.NefServerCert
+		nefCtx.cfg.HTTP2Config.NefServerKey = cfg.HTTP2Config.NefServerKey
+	}
+	if afTLS {
+		nefCtx.cfg.HTTP2Config.AfClientCert = cfg.HTTP2Config.AfClientCert
+		nefCtx.cfg.AfNotifConfig.ClientCert = cfg.AfNotifConfig.ClientCert
+		nefCtx.cfg.AfNotifConfig.ClientKey = cfg.AfNotifConfig.ClientKey
+	}
+	nefCtx.cfgMu.Unlock()
+
+	log.Infof("NEF configuration reloaded, applied: %v", res.Applied)
+	if len(res.RestartRequired) != 0 {
+		log.Infof("NEF restart required for: %v", res.RestartRequired)
+	}
+	return res, nil
+}
+
+// ReloadConfig : Reloads the NEF configuration file and returns the
+// settings applied and the ones needing a restart
+func ReloadConfig(w http.ResponseWriter, r *http.Request) {
+
+	nefCtx := r.Context().Value(nefCtxKey("nefCtx")).(*nefContext)
+
+	res, err := nefCtx.reloadConfig()
+	if err != nil {
+		log.Errf("NEF configuration reload failed: %v", err)
+		sendErrorResponseToAF(w, nefSBRspData{errorCode: 400,
+			pd: ProblemDetails{Title: "Invalid configuration", Status: 400,
+				Detail: err.Error()}})
+		return
+	}
+	sendAdminRsp(w, http.StatusOK, res)
+}
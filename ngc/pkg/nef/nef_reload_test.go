@@ -0,0 +1,91 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	ngcnef "github.com/open-ness/epcforedge/ngc/pkg/nef"
+)
+
+const configReloadURL = "http://localhost:8091/nef-admin/v1/config/reload"
+
+var _ = Describe("Test NEF Configuration Reload", func() {
+
+	var (
+		dir     string
+		cfgPath string
+	)
+
+	reload := func() *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPost, configReloadURL, nil)
+		Expect(err).Should(BeNil())
+		rr := httptest.NewRecorder()
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		return rr
+	}
+
+	postTI := func() int {
+		body, err := ioutil.ReadFile(testJSONPath + "AF_NEF_POST_01.json")
+		Expect(err).Should(BeNil())
+		rr, req := CreateReqForNEF(context.Background(), "POST", "", body)
+		req.Header.Set("Content-Type", "application/json")
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "nefreload")
+		Expect(err).Should(BeNil())
+		cfgPath = filepath.Join(dir, "nef.json")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("Applies the limits live and reports the restart settings", func() {
+
+		createNefCfg(dir, nil)
+		_, cancel := startNefWithCfg(cfgPath)
+		defer cancel()
+
+		Expect(postTI()).Should(Equal(http.StatusCreated))
+
+		// An invalid configuration is not applied
+		createNefCfg(dir, map[string]interface{}{"MaxSubSupport": 0})
+		rr := reload()
+		Expect(rr.Code).Should(Equal(http.StatusBadRequest))
+
+		createNefCfg(dir, map[string]interface{}{"MaxSubSupport": 1,
+			"LocationPrefix": "/3gpp-traffic-influence/v2/",
+			"logLevel":       "debug"})
+		rr = reload()
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+
+		var res ngcnef.ConfigReloadResult
+		Expect(json.Unmarshal(rr.Body.Bytes(), &res)).Should(BeNil())
+		Expect(res.Applied).Should(ConsistOf("MaxSubSupport", "LogLevel"))
+		Expect(res.RestartRequired).Should(ConsistOf("LocationPrefix"))
+
+		// The subscription limit is reached
+		Expect(postTI()).Should(Equal(http.StatusBadRequest))
+
+		createNefCfg(dir, map[string]interface{}{"MaxSubSupport": 2,
+			"logLevel": "info"})
+		rr = reload()
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+		Expect(postTI()).Should(Equal(http.StatusCreated))
+	})
+})
@@ -63,6 +63,54 @@ var NEFRoutes = []Route{
 		"/3gpp-traffic-influence/v1/{afId}/subscriptions/{subscriptionId}",
 		DeleteTrafficInfluenceSubscription,
 	},
+	{
+		"ConnectTrafficInfluenceWebsocket",
+		strings.ToUpper("Get"),
+		"/3gpp-traffic-influence/v1/{afId}/subscriptions/{subscriptionId}" +
+			"/websocket",
+		ConnectTrafficInfluenceWebsocket,
+	},
+	// AS Session with QoS Routes
+	{
+		"ReadAllAsSessionWithQoSSubscription",
+		strings.ToUpper("Get"),
+		"/3gpp-as-session-with-qos/v1/{scsAsId}/subscriptions",
+		ReadAllAsSessionWithQoSSubscription,
+	},
+	{
+		"CreateAsSessionWithQoSSubscription",
+		strings.ToUpper("Post"),
+		"/3gpp-as-session-with-qos/v1/{scsAsId}/subscriptions",
+		CreateAsSessionWithQoSSubscription,
+	},
+	{
+		"ReadAsSessionWithQoSSubscription",
+		strings.ToUpper("Get"),
+		"/3gpp-as-session-with-qos/v1/{scsAsId}/subscriptions/" +
+			"{subscriptionId}",
+		ReadAsSessionWithQoSSubscription,
+	},
+	{
+		"UpdatePutAsSessionWithQoSSubscription",
+		strings.ToUpper("Put"),
+		"/3gpp-as-session-with-qos/v1/{scsAsId}/subscriptions/" +
+			"{subscriptionId}",
+		UpdatePutAsSessionWithQoSSubscription,
+	},
+	{
+		"UpdatePatchAsSessionWithQoSSubscription",
+		strings.ToUpper("Patch"),
+		"/3gpp-as-session-with-qos/v1/{scsAsId}/subscriptions/" +
+			"{subscriptionId}",
+		UpdatePatchAsSessionWithQoSSubscription,
+	},
+	{
+		"DeleteAsSessionWithQoSSubscription",
+		strings.ToUpper("Delete"),
+		"/3gpp-as-session-with-qos/v1/{scsAsId}/subscriptions/" +
+			"{subscriptionId}",
+		DeleteAsSessionWithQoSSubscription,
+	},
 	// PFD Management Routes
 	{
 		"ReadAllPFDManagementTransaction",
@@ -130,6 +178,135 @@ var NEFRoutes = []Route{
 			"applications/{appId}",
 		PatchPFDManagementApplication,
 	},
+	// Nnef_PFDManagement Routes
+	{
+		"FetchPfdApplications",
+		strings.ToUpper("Get"),
+		"/nnef-pfdmanagement/v1/applications",
+		FetchPfdApplications,
+	},
+	{
+		"FetchPfdApplication",
+		strings.ToUpper("Get"),
+		"/nnef-pfdmanagement/v1/applications/{appId}",
+		FetchPfdApplication,
+	},
+	{
+		"CreatePfdSubscription",
+		strings.ToUpper("Post"),
+		"/nnef-pfdmanagement/v1/subscriptions",
+		CreatePfdSubscription,
+	},
+	{
+		"DeletePfdSubscription",
+		strings.ToUpper("Delete"),
+		"/nnef-pfdmanagement/v1/subscriptions/{subscriptionId}",
+		DeletePfdSubscription,
+	},
+	// Monitoring Event Routes
+	{
+		"ReadAllMonitoringEventSubscription",
+		strings.ToUpper("Get"),
+		"/3gpp-monitoring-event/v1/{scsAsId}/subscriptions",
+		ReadAllMonitoringEventSubscription,
+	},
+	{
+		"CreateMonitoringEventSubscription",
+		strings.ToUpper("Post"),
+		"/3gpp-monitoring-event/v1/{scsAsId}/subscriptions",
+		CreateMonitoringEventSubscription,
+	},
+	{
+		"ReadMonitoringEventSubscription",
+		strings.ToUpper("Get"),
+		"/3gpp-monitoring-event/v1/{scsAsId}/subscriptions/{subscriptionId}",
+		ReadMonitoringEventSubscription,
+	},
+	{
+		"UpdatePutMonitoringEventSubscription",
+		strings.ToUpper("Put"),
+		"/3gpp-monitoring-event/v1/{scsAsId}/subscriptions/{subscriptionId}",
+		UpdatePutMonitoringEventSubscription,
+	},
+	{
+		"DeleteMonitoringEventSubscription",
+		strings.ToUpper("Delete"),
+		"/3gpp-monitoring-event/v1/{scsAsId}/subscriptions/{subscriptionId}",
+		DeleteMonitoringEventSubscription,
+	},
+	{
+		"ReadAllGeoZones",
+		strings.ToUpper("Get"),
+		"/nef-admin/v1/geo-zones",
+		ReadAllGeoZones,
+	},
+	{
+		"ReadGeoZone",
+		strings.ToUpper("Get"),
+		"/nef-admin/v1/geo-zones/{geoZoneId}",
+		ReadGeoZone,
+	},
+	{
+		"PutGeoZone",
+		strings.ToUpper("Put"),
+		"/nef-admin/v1/geo-zones/{geoZoneId}",
+		PutGeoZone,
+	},
+	{
+		"DeleteGeoZone",
+		strings.ToUpper("Delete"),
+		"/nef-admin/v1/geo-zones/{geoZoneId}",
+		DeleteGeoZone,
+	},
+	{
+		"ReadAllAfServices",
+		strings.ToUpper("Get"),
+		"/nef-admin/v1/af-services",
+		ReadAllAfServices,
+	},
+	{
+		"ReadAfService",
+		strings.ToUpper("Get"),
+		"/nef-admin/v1/af-services/{afServiceId}",
+		ReadAfService,
+	},
+	{
+		"PutAfService",
+		strings.ToUpper("Put"),
+		"/nef-admin/v1/af-services/{afServiceId}",
+		PutAfService,
+	},
+	{
+		"DeleteAfService",
+		strings.ToUpper("Delete"),
+		"/nef-admin/v1/af-services/{afServiceId}",
+		DeleteAfService,
+	},
+	{
+		"ReadAfNotificationStatus",
+		strings.ToUpper("Get"),
+		"/nef-admin/v1/af-notifications",
+		ReadAfNotificationStatus,
+	},
+	{
+		"ReloadConfig",
+		strings.ToUpper("Post"),
+		"/nef-admin/v1/config/reload",
+		ReloadConfig,
+	},
+	// OAuth2 Routes
+	{
+		"AccessTokenRequest",
+		strings.ToUpper("Post"),
+		oauth2.TokenURLPath,
+		oauth2.TokenHandler,
+	},
+	{
+		"GetJWKS",
+		strings.ToUpper("Get"),
+		oauth2.JWKSURLPath,
+		oauth2.ServeJWKS,
+	},
 }
 
 type nefCtxKey string
@@ -153,6 +330,14 @@ func NewNEFRouter(nefCtx *nefContext) *mux.Router {
 	smfNotif.Pattern = nefCtx.cfg.UpfNotificationResURIPath
 	NEFRoutes = append(NEFRoutes, smfNotif)
 
+	// udm monitoring event notification route
+	udmNotif := Route{}
+	udmNotif.Name = "NotifyUdmMonitoringEvent"
+	udmNotif.Method = strings.ToUpper("Post")
+	udmNotif.Handler = NotifyUdmMonitoringEvent
+	udmNotif.Pattern = nefCtx.cfg.UdmNotificationResURIPath + "/{corrId}"
+	NEFRoutes = append(NEFRoutes, udmNotif)
+
 	for _, route := range NEFRoutes {
 
 		var handler http.Handler = route.Handler
@@ -172,8 +357,14 @@ func NewNEFRouter(nefCtx *nefContext) *mux.Router {
 				nefCtxKey("nefCtx"),
 				nefCtx)
 
-			if nefCtx.cfg.OAuth2Support {
-				if nefValidateAccessToken(w, r) {
+			cfg := nefCtx.liveConfig()
+			if !nefAuthorizeCert(w, r, cfg.HTTP2Config) {
+				return
+			}
+			if cfg.OAuth2Support &&
+				!nefPublicRoute(mux.CurrentRoute(r)) {
+				claims, ok := nefValidateAccessToken(w, r)
+				if ok && nefAuthorizeAccess(w, r, nefCtx, claims) {
 					next.ServeHTTP(w, r.WithContext(ctx))
 				}
 			} else {
@@ -185,36 +376,165 @@ func NewNEFRouter(nefCtx *nefContext) *mux.Router {
 	return router
 }
 
-func nefValidateAccessToken(w http.ResponseWriter, r *http.Request) bool {
+func nefValidateAccessToken(w http.ResponseWriter,
+	r *http.Request) (*oauth2.AccessTokenClaims, bool) {
 
 	reqToken := r.Header.Get("Authorization")
 
-	if len(reqToken) == 0 {
+	if !strings.HasPrefix(reqToken, "Bearer ") {
 		log.Info("Authorization header missing")
 		//Authorization header is not present
 		w.Header().Set("WWW-Authenticate", "Bearer realm="+r.RequestURI)
 
 		w.WriteHeader(http.StatusUnauthorized)
-		return false
+		return nil, false
 	}
 
 	//Get the token
-	splitToken := strings.Split(reqToken, "Bearer ")
-	reqToken = splitToken[1]
+	reqToken = strings.TrimPrefix(reqToken, "Bearer ")
 
-	status, err := oauth2.ValidateAccessToken(reqToken)
+	claims, status, err := oauth2.ParseAccessToken(reqToken)
 
 	if err != nil {
 		log.Infoln("Token Validation failed")
 		if status == oauth2.StatusInvalidToken {
 			w.Header().Set("WWW-Authenticate", "Bearer realm="+r.RequestURI)
 			w.WriteHeader(http.StatusUnauthorized)
-			return false
+			return nil, false
 		} else if status == oauth2.StatusBadRequest {
 			w.WriteHeader(http.StatusBadRequest)
-			return false
+			return nil, false
 		}
 		w.WriteHeader(http.StatusInternalServerError)
+		return nil, false
+	}
+	return claims, true
+}
+
+// nefPublicRoute returns true for the routes used without access token, the
+// token endpoint and the keys verifying its tokens
+func nefPublicRoute(route *mux.Route) bool {
+
+	if route == nil {
+		return false
+	}
+	switch route.GetName() {
+	case "AccessTokenRequest", "GetJWKS":
+		return true
+	}
+	return false
+}
+
+// nefAPIScopes maps the path prefix of the NEF APIs onto the OAuth2 scope the
+// token must grant to use them
+var nefAPIScopes = []struct {
+	prefix string
+	scope  string
+}{
+	{"/3gpp-traffic-influence/", oauth2.ScopeTrafficInfluence},
+	{"/3gpp-pfd-management/", oauth2.ScopePfdManagement},
+	{"/3gpp-as-session-with-qos/", oauth2.ScopeAsSessionWithQos},
+	{"/3gpp-monitoring-event/", oauth2.ScopeMonitoringEvent},
+	{nnefPfdMgmtPrefix + "/", oauth2.ScopeNnefPfdManagement},
+	{"/nef-admin/", oauth2.ScopeNefAdmin},
+}
+
+// nef5gcNotifRoute returns true for the routes of the notifications sent by
+// the SMF and the UDM
+func nef5gcNotifRoute(route *mux.Route) bool {
+
+	if route == nil {
+		return false
+	}
+	switch route.GetName() {
+	case "NotifySmfUPFEvent", "NotifyUdmMonitoringEvent":
+		return true
+	}
+	return false
+}
+
+// nefRouteScope returns the scope required by the route, an empty string if
+// any valid token is accepted
+func nefRouteScope(route *mux.Route) string {
+
+	if route == nil {
+		return ""
+	}
+	if nef5gcNotifRoute(route) {
+		return oauth2.Scope5gcNotification
+	}
+	tpl, err := route.GetPathTemplate()
+	if err != nil {
+		return ""
+	}
+	for _, s := range nefAPIScopes {
+		if strings.HasPrefix(tpl, s.prefix) {
+			return s.scope
+		}
+	}
+	return ""
+}
+
+// nefAfSubject returns true if the subject of a token is an AF: an AF client
+// of the token endpoint, an AF of the client certificates or a known AF
+func nefAfSubject(nefCtx *nefContext, subject string) bool {
+
+	if oauth2.AFClient(subject) {
+		return true
+	}
+	for _, m := range nefCtx.liveConfig().HTTP2Config.AfCertIdentities {
+		for _, afID := range m.AfIDs {
+			if afID == subject {
+				return true
+			}
+		}
+	}
+
+	nefCtx.nef.mu.RLock()
+	defer nefCtx.nef.mu.RUnlock()
+	_, ok := nefCtx.nef.afs[subject]
+	return ok
+}
+
+// nefAuthorizeAccess checks that the token grants the scope of the API and,
+// for the resources of an AF, that the AF ID of the path is the subject of
+// the token. The notifications of the 5GC are refused to the AFs. A 403
+// response is sent if not.
+func nefAuthorizeAccess(w http.ResponseWriter, r *http.Request,
+	nefCtx *nefContext, claims *oauth2.AccessTokenClaims) bool {
+
+	scope := nefRouteScope(mux.CurrentRoute(r))
+	if scope != "" && !claims.HasScope(scope) {
+		log.Infof("Access token of %s lacks the scope %s", claims.Subject,
+			scope)
+		w.Header().Set("WWW-Authenticate", "Bearer realm="+r.RequestURI+
+			`, error="insufficient_scope", scope="`+scope+`"`)
+		sendErrorResponseToAF(w, nefSBRspData{errorCode: 403,
+			pd: ProblemDetails{Title: "Insufficient scope", Status: 403,
+				Detail: "The access token does not grant the scope " +
+					scope}})
+		return false
+	}
+
+	if nef5gcNotifRoute(mux.CurrentRoute(r)) &&
+		nefAfSubject(nefCtx, claims.Subject) {
+		log.Infof("Access token of the AF %s used for a 5GC notification",
+			claims.Subject)
+		sendErrorResponseToAF(w, nefSBRspData{errorCode: 403,
+			pd: ProblemDetails{Title: "AF not authorized", Status: 403,
+				Detail: "The notifications of the 5GC are not granted to " +
+					"the AF " + claims.Subject}})
+		return false
+	}
+
+	afID, ok := routeAfID(r)
+	if ok && afID != claims.Subject {
+		log.Infof("Access token of %s used for the AF %s", claims.Subject,
+			afID)
+		sendErrorResponseToAF(w, nefSBRspData{errorCode: 403,
+			pd: ProblemDetails{Title: "AF not authorized", Status: 403,
+				Detail: "The access token is not granted to the AF " +
+					afID}})
 		return false
 	}
 	return true
@@ -0,0 +1,273 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+/* Common HTTP/2 client functions used by the southbound clients towards the
+5GC network functions (PCF, UDR, SMF, NRF) */
+
+package ngcnef
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+const sbDefaultTimeout = 15
+
+// sbClient contains the HTTP/2 client and the settings shared by the
+// southbound clients
+type sbClient struct {
+	apiRoot   string
+	userAgent string
+	// client is used for https and h2c for http
+	client *http.Client
+	h2c    *http.Client
+
+	// nrf discovers the network function instance if set, the apiRoot is
+	// used if the discovery fails
+	nrf     *nrfClient
+	nfType  NFType
+	service string
+
+	// rootsMu guards roots
+	rootsMu sync.Mutex
+	// API root of the instance where a resource was created, the resource
+	// is only known by this instance
+	roots map[string]string
+}
+
+// sbResponse contains the response received from a network function
+type sbResponse struct {
+	code   int
+	header http.Header
+	body   []byte
+}
+
+// newSBClient creates the HTTP/2 client using the configuration of the
+// network function. https uses TLS with the configured root CA, http uses
+// HTTP/2 with prior knowledge (h2c).
+func newSBClient(cfg *SBClientConfig, userAgent string) (*sbClient, error) {
+
+	// The API root is empty if the network function is discovered
+	u, err := url.Parse(cfg.APIRoot)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.APIRoot != "" && u.Scheme != "https" && u.Scheme != "http" {
+		return nil, errors.New("Unsupported url scheme: " + u.Scheme)
+	}
+
+	tlsCfg := &tls.Config{}
+	if cfg.RootCACert != "" && u.Scheme != "http" {
+		caCert, err := ioutil.ReadFile(cfg.RootCACert)
+		if err != nil {
+			return nil, err
+		}
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(caCert) {
+			return nil, errors.New("Failed to load root CA " +
+				cfg.RootCACert)
+		}
+		tlsCfg.RootCAs = caCertPool
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = sbDefaultTimeout
+	}
+
+	c := &sbClient{}
+	c.apiRoot = strings.TrimSuffix(cfg.APIRoot, "/")
+	c.userAgent = userAgent
+	c.roots = make(map[string]string)
+	c.client = &http.Client{
+		Timeout:   time.Duration(timeout) * time.Second,
+		Transport: &http2.Transport{TLSClientConfig: tlsCfg},
+	}
+	c.h2c = &http.Client{
+		Timeout: time.Duration(timeout) * time.Second,
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string,
+				_ *tls.Config) (net.Conn, error) {
+				return net.Dial(network, addr)
+			},
+		},
+	}
+	return c, nil
+}
+
+// useNRF sets the NRF used to discover the instances of the network function
+// providing the service
+func (c *sbClient) useNRF(nrf *nrfClient, nfType NFType, service string) {
+
+	c.nrf = nrf
+	c.nfType = nfType
+	c.service = service
+}
+
+// root returns the API root of the network function instance serving the
+// DNN and S-NSSAI (if not empty). The instance is discovered from the NRF,
+// the configured API root is used if the discovery fails.
+func (c *sbClient) root(ctx context.Context, dnn Dnn,
+	snssai Snssai) (string, error) {
+
+	if c.nrf != nil {
+		apiRoot, err := c.nrf.discover(ctx, c.nfType, c.service, dnn,
+			snssai)
+		if err == nil {
+			return apiRoot, nil
+		}
+		log.Errf("%s discovery failed: %v", string(c.nfType), err)
+		if c.apiRoot == "" {
+			return "", err
+		}
+		log.Infof("%s configured API root used: %s", string(c.nfType),
+			c.apiRoot)
+	}
+	if c.apiRoot == "" {
+		return "", errors.New("API root not configured")
+	}
+	return c.apiRoot, nil
+}
+
+// setResourceRoot saves the API root of the instance where the resource was
+// created
+func (c *sbClient) setResourceRoot(id string, apiRoot string) {
+
+	c.rootsMu.Lock()
+	defer c.rootsMu.Unlock()
+	c.roots[id] = apiRoot
+}
+
+// deleteResourceRoot removes the resource deleted from the saved API roots
+func (c *sbClient) deleteResourceRoot(id string) {
+
+	c.rootsMu.Lock()
+	defer c.rootsMu.Unlock()
+	delete(c.roots, id)
+}
+
+// resourceRoot returns the API root of the instance where the resource was
+// created. If it is not known (ex: after a restart) any instance is used.
+func (c *sbClient) resourceRoot(ctx context.Context,
+	id string) (string, error) {
+
+	c.rootsMu.Lock()
+	apiRoot, ok := c.roots[id]
+	c.rootsMu.Unlock()
+	if ok {
+		return apiRoot, nil
+	}
+	return c.root(ctx, "", Snssai{})
+}
+
+// send marshals the body (if not nil) and sends the request to the network
+// function. The error is only set if no response has been received.
+func (c *sbClient) send(ctx context.Context, method string, uri string,
+	contentType string, body interface{}) (rsp sbResponse, err error) {
+
+	var reqBody []byte
+	if body != nil {
+		reqBody, err = json.Marshal(body)
+		if err != nil {
+			return rsp, err
+		}
+	}
+
+	req, err := http.NewRequest(method, uri, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return rsp, err
+	}
+	req.Header.Set("Accept", "application/json, application/problem+json")
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	client := c.client
+	if strings.HasPrefix(uri, "http:") {
+		client = c.h2c
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return rsp, err
+	}
+	defer func() {
+		if err1 := resp.Body.Close(); err1 != nil {
+			log.Errf("response body was not closed properly")
+		}
+	}()
+
+	rsp.code = resp.StatusCode
+	rsp.header = resp.Header
+	rsp.body, err = ioutil.ReadAll(resp.Body)
+	return rsp, err
+}
+
+// sbProblemDetails returns the ProblemDetails in the body of a failure
+// response. If the body is not a ProblemDetails one is created from the
+// response code.
+func sbProblemDetails(rsp sbResponse) *ProblemDetails {
+
+	pd := ProblemDetails{}
+	if len(rsp.body) == 0 || json.Unmarshal(rsp.body, &pd) != nil {
+		pd = ProblemDetails{}
+	}
+	if pd.Status == 0 {
+		pd.Status = int32(rsp.code)
+	}
+	if pd.Title == "" {
+		pd.Title = http.StatusText(rsp.code)
+	}
+	return &pd
+}
+
+// sbUnreachableProblem returns the ProblemDetails used when no response was
+// received from the network function
+func sbUnreachableProblem(nf string, err error) *ProblemDetails {
+
+	return &ProblemDetails{
+		Title:  nf + " not reachable",
+		Detail: err.Error(),
+		Status: http.StatusServiceUnavailable,
+	}
+}
+
+// sbResourceID returns the last path segment of the Location header after
+// the collection path ex: "/app-sessions/"
+func sbResourceID(rsp sbResponse, collection string) (string, error) {
+
+	loc := rsp.header.Get("Location")
+	if loc == "" {
+		return "", errors.New("Location header missing")
+	}
+	u, err := url.Parse(loc)
+	if err != nil {
+		return "", err
+	}
+	idx := strings.LastIndex(u.Path, collection)
+	if idx < 0 {
+		return "", errors.New("Invalid Location header: " + loc)
+	}
+	id := strings.Trim(u.Path[idx+len(collection):], "/")
+	if id == "" || strings.Contains(id, "/") {
+		return "", errors.New("Invalid Location header: " + loc)
+	}
+	return id, nil
+}
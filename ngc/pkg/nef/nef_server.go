@@ -6,12 +6,16 @@ package ngcnef
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
 	logtool "github.com/open-ness/common/log"
+	"github.com/open-ness/epcforedge/ngc/pkg/config"
+	"github.com/open-ness/epcforedge/ngc/pkg/oauth2"
 	"golang.org/x/net/http2"
 )
 
@@ -34,12 +38,94 @@ type HTTPConfig struct {
 	Endpoint string `json:"endpoint"`
 }
 
+//StoreConfig contains the configuration for the NEF persistent store. If the
+//path is empty the NEF data is not persisted. The journal is compacted when
+//the records appended since the last compaction reach CompactRecords and
+//outnumber the records of the current data.
+type StoreConfig struct {
+	Path           string `json:"path"`
+	CompactRecords int    `json:"compactRecords"`
+}
+
+//GeoZoneConfig contains the path of the JSON file with the geographic zones
+//used for the validGeoZoneIds of the traffic influence subscriptions. If the
+//path is empty the zones added through the admin API are not persisted.
+type GeoZoneConfig struct {
+	Path string `json:"path"`
+}
+
+//SBClientConfig contains the configuration for a southbound HTTP/2 client
+//towards a 5GC network function. If the APIRoot is empty the stub client is
+//used instead. APIRoot with http scheme uses HTTP/2 over clear text (h2c).
+type SBClientConfig struct {
+	APIRoot    string `json:"apiRoot"`
+	RootCACert string `json:"rootCACert"`
+	// Timeout for each request in seconds, defaults to 15 seconds
+	Timeout int `json:"timeout"`
+}
+
+//NRFConfig contains the configuration for the NRF. If the APIRoot is set the
+//NEF registers in the NRF and discovers the PCF, UDR, SMF and UDM instances,
+//the API roots configured for them are used if the discovery fails.
+type NRFConfig struct {
+	SBClientConfig
+	// NF Instance ID (UUID) of the NEF, generated at start if empty
+	NfInstanceID string `json:"nfInstanceId"`
+	// HeartBeatTimer in seconds proposed to the NRF, defaults to 60 seconds
+	HeartBeatTimer int `json:"heartBeatTimer"`
+	// CacheTime in seconds of the discovery results if the NRF does not
+	// provide a validity period, defaults to 300 seconds
+	CacheTime int `json:"cacheTime"`
+}
+
+//AfNotifConfig contains the configuration of the dispatcher of the
+//notifications sent to the AFs
+type AfNotifConfig struct {
+	// Number of workers sending the notifications, defaults to 4
+	Workers int `json:"workers"`
+	// Maximum number of notifications queued per AF destination, defaults
+	// to 100
+	QueueSize int `json:"queueSize"`
+	// Maximum number of attempts to send a notification, defaults to 5
+	MaxAttempts int `json:"maxAttempts"`
+	// Delay in milliseconds before the first retry, doubled for each next
+	// retry, defaults to 500
+	RetryInterval int `json:"retryInterval"`
+	// Maximum delay in milliseconds between the retries, defaults to 30000
+	MaxRetryInterval int `json:"maxRetryInterval"`
+	// Number of notifications kept in the dead-letter list, defaults to 100
+	DeadLetterSize int `json:"deadLetterSize"`
+	// Send an OAuth2 access token of the NEF with the notifications
+	OAuth2 bool `json:"OAuth2"`
+	// Lifetime in seconds of the access tokens bound to each AF host,
+	// defaults to 60
+	TokenLifetime int `json:"tokenLifetime"`
+	// Client certificate and key of the NEF for mutual TLS with the AFs
+	ClientCert string `json:"ClientCert"`
+	ClientKey  string `json:"ClientKey"`
+}
+
+//PfdConfig contains the configuration of the PFD management
+type PfdConfig struct {
+	// Minimum time in seconds needed to propagate the PFDs to the SMFs. The
+	// applications with a smaller allowedDelay are rejected with the
+	// SHORT_DELAY failure code, 0 disables the check.
+	MinAllowedDelay int `json:"minAllowedDelay"`
+}
+
 //HTTP2Config Contains the configuration for the HTTP2
 type HTTP2Config struct {
 	Endpoint      string `json:"endpoint"`
 	NefServerCert string `json:"NefServerCert"`
 	NefServerKey  string `json:"NefServerKey"`
 	AfClientCert  string `json:"AfClientCert"`
+	// Client certificates requested from the AFs: none (default),
+	// optional or required
+	ClientAuth string `json:"ClientAuth"`
+	// Root CA certificate verifying the AF client certificates
+	ClientCACert string `json:"ClientCACert"`
+	// AF IDs granted to the identities of the client certificates
+	AfCertIdentities []AfCertIdentity `json:"AfCertIdentities"`
 }
 
 // Config contains NEF Module Configuration Data Structure
@@ -48,23 +134,47 @@ type Config struct {
 	NefAPIRoot                string `json:"nefAPIRoot"`
 	LocationPrefix            string `json:"locationPrefix"`
 	LocationPrefixPfd         string `json:"locationPrefixPfd"`
+	LocationPrefixQos         string `json:"locationPrefixQos"`
+	LocationPrefixMe          string `json:"locationPrefixMe"`
 	MaxSubSupport             int    `json:"maxSubSupport"`
 	MaxPfdTransSupport        int    `json:"maxPfdTransSupport"`
 	MaxAFSupport              int    `json:"maxAFSupport"`
 	SubStartID                int    `json:"subStartID"`
 	PfdTransStartID           int    `json:"pfdTransStartID"`
 	UpfNotificationResURIPath string `json:"UpfNotificationResUriPath"`
+	UdmNotificationResURIPath string `json:"UdmNotificationResUriPath"`
 	UserAgent                 string `json:"UserAgent"`
 	HTTPConfig                HTTPConfig
 	HTTP2Config               HTTP2Config
-	AfServiceIDs              []interface{} `json:"afServiceIDs"`
-	OAuth2Support             bool          `json:"OAuth2Support"`
+	StoreConfig               StoreConfig
+	GeoZoneConfig             GeoZoneConfig
+	AfNotifConfig             AfNotifConfig
+	PfdConfig                 PfdConfig
+	PCFConfig                 SBClientConfig
+	UDRConfig                 SBClientConfig
+	SMFConfig                 SBClientConfig
+	UDMConfig                 SBClientConfig
+	NRFConfig                 NRFConfig
+	AfServiceIDs              []AfServiceMapping `json:"afServiceIDs"`
+	OAuth2Support             bool               `json:"OAuth2Support"`
+	// Log level (err, info, debug...), unchanged if empty
+	LogLevel string `json:"logLevel"`
+	// Interval in seconds of the checks of the configuration file changes,
+	// 0 reloads the configuration only on SIGHUP
+	ConfigWatchInterval int `json:"configWatchInterval"`
 }
 
 // NEF Module Context Data Structure
 type nefContext struct {
 	cfg Config
 	nef nefData
+	// Path of the configuration file, read again on reload
+	cfgPath string
+	// cfgMu protects the settings of cfg applied by a reload
+	cfgMu    sync.RWMutex
+	reloadMu sync.Mutex
+	// Certificate of the HTTP2 server, replaced on reload
+	certs *config.CertReloader
 }
 
 /* Go Routine is spawned here for starting HTTP Server */
@@ -83,10 +193,15 @@ func startHTTPServer(server *http.Server,
 func startHTTP2Server(serverHTTP2 *http.Server, nefCtx *nefContext,
 	stopServerCh chan bool) {
 	if serverHTTP2 != nil {
+		certFile := nefCtx.cfg.HTTP2Config.NefServerCert
+		keyFile := nefCtx.cfg.HTTP2Config.NefServerKey
+		if nefCtx.certs != nil {
+			/* The certificate is given by the TLS GetCertificate */
+			certFile, keyFile = "", ""
+		}
 		log.Infof("HTTP 2.0 listening on %s", serverHTTP2.Addr)
-		if err := serverHTTP2.ListenAndServeTLS(
-			nefCtx.cfg.HTTP2Config.NefServerCert,
-			nefCtx.cfg.HTTP2Config.NefServerKey); err != nil {
+		if err := serverHTTP2.ListenAndServeTLS(certFile,
+			keyFile); err != nil {
 			log.Errf("HTTP2server error: " + err.Error())
 		}
 	}
@@ -114,6 +229,12 @@ func runServer(ctx context.Context, nefCtx *nefContext) error {
 	nefRouter := NewNEFRouter(nefCtx)
 	NefAppG.NefRouter = nefRouter
 
+	if nefCtx.cfg.OAuth2Support && oauth2.SharedKeySigning() {
+		log.Err("OAuth2 tokens signed with the HS256 shared key: its " +
+			"holders can issue tokens with any subject and scope, the " +
+			"scope and AF ID checks are void")
+	}
+
 	// 1 for http2, 1 for http and 1 for the os signal
 	numchannels := 3
 
@@ -124,6 +245,11 @@ func runServer(ctx context.Context, nefCtx *nefContext) error {
 		log.Info("HTTP Server not configured")
 		numchannels--
 	} else {
+		if nefCtx.cfg.HTTP2Config.ClientAuth != "" &&
+			nefCtx.cfg.HTTP2Config.ClientAuth != clientAuthNone {
+			log.Info("HTTP Server does not serve the AF APIs with " +
+				"mutual TLS")
+		}
 		// HTTP Server object is created
 		server = &http.Server{
 			Addr:           nefCtx.cfg.HTTPConfig.Endpoint,
@@ -146,6 +272,28 @@ func runServer(ctx context.Context, nefCtx *nefContext) error {
 			MaxHeaderBytes: 1 << 20,
 		}
 
+		/* Mutual TLS with the AFs if configured */
+		serverHTTP2.TLSConfig, err = nefServerTLSConfig(
+			nefCtx.cfg.HTTP2Config)
+		if err != nil {
+			log.Errf("failed at configuring mutual TLS: %v", err)
+			return err
+		}
+
+		/* Server certificate replaced on configuration reload */
+		nefCtx.certs, err = config.NewCertReloader(
+			nefCtx.cfg.HTTP2Config.NefServerCert,
+			nefCtx.cfg.HTTP2Config.NefServerKey)
+		if err != nil {
+			log.Errf("failed at loading the HTTP2 server certificate: %v",
+				err)
+		} else {
+			if serverHTTP2.TLSConfig == nil {
+				serverHTTP2.TLSConfig = &tls.Config{}
+			}
+			serverHTTP2.TLSConfig.GetCertificate = nefCtx.certs.GetCertificate
+		}
+
 		if err = http2.ConfigureServer(serverHTTP2,
 			&http2.Server{}); err != nil {
 			log.Errf("failed at configuring HTTP2 server")
@@ -185,6 +333,19 @@ func runServer(ctx context.Context, nefCtx *nefContext) error {
 		stopServerCh <- true
 	}(stopServerCh)
 
+	/* Registers the NEF in the NRF if configured */
+	nefCtx.nef.nefRegister()
+
+	/* Go Routine is spawned here for reloading the configuration on SIGHUP
+	 * or when the configuration file changes */
+	go config.WatchReload(ctx, []string{nefCtx.cfgPath},
+		time.Duration(nefCtx.cfg.ConfigWatchInterval)*time.Second,
+		func() {
+			if _, rerr := nefCtx.reloadConfig(); rerr != nil {
+				log.Errf("NEF configuration reload failed: %v", rerr)
+			}
+		})
+
 	/* Go Routine is spawned here for starting HTTP Server */
 	go startHTTPServer(server, stopServerCh)
 	/* Go Routine is spawned here for starting HTTP-2 Server */
@@ -215,6 +376,7 @@ func Run(ctx context.Context, cfgPath string) error {
 
 	var nefCtx nefContext
 
+	nefCtx.cfgPath = cfgPath
 	/* Reads NEF Configuration file which is json format. Also it converts
 	 * configuration data from json format to structure data */
 	err := loadJSONConfig(cfgPath, &nefCtx.cfg)
@@ -224,6 +386,10 @@ func Run(ctx context.Context, cfgPath string) error {
 
 	}
 
+	if err = setLogLevel(nefCtx.cfg.LogLevel); err != nil {
+		log.Errf("Invalid NEF log level: %v", err)
+		return err
+	}
 	printConfig(nefCtx.cfg)
 
 	/* Creates/Initializes NEF Data */
@@ -233,6 +399,11 @@ func Run(ctx context.Context, cfgPath string) error {
 		return err
 	}
 	NefAppG.NefCtx = &nefCtx
+
+	/* Arms the temporal validity timers of the restored subscriptions */
+	nefCtx.nef.nefStartTempValidity(&nefCtx)
+	/* Arms the caching time timers of the restored PFD applications */
+	nefCtx.nef.nefStartPfdExpiry(&nefCtx)
 	return runServer(ctx, &nefCtx)
 }
 
@@ -242,16 +413,32 @@ func printConfig(cfg Config) {
 	log.Infoln("APIRoot: ", cfg.NefAPIRoot)
 	log.Infoln("LocationPrefix: ", cfg.LocationPrefix)
 	log.Infoln("LocationPrefixPfd: ", cfg.LocationPrefixPfd)
+	log.Infoln("LocationPrefixQos: ", cfg.LocationPrefixQos)
+	log.Infoln("LocationPrefixMe: ", cfg.LocationPrefixMe)
 	log.Infoln("UpfNotificationResUriPath:", cfg.UpfNotificationResURIPath)
+	log.Infoln("UdmNotificationResUriPath:", cfg.UdmNotificationResURIPath)
 	log.Infoln("Trans Start ID", cfg.PfdTransStartID)
 	log.Infoln("UserAgent:", cfg.UserAgent)
 	log.Infoln("OAuth2Support:", cfg.OAuth2Support)
+	log.Infoln("LogLevel:", cfg.LogLevel)
+	log.Infoln("ConfigWatchInterval:", cfg.ConfigWatchInterval)
 	log.Infoln("-------------------------- NEF SERVER ----------------------")
 	log.Infoln("EndPoint(HTTP): ", cfg.HTTPConfig.Endpoint)
 	log.Infoln("EndPoint(HTTP2): ", cfg.HTTP2Config.Endpoint)
 	log.Infoln("ServerCert(HTTP2): ", cfg.HTTP2Config.NefServerCert)
 	log.Infoln("ServerKey(HTTP2): ", cfg.HTTP2Config.NefServerKey)
 	log.Infoln("AFClientCert(HTTP2): ", cfg.HTTP2Config.AfClientCert)
+	log.Infoln("ClientAuth(HTTP2): ", cfg.HTTP2Config.ClientAuth)
+	log.Infoln("ClientCACert(HTTP2): ", cfg.HTTP2Config.ClientCACert)
+	log.Infoln("StorePath: ", cfg.StoreConfig.Path)
+	log.Infoln("GeoZonePath: ", cfg.GeoZoneConfig.Path)
+	log.Infoln("PFD MinAllowedDelay: ", cfg.PfdConfig.MinAllowedDelay)
+	log.Infoln("AF services: ", len(cfg.AfServiceIDs))
+	log.Infoln("PCF APIRoot: ", cfg.PCFConfig.APIRoot)
+	log.Infoln("UDR APIRoot: ", cfg.UDRConfig.APIRoot)
+	log.Infoln("SMF APIRoot: ", cfg.SMFConfig.APIRoot)
+	log.Infoln("UDM APIRoot: ", cfg.UDMConfig.APIRoot)
+	log.Infoln("NRF APIRoot: ", cfg.NRFConfig.APIRoot)
 	log.Infoln("*************************************************************")
 
 }
@@ -0,0 +1,95 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+/* Client implementation of the SMF Stub */
+
+package ngcnef
+
+import (
+	"context"
+	"strconv"
+	"sync"
+)
+
+// SmfClientStub is an implementation of the Smf Event Exposure
+type SmfClientStub struct {
+	smf    string
+	nextID int
+	// mu guards the database as requests are handled concurrently
+	mu sync.Mutex
+	// database to store the event exposure subscriptions created
+	neeDb map[SmfSubscriptionID]NsmfEventExposure
+}
+
+// NewSMFClient creates a new SMF Client
+func NewSMFClient(cfg *Config) *SmfClientStub {
+
+	c := &SmfClientStub{}
+	c.smf = "SMF Stub"
+	c.nextID = 1
+	c.neeDb = make(map[SmfSubscriptionID]NsmfEventExposure)
+	log.Info("SMF Stub Client created")
+	return c
+}
+
+// SmfEventExposureSubscribe is a stub implementation
+// Successful response : 201 and body contains NsmfEventExposure
+func (smf *SmfClientStub) SmfEventExposureSubscribe(ctx context.Context,
+	body NsmfEventExposure) (SmfSubscriptionID, SmfEventExposureResponse,
+	error) {
+
+	_ = ctx
+	smf.mu.Lock()
+	defer smf.mu.Unlock()
+
+	subID := SmfSubscriptionID(strconv.Itoa(smf.nextID))
+	smf.nextID++
+	body.SubID = string(subID)
+	smf.neeDb[subID] = body
+	log.Infof("SMFs EventExposureSubscribe [SubId,NotifId] => [%s,%s]",
+		string(subID), body.NotifID)
+	return subID, SmfEventExposureResponse{ResponseCode: 201, Nee: &body},
+		nil
+}
+
+// SmfEventExposureUpdate is a stub implementation
+// Successful response : 200 and body contains NsmfEventExposure
+func (smf *SmfClientStub) SmfEventExposureUpdate(ctx context.Context,
+	body NsmfEventExposure, subID SmfSubscriptionID) (
+	SmfEventExposureResponse, error) {
+
+	_ = ctx
+	smf.mu.Lock()
+	defer smf.mu.Unlock()
+
+	if _, ok := smf.neeDb[subID]; !ok {
+		log.Infof("SMFs EventExposureUpdate SubId %s not found",
+			string(subID))
+		return SmfEventExposureResponse{ResponseCode: 404}, nil
+	}
+	body.SubID = string(subID)
+	smf.neeDb[subID] = body
+	log.Infof("SMFs EventExposureUpdate [SubId,NotifId] => [%s,%s]",
+		string(subID), body.NotifID)
+	return SmfEventExposureResponse{ResponseCode: 200, Nee: &body}, nil
+}
+
+// SmfEventExposureUnsubscribe is a stub implementation
+// Successful response : 204 and empty body
+func (smf *SmfClientStub) SmfEventExposureUnsubscribe(ctx context.Context,
+	subID SmfSubscriptionID) (SmfEventExposureResponse, error) {
+
+	_ = ctx
+	smf.mu.Lock()
+	defer smf.mu.Unlock()
+
+	if _, ok := smf.neeDb[subID]; !ok {
+		log.Infof("SMFs EventExposureUnsubscribe SubId %s not found",
+			string(subID))
+		return SmfEventExposureResponse{ResponseCode: 404}, nil
+	}
+	delete(smf.neeDb, subID)
+	log.Infof("SMFs EventExposureUnsubscribe SubId %s", string(subID))
+	return SmfEventExposureResponse{ResponseCode: 204}, nil
+}
@@ -0,0 +1,154 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+/* Client implementation of the Nsmf_EventExposure service
+(3GPP TS 29.508) */
+
+package ngcnef
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+const smfSubscriptionsPath = "/nsmf-event-exposure/v1/subscriptions"
+
+// SmfClient is an HTTP/2 implementation of the Smf Event Exposure
+type SmfClient struct {
+	sb *sbClient
+}
+
+// NewSMFHTTPClient creates a new SMF Client sending the requests to the
+// SMF configured in SMFConfig
+func NewSMFHTTPClient(cfg *Config) (*SmfClient, error) {
+
+	sb, err := newSBClient(&cfg.SMFConfig, cfg.UserAgent)
+	if err != nil {
+		log.Errf("SMF Client creation failed: %v", err)
+		return nil, err
+	}
+	log.Infof("SMF Client created for %s", sb.apiRoot)
+	return &SmfClient{sb: sb}, nil
+}
+
+// subscriptionURI returns the uri of the subscription resource on the SMF
+// instance where it was created
+func (smf *SmfClient) subscriptionURI(ctx context.Context,
+	subID SmfSubscriptionID) (string, error) {
+
+	apiRoot, err := smf.sb.resourceRoot(ctx, string(subID))
+	if err != nil {
+		return "", err
+	}
+	return apiRoot + smfSubscriptionsPath + "/" +
+		url.PathEscape(string(subID)), nil
+}
+
+// eventExposureResponse converts the response received from the SMF
+func eventExposureResponse(rsp sbResponse,
+	err error) (SmfEventExposureResponse, error) {
+
+	smfPr := SmfEventExposureResponse{}
+	if err != nil {
+		log.Errf("SMF request failed: %v", err)
+		smfPr.ResponseCode = http.StatusServiceUnavailable
+		smfPr.Pd = sbUnreachableProblem("SMF", err)
+		return smfPr, err
+	}
+
+	smfPr.ResponseCode = uint16(rsp.code)
+	if rsp.code >= 300 {
+		smfPr.Pd = sbProblemDetails(rsp)
+		return smfPr, nil
+	}
+
+	if rsp.code == http.StatusNoContent || len(rsp.body) == 0 {
+		return smfPr, nil
+	}
+
+	nee := NsmfEventExposure{}
+	if err = json.Unmarshal(rsp.body, &nee); err != nil {
+		log.Errf("SMF response decode failed: %v", err)
+		smfPr.ResponseCode = http.StatusInternalServerError
+		smfPr.Pd = &ProblemDetails{Title: "Invalid SMF response",
+			Status: http.StatusInternalServerError}
+		return smfPr, err
+	}
+	smfPr.Nee = &nee
+	return smfPr, nil
+}
+
+// SmfEventExposureSubscribe sends POST to the subscriptions collection
+// Successful response : 201, Location header and body contains
+// NsmfEventExposure
+func (smf *SmfClient) SmfEventExposureSubscribe(ctx context.Context,
+	body NsmfEventExposure) (SmfSubscriptionID, SmfEventExposureResponse,
+	error) {
+
+	log.Infof("SMF EventExposureSubscribe Entered for %s", body.NotifID)
+
+	snssai := Snssai{}
+	if body.Snssai != nil {
+		snssai = *body.Snssai
+	}
+	apiRoot, err := smf.sb.root(ctx, body.Dnn, snssai)
+	if err != nil {
+		smfPr, err := eventExposureResponse(sbResponse{}, err)
+		return "", smfPr, err
+	}
+	rsp, err := smf.sb.send(ctx, http.MethodPost,
+		apiRoot+smfSubscriptionsPath, "application/json", body)
+	smfPr, err := eventExposureResponse(rsp, err)
+	if err != nil || smfPr.ResponseCode != http.StatusCreated {
+		return "", smfPr, err
+	}
+
+	id, err := sbResourceID(rsp, smfSubscriptionsPath+"/")
+	if err != nil {
+		log.Errf("SMF EventExposureSubscribe: %v", err)
+		smfPr.ResponseCode = http.StatusInternalServerError
+		smfPr.Pd = &ProblemDetails{Title: "Invalid SMF response",
+			Detail: err.Error(), Status: http.StatusInternalServerError}
+		return "", smfPr, err
+	}
+	smf.sb.setResourceRoot(id, apiRoot)
+	return SmfSubscriptionID(id), smfPr, nil
+}
+
+// SmfEventExposureUpdate sends PUT to the subscription
+// Successful response : 200 and body contains NsmfEventExposure or 204
+func (smf *SmfClient) SmfEventExposureUpdate(ctx context.Context,
+	body NsmfEventExposure, subID SmfSubscriptionID) (
+	SmfEventExposureResponse, error) {
+
+	log.Infof("SMF EventExposureUpdate Entered for %s", string(subID))
+
+	uri, err := smf.subscriptionURI(ctx, subID)
+	if err != nil {
+		return eventExposureResponse(sbResponse{}, err)
+	}
+	rsp, err := smf.sb.send(ctx, http.MethodPut, uri, "application/json",
+		body)
+	return eventExposureResponse(rsp, err)
+}
+
+// SmfEventExposureUnsubscribe sends DELETE to the subscription
+// Successful response : 204
+func (smf *SmfClient) SmfEventExposureUnsubscribe(ctx context.Context,
+	subID SmfSubscriptionID) (SmfEventExposureResponse, error) {
+
+	log.Infof("SMF EventExposureUnsubscribe Entered for %s", string(subID))
+
+	uri, err := smf.subscriptionURI(ctx, subID)
+	if err != nil {
+		return eventExposureResponse(sbResponse{}, err)
+	}
+	rsp, err := smf.sb.send(ctx, http.MethodDelete, uri, "", nil)
+	if err == nil && (rsp.code < 300 || rsp.code == http.StatusNotFound) {
+		smf.sb.deleteResourceRoot(string(subID))
+	}
+	return eventExposureResponse(rsp, err)
+}
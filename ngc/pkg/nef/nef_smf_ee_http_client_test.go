@@ -0,0 +1,241 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	ngcnef "github.com/open-ness/epcforedge/ngc/pkg/nef"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+const fakeSMFSubscriptions = "/nsmf-event-exposure/v1/subscriptions"
+
+// fakeSMF is a local Nsmf_EventExposure server used for testing the SMF
+// client over HTTP/2 clear text
+type fakeSMF struct {
+	server *httptest.Server
+	mu     sync.Mutex
+	nextID int
+	nees   map[string]ngcnef.NsmfEventExposure
+}
+
+func newFakeSMF() *fakeSMF {
+
+	smf := &fakeSMF{nextID: 1, nees: map[string]ngcnef.NsmfEventExposure{}}
+	smf.server = httptest.NewServer(h2c.NewHandler(
+		http.HandlerFunc(smf.serveHTTP), &http2.Server{}))
+	return smf
+}
+
+func (smf *fakeSMF) subscriptions() []ngcnef.NsmfEventExposure {
+	smf.mu.Lock()
+	defer smf.mu.Unlock()
+	nees := []ngcnef.NsmfEventExposure{}
+	for _, nee := range smf.nees {
+		nees = append(nees, nee)
+	}
+	return nees
+}
+
+func (smf *fakeSMF) serveHTTP(w http.ResponseWriter, r *http.Request) {
+
+	smf.mu.Lock()
+	defer smf.mu.Unlock()
+
+	if r.ProtoMajor != 2 {
+		problem(w, http.StatusHTTPVersionNotSupported, "")
+		return
+	}
+
+	if r.URL.Path == fakeSMFSubscriptions && r.Method == http.MethodPost {
+		nee := ngcnef.NsmfEventExposure{}
+		if err := json.NewDecoder(r.Body).Decode(&nee); err != nil {
+			problem(w, http.StatusBadRequest, "")
+			return
+		}
+		id := strconv.Itoa(smf.nextID)
+		smf.nextID++
+		nee.SubID = id
+		smf.nees[id] = nee
+		w.Header().Set("Location",
+			smf.server.URL+fakeSMFSubscriptions+"/"+id)
+		writeJSON(w, http.StatusCreated, "application/json", nee)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, fakeSMFSubscriptions+"/")
+	if _, ok := smf.nees[id]; !ok {
+		problem(w, http.StatusNotFound, "SUBSCRIPTION_NOT_FOUND")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		nee := ngcnef.NsmfEventExposure{}
+		if err := json.NewDecoder(r.Body).Decode(&nee); err != nil {
+			problem(w, http.StatusBadRequest, "")
+			return
+		}
+		nee.SubID = id
+		smf.nees[id] = nee
+		writeJSON(w, http.StatusOK, "application/json", nee)
+	case http.MethodDelete:
+		delete(smf.nees, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// createSMFCfg writes a copy of the valid NEF configuration using the SMF
+// at apiRoot and returns its path
+func createSMFCfg(dir string, apiRoot string) string {
+	return createNefCfg(dir, map[string]interface{}{
+		"SMFConfig": sbConfig(apiRoot)})
+}
+
+var _ = Describe("NEF SMF Event Exposure Client", func() {
+
+	var (
+		smf *fakeSMF
+		cfg ngcnef.Config
+	)
+
+	BeforeEach(func() {
+		smf = newFakeSMF()
+		cfg = ngcnef.Config{UserAgent: "NEF-OPENNESS-1912"}
+		cfg.SMFConfig.APIRoot = smf.server.URL
+	})
+
+	AfterEach(func() {
+		smf.server.Close()
+	})
+
+	It("Subscribe, update and unsubscribe", func() {
+
+		client, err := ngcnef.NewSMFHTTPClient(&cfg)
+		Expect(err).Should(BeNil())
+		ctx := context.Background()
+
+		nee := ngcnef.NsmfEventExposure{AnyUeInd: true, NotifID: "1",
+			EventSubs: []ngcnef.EventSubscription{
+				{Event: ngcnef.SmfEventUpPathCh}}}
+		id, rsp, err := client.SmfEventExposureSubscribe(ctx, nee)
+		Expect(err).Should(BeNil())
+		Expect(rsp.ResponseCode).Should(Equal(uint16(http.StatusCreated)))
+		Expect(id).Should(Equal(ngcnef.SmfSubscriptionID("1")))
+
+		nee.NotifID = "2"
+		rsp, err = client.SmfEventExposureUpdate(ctx, nee, id)
+		Expect(err).Should(BeNil())
+		Expect(rsp.ResponseCode).Should(Equal(uint16(http.StatusOK)))
+		Expect(rsp.Nee.NotifID).Should(Equal("2"))
+
+		rsp, err = client.SmfEventExposureUnsubscribe(ctx, id)
+		Expect(err).Should(BeNil())
+		Expect(rsp.ResponseCode).Should(Equal(uint16(http.StatusNoContent)))
+		Expect(len(smf.subscriptions())).Should(Equal(0))
+	})
+
+	It("Maps the ProblemDetails of a failure response", func() {
+
+		client, err := ngcnef.NewSMFHTTPClient(&cfg)
+		Expect(err).Should(BeNil())
+
+		rsp, err := client.SmfEventExposureUnsubscribe(context.Background(),
+			"10")
+		Expect(err).Should(BeNil())
+		Expect(rsp.ResponseCode).Should(Equal(uint16(http.StatusNotFound)))
+		Expect(rsp.Pd.Cause).Should(Equal("SUBSCRIPTION_NOT_FOUND"))
+	})
+
+	It("Returns an error when the SMF is not reachable", func() {
+
+		client, err := ngcnef.NewSMFHTTPClient(&cfg)
+		Expect(err).Should(BeNil())
+		smf.server.Close()
+
+		_, rsp, err := client.SmfEventExposureSubscribe(context.Background(),
+			ngcnef.NsmfEventExposure{})
+		Expect(err).ShouldNot(BeNil())
+		Expect(rsp.ResponseCode).Should(
+			Equal(uint16(http.StatusServiceUnavailable)))
+	})
+
+	It("Any UE subscription to UP path change subscribes to the SMF",
+		func() {
+
+			dir, err := ioutil.TempDir("", "nefsmf")
+			Expect(err).Should(BeNil())
+			defer os.RemoveAll(dir)
+
+			ctx, cancel := startNefWithCfg(createSMFCfg(dir, smf.server.URL))
+			defer cancel()
+
+			postbody, _ := ioutil.ReadFile(testJSONPath +
+				"AF_NEF_POST_UDR_01.json")
+			putbody, _ := ioutil.ReadFile(testJSONPath +
+				"AF_NEF_PUT_UDR_01.json")
+
+			rr, req := CreateReqForNEF(ctx, "POST", "", postbody)
+			ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+			Expect(rr.Code).Should(Equal(http.StatusCreated))
+			nees := smf.subscriptions()
+			Expect(len(nees)).Should(Equal(1))
+			Expect(nees[0].AnyUeInd).Should(BeTrue())
+			Expect(nees[0].NotifID).ShouldNot(BeEmpty())
+			Expect(nees[0].EventSubs[0].Event).Should(
+				Equal(ngcnef.SmfEventUpPathCh))
+			notifID := nees[0].NotifID
+
+			rr, req = CreateReqForNEF(ctx, "PUT", "11111", putbody)
+			ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+			Expect(rr.Code).Should(Equal(http.StatusOK))
+			nees = smf.subscriptions()
+			Expect(len(nees)).Should(Equal(1))
+			Expect(nees[0].NotifID).ShouldNot(Equal(notifID))
+
+			rr, req = CreateReqForNEF(ctx, "DELETE", "11111", nil)
+			ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+			Expect(rr.Code).Should(Equal(http.StatusNoContent))
+			Expect(len(smf.subscriptions())).Should(Equal(0))
+		})
+
+	It("Subscription fails if the SMF rejects it", func() {
+
+		dir, err := ioutil.TempDir("", "nefsmf")
+		Expect(err).Should(BeNil())
+		defer os.RemoveAll(dir)
+
+		server := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				problem(w, http.StatusForbidden, "")
+			}), &http2.Server{}))
+		defer server.Close()
+
+		ctx, cancel := startNefWithCfg(createSMFCfg(dir, server.URL))
+		defer cancel()
+		count := fakeUDRG.influenceData()
+
+		postbody, _ := ioutil.ReadFile(testJSONPath +
+			"AF_NEF_POST_UDR_01.json")
+		rr, req := CreateReqForNEF(ctx, "POST", "", postbody)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusBadRequest))
+		Expect(fakeUDRG.influenceData()).Should(Equal(count))
+	})
+})
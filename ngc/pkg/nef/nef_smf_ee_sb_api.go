@@ -0,0 +1,49 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef
+
+import "context"
+
+/* The SB interfaces towards the SMF that need to be implemented by
+   eith the NEF SB stub / NEF SB client receivers */
+
+// SmfEventExposureResponse contains the response from SMF
+type SmfEventExposureResponse struct {
+	// responseCode contains the http response code provided by the SMF
+	ResponseCode uint16
+	// nee if not nil contains the NsmfEventExposure data provided by SMF
+	Nee *NsmfEventExposure
+	// pd if not nil contains the problem information from SMF.
+	// Valid for 3xx, 4xx, 5xx or 6xx responses
+	Pd *ProblemDetails
+}
+
+// SmfSubscriptionID contains the subscription id returned by the SMF
+// Its present in the location header as below:
+// "{apiRoot}/nsmf-event-exposure/v1/subscriptions/{subId}"
+type SmfSubscriptionID string
+
+// SmfEventExposure defines the interfaces that are exposed for the UP path
+// change events of TrafficInfluence
+type SmfEventExposure interface {
+	// SmfEventExposureSubscribe sends POST request to the SMF to create a
+	// subscription. It returns the subscription id, the response received
+	// from the SMF and any error encountered when sending the request.
+	SmfEventExposureSubscribe(ctx context.Context,
+		body NsmfEventExposure) (SmfSubscriptionID,
+		SmfEventExposureResponse, error)
+
+	// SmfEventExposureUpdate sends PUT request to the SMF to replace the
+	// subscription. It returns the response received from the SMF and any
+	// error encountered when sending the request.
+	SmfEventExposureUpdate(ctx context.Context, body NsmfEventExposure,
+		subID SmfSubscriptionID) (SmfEventExposureResponse, error)
+
+	// SmfEventExposureUnsubscribe sends DELETE request to the SMF to remove
+	// the subscription. It returns the response received from the SMF and
+	// any error encountered when sending the request.
+	SmfEventExposureUnsubscribe(ctx context.Context,
+		subID SmfSubscriptionID) (SmfEventExposureResponse, error)
+}
@@ -0,0 +1,650 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+/* The NEF store keeps a copy of the AF, traffic influence subscription, AS
+   session with QoS subscription, monitoring event subscription and PFD
+   transaction data so that it can be restored when the NEF restarts.
+   The PCF/UDR/UDM keep the policies and subscriptions created by the NEF
+   across a restart, so the NEF must not forget the southbound handles
+   (appSessionID, iid, udmSubID) and the notification correlation IDs linked
+   to them. */
+
+// Store record kinds
+const (
+	storeKindNef      = "nef"
+	storeKindAf       = "af"
+	storeKindSub      = "sub"
+	storeKindPfdTrans = "pfdtrans"
+	storeKindQosSub   = "qossub"
+	storeKindMeSub    = "mesub"
+)
+
+// defStoreCompactRecords is the default CompactRecords of the StoreConfig
+const defStoreCompactRecords = 1000
+
+// Store record operations
+const (
+	storeOpPut = "put"
+	storeOpDel = "del"
+)
+
+// nefStoreAf is the persisted form of afData
+type nefStoreAf struct {
+	AfID       string `json:"afId"`
+	SubIDnum   int    `json:"subIdNum"`
+	TransIDnum int    `json:"transIdNum"`
+}
+
+// nefStoreSub is the persisted form of afSubscription
+type nefStoreSub struct {
+	SubID                     string            `json:"subId"`
+	Ti                        TrafficInfluSub   `json:"ti"`
+	AppSessionID              AppSessionID      `json:"appSessionId,omitempty"`
+	Iid                       InfluenceID       `json:"iid,omitempty"`
+	SmfSubID                  SmfSubscriptionID `json:"smfSubId,omitempty"`
+	NotifCorreID              string            `json:"notifCorreId,omitempty"`
+	AfNotificationDestination Link              `json:"afNotifDest,omitempty"`
+	Inactive                  bool              `json:"inactive,omitempty"`
+}
+
+// nefStoreQosSub is the persisted form of afQosSubscription
+type nefStoreQosSub struct {
+	SubID        string                       `json:"subId"`
+	Qos          AsSessionWithQoSSubscription `json:"qos"`
+	AppSessionID AppSessionID                 `json:"appSessionId,omitempty"`
+}
+
+// nefStoreMeSub is the persisted form of afMeSubscription
+type nefStoreMeSub struct {
+	SubID      string                      `json:"subId"`
+	Me         MonitoringEventSubscription `json:"me"`
+	UdmSubID   UdmEeSubscriptionID         `json:"udmSubId,omitempty"`
+	UeIdentity string                      `json:"ueIdentity"`
+	CorrID     string                      `json:"corrId"`
+	NumReports int32                       `json:"numReports,omitempty"`
+}
+
+// nefStorePfdTrans is the persisted form of afPfdTransaction
+type nefStorePfdTrans struct {
+	TransID       string        `json:"transId"`
+	PfdManagement PfdManagement `json:"pfdManagement"`
+	// Time at which the caching time of the applications elapses
+	CacheExpiries map[string]time.Time `json:"cacheExpiries,omitempty"`
+}
+
+// nefStoreAfState contains the restored data of a single AF
+type nefStoreAfState struct {
+	af       nefStoreAf
+	subs     map[string]nefStoreSub
+	qosSubs  map[string]nefStoreQosSub
+	meSubs   map[string]nefStoreMeSub
+	pfdtrans map[string]nefStorePfdTrans
+}
+
+// nefStoreState contains the complete restored NEF data
+type nefStoreState struct {
+	corrID uint
+	afs    map[string]*nefStoreAfState
+}
+
+// nefStore defines the interfaces to be implemented by a NEF persistent store
+type nefStore interface {
+	// load returns the NEF data saved in the store
+	load() (*nefStoreState, error)
+	// putCorrID saves the next notification correlation ID to be used
+	putCorrID(corrID uint) error
+	// putAf saves the AF data
+	putAf(af nefStoreAf) error
+	// deleteAf removes the AF data along with its subscriptions and PFD
+	// transactions
+	deleteAf(afID string) error
+	// putSub saves a subscription of an AF
+	putSub(afID string, sub nefStoreSub) error
+	// deleteSub removes a subscription of an AF
+	deleteSub(afID string, subID string) error
+	// putQosSub saves an AS session with QoS subscription of an AF
+	putQosSub(afID string, sub nefStoreQosSub) error
+	// deleteQosSub removes an AS session with QoS subscription of an AF
+	deleteQosSub(afID string, subID string) error
+	// putMeSub saves a monitoring event subscription of an AF
+	putMeSub(afID string, sub nefStoreMeSub) error
+	// deleteMeSub removes a monitoring event subscription of an AF
+	deleteMeSub(afID string, subID string) error
+	// putPfdTrans saves a PFD transaction of an AF
+	putPfdTrans(afID string, trans nefStorePfdTrans) error
+	// deletePfdTrans removes a PFD transaction of an AF
+	deletePfdTrans(afID string, transID string) error
+	// close releases the resources held by the store
+	close() error
+}
+
+// newNefStore creates the store selected in the NEF configuration. If no
+// store path is configured the NEF data is kept in memory only.
+func newNefStore(cfg *Config) (nefStore, error) {
+
+	if cfg.StoreConfig.Path == "" {
+		log.Info("NEF Store not configured, data will not be persisted")
+		return &nefNullStore{}, nil
+	}
+	compactRecords := cfg.StoreConfig.CompactRecords
+	if compactRecords <= 0 {
+		compactRecords = defStoreCompactRecords
+	}
+	return newNefJournalStore(cfg.StoreConfig.Path, compactRecords)
+}
+
+func newNefStoreState() *nefStoreState {
+	return &nefStoreState{afs: make(map[string]*nefStoreAfState)}
+}
+
+func (s *nefStoreState) getAf(afID string) *nefStoreAfState {
+
+	afs, ok := s.afs[afID]
+	if !ok {
+		afs = &nefStoreAfState{af: nefStoreAf{AfID: afID},
+			subs:     make(map[string]nefStoreSub),
+			qosSubs:  make(map[string]nefStoreQosSub),
+			meSubs:   make(map[string]nefStoreMeSub),
+			pfdtrans: make(map[string]nefStorePfdTrans)}
+		s.afs[afID] = afs
+	}
+	return afs
+}
+
+/**********************************/
+/* Null store                     */
+/**********************************/
+
+// nefNullStore is used when persistence is disabled
+type nefNullStore struct{}
+
+func (s *nefNullStore) load() (*nefStoreState, error) {
+	return newNefStoreState(), nil
+}
+
+func (s *nefNullStore) putCorrID(corrID uint) error { return nil }
+
+func (s *nefNullStore) putAf(af nefStoreAf) error { return nil }
+
+func (s *nefNullStore) deleteAf(afID string) error { return nil }
+
+func (s *nefNullStore) putSub(afID string, sub nefStoreSub) error {
+	return nil
+}
+
+func (s *nefNullStore) deleteSub(afID string, subID string) error {
+	return nil
+}
+
+func (s *nefNullStore) putQosSub(afID string, sub nefStoreQosSub) error {
+	return nil
+}
+
+func (s *nefNullStore) deleteQosSub(afID string, subID string) error {
+	return nil
+}
+
+func (s *nefNullStore) putMeSub(afID string, sub nefStoreMeSub) error {
+	return nil
+}
+
+func (s *nefNullStore) deleteMeSub(afID string, subID string) error {
+	return nil
+}
+
+func (s *nefNullStore) putPfdTrans(afID string,
+	trans nefStorePfdTrans) error {
+	return nil
+}
+
+func (s *nefNullStore) deletePfdTrans(afID string, transID string) error {
+	return nil
+}
+
+func (s *nefNullStore) close() error { return nil }
+
+/**********************************/
+/* Journal store                  */
+/**********************************/
+
+// nefStoreRecord is a single entry of the journal
+type nefStoreRecord struct {
+	Op   string          `json:"op"`
+	Kind string          `json:"kind"`
+	AfID string          `json:"afId,omitempty"`
+	ID   string          `json:"id,omitempty"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// nefJournalStore is an append only JSON journal. Every change is written as
+// a single line and synced to the disk. The journal is compacted to the
+// current state every time it is opened and when the records appended since
+// the last compaction reach compactRecords and outnumber the current ones.
+type nefJournalStore struct {
+	mu             sync.Mutex
+	path           string
+	file           *os.File
+	state          *nefStoreState
+	compactRecords int
+	// records written by the last compaction and appended since then
+	compacted int
+	appended  int
+}
+
+// newNefJournalStore opens the journal at path, replays it and compacts it
+func newNefJournalStore(path string, compactRecords int) (*nefJournalStore,
+	error) {
+
+	s := &nefJournalStore{path: filepath.Clean(path),
+		compactRecords: compactRecords}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0750); err != nil {
+		return nil, err
+	}
+
+	state, err := s.replay()
+	if err != nil {
+		return nil, err
+	}
+	s.state = state
+
+	if err = s.compact(); err != nil {
+		return nil, err
+	}
+	if err = syncDir(filepath.Dir(s.path)); err != nil {
+		return nil, err
+	}
+
+	s.file, err = os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("NEF Store opened: %s", s.path)
+	return s, nil
+}
+
+// compactOpen compacts the open journal and reopens it, the caller must
+// hold mu. The old journal is kept when the compaction fails.
+func (s *nefJournalStore) compactOpen() error {
+
+	if err := s.compact(); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err := s.file.Close(); err != nil {
+		log.Errf("NEF Store journal was not closed properly")
+	}
+	// The old journal was replaced, the next writes fail rather than being
+	// appended to it
+	s.file = f
+	if err != nil {
+		s.file = nil
+		return err
+	}
+	log.Infof("NEF Store compacted to %d records", s.compacted)
+	return syncDir(filepath.Dir(s.path))
+}
+
+// replay reads the journal and builds the NEF data out of it
+func (s *nefJournalStore) replay() (*nefStoreState, error) {
+
+	state := newNefStoreState()
+
+	f, err := os.Open(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, err
+	}
+	defer func() {
+		if err = f.Close(); err != nil {
+			log.Errf("NEF Store journal was not closed properly")
+		}
+	}()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		var rec nefStoreRecord
+		if err = json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			// A partially written record is expected only at the end of the
+			// journal when the NEF stopped in the middle of a write
+			log.Errf("NEF Store skipping invalid record at line %d: %v",
+				line, err)
+			continue
+		}
+		if err = state.apply(rec); err != nil {
+			log.Errf("NEF Store skipping record at line %d: %v", line, err)
+		}
+	}
+	return state, scanner.Err()
+}
+
+// apply updates the state with a journal record
+func (s *nefStoreState) apply(rec nefStoreRecord) error {
+
+	switch rec.Kind {
+	case storeKindNef:
+		return json.Unmarshal(rec.Data, &s.corrID)
+
+	case storeKindAf:
+		if rec.Op == storeOpDel {
+			delete(s.afs, rec.AfID)
+			return nil
+		}
+		var af nefStoreAf
+		if err := json.Unmarshal(rec.Data, &af); err != nil {
+			return err
+		}
+		s.getAf(rec.AfID).af = af
+
+	case storeKindSub:
+		afs := s.getAf(rec.AfID)
+		if rec.Op == storeOpDel {
+			delete(afs.subs, rec.ID)
+			return nil
+		}
+		var sub nefStoreSub
+		if err := json.Unmarshal(rec.Data, &sub); err != nil {
+			return err
+		}
+		afs.subs[rec.ID] = sub
+
+	case storeKindQosSub:
+		afs := s.getAf(rec.AfID)
+		if rec.Op == storeOpDel {
+			delete(afs.qosSubs, rec.ID)
+			return nil
+		}
+		var sub nefStoreQosSub
+		if err := json.Unmarshal(rec.Data, &sub); err != nil {
+			return err
+		}
+		afs.qosSubs[rec.ID] = sub
+
+	case storeKindMeSub:
+		afs := s.getAf(rec.AfID)
+		if rec.Op == storeOpDel {
+			delete(afs.meSubs, rec.ID)
+			return nil
+		}
+		var sub nefStoreMeSub
+		if err := json.Unmarshal(rec.Data, &sub); err != nil {
+			return err
+		}
+		afs.meSubs[rec.ID] = sub
+
+	case storeKindPfdTrans:
+		afs := s.getAf(rec.AfID)
+		if rec.Op == storeOpDel {
+			delete(afs.pfdtrans, rec.ID)
+			return nil
+		}
+		var trans nefStorePfdTrans
+		if err := json.Unmarshal(rec.Data, &trans); err != nil {
+			return err
+		}
+		afs.pfdtrans[rec.ID] = trans
+
+	default:
+		return errors.New("unknown record kind " + rec.Kind)
+	}
+	return nil
+}
+
+// records returns the minimal list of journal records describing the state
+func (s *nefStoreState) records() ([]nefStoreRecord, error) {
+
+	var recs []nefStoreRecord
+
+	data, err := json.Marshal(s.corrID)
+	if err != nil {
+		return nil, err
+	}
+	recs = append(recs, nefStoreRecord{Op: storeOpPut, Kind: storeKindNef,
+		Data: data})
+
+	for afID, afs := range s.afs {
+		if data, err = json.Marshal(afs.af); err != nil {
+			return nil, err
+		}
+		recs = append(recs, nefStoreRecord{Op: storeOpPut,
+			Kind: storeKindAf, AfID: afID, Data: data})
+
+		for subID, sub := range afs.subs {
+			if data, err = json.Marshal(sub); err != nil {
+				return nil, err
+			}
+			recs = append(recs, nefStoreRecord{Op: storeOpPut,
+				Kind: storeKindSub, AfID: afID, ID: subID, Data: data})
+		}
+		for subID, sub := range afs.qosSubs {
+			if data, err = json.Marshal(sub); err != nil {
+				return nil, err
+			}
+			recs = append(recs, nefStoreRecord{Op: storeOpPut,
+				Kind: storeKindQosSub, AfID: afID, ID: subID, Data: data})
+		}
+		for subID, sub := range afs.meSubs {
+			if data, err = json.Marshal(sub); err != nil {
+				return nil, err
+			}
+			recs = append(recs, nefStoreRecord{Op: storeOpPut,
+				Kind: storeKindMeSub, AfID: afID, ID: subID, Data: data})
+		}
+		for transID, trans := range afs.pfdtrans {
+			if data, err = json.Marshal(trans); err != nil {
+				return nil, err
+			}
+			recs = append(recs, nefStoreRecord{Op: storeOpPut,
+				Kind: storeKindPfdTrans, AfID: afID, ID: transID,
+				Data: data})
+		}
+	}
+	return recs, nil
+}
+
+// compact rewrites the journal with the current state. The directory of the
+// journal must be synced afterwards for the rename to be durable.
+func (s *nefJournalStore) compact() error {
+
+	recs, err := s.state.records()
+	if err != nil {
+		return err
+	}
+
+	tmpPath := s.path + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, rec := range recs {
+		if err = enc.Encode(rec); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+	if err = w.Flush(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmpPath, s.path); err != nil {
+		return err
+	}
+	s.compacted = len(recs)
+	s.appended = 0
+	return nil
+}
+
+// syncDir syncs the directory at path so that a rename in it is durable
+func syncDir(path string) error {
+
+	d, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return err
+	}
+	if err = d.Sync(); err != nil {
+		_ = d.Close()
+		return err
+	}
+	return d.Close()
+}
+
+// write appends a record to the journal and applies it to the state
+func (s *nefJournalStore) write(op string, kind string, afID string,
+	id string, v interface{}) error {
+
+	rec := nefStoreRecord{Op: op, Kind: kind, AfID: afID, ID: id}
+	if v != nil {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		rec.Data = data
+	}
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return errors.New("NEF Store is closed")
+	}
+	if _, err = s.file.Write(line); err != nil {
+		return err
+	}
+	if err = s.file.Sync(); err != nil {
+		return err
+	}
+	if err = s.state.apply(rec); err != nil {
+		return err
+	}
+
+	s.appended++
+	if s.appended >= s.compactRecords && s.appended > s.compacted {
+		if err = s.compactOpen(); err != nil {
+			// The record is saved, the compaction is retried after the
+			// next compactRecords records
+			log.Errf("NEF Store compaction failed: %v", err)
+			s.appended = 0
+		}
+	}
+	return nil
+}
+
+func (s *nefJournalStore) load() (*nefStoreState, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Hand out a copy so that later writes do not change the loaded data
+	state := newNefStoreState()
+	state.corrID = s.state.corrID
+	for afID, afs := range s.state.afs {
+		cp := state.getAf(afID)
+		cp.af = afs.af
+		for k, v := range afs.subs {
+			cp.subs[k] = v
+		}
+		for k, v := range afs.qosSubs {
+			cp.qosSubs[k] = v
+		}
+		for k, v := range afs.meSubs {
+			cp.meSubs[k] = v
+		}
+		for k, v := range afs.pfdtrans {
+			cp.pfdtrans[k] = v
+		}
+	}
+	return state, nil
+}
+
+func (s *nefJournalStore) putCorrID(corrID uint) error {
+	return s.write(storeOpPut, storeKindNef, "", "", corrID)
+}
+
+func (s *nefJournalStore) putAf(af nefStoreAf) error {
+	return s.write(storeOpPut, storeKindAf, af.AfID, "", af)
+}
+
+func (s *nefJournalStore) deleteAf(afID string) error {
+	return s.write(storeOpDel, storeKindAf, afID, "", nil)
+}
+
+func (s *nefJournalStore) putSub(afID string, sub nefStoreSub) error {
+	return s.write(storeOpPut, storeKindSub, afID, sub.SubID, sub)
+}
+
+func (s *nefJournalStore) deleteSub(afID string, subID string) error {
+	return s.write(storeOpDel, storeKindSub, afID, subID, nil)
+}
+
+func (s *nefJournalStore) putQosSub(afID string, sub nefStoreQosSub) error {
+	return s.write(storeOpPut, storeKindQosSub, afID, sub.SubID, sub)
+}
+
+func (s *nefJournalStore) deleteQosSub(afID string, subID string) error {
+	return s.write(storeOpDel, storeKindQosSub, afID, subID, nil)
+}
+
+func (s *nefJournalStore) putMeSub(afID string, sub nefStoreMeSub) error {
+	return s.write(storeOpPut, storeKindMeSub, afID, sub.SubID, sub)
+}
+
+func (s *nefJournalStore) deleteMeSub(afID string, subID string) error {
+	return s.write(storeOpDel, storeKindMeSub, afID, subID, nil)
+}
+
+func (s *nefJournalStore) putPfdTrans(afID string,
+	trans nefStorePfdTrans) error {
+	return s.write(storeOpPut, storeKindPfdTrans, afID, trans.TransID, trans)
+}
+
+func (s *nefJournalStore) deletePfdTrans(afID string, transID string) error {
+	return s.write(storeOpDel, storeKindPfdTrans, afID, transID, nil)
+}
+
+func (s *nefJournalStore) close() error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
@@ -0,0 +1,182 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	ngcnef "github.com/open-ness/epcforedge/ngc/pkg/nef"
+)
+
+// createStoreCfg writes a copy of the valid NEF configuration with the
+// persistent store enabled and returns its path
+func createStoreCfg(dir string) string {
+	return createNefCfg(dir, map[string]interface{}{
+		"StoreConfig": map[string]string{
+			"path": filepath.Join(dir, "nef.journal")}})
+}
+
+// journalRecords returns the number of records of the journal in dir
+func journalRecords(dir string) int {
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "nef.journal"))
+	Expect(err).Should(BeNil())
+	return strings.Count(string(b), "\n")
+}
+
+func startNefWithCfg(cfgPath string) (context.Context, func()) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		err := ngcnef.Run(ctx, cfgPath)
+		Expect(err).To(BeNil())
+	}()
+	time.Sleep(2 * time.Second)
+	return ctx, cancel
+}
+
+var _ = Describe("NEF Store", func() {
+
+	var (
+		dir     string
+		cfgPath string
+		subLoc  string
+	)
+
+	Describe("Restore of subscriptions and PFD transactions", func() {
+
+		It("Create data and restart the NEF", func() {
+
+			var err error
+			dir, err = ioutil.TempDir("", "nefstore")
+			Expect(err).Should(BeNil())
+			cfgPath = createStoreCfg(dir)
+
+			ctx, cancel := startNefWithCfg(cfgPath)
+
+			postbody, _ := ioutil.ReadFile(testJSONPath +
+				"AF_NEF_POST_01.json")
+			rr, req := CreateReqForNEF(ctx, "POST", "", postbody)
+			req.Header.Set("Content-Type", "application/json")
+			ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+			Expect(rr.Code).Should(Equal(http.StatusCreated))
+			subLoc = rr.Header().Get("Location")
+
+			pfdbody, _ := ioutil.ReadFile(testJSONPFDPath +
+				"AF_NEF_PFD_POST_001.json")
+			rr, req = CreatePFDReqForNEF(ctx, "POST", "", "", pfdbody)
+			req.Header.Set("Content-Type", "application/json")
+			ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+			Expect(rr.Code).Should(Equal(http.StatusCreated))
+
+			cancel()
+			time.Sleep(1 * time.Second)
+		})
+
+		It("Restored subscription and PFD transaction are available",
+			func() {
+
+				ctx, cancel := startNefWithCfg(cfgPath)
+				defer cancel()
+				defer os.RemoveAll(dir)
+
+				rr, req := CreateReqForNEF(ctx, "GET",
+					filepath.Base(subLoc), nil)
+				ngcnef.NefAppG.NefRouter.ServeHTTP(rr,
+					req.WithContext(ctx))
+				Expect(rr.Code).Should(Equal(http.StatusOK))
+
+				var ti ngcnef.TrafficInfluSub
+				Expect(json.Unmarshal(rr.Body.Bytes(), &ti)).Should(BeNil())
+				Expect(string(ti.Self)).Should(Equal(subLoc))
+
+				rr, req = CreatePFDReqForNEF(ctx, "GET", "10000", "app1",
+					nil)
+				ngcnef.NefAppG.NefRouter.ServeHTTP(rr,
+					req.WithContext(ctx))
+				Expect(rr.Code).Should(Equal(http.StatusOK))
+
+				// Application IDs of restored transactions stay reserved
+				pfdbody, _ := ioutil.ReadFile(testJSONPFDPath +
+					"AF_NEF_PFD_POST_001.json")
+				rr, req = CreatePFDReqForNEF(ctx, "POST", "", "", pfdbody)
+				req.Header.Set("Content-Type", "application/json")
+				ngcnef.NefAppG.NefRouter.ServeHTTP(rr,
+					req.WithContext(ctx))
+				Expect(rr.Code).Should(Equal(http.StatusInternalServerError))
+
+				// Clean up the subscription and transaction
+				rr, req = CreateReqForNEF(ctx, "DELETE",
+					filepath.Base(subLoc), nil)
+				ngcnef.NefAppG.NefRouter.ServeHTTP(rr,
+					req.WithContext(ctx))
+				Expect(rr.Code).Should(Equal(http.StatusNoContent))
+			})
+	})
+
+	Describe("Compaction of the journal", func() {
+
+		It("Compacts the journal while the NEF runs", func() {
+
+			dir, err := ioutil.TempDir("", "nefstore")
+			Expect(err).Should(BeNil())
+			defer os.RemoveAll(dir)
+			cfgPath = createNefCfg(dir, map[string]interface{}{
+				"StoreConfig": map[string]interface{}{
+					"path":           filepath.Join(dir, "nef.journal"),
+					"compactRecords": 4}})
+
+			ctx, cancel := startNefWithCfg(cfgPath)
+
+			postbody, _ := ioutil.ReadFile(testJSONPath +
+				"AF_NEF_POST_01.json")
+			rr, req := CreateReqForNEF(ctx, "POST", "", postbody)
+			ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+			Expect(rr.Code).Should(Equal(http.StatusCreated))
+			subLoc = rr.Header().Get("Location")
+
+			// Every cycle appends at least 2 records, the journal only
+			// keeps the ones of the remaining subscription
+			for i := 0; i < 20; i++ {
+				rr, req = CreateReqForNEF(ctx, "POST", "", postbody)
+				ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+				Expect(rr.Code).Should(Equal(http.StatusCreated))
+
+				rr, req = CreateReqForNEF(ctx, "DELETE",
+					filepath.Base(rr.Header().Get("Location")), nil)
+				ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+				Expect(rr.Code).Should(Equal(http.StatusNoContent))
+			}
+			Expect(journalRecords(dir)).Should(BeNumerically("<=", 10))
+			_, err = os.Stat(filepath.Join(dir, "nef.journal.tmp"))
+			Expect(os.IsNotExist(err)).Should(BeTrue())
+
+			cancel()
+			time.Sleep(1 * time.Second)
+
+			// The compacted journal restores the subscription
+			ctx, cancel = startNefWithCfg(cfgPath)
+			defer cancel()
+
+			rr, req = CreateReqForNEF(ctx, "GET", filepath.Base(subLoc), nil)
+			ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+			Expect(rr.Code).Should(Equal(http.StatusOK))
+
+			rr, req = CreateReqForNEF(ctx, "DELETE", filepath.Base(subLoc),
+				nil)
+			ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+			Expect(rr.Code).Should(Equal(http.StatusNoContent))
+		})
+	})
+})
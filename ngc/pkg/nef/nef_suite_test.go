@@ -16,6 +16,17 @@ const NefTestJSONBasepath = "../../test/nef/nef-cli-scripts/json/"
 const NefTIFApiPrefix = "http://localhost:8091/3gpp-traffic-influence/v1/"
 const NefTIFApiPrefixHTTP2 = "https://localhost:8090/3gpp-traffic-influence/v1/"
 
+// fakeUDRG is the UDR used by the NEF started with the test config
+var fakeUDRG *fakeUDR
+
+var _ = BeforeSuite(func() {
+	fakeUDRG = newFakeUDR(fakeUDRAddr)
+})
+
+var _ = AfterSuite(func() {
+	fakeUDRG.server.Close()
+})
+
 func TestNef(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Nef Suite")
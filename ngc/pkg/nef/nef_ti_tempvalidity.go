@@ -0,0 +1,242 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// Delay before retrying a failed activation or deactivation of the policy of
+// a subscription
+var tempValidityRetryInterval = 30 * time.Second
+
+// parseTempValidity returns the start and stop time of the temporal validity.
+// A missing start or stop time is returned as the zero time.
+func parseTempValidity(tv TemporalValidity) (start time.Time,
+	stop time.Time, err error) {
+
+	if tv.StartTime != "" {
+		if start, err = time.Parse(time.RFC3339, tv.StartTime); err != nil {
+			return start, stop, err
+		}
+	}
+	if tv.StopTime != "" {
+		if stop, err = time.Parse(time.RFC3339, tv.StopTime); err != nil {
+			return start, stop, err
+		}
+	}
+	if !start.IsZero() && !stop.IsZero() && !stop.After(start) {
+		return start, stop, errors.New("stopTime is not after startTime")
+	}
+	return start, stop, nil
+}
+
+// validateTempValidities checks the time format and the order of the start
+// and stop time of the temporal validities
+func validateTempValidities(tvs []TemporalValidity) (rsp nefSBRspData,
+	status bool) {
+
+	for i, tv := range tvs {
+		if _, _, err := parseTempValidity(tv); err != nil {
+			rsp.pd.InvalidParams = append(rsp.pd.InvalidParams,
+				InvalidParam{Param: "/tempValidities/" + strconv.Itoa(i),
+					Reason: err.Error()})
+		}
+	}
+	if len(rsp.pd.InvalidParams) != 0 {
+		rsp.errorCode = 400
+		rsp.pd.Title = "Invalid tempValidities attribute"
+		return rsp, false
+	}
+	return rsp, true
+}
+
+// tempValidityState returns true if now is inside one of the temporal
+// validities and the time of the next start or stop after now, the zero time
+// if there is none. Without temporal validities the subscription is always
+// active.
+func tempValidityState(tvs []TemporalValidity, now time.Time) (active bool,
+	next time.Time) {
+
+	if len(tvs) == 0 {
+		return true, next
+	}
+
+	for _, tv := range tvs {
+		start, stop, err := parseTempValidity(tv)
+		if err != nil {
+			log.Errf("Invalid temporal validity ignored: %v", err)
+			continue
+		}
+		if !start.After(now) && (stop.IsZero() || stop.After(now)) {
+			active = true
+		}
+		for _, t := range []time.Time{start, stop} {
+			if t.After(now) && (next.IsZero() || t.Before(next)) {
+				next = t
+			}
+		}
+	}
+	return active, next
+}
+
+// activationState returns the activation state reported to the AF
+func (sub *afSubscription) activationState() ActivationState {
+
+	if sub.active {
+		return ActivationStateActive
+	}
+	return ActivationStateInactive
+}
+
+// afSBApply installs, updates or removes the policy of the subscription in
+// the 5GC depending on the temporal validity of the changed subscription ti.
+// The update is used when the policy stays installed. It returns the new
+// activation state, the caller must hold af.mu and call afSetActive on
+// success.
+func (af *afData) afSBApply(nefCtx *nefContext, sub *afSubscription,
+	ti TrafficInfluSub, update func() (nefSBRspData, error)) (
+	rsp nefSBRspData, active bool, err error) {
+
+	active, _ = tempValidityState(ti.TempValidities, time.Now())
+
+	switch {
+	case active && sub.active:
+		rsp, err = update()
+	case active:
+		log.Infof("Activating subscription %s", sub.subid)
+		rsp, err = sub.NEFSBPost(sub, nefCtx, ti)
+		if err == nil && rsp.errorCode >= 300 {
+			err = errors.New("Failed to activate the subscription")
+		}
+	case sub.active:
+		log.Infof("Deactivating subscription %s", sub.subid)
+		rsp, err = sub.NEFSBDelete(sub, nefCtx)
+	}
+	return rsp, active, err
+}
+
+// afSetActive records the activation state of the subscription and links
+// its new correlation ID, the caller must hold af.mu
+func (af *afData) afSetActive(nefCtx *nefContext, sub *afSubscription,
+	active bool, oldCorrID string) {
+
+	if !active {
+		sub.NotifCorreID = ""
+		sub.appSessionID = ""
+	}
+	sub.active = active
+	nefCtx.nef.nefIndexSub(af, sub, oldCorrID)
+}
+
+// afScheduleTempValidity arms the timer of the next activation state change
+// of the subscription. It fires at once if the state does not match the
+// temporal validities, ex: after a restart. The caller must hold af.mu.
+func (af *afData) afScheduleTempValidity(nefCtx *nefContext,
+	sub *afSubscription) {
+
+	af.afStopTempValidity(sub)
+
+	active, next := tempValidityState(sub.ti.TempValidities, time.Now())
+	var d time.Duration
+	switch {
+	case active != sub.active:
+		d = 0
+	case next.IsZero():
+		return
+	default:
+		d = time.Until(next)
+	}
+	af.afArmTempValidity(nefCtx, sub, d)
+}
+
+// afArmTempValidity starts the timer of the subscription, the caller must
+// hold af.mu
+func (af *afData) afArmTempValidity(nefCtx *nefContext, sub *afSubscription,
+	d time.Duration) {
+
+	gen := sub.tvGen
+	subID := sub.subid
+	sub.tvTimer = time.AfterFunc(d, func() {
+		af.afTempValidityChange(nefCtx, subID, gen)
+	})
+}
+
+// afStopTempValidity stops the timer of the subscription, the caller must
+// hold af.mu
+func (af *afData) afStopTempValidity(sub *afSubscription) {
+
+	if sub.tvTimer != nil {
+		sub.tvTimer.Stop()
+		sub.tvTimer = nil
+	}
+	// A timer already fired waiting for af.mu is ignored
+	sub.tvGen++
+}
+
+// afTempValidityChange activates or deactivates the subscription when its
+// timer fires
+func (af *afData) afTempValidityChange(nefCtx *nefContext, subID string,
+	gen uint) {
+
+	af.mu.Lock()
+	defer af.mu.Unlock()
+
+	sub, ok := af.subs[subID]
+	if af.deleted || !ok || sub.tvGen != gen || nefCtx.nef.ctx.Err() != nil {
+		return
+	}
+	sub.tvTimer = nil
+
+	oldCorrID := sub.NotifCorreID
+	_, active, err := af.afSBApply(nefCtx, sub, sub.ti,
+		func() (nefSBRspData, error) { return nefSBRspData{}, nil })
+	if err != nil {
+		log.Errf("Temporal validity change of subscription %s failed: %v",
+			subID, err)
+		sub.NotifCorreID = oldCorrID
+		af.afArmTempValidity(nefCtx, sub, tempValidityRetryInterval)
+		return
+	}
+	if active != sub.active {
+		af.afSetActive(nefCtx, sub, active, oldCorrID)
+		nefCtx.nef.nefSaveSub(af, sub)
+		log.Infof("Subscription %s is %s", subID, sub.activationState())
+	}
+	af.afScheduleTempValidity(nefCtx, sub)
+}
+
+// nefStartTempValidity arms the timers of the subscriptions restored from the
+// store
+func (nef *nefData) nefStartTempValidity(nefCtx *nefContext) {
+
+	nef.mu.RLock()
+	defer nef.mu.RUnlock()
+
+	for _, af := range nef.afs {
+		af.mu.Lock()
+		for _, sub := range af.subs {
+			af.afScheduleTempValidity(nefCtx, sub)
+		}
+		af.mu.Unlock()
+	}
+}
+
+// nefStopTempValidity stops the timers of all the subscriptions
+func (nef *nefData) nefStopTempValidity() {
+
+	nef.mu.RLock()
+	defer nef.mu.RUnlock()
+
+	for _, af := range nef.afs {
+		af.mu.Lock()
+		for _, sub := range af.subs {
+			af.afStopTempValidity(sub)
+		}
+		af.mu.Unlock()
+	}
+}
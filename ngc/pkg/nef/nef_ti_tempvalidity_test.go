@@ -0,0 +1,153 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	ngcnef "github.com/open-ness/epcforedge/ngc/pkg/nef"
+)
+
+var _ = Describe("Test NEF Traffic Influence Temporal Validity", func() {
+
+	var (
+		pcf *fakePCF
+		dir string
+	)
+
+	postbody, _ := ioutil.ReadFile(testJSONPath + "AF_NEF_POST_01.json")
+
+	// tiBody returns the POST body with the temporal validities changed
+	tiBody := func(tvs ...ngcnef.TemporalValidity) []byte {
+		var ti ngcnef.TrafficInfluSub
+		Expect(json.Unmarshal(postbody, &ti)).Should(BeNil())
+		ti.TempValidities = tvs
+		b, _ := json.Marshal(ti)
+		return b
+	}
+
+	// window returns a temporal validity starting and stopping after the
+	// durations from now
+	window := func(start time.Duration,
+		stop time.Duration) ngcnef.TemporalValidity {
+		now := time.Now()
+		return ngcnef.TemporalValidity{
+			StartTime: now.Add(start).Format(time.RFC3339),
+			StopTime:  now.Add(stop).Format(time.RFC3339)}
+	}
+
+	activationState := func(ctx context.Context) ngcnef.ActivationState {
+		var ti ngcnef.TrafficInfluSub
+		rr, req := CreateReqForNEF(ctx, "GET", "11111", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+		Expect(json.Unmarshal(rr.Body.Bytes(), &ti)).Should(BeNil())
+		return ti.ActivationState
+	}
+
+	BeforeEach(func() {
+		var err error
+		pcf = newFakePCF()
+		dir, err = ioutil.TempDir("", "neftempvalidity")
+		Expect(err).Should(BeNil())
+	})
+
+	AfterEach(func() {
+		pcf.server.Close()
+		os.RemoveAll(dir)
+	})
+
+	It("Installs the policy only inside the temporal validity", func() {
+
+		ctx, cancel := startNefWithCfg(createPCFCfg(dir, pcf.server.URL))
+		defer cancel()
+
+		rr, req := CreateReqForNEF(ctx, "POST", "",
+			tiBody(window(2*time.Second, 4*time.Second)))
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+		Expect(activationState(ctx)).Should(
+			Equal(ngcnef.ActivationStateInactive))
+		Expect(pcf.sessions()).Should(Equal(0))
+
+		Eventually(pcf.sessions, 4*time.Second,
+			100*time.Millisecond).Should(Equal(1))
+		Expect(activationState(ctx)).Should(
+			Equal(ngcnef.ActivationStateActive))
+
+		Eventually(pcf.sessions, 4*time.Second,
+			100*time.Millisecond).Should(Equal(0))
+		Expect(activationState(ctx)).Should(
+			Equal(ngcnef.ActivationStateInactive))
+
+		rr, req = CreateReqForNEF(ctx, "DELETE", "11111", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusNoContent))
+	})
+
+	It("Activates and deactivates on a change of the validity", func() {
+
+		ctx, cancel := startNefWithCfg(createPCFCfg(dir, pcf.server.URL))
+		defer cancel()
+
+		rr, req := CreateReqForNEF(ctx, "POST", "",
+			tiBody(window(time.Hour, 2*time.Hour)))
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+		Expect(pcf.sessions()).Should(Equal(0))
+
+		patch, _ := json.Marshal(ngcnef.TrafficInfluSubPatch{
+			TempValidities: []ngcnef.TemporalValidity{
+				window(-time.Hour, time.Hour)}})
+		rr, req = CreateReqForNEF(ctx, "PATCH", "11111", patch)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+		Expect(pcf.sessions()).Should(Equal(1))
+		Expect(activationState(ctx)).Should(
+			Equal(ngcnef.ActivationStateActive))
+
+		rr, req = CreateReqForNEF(ctx, "PUT", "11111",
+			tiBody(window(time.Hour, 2*time.Hour)))
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+		Expect(pcf.sessions()).Should(Equal(0))
+		Expect(activationState(ctx)).Should(
+			Equal(ngcnef.ActivationStateInactive))
+
+		rr, req = CreateReqForNEF(ctx, "DELETE", "11111", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusNoContent))
+	})
+
+	It("Rejects invalid temporal validities", func() {
+
+		ctx, cancel := startNefWithCfg(createPCFCfg(dir, pcf.server.URL))
+		defer cancel()
+
+		rr, req := CreateReqForNEF(ctx, "POST", "",
+			tiBody(ngcnef.TemporalValidity{StartTime: "10:30:00"}))
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusBadRequest))
+
+		rr, req = CreateReqForNEF(ctx, "POST", "",
+			tiBody(window(2*time.Hour, time.Hour)))
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusBadRequest))
+
+		var pd ngcnef.ProblemDetails
+		Expect(json.Unmarshal(rr.Body.Bytes(), &pd)).Should(BeNil())
+		Expect(pd.InvalidParams).Should(Equal([]ngcnef.InvalidParam{{
+			Param:  "/tempValidities/0",
+			Reason: "stopTime is not after startTime"}}))
+		Expect(pcf.sessions()).Should(Equal(0))
+	})
+})
@@ -0,0 +1,167 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/net/websocket"
+)
+
+// Path of the websocket of a traffic influence subscription relative to the
+// subscription resource
+const tiWebsocketPath = "/websocket"
+
+// Timeout of sending a notification through the websocket
+var wsNotifTimeout = 15 * time.Second
+
+// setTiWebsocketURI sets the websocketUri of the subscription from its self
+// link when the AF requested the websocket delivery and clears it otherwise
+func setTiWebsocketURI(ti *TrafficInfluSub) {
+
+	if !ti.WebsockNotifConfig.RequestWebsocketURI || ti.Self == "" {
+		ti.WebsockNotifConfig.WebsocketURI = ""
+		return
+	}
+
+	uri := string(ti.Self)
+	if strings.HasPrefix(uri, "https://") {
+		uri = "wss://" + strings.TrimPrefix(uri, "https://")
+	} else {
+		uri = "ws://" + strings.TrimPrefix(uri, "http://")
+	}
+	ti.WebsockNotifConfig.WebsocketURI = Link(uri + tiWebsocketPath)
+}
+
+// afSetWebsocket replaces the websocket of the subscription, the caller must
+// hold af.mu
+func (sub *afSubscription) afSetWebsocket(ws *websocket.Conn) {
+
+	if sub.ws != nil && sub.ws != ws {
+		if err := sub.ws.Close(); err != nil {
+			log.Errf("Websocket close of subscription %s failed: %v",
+				sub.subid, err)
+		}
+	}
+	sub.ws = ws
+}
+
+// sendWebsocketNotif sends the event through the websocket of the
+// subscription and returns false if no websocket is connected or the
+// sending failed
+func (sub *afSubscription) sendWebsocketNotif(ev EventNotification) bool {
+
+	if sub.ws == nil {
+		return false
+	}
+	err := sub.ws.SetWriteDeadline(time.Now().Add(wsNotifTimeout))
+	if err != nil {
+		log.Errf("Websocket deadline of subscription %s: %v", sub.subid, err)
+		return false
+	}
+	if err = websocket.JSON.Send(sub.ws, ev); err != nil {
+		log.Errf("Websocket notification of subscription %s failed: %v",
+			sub.subid, err)
+		return false
+	}
+	log.Infof("Notification sent through the websocket of subscription %s",
+		sub.subid)
+	return true
+}
+
+// afServeWebsocket keeps the websocket of the subscription until the AF or
+// the NEF closes it. The messages received from the AF are discarded.
+func (af *afData) afServeWebsocket(subID string, ws *websocket.Conn) {
+
+	af.mu.Lock()
+	sub, ok := af.subs[subID]
+	if !ok || af.deleted {
+		af.mu.Unlock()
+		_ = ws.Close()
+		return
+	}
+	sub.afSetWebsocket(ws)
+	subCopy := *sub
+	af.mu.Unlock()
+	log.Infof("Websocket of subscription %s connected", subID)
+
+	//The test notification is sent once the websocket is connected
+	if subCopy.ti.RequestTestNotification {
+		subCopy.sendWebsocketNotif(tiTestNotification(subCopy.ti))
+	}
+
+	var msg []byte
+	for {
+		if err := websocket.Message.Receive(ws, &msg); err != nil {
+			break
+		}
+	}
+
+	af.mu.Lock()
+	if sub, ok = af.subs[subID]; ok && sub.ws == ws {
+		sub.ws = nil
+	}
+	af.mu.Unlock()
+	_ = ws.Close()
+	log.Infof("Websocket of subscription %s disconnected", subID)
+}
+
+// nefCloseWebsockets closes the websockets of all the subscriptions
+func (nef *nefData) nefCloseWebsockets() {
+
+	nef.mu.RLock()
+	defer nef.mu.RUnlock()
+
+	for _, af := range nef.afs {
+		af.mu.Lock()
+		for _, sub := range af.subs {
+			sub.afSetWebsocket(nil)
+		}
+		af.mu.Unlock()
+	}
+}
+
+// ConnectTrafficInfluenceWebsocket : Accepts the websocket of the AF for the
+// notifications of a subscription which requested the websocket delivery
+func ConnectTrafficInfluenceWebsocket(w http.ResponseWriter,
+	r *http.Request) {
+
+	nefCtx := r.Context().Value(nefCtxKey("nefCtx")).(*nefContext)
+	nef := &nefCtx.nef
+
+	vars := mux.Vars(r)
+	log.Infof(" AFID  : %s", vars["afId"])
+	log.Infof(" SUBSCRIPTION ID  : %s", vars["subscriptionId"])
+
+	af, err := nef.nefGetAf(vars["afId"])
+	if err != nil {
+		log.Err(err)
+		sendCustomeErrorRspToAF(w, 404, "Failed to find AF records")
+		return
+	}
+
+	af.mu.Lock()
+	sub, ok := af.subs[vars["subscriptionId"]]
+	requested := ok && sub.ti.WebsockNotifConfig.RequestWebsocketURI
+	af.mu.Unlock()
+	if !ok {
+		sendCustomeErrorRspToAF(w, 404, subNotFound)
+		return
+	}
+	if !requested {
+		sendCustomeErrorRspToAF(w, 404, "Websocket delivery not requested")
+		return
+	}
+
+	//The origin is not checked, the AF is authenticated as for the other
+	//requests
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		af.afServeWebsocket(vars["subscriptionId"], ws)
+	}}
+	server.ServeHTTP(w, r)
+}
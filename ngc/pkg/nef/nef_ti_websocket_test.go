@@ -0,0 +1,194 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	ngcnef "github.com/open-ness/epcforedge/ngc/pkg/nef"
+	"golang.org/x/net/websocket"
+)
+
+var _ = Describe("Test NEF Traffic Influence Websocket Notifications", func() {
+
+	var (
+		pcf       *fakePCF
+		nefServer *httptest.Server
+		afServer  *httptest.Server
+		dir       string
+
+		mu      sync.Mutex
+		afPosts int
+	)
+
+	postbody, _ := ioutil.ReadFile(testJSONPath + "AF_NEF_POST_01.json")
+	notifbody, _ := ioutil.ReadFile(testJSONPath + "SMF_NEF_NOTIF_01.json")
+	wsPath := "/3gpp-traffic-influence/v1/AF_01/subscriptions/11111/websocket"
+
+	// tiBody returns the POST body with the websocket delivery requested
+	tiBody := func(requestWs bool) []byte {
+		var ti ngcnef.TrafficInfluSub
+		Expect(json.Unmarshal(postbody, &ti)).Should(BeNil())
+		ti.NotificationDestination = ngcnef.Link(afServer.URL)
+		ti.WebsockNotifConfig.RequestWebsocketURI = requestWs
+		b, _ := json.Marshal(ti)
+		return b
+	}
+
+	// wsURL returns the URL of the websocket of the subscription 11111
+	wsURL := func() string {
+		return strings.Replace(nefServer.URL, "http", "ws", 1) + wsPath
+	}
+
+	// notifySmf sends the UP path change notification of the SMF to the NEF
+	notifySmf := func() {
+		rsp, err := http.Post(nefServer.URL+
+			"/3gpp-traffic-influence/v1/notification/upf",
+			"application/json", bytes.NewBuffer(notifbody))
+		Expect(err).Should(BeNil())
+		Expect(rsp.StatusCode).Should(Equal(http.StatusOK))
+		_ = rsp.Body.Close()
+	}
+
+	posts := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return afPosts
+	}
+
+	BeforeEach(func() {
+		var err error
+		pcf = newFakePCF()
+		afPosts = 0
+		afServer = httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				afPosts++
+				mu.Unlock()
+				w.WriteHeader(http.StatusNoContent)
+			}))
+		dir, err = ioutil.TempDir("", "nefwebsocket")
+		Expect(err).Should(BeNil())
+	})
+
+	AfterEach(func() {
+		pcf.server.Close()
+		afServer.Close()
+		os.RemoveAll(dir)
+	})
+
+	It("Sends the notifications through the websocket of the AF", func() {
+
+		ctx, cancel := startNefWithCfg(createPCFCfg(dir, pcf.server.URL))
+		defer cancel()
+		nefServer = httptest.NewServer(ngcnef.NefAppG.NefRouter)
+		defer nefServer.Close()
+
+		rr, req := CreateReqForNEF(ctx, "POST", "", tiBody(true))
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+
+		var ti ngcnef.TrafficInfluSub
+		Expect(json.Unmarshal(rr.Body.Bytes(), &ti)).Should(BeNil())
+		wsURI := string(ti.WebsockNotifConfig.WebsocketURI)
+		Expect(strings.HasPrefix(wsURI, "wss://")).Should(BeTrue())
+		Expect(strings.HasSuffix(wsURI, wsPath)).Should(BeTrue())
+
+		ws, err := websocket.Dial(strings.Replace(nefServer.URL, "http",
+			"ws", 1)+wsPath, "", "http://localhost/")
+		Expect(err).Should(BeNil())
+		// The NEF links the websocket after the handshake
+		time.Sleep(200 * time.Millisecond)
+
+		notifySmf()
+		var ev ngcnef.EventNotification
+		Expect(ws.SetReadDeadline(time.Now().Add(2 * time.Second))).
+			Should(BeNil())
+		Expect(websocket.JSON.Receive(ws, &ev)).Should(BeNil())
+		Expect(ev.AfTransID).Should(Equal(ti.AfTransID))
+		Expect(ev.SubscribedEvent).Should(
+			Equal(ngcnef.SubscribedEvent("UP_PATH_CHANGE")))
+		Expect(posts()).Should(Equal(0))
+
+		// Without the websocket the notification is posted
+		Expect(ws.Close()).Should(BeNil())
+		time.Sleep(200 * time.Millisecond)
+		notifySmf()
+		Eventually(posts, 2*time.Second).Should(Equal(1))
+
+		rr, req = CreateReqForNEF(ctx, "DELETE", "11111", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusNoContent))
+	})
+
+	It("Sends the test notification once the websocket is connected",
+		func() {
+
+			ctx, cancel := startNefWithCfg(createPCFCfg(dir,
+				pcf.server.URL))
+			defer cancel()
+			nefServer = httptest.NewServer(ngcnef.NefAppG.NefRouter)
+			defer nefServer.Close()
+
+			var ti ngcnef.TrafficInfluSub
+			Expect(json.Unmarshal(tiBody(true), &ti)).Should(BeNil())
+			ti.RequestTestNotification = true
+			b, _ := json.Marshal(ti)
+
+			rr, req := CreateReqForNEF(ctx, "POST", "", b)
+			ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+			Expect(rr.Code).Should(Equal(http.StatusCreated))
+
+			ws, err := websocket.Dial(wsURL(), "", "http://localhost/")
+			Expect(err).Should(BeNil())
+			defer ws.Close()
+
+			var ev ngcnef.EventNotification
+			Expect(ws.SetReadDeadline(time.Now().Add(2 * time.Second))).
+				Should(BeNil())
+			Expect(websocket.JSON.Receive(ws, &ev)).Should(BeNil())
+			Expect(ev.AfTransID).Should(Equal(ti.AfTransID))
+			Expect(ev.SubscribedEvent).Should(
+				Equal(ngcnef.TestNotification))
+			Expect(posts()).Should(Equal(0))
+		})
+
+	It("Rejects the websocket not requested by the AF", func() {
+
+		ctx, cancel := startNefWithCfg(createPCFCfg(dir, pcf.server.URL))
+		defer cancel()
+		nefServer = httptest.NewServer(ngcnef.NefAppG.NefRouter)
+		defer nefServer.Close()
+
+		rr, req := CreateReqForNEF(ctx, "POST", "", tiBody(false))
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+
+		var ti ngcnef.TrafficInfluSub
+		Expect(json.Unmarshal(rr.Body.Bytes(), &ti)).Should(BeNil())
+		Expect(ti.WebsockNotifConfig.WebsocketURI).Should(BeEmpty())
+
+		_, err := websocket.Dial(strings.Replace(nefServer.URL, "http",
+			"ws", 1)+wsPath, "", "http://localhost/")
+		Expect(err).ShouldNot(BeNil())
+
+		notifySmf()
+		Eventually(posts, 2*time.Second).Should(Equal(1))
+
+		rr, req = CreateReqForNEF(ctx, "DELETE", "11111", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusNoContent))
+	})
+})
@@ -0,0 +1,74 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+/* Client implementation of the UDM Stub */
+
+package ngcnef
+
+import (
+	"context"
+	"strconv"
+	"sync"
+)
+
+// UdmClientStub is an implementation of the Udm Event Exposure
+type UdmClientStub struct {
+	udm    string
+	nextID int
+	// mu guards the database as requests are handled concurrently
+	mu sync.Mutex
+	// database to store the event exposure subscriptions created
+	eeDb map[UdmEeSubscriptionID]EeSubscription
+}
+
+// NewUDMClient creates a new UDM Client
+func NewUDMClient(cfg *Config) *UdmClientStub {
+
+	c := &UdmClientStub{}
+	c.udm = "UDM Stub"
+	c.nextID = 1
+	c.eeDb = make(map[UdmEeSubscriptionID]EeSubscription)
+	log.Info("UDM Stub Client created")
+	return c
+}
+
+// UdmEventExposureSubscribe is a stub implementation
+// Successful response : 201 and body contains CreatedEeSubscription
+func (udm *UdmClientStub) UdmEventExposureSubscribe(ctx context.Context,
+	ueIdentity string, body EeSubscription) (UdmEeSubscriptionID,
+	UdmEventExposureResponse, error) {
+
+	_ = ctx
+	udm.mu.Lock()
+	defer udm.mu.Unlock()
+
+	subID := UdmEeSubscriptionID(strconv.Itoa(udm.nextID))
+	udm.nextID++
+	udm.eeDb[subID] = body
+	log.Infof("UDMs EventExposureSubscribe [SubId,UeIdentity] => [%s,%s]",
+		string(subID), ueIdentity)
+	return subID, UdmEventExposureResponse{ResponseCode: 201,
+		Ees: &CreatedEeSubscription{EeSubscription: body}}, nil
+}
+
+// UdmEventExposureUnsubscribe is a stub implementation
+// Successful response : 204 and empty body
+func (udm *UdmClientStub) UdmEventExposureUnsubscribe(ctx context.Context,
+	ueIdentity string, subID UdmEeSubscriptionID) (UdmEventExposureResponse,
+	error) {
+
+	_ = ctx
+	udm.mu.Lock()
+	defer udm.mu.Unlock()
+
+	if _, ok := udm.eeDb[subID]; !ok {
+		log.Infof("UDMs EventExposureUnsubscribe SubId %s not found",
+			string(subID))
+		return UdmEventExposureResponse{ResponseCode: 404}, nil
+	}
+	delete(udm.eeDb, subID)
+	log.Infof("UDMs EventExposureUnsubscribe [SubId,UeIdentity] => [%s,%s]",
+		string(subID), ueIdentity)
+	return UdmEventExposureResponse{ResponseCode: 204}, nil
+}
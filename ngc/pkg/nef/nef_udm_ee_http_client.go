@@ -0,0 +1,133 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+/* Client implementation of the Nudm_EventExposure service
+(3GPP TS 29.503) */
+
+package ngcnef
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+const udmEventExposurePath = "/nudm-ee/v1/"
+const udmEeSubscriptionsPath = "/ee-subscriptions"
+
+// UdmClient is an HTTP/2 implementation of the Udm Event Exposure
+type UdmClient struct {
+	sb *sbClient
+}
+
+// NewUDMHTTPClient creates a new UDM Client sending the requests to the
+// UDM configured in UDMConfig
+func NewUDMHTTPClient(cfg *Config) (*UdmClient, error) {
+
+	sb, err := newSBClient(&cfg.UDMConfig, cfg.UserAgent)
+	if err != nil {
+		log.Errf("UDM Client creation failed: %v", err)
+		return nil, err
+	}
+	log.Infof("UDM Client created for %s", sb.apiRoot)
+	return &UdmClient{sb: sb}, nil
+}
+
+// udmSubscriptionsURI returns the uri of the subscriptions collection of
+// the UE
+func udmSubscriptionsURI(apiRoot string, ueIdentity string) string {
+
+	return apiRoot + udmEventExposurePath + url.PathEscape(ueIdentity) +
+		udmEeSubscriptionsPath
+}
+
+// udmEventExposureResponse converts the response received from the UDM
+func udmEventExposureResponse(rsp sbResponse,
+	err error) (UdmEventExposureResponse, error) {
+
+	udmPr := UdmEventExposureResponse{}
+	if err != nil {
+		log.Errf("UDM request failed: %v", err)
+		udmPr.ResponseCode = http.StatusServiceUnavailable
+		udmPr.Pd = sbUnreachableProblem("UDM", err)
+		return udmPr, err
+	}
+
+	udmPr.ResponseCode = uint16(rsp.code)
+	if rsp.code >= 300 {
+		udmPr.Pd = sbProblemDetails(rsp)
+		return udmPr, nil
+	}
+
+	if rsp.code == http.StatusNoContent || len(rsp.body) == 0 {
+		return udmPr, nil
+	}
+
+	ees := CreatedEeSubscription{}
+	if err = json.Unmarshal(rsp.body, &ees); err != nil {
+		log.Errf("UDM response decode failed: %v", err)
+		udmPr.ResponseCode = http.StatusInternalServerError
+		udmPr.Pd = &ProblemDetails{Title: "Invalid UDM response",
+			Status: http.StatusInternalServerError}
+		return udmPr, err
+	}
+	udmPr.Ees = &ees
+	return udmPr, nil
+}
+
+// UdmEventExposureSubscribe sends POST to the subscriptions collection of
+// the UE
+// Successful response : 201, Location header and body contains
+// CreatedEeSubscription
+func (udm *UdmClient) UdmEventExposureSubscribe(ctx context.Context,
+	ueIdentity string, body EeSubscription) (UdmEeSubscriptionID,
+	UdmEventExposureResponse, error) {
+
+	log.Infof("UDM EventExposureSubscribe Entered for %s", ueIdentity)
+
+	apiRoot, err := udm.sb.root(ctx, "", Snssai{})
+	if err != nil {
+		udmPr, err := udmEventExposureResponse(sbResponse{}, err)
+		return "", udmPr, err
+	}
+	rsp, err := udm.sb.send(ctx, http.MethodPost,
+		udmSubscriptionsURI(apiRoot, ueIdentity), "application/json", body)
+	udmPr, err := udmEventExposureResponse(rsp, err)
+	if err != nil || udmPr.ResponseCode != http.StatusCreated {
+		return "", udmPr, err
+	}
+
+	id, err := sbResourceID(rsp, udmEeSubscriptionsPath+"/")
+	if err != nil {
+		log.Errf("UDM EventExposureSubscribe: %v", err)
+		udmPr.ResponseCode = http.StatusInternalServerError
+		udmPr.Pd = &ProblemDetails{Title: "Invalid UDM response",
+			Detail: err.Error(), Status: http.StatusInternalServerError}
+		return "", udmPr, err
+	}
+	udm.sb.setResourceRoot(id, apiRoot)
+	return UdmEeSubscriptionID(id), udmPr, nil
+}
+
+// UdmEventExposureUnsubscribe sends DELETE to the subscription
+// Successful response : 204
+func (udm *UdmClient) UdmEventExposureUnsubscribe(ctx context.Context,
+	ueIdentity string, subID UdmEeSubscriptionID) (UdmEventExposureResponse,
+	error) {
+
+	log.Infof("UDM EventExposureUnsubscribe Entered for %s", string(subID))
+
+	apiRoot, err := udm.sb.resourceRoot(ctx, string(subID))
+	if err != nil {
+		return udmEventExposureResponse(sbResponse{}, err)
+	}
+	rsp, err := udm.sb.send(ctx, http.MethodDelete,
+		udmSubscriptionsURI(apiRoot, ueIdentity)+"/"+
+			url.PathEscape(string(subID)), "", nil)
+	if err == nil && (rsp.code < 300 || rsp.code == http.StatusNotFound) {
+		udm.sb.deleteResourceRoot(string(subID))
+	}
+	return udmEventExposureResponse(rsp, err)
+}
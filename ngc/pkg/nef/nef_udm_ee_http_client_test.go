@@ -0,0 +1,224 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	ngcnef "github.com/open-ness/epcforedge/ngc/pkg/nef"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+const fakeUDMEventExposure = "/nudm-ee/v1/"
+const fakeUDMSdm = "/nudm-sdm/v2/"
+
+// fakeUDM is a local Nudm_EventExposure server used for testing the UDM
+// client over HTTP/2 clear text
+type fakeUDM struct {
+	server *httptest.Server
+	mu     sync.Mutex
+	nextID int
+	ees    map[string]ngcnef.EeSubscription
+	ues    map[string]string
+	// reports returned as the immediate reports of a new subscription
+	reports []ngcnef.MonitoringReport
+	// SUPI of the GPSIs known by the UDM
+	supis map[string]ngcnef.Supi
+	// status of the failure of the identifier translations, none if 0
+	sdmFailure int
+}
+
+func newFakeUDM() *fakeUDM {
+
+	udm := &fakeUDM{nextID: 1, ees: map[string]ngcnef.EeSubscription{},
+		ues: map[string]string{}, supis: map[string]ngcnef.Supi{}}
+	udm.server = httptest.NewServer(h2c.NewHandler(
+		http.HandlerFunc(udm.serveHTTP), &http2.Server{}))
+	return udm
+}
+
+// subscriptions returns the event exposure subscriptions created in the UDM
+func (udm *fakeUDM) subscriptions() []ngcnef.EeSubscription {
+	udm.mu.Lock()
+	defer udm.mu.Unlock()
+	ees := []ngcnef.EeSubscription{}
+	for _, ee := range udm.ees {
+		ees = append(ees, ee)
+	}
+	return ees
+}
+
+// ueIdentities returns the UE identities of the subscriptions
+func (udm *fakeUDM) ueIdentities() []string {
+	udm.mu.Lock()
+	defer udm.mu.Unlock()
+	ues := []string{}
+	for _, ue := range udm.ues {
+		ues = append(ues, ue)
+	}
+	return ues
+}
+
+func (udm *fakeUDM) serveHTTP(w http.ResponseWriter, r *http.Request) {
+
+	udm.mu.Lock()
+	defer udm.mu.Unlock()
+
+	if r.ProtoMajor != 2 {
+		problem(w, http.StatusHTTPVersionNotSupported, "")
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, fakeUDMSdm) {
+		udm.serveIDTranslation(w, r)
+		return
+	}
+
+	// {ueIdentity}/ee-subscriptions[/{subscriptionId}]
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path,
+		fakeUDMEventExposure), "/")
+	if len(parts) < 2 || parts[1] != "ee-subscriptions" {
+		problem(w, http.StatusNotFound, "")
+		return
+	}
+
+	if len(parts) == 2 && r.Method == http.MethodPost {
+		ee := ngcnef.EeSubscription{}
+		if err := json.NewDecoder(r.Body).Decode(&ee); err != nil {
+			problem(w, http.StatusBadRequest, "")
+			return
+		}
+		id := strconv.Itoa(udm.nextID)
+		udm.nextID++
+		udm.ees[id] = ee
+		udm.ues[id] = parts[0]
+		w.Header().Set("Location", udm.server.URL+fakeUDMEventExposure+
+			parts[0]+"/ee-subscriptions/"+id)
+		writeJSON(w, http.StatusCreated, "application/json",
+			ngcnef.CreatedEeSubscription{EeSubscription: ee,
+				EventReports: udm.reports})
+		return
+	}
+
+	if len(parts) != 3 || r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if ue, ok := udm.ues[parts[2]]; !ok || ue != parts[0] {
+		problem(w, http.StatusNotFound, "SUBSCRIPTION_NOT_FOUND")
+		return
+	}
+	delete(udm.ees, parts[2])
+	delete(udm.ues, parts[2])
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serveIDTranslation serves the {ueId}/id-translation-result of the SDM
+func (udm *fakeUDM) serveIDTranslation(w http.ResponseWriter,
+	r *http.Request) {
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, fakeUDMSdm), "/")
+	if len(parts) != 2 || parts[1] != "id-translation-result" ||
+		r.Method != http.MethodGet {
+		problem(w, http.StatusNotFound, "")
+		return
+	}
+	if udm.sdmFailure != 0 {
+		problem(w, udm.sdmFailure, "SYSTEM_FAILURE")
+		return
+	}
+	supi, ok := udm.supis[parts[0]]
+	if !ok {
+		problem(w, http.StatusNotFound, "USER_NOT_FOUND")
+		return
+	}
+	writeJSON(w, http.StatusOK, "application/json",
+		ngcnef.IDTranslationResult{Supi: supi, Gpsi: ngcnef.Gpsi(parts[0])})
+}
+
+// createUDMCfg writes a copy of the valid NEF configuration using the UDM
+// at apiRoot and returns its path
+func createUDMCfg(dir string, apiRoot string) string {
+	return createNefCfg(dir, map[string]interface{}{
+		"UDMConfig": sbConfig(apiRoot)})
+}
+
+var _ = Describe("NEF UDM Event Exposure Client", func() {
+
+	var (
+		udm *fakeUDM
+		cfg ngcnef.Config
+	)
+
+	BeforeEach(func() {
+		udm = newFakeUDM()
+		cfg = ngcnef.Config{UserAgent: "NEF-OPENNESS-1912"}
+		cfg.UDMConfig.APIRoot = udm.server.URL
+	})
+
+	AfterEach(func() {
+		udm.server.Close()
+	})
+
+	It("Subscribe and unsubscribe", func() {
+
+		client, err := ngcnef.NewUDMHTTPClient(&cfg)
+		Expect(err).Should(BeNil())
+		ctx := context.Background()
+
+		ee := ngcnef.EeSubscription{CallbackReference: "http://nef/1",
+			MonitoringConfigurations: map[string]ngcnef.MonitoringConfiguration{
+				"1": {EventType: ngcnef.UdmEventLossOfConnectivity}}}
+		id, rsp, err := client.UdmEventExposureSubscribe(ctx,
+			"extid-ue1@domain.com", ee)
+		Expect(err).Should(BeNil())
+		Expect(rsp.ResponseCode).Should(Equal(uint16(http.StatusCreated)))
+		Expect(id).Should(Equal(ngcnef.UdmEeSubscriptionID("1")))
+		Expect(rsp.Ees.EeSubscription.CallbackReference).Should(
+			Equal(ngcnef.URI("http://nef/1")))
+		Expect(udm.ueIdentities()).Should(
+			Equal([]string{"extid-ue1@domain.com"}))
+
+		rsp, err = client.UdmEventExposureUnsubscribe(ctx,
+			"extid-ue1@domain.com", id)
+		Expect(err).Should(BeNil())
+		Expect(rsp.ResponseCode).Should(Equal(uint16(http.StatusNoContent)))
+		Expect(len(udm.subscriptions())).Should(Equal(0))
+	})
+
+	It("Maps the ProblemDetails of a failure response", func() {
+
+		client, err := ngcnef.NewUDMHTTPClient(&cfg)
+		Expect(err).Should(BeNil())
+
+		rsp, err := client.UdmEventExposureUnsubscribe(context.Background(),
+			"msisdn-918369110173", "10")
+		Expect(err).Should(BeNil())
+		Expect(rsp.ResponseCode).Should(Equal(uint16(http.StatusNotFound)))
+		Expect(rsp.Pd.Cause).Should(Equal("SUBSCRIPTION_NOT_FOUND"))
+	})
+
+	It("Returns an error when the UDM is not reachable", func() {
+
+		client, err := ngcnef.NewUDMHTTPClient(&cfg)
+		Expect(err).Should(BeNil())
+		udm.server.Close()
+
+		_, rsp, err := client.UdmEventExposureSubscribe(context.Background(),
+			"msisdn-918369110173", ngcnef.EeSubscription{})
+		Expect(err).ShouldNot(BeNil())
+		Expect(rsp.ResponseCode).Should(
+			Equal(uint16(http.StatusServiceUnavailable)))
+	})
+})
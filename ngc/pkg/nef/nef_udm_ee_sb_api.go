@@ -0,0 +1,45 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef
+
+import "context"
+
+/* The SB interfaces towards the UDM that need to be implemented by
+   eith the NEF SB stub / NEF SB client receivers */
+
+// UdmEventExposureResponse contains the response from UDM
+type UdmEventExposureResponse struct {
+	// responseCode contains the http response code provided by the UDM
+	ResponseCode uint16
+	// ees if not nil contains the CreatedEeSubscription provided by UDM
+	Ees *CreatedEeSubscription
+	// pd if not nil contains the problem information from UDM.
+	// Valid for 3xx, 4xx, 5xx or 6xx responses
+	Pd *ProblemDetails
+}
+
+// UdmEeSubscriptionID contains the subscription id returned by the UDM
+// Its present in the location header as below:
+// "{apiRoot}/nudm-ee/v1/{ueIdentity}/ee-subscriptions/{subscriptionId}"
+type UdmEeSubscriptionID string
+
+// UdmEventExposure defines the interfaces that are exposed for the
+// Monitoring Events. The UDM forwards the subscription to the AMF for the
+// events detected by the AMF.
+type UdmEventExposure interface {
+	// UdmEventExposureSubscribe sends POST request to the UDM to create a
+	// subscription for the ueIdentity (extid-, msisdn- or extgroupid-).
+	// It returns the subscription id, the response received from the UDM
+	// and any error encountered when sending the request.
+	UdmEventExposureSubscribe(ctx context.Context, ueIdentity string,
+		body EeSubscription) (UdmEeSubscriptionID,
+		UdmEventExposureResponse, error)
+
+	// UdmEventExposureUnsubscribe sends DELETE request to the UDM to remove
+	// the subscription. It returns the response received from the UDM and
+	// any error encountered when sending the request.
+	UdmEventExposureUnsubscribe(ctx context.Context, ueIdentity string,
+		subID UdmEeSubscriptionID) (UdmEventExposureResponse, error)
+}
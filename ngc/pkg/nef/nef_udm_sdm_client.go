@@ -0,0 +1,60 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+/* Client implementation of the UDM SDM Stub */
+
+package ngcnef
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+// UdmSdmClientStub is an implementation of the Udm Identifier Translation
+type UdmSdmClientStub struct {
+	udm string
+}
+
+// NewUDMSdmClient creates a new UDM SDM Client
+func NewUDMSdmClient(cfg *Config) *UdmSdmClientStub {
+
+	c := &UdmSdmClientStub{}
+	c.udm = "UDM SDM Stub"
+	log.Info("UDM SDM Stub Client created")
+	return c
+}
+
+// UdmIDTranslationGet is a stub implementation. The SUPI of a msisdn- GPSI
+// uses the same digits, the SUPI of an extid- GPSI is derived from a hash of
+// the external identifier. Any other identity is not found.
+// Successful response : 200 and body contains IDTranslationResult
+func (udm *UdmSdmClientStub) UdmIDTranslationGet(ctx context.Context,
+	ueID string) (UdmIDTranslationResponse, error) {
+
+	_ = ctx
+	var supi Supi
+
+	switch {
+	case strings.HasPrefix(ueID, udmUeIdentityMsisdn) &&
+		len(ueID) > len(udmUeIdentityMsisdn):
+		supi = Supi("imsi-" + strings.TrimPrefix(ueID, udmUeIdentityMsisdn))
+	case strings.HasPrefix(ueID, udmUeIdentityExternalID) &&
+		strings.Contains(ueID, "@"):
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(ueID))
+		supi = Supi(fmt.Sprintf("imsi-%015d", h.Sum64()%1000000000000000))
+	default:
+		log.Infof("UDMs IDTranslationGet UeId %s not found", ueID)
+		return UdmIDTranslationResponse{ResponseCode: 404,
+			Pd: &ProblemDetails{Title: "User not found", Status: 404,
+				Cause: "USER_NOT_FOUND"}}, nil
+	}
+
+	log.Infof("UDMs IDTranslationGet [UeId,Supi] => [%s,%s]", ueID,
+		string(supi))
+	return UdmIDTranslationResponse{ResponseCode: 200,
+		Result: &IDTranslationResult{Supi: supi, Gpsi: Gpsi(ueID)}}, nil
+}
@@ -0,0 +1,89 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+/* Client implementation of the Nudm_SubscriberDataManagement service
+identifier translation (3GPP TS 29.503) */
+
+package ngcnef
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+const udmSdmPath = "/nudm-sdm/v2/"
+const udmIDTranslationPath = "/id-translation-result"
+
+// UdmSdmClient is an HTTP/2 implementation of the Udm Identifier
+// Translation
+type UdmSdmClient struct {
+	sb *sbClient
+}
+
+// NewUDMSdmHTTPClient creates a new UDM SDM Client sending the requests to
+// the UDM configured in UDMConfig
+func NewUDMSdmHTTPClient(cfg *Config) (*UdmSdmClient, error) {
+
+	sb, err := newSBClient(&cfg.UDMConfig, cfg.UserAgent)
+	if err != nil {
+		log.Errf("UDM SDM Client creation failed: %v", err)
+		return nil, err
+	}
+	log.Infof("UDM SDM Client created for %s", sb.apiRoot)
+	return &UdmSdmClient{sb: sb}, nil
+}
+
+// udmIDTranslationResponse converts the response received from the UDM
+func udmIDTranslationResponse(rsp sbResponse,
+	err error) (UdmIDTranslationResponse, error) {
+
+	udmPr := UdmIDTranslationResponse{}
+	if err != nil {
+		log.Errf("UDM request failed: %v", err)
+		udmPr.ResponseCode = http.StatusServiceUnavailable
+		udmPr.Pd = sbUnreachableProblem("UDM", err)
+		return udmPr, err
+	}
+
+	udmPr.ResponseCode = uint16(rsp.code)
+	if rsp.code >= 300 {
+		udmPr.Pd = sbProblemDetails(rsp)
+		return udmPr, nil
+	}
+
+	result := IDTranslationResult{}
+	if err = json.Unmarshal(rsp.body, &result); err == nil &&
+		result.Supi == "" {
+		err = errors.New("supi missing")
+	}
+	if err != nil {
+		log.Errf("UDM response decode failed: %v", err)
+		udmPr.ResponseCode = http.StatusInternalServerError
+		udmPr.Pd = &ProblemDetails{Title: "Invalid UDM response",
+			Status: http.StatusInternalServerError}
+		return udmPr, err
+	}
+	udmPr.Result = &result
+	return udmPr, nil
+}
+
+// UdmIDTranslationGet sends GET to the identifier translation result of the
+// UE
+// Successful response : 200 and body contains IDTranslationResult
+func (udm *UdmSdmClient) UdmIDTranslationGet(ctx context.Context,
+	ueID string) (UdmIDTranslationResponse, error) {
+
+	log.Infof("UDM IDTranslationGet Entered for %s", ueID)
+
+	apiRoot, err := udm.sb.root(ctx, "", Snssai{})
+	if err != nil {
+		return udmIDTranslationResponse(sbResponse{}, err)
+	}
+	rsp, err := udm.sb.send(ctx, http.MethodGet, apiRoot+udmSdmPath+
+		url.PathEscape(ueID)+udmIDTranslationPath, "", nil)
+	return udmIDTranslationResponse(rsp, err)
+}
@@ -0,0 +1,199 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	ngcnef "github.com/open-ness/epcforedge/ngc/pkg/nef"
+)
+
+var _ = Describe("NEF UDM Identifier Translation Client", func() {
+
+	var (
+		udm *fakeUDM
+		cfg ngcnef.Config
+	)
+
+	BeforeEach(func() {
+		udm = newFakeUDM()
+		udm.supis["msisdn-1234567890"] = "imsi-634635123456789"
+		cfg = ngcnef.Config{UserAgent: "NEF-OPENNESS-1912"}
+		cfg.UDMConfig.APIRoot = udm.server.URL
+	})
+
+	AfterEach(func() {
+		udm.server.Close()
+	})
+
+	It("Translates a GPSI into the SUPI", func() {
+
+		client, err := ngcnef.NewUDMSdmHTTPClient(&cfg)
+		Expect(err).Should(BeNil())
+
+		rsp, err := client.UdmIDTranslationGet(context.Background(),
+			"msisdn-1234567890")
+		Expect(err).Should(BeNil())
+		Expect(rsp.ResponseCode).Should(Equal(uint16(http.StatusOK)))
+		Expect(rsp.Result.Supi).Should(
+			Equal(ngcnef.Supi("imsi-634635123456789")))
+	})
+
+	It("Maps the ProblemDetails of an unknown GPSI", func() {
+
+		client, err := ngcnef.NewUDMSdmHTTPClient(&cfg)
+		Expect(err).Should(BeNil())
+
+		rsp, err := client.UdmIDTranslationGet(context.Background(),
+			"extid-ue1@domain.com")
+		Expect(err).Should(BeNil())
+		Expect(rsp.ResponseCode).Should(Equal(uint16(http.StatusNotFound)))
+		Expect(rsp.Pd.Cause).Should(Equal("USER_NOT_FOUND"))
+		Expect(rsp.Result).Should(BeNil())
+	})
+
+	It("Returns an error when the UDM is not reachable", func() {
+
+		client, err := ngcnef.NewUDMSdmHTTPClient(&cfg)
+		Expect(err).Should(BeNil())
+		udm.server.Close()
+
+		rsp, err := client.UdmIDTranslationGet(context.Background(),
+			"msisdn-1234567890")
+		Expect(err).ShouldNot(BeNil())
+		Expect(rsp.ResponseCode).Should(
+			Equal(uint16(http.StatusServiceUnavailable)))
+	})
+})
+
+var _ = Describe("Test NEF Server GPSI to SUPI translation", func() {
+
+	var (
+		pcf *fakePCF
+		udm *fakeUDM
+		dir string
+	)
+
+	postbody, _ := ioutil.ReadFile(testJSONPath + "AF_NEF_POST_01.json")
+
+	// tiBody returns the POST body with the GPSI changed
+	tiBody := func(gpsi string) []byte {
+		var ti ngcnef.TrafficInfluSub
+		Expect(json.Unmarshal(postbody, &ti)).Should(BeNil())
+		ti.Gpsi = ngcnef.Gpsi(gpsi)
+		b, _ := json.Marshal(ti)
+		return b
+	}
+
+	// createCfg creates a configuration using the PCF and the UDM
+	createCfg := func() string {
+		return createNefCfg(dir, map[string]interface{}{
+			"PCFConfig": sbConfig(pcf.server.URL),
+			"UDMConfig": sbConfig(udm.server.URL)})
+	}
+
+	BeforeEach(func() {
+		var err error
+		pcf = newFakePCF()
+		udm = newFakeUDM()
+		udm.supis["msisdn-1234567890"] = "imsi-634635123456789"
+		dir, err = ioutil.TempDir("", "nefsdm")
+		Expect(err).Should(BeNil())
+	})
+
+	AfterEach(func() {
+		pcf.server.Close()
+		udm.server.Close()
+		os.RemoveAll(dir)
+	})
+
+	It("Sends the SUPI of the GPSI to the PCF", func() {
+
+		ctx, cancel := startNefWithCfg(createCfg())
+		defer cancel()
+
+		rr, req := CreateReqForNEF(ctx, "POST", "",
+			tiBody("msisdn-1234567890"))
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+
+		ascs := pcf.appSessions()
+		Expect(len(ascs)).Should(Equal(1))
+		Expect(ascs[0].AscReqData.Supi).Should(
+			Equal(ngcnef.Supi("imsi-634635123456789")))
+
+		rr, req = CreateReqForNEF(ctx, "DELETE", "11111", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusNoContent))
+	})
+
+	It("Rejects a GPSI not known by the UDM", func() {
+
+		ctx, cancel := startNefWithCfg(createCfg())
+		defer cancel()
+
+		rr, req := CreateReqForNEF(ctx, "POST", "",
+			tiBody("msisdn-9999999999"))
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusNotFound))
+
+		var pd ngcnef.ProblemDetails
+		Expect(json.Unmarshal(rr.Body.Bytes(), &pd)).Should(BeNil())
+		Expect(pd.Cause).Should(Equal("USER_NOT_FOUND"))
+		Expect(pd.InvalidParams).Should(Equal([]ngcnef.InvalidParam{{
+			Param:  "/gpsi",
+			Reason: "Unknown UE identity msisdn-9999999999"}}))
+		Expect(pcf.sessions()).Should(Equal(0))
+
+		rr, req = CreateReqForNEF(ctx, "GET", "", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusOK))
+		Expect(rr.Body.String()).Should(Equal("null"))
+	})
+
+	It("Reports the UDM failures as server errors", func() {
+
+		ctx, cancel := startNefWithCfg(createCfg())
+		defer cancel()
+
+		// The ProblemDetails of the UDM is sent to the AF
+		udm.mu.Lock()
+		udm.sdmFailure = http.StatusInternalServerError
+		udm.mu.Unlock()
+		rr, req := CreateReqForNEF(ctx, "POST", "",
+			tiBody("msisdn-1234567890"))
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusInternalServerError))
+
+		var pd ngcnef.ProblemDetails
+		Expect(json.Unmarshal(rr.Body.Bytes(), &pd)).Should(BeNil())
+		Expect(pd.Cause).Should(Equal("SYSTEM_FAILURE"))
+		Expect(pd.InvalidParams).Should(BeEmpty())
+
+		// A failure of the NEF request to the UDM is not a client error
+		udm.mu.Lock()
+		udm.sdmFailure = http.StatusBadRequest
+		udm.mu.Unlock()
+		rr, req = CreateReqForNEF(ctx, "POST", "",
+			tiBody("msisdn-1234567890"))
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusInternalServerError))
+
+		udm.mu.Lock()
+		udm.sdmFailure = http.StatusServiceUnavailable
+		udm.mu.Unlock()
+		rr, req = CreateReqForNEF(ctx, "POST", "",
+			tiBody("msisdn-1234567890"))
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req)
+		Expect(rr.Code).Should(Equal(http.StatusServiceUnavailable))
+		Expect(pcf.sessions()).Should(Equal(0))
+	})
+})
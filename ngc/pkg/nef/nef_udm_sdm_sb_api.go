@@ -0,0 +1,33 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef
+
+import "context"
+
+/* The SB interfaces towards the UDM that need to be implemented by
+   eith the NEF SB stub / NEF SB client receivers */
+
+// UdmIDTranslationResponse contains the response from UDM
+type UdmIDTranslationResponse struct {
+	// responseCode contains the http response code provided by the UDM
+	ResponseCode uint16
+	// result if not nil contains the IDTranslationResult provided by UDM
+	Result *IDTranslationResult
+	// pd if not nil contains the problem information from UDM.
+	// Valid for 3xx, 4xx, 5xx or 6xx responses
+	Pd *ProblemDetails
+}
+
+// UdmIdentifierTranslation defines the interfaces that are exposed for the
+// translation of the UE identities received from the AF into the SUPI used
+// towards the 5GC
+type UdmIdentifierTranslation interface {
+	// UdmIDTranslationGet sends GET request to the UDM to translate the ueID
+	// (msisdn- or extid- GPSI) into the SUPI. It returns the response
+	// received from the UDM and any error encountered when sending the
+	// request.
+	UdmIDTranslationGet(ctx context.Context, ueID string) (
+		UdmIDTranslationResponse, error)
+}
@@ -8,11 +8,14 @@ package ngcnef
 
 import (
 	"context"
+	"sync"
 )
 
 // UdrClientStub is an implementation of the Udr Influence data
 type UdrClientStub struct {
 	udr string
+	// mu guards the database as requests are handled concurrently
+	mu sync.Mutex
 	// database to store the contents of the udr influence data
 	tidDb map[string]TrafficInfluData
 }
@@ -33,6 +36,8 @@ func (udr *UdrClientStub) UdrInfluenceDataCreate(ctx context.Context,
 
 	log.Infof("UDRs InfluenceDataCreate Entered for %s", string(iid))
 	_ = ctx
+	udr.mu.Lock()
+	defer udr.mu.Unlock()
 
 	var err error
 	udrPr := UdrInfluenceResponse{}
@@ -90,7 +95,9 @@ func updateTidWithTidPatch(tid *TrafficInfluData,
 	if body.ValidStartTime != "" {
 		tid.ValidStartTime = body.ValidStartTime
 	}
-	tid.NwAreaInfo = body.NwAreaInfo
+	if body.NwAreaInfo != nil {
+		tid.NwAreaInfo = body.NwAreaInfo
+	}
 	if body.UpPathChgNotifURI != "" {
 		tid.UpPathChgNotifURI = body.UpPathChgNotifURI
 	}
@@ -103,6 +110,8 @@ func (udr *UdrClientStub) UdrInfluenceDataUpdate(ctx context.Context,
 	error) {
 	log.Infof("UDRs InfluenceDataUpdate Entered for %s", string(iid))
 	_ = ctx
+	udr.mu.Lock()
+	defer udr.mu.Unlock()
 
 	var err error
 	udrPr := UdrInfluenceResponse{}
@@ -132,6 +141,8 @@ func (udr *UdrClientStub) UdrInfluenceDataDelete(ctx context.Context,
 
 	log.Infof("UDRs InfluenceDataDelete for %s", string(iid))
 	_ = ctx
+	udr.mu.Lock()
+	defer udr.mu.Unlock()
 
 	var err error
 	udrPr := UdrInfluenceResponse{}
@@ -159,6 +170,8 @@ func (udr *UdrClientStub) UdrInfluenceDataGet(ctx context.Context) (
 	UdrInfluenceResponse, error) {
 	log.Infof("UdrInfluenceDataGet Stub Entered")
 	_ = ctx
+	udr.mu.Lock()
+	defer udr.mu.Unlock()
 	udrPr := UdrInfluenceResponse{}
 	var err error
 	log.Infof("UdrInfluenceDataGet Stub Exited")
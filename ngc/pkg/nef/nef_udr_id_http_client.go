@@ -0,0 +1,156 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+/* Client implementation of the Nudr_DataRepository service for the
+traffic influence data (3GPP TS 29.519) */
+
+package ngcnef
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+const udrInfluenceDataPath = "/nudr-dr/v1/application-data/influenceData"
+
+// UdrClient is an HTTP/2 implementation of the Udr Influence data
+type UdrClient struct {
+	sb *sbClient
+}
+
+// NewUDRHTTPClient creates a new UDR Client sending the requests to the
+// UDR configured in UDRConfig
+func NewUDRHTTPClient(cfg *Config) (*UdrClient, error) {
+
+	sb, err := newSBClient(&cfg.UDRConfig, cfg.UserAgent)
+	if err != nil {
+		log.Errf("UDR Client creation failed: %v", err)
+		return nil, err
+	}
+	log.Infof("UDR Client created for %s", sb.apiRoot)
+	return &UdrClient{sb: sb}, nil
+}
+
+// influenceDataURI returns the uri of the influence data resource, the
+// collection if iid is empty. The data is shared by all the UDR instances.
+func (udr *UdrClient) influenceDataURI(ctx context.Context,
+	iid InfluenceID) (string, error) {
+
+	apiRoot, err := udr.sb.root(ctx, "", Snssai{})
+	if err != nil {
+		return "", err
+	}
+	if iid == "" {
+		return apiRoot + udrInfluenceDataPath, nil
+	}
+	return apiRoot + udrInfluenceDataPath + "/" +
+		url.PathEscape(string(iid)), nil
+}
+
+// influenceResponse converts the response received from the UDR. The body
+// is decoded into tid or tids for a 200 or 201 response.
+func influenceResponse(rsp sbResponse, err error,
+	list bool) (UdrInfluenceResponse, error) {
+
+	udrPr := UdrInfluenceResponse{}
+	if err != nil {
+		log.Errf("UDR request failed: %v", err)
+		udrPr.ResponseCode = http.StatusServiceUnavailable
+		udrPr.Pd = sbUnreachableProblem("UDR", err)
+		return udrPr, err
+	}
+
+	udrPr.ResponseCode = uint16(rsp.code)
+	if rsp.code >= 300 {
+		udrPr.Pd = sbProblemDetails(rsp)
+		return udrPr, nil
+	}
+
+	if rsp.code == http.StatusNoContent || len(rsp.body) == 0 {
+		return udrPr, nil
+	}
+
+	if list {
+		err = json.Unmarshal(rsp.body, &udrPr.Tids)
+	} else {
+		tid := TrafficInfluData{}
+		err = json.Unmarshal(rsp.body, &tid)
+		udrPr.Tid = &tid
+	}
+	if err != nil {
+		log.Errf("UDR response decode failed: %v", err)
+		udrPr.ResponseCode = http.StatusInternalServerError
+		udrPr.Pd = &ProblemDetails{Title: "Invalid UDR response",
+			Status: http.StatusInternalServerError}
+		udrPr.Tid = nil
+		udrPr.Tids = nil
+	}
+	return udrPr, err
+}
+
+// UdrInfluenceDataCreate sends PUT to the influence data resource
+// Successful response : 201 or 200 and body contains TrafficInfluData, or
+// 204
+func (udr *UdrClient) UdrInfluenceDataCreate(ctx context.Context,
+	body TrafficInfluData, iid InfluenceID) (UdrInfluenceResponse, error) {
+
+	log.Infof("UDR InfluenceDataCreate Entered for %s", string(iid))
+
+	uri, err := udr.influenceDataURI(ctx, iid)
+	if err != nil {
+		return influenceResponse(sbResponse{}, err, false)
+	}
+	rsp, err := udr.sb.send(ctx, http.MethodPut, uri, "application/json",
+		body)
+	return influenceResponse(rsp, err, false)
+}
+
+// UdrInfluenceDataUpdate sends PATCH to the influence data resource
+// Successful response : 200 and body contains TrafficInfluData or 204
+func (udr *UdrClient) UdrInfluenceDataUpdate(ctx context.Context,
+	body TrafficInfluDataPatch, iid InfluenceID) (UdrInfluenceResponse,
+	error) {
+
+	log.Infof("UDR InfluenceDataUpdate Entered for %s", string(iid))
+
+	uri, err := udr.influenceDataURI(ctx, iid)
+	if err != nil {
+		return influenceResponse(sbResponse{}, err, false)
+	}
+	rsp, err := udr.sb.send(ctx, http.MethodPatch, uri,
+		"application/merge-patch+json", body)
+	return influenceResponse(rsp, err, false)
+}
+
+// UdrInfluenceDataDelete sends DELETE to the influence data resource
+// Successful response : 204
+func (udr *UdrClient) UdrInfluenceDataDelete(ctx context.Context,
+	iid InfluenceID) (UdrInfluenceResponse, error) {
+
+	log.Infof("UDR InfluenceDataDelete Entered for %s", string(iid))
+
+	uri, err := udr.influenceDataURI(ctx, iid)
+	if err != nil {
+		return influenceResponse(sbResponse{}, err, false)
+	}
+	rsp, err := udr.sb.send(ctx, http.MethodDelete, uri, "", nil)
+	return influenceResponse(rsp, err, false)
+}
+
+// UdrInfluenceDataGet sends GET to the influence data collection
+// Successful response : 200 and body contains list of TrafficInfluData
+func (udr *UdrClient) UdrInfluenceDataGet(ctx context.Context) (
+	UdrInfluenceResponse, error) {
+
+	log.Infof("UDR InfluenceDataGet Entered")
+
+	uri, err := udr.influenceDataURI(ctx, "")
+	if err != nil {
+		return influenceResponse(sbResponse{}, err, true)
+	}
+	rsp, err := udr.sb.send(ctx, http.MethodGet, uri, "", nil)
+	return influenceResponse(rsp, err, true)
+}
@@ -0,0 +1,116 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	ngcnef "github.com/open-ness/epcforedge/ngc/pkg/nef"
+)
+
+var _ = Describe("NEF UDR Influence Data Client", func() {
+
+	var (
+		udr *fakeUDR
+		cfg ngcnef.Config
+	)
+
+	BeforeEach(func() {
+		udr = newFakeUDR("")
+		cfg = ngcnef.Config{UserAgent: "NEF-OPENNESS-1912"}
+		cfg.UDRConfig.APIRoot = udr.server.URL
+	})
+
+	AfterEach(func() {
+		udr.server.Close()
+	})
+
+	It("Create, update, get and delete influence data", func() {
+
+		client, err := ngcnef.NewUDRHTTPClient(&cfg)
+		Expect(err).Should(BeNil())
+		ctx := context.Background()
+
+		tid := ngcnef.TrafficInfluData{AfAppID: "app1"}
+		rsp, err := client.UdrInfluenceDataCreate(ctx, tid, "AF_01-11111")
+		Expect(err).Should(BeNil())
+		Expect(rsp.ResponseCode).Should(Equal(uint16(http.StatusCreated)))
+		Expect(rsp.Tid.AfAppID).Should(Equal("app1"))
+
+		rsp, err = client.UdrInfluenceDataCreate(ctx, tid, "AF_01-11111")
+		Expect(err).Should(BeNil())
+		Expect(rsp.ResponseCode).Should(Equal(uint16(http.StatusOK)))
+
+		patch := ngcnef.TrafficInfluDataPatch{AppReloInd: true}
+		rsp, err = client.UdrInfluenceDataUpdate(ctx, patch, "AF_01-11111")
+		Expect(err).Should(BeNil())
+		Expect(rsp.ResponseCode).Should(Equal(uint16(http.StatusOK)))
+		Expect(rsp.Tid.AppReloInd).Should(BeTrue())
+
+		rsp, err = client.UdrInfluenceDataGet(ctx)
+		Expect(err).Should(BeNil())
+		Expect(rsp.ResponseCode).Should(Equal(uint16(http.StatusOK)))
+		Expect(len(rsp.Tids)).Should(Equal(1))
+
+		rsp, err = client.UdrInfluenceDataDelete(ctx, "AF_01-11111")
+		Expect(err).Should(BeNil())
+		Expect(rsp.ResponseCode).Should(Equal(uint16(http.StatusNoContent)))
+		Expect(udr.influenceData()).Should(Equal(0))
+	})
+
+	It("Maps the ProblemDetails of a failure response", func() {
+
+		client, err := ngcnef.NewUDRHTTPClient(&cfg)
+		Expect(err).Should(BeNil())
+
+		rsp, err := client.UdrInfluenceDataDelete(context.Background(),
+			"AF_01-0")
+		Expect(err).Should(BeNil())
+		Expect(rsp.ResponseCode).Should(Equal(uint16(http.StatusNotFound)))
+		Expect(rsp.Pd.Cause).Should(Equal("DATA_NOT_FOUND"))
+	})
+
+	It("Returns an error when the UDR is not reachable", func() {
+
+		client, err := ngcnef.NewUDRHTTPClient(&cfg)
+		Expect(err).Should(BeNil())
+		udr.server.Close()
+
+		rsp, err := client.UdrInfluenceDataGet(context.Background())
+		Expect(err).ShouldNot(BeNil())
+		Expect(rsp.ResponseCode).Should(
+			Equal(uint16(http.StatusServiceUnavailable)))
+	})
+
+	It("Any UE traffic influence subscription is stored in the UDR", func() {
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			err := ngcnef.Run(ctx, NefTestCfgBasepath+"valid.json")
+			Expect(err).To(BeNil())
+		}()
+		time.Sleep(2 * time.Second)
+
+		postbody, _ := ioutil.ReadFile(testJSONPath +
+			"AF_NEF_POST_UDR_01.json")
+		count := fakeUDRG.influenceData()
+
+		rr, req := CreateReqForNEF(ctx, "POST", "", postbody)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+		Expect(fakeUDRG.influenceData()).Should(Equal(count + 1))
+
+		rr, req = CreateReqForNEF(ctx, "DELETE", "11111", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusNoContent))
+		Expect(fakeUDRG.influenceData()).Should(Equal(count))
+	})
+})
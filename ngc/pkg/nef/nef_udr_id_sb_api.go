@@ -15,6 +15,8 @@ type UdrInfluenceResponse struct {
 	ResponseCode uint16
 	// tid if not nil contains the TrafficInfluData data provided by UDR
 	Tid *TrafficInfluData
+	// tids contains the TrafficInfluData list provided by UDR for a GET
+	Tids []TrafficInfluData
 	// pd if not not contains the problem infomration from UDR.
 	// Valid for 3xx, 4xx, 5xx or 6xx responses
 	Pd *ProblemDetails
@@ -9,6 +9,7 @@ package ngcnef
 import (
 	"context"
 	"errors"
+	"sync"
 )
 
 // TestClient variable is only for UnitTesting purpose to inject errors in stub
@@ -17,6 +18,8 @@ var TestClient = false
 // UdrPfdClientStub is an implementation of the Udr Influence data
 type UdrPfdClientStub struct {
 	udr string
+	// mu guards the database as requests are handled concurrently
+	mu sync.Mutex
 	//database to store content of udr PFD data
 	appPfd map[string]*PfdDataForApp
 }
@@ -37,6 +40,8 @@ func (udr *UdrPfdClientStub) UdrPfdDataCreate(ctx context.Context,
 
 	log.Infof("UdrPfdDataCreate Stub Entered")
 	_ = ctx
+	udr.mu.Lock()
+	defer udr.mu.Unlock()
 
 	log.Info("UdrPfdDataCreate: Invoke UDR SB PUT -> ")
 	udr.appPfd[string(body.AppID)] = &body
@@ -54,6 +59,8 @@ func (udr *UdrPfdClientStub) UdrPfdDataGet(ctx context.Context,
 	appID UdrAppID) (rsp UdrPfdResponse, err error) {
 	log.Infof("UdrPfdDataGet Stub Entered")
 	_ = ctx
+	udr.mu.Lock()
+	defer udr.mu.Unlock()
 
 	rsp.AppPfd = udr.appPfd[string(appID)]
 	log.Info("Get PFD for AppId : ", appID)
@@ -73,6 +80,8 @@ func (udr *UdrPfdClientStub) UdrPfdDataDelete(ctx context.Context,
 	appID UdrAppID) (rsp UdrPfdResponse, err error) {
 	log.Infof("UdrPfdDataDelete Stub Entered")
 	_ = ctx
+	udr.mu.Lock()
+	defer udr.mu.Unlock()
 	log.Info("Deleted PFD AppId : ", appID)
 	log.Info("UdrPfdDataDelete: Invoke UDR SB DELETE -> ")
 
@@ -0,0 +1,136 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+/* Client implementation of the Nudr_DataRepository service for the PFD data
+(3GPP TS 29.519) */
+
+package ngcnef
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const udrPfdsPath = "/nudr-dr/v1/application-data/pfds"
+
+// UdrPfdClient is an HTTP/2 implementation of the Udr PFD data
+type UdrPfdClient struct {
+	sb *sbClient
+}
+
+// NewUDRPfdHTTPClient creates a new UDR PFD Client sending the requests to
+// the UDR configured in UDRConfig
+func NewUDRPfdHTTPClient(cfg *Config) (*UdrPfdClient, error) {
+
+	sb, err := newSBClient(&cfg.UDRConfig, cfg.UserAgent)
+	if err != nil {
+		log.Errf("UDR PFD Client creation failed: %v", err)
+		return nil, err
+	}
+	log.Infof("UDR PFD Client created for %s", sb.apiRoot)
+	return &UdrPfdClient{sb: sb}, nil
+}
+
+// pfdsURI returns the uri of the PFD data of the application. The data is
+// shared by all the UDR instances.
+func (udr *UdrPfdClient) pfdsURI(ctx context.Context,
+	appID UdrAppID) (string, error) {
+
+	apiRoot, err := udr.sb.root(ctx, "", Snssai{})
+	if err != nil {
+		return "", err
+	}
+	return apiRoot + udrPfdsPath + "/" + url.PathEscape(string(appID)), nil
+}
+
+// pfdResponse converts the response received from the UDR. As for the stub
+// an error is returned for a failure response, except for a 404 when
+// notFoundOk is set.
+func pfdResponse(rsp sbResponse, err error,
+	notFoundOk bool) (UdrPfdResponse, error) {
+
+	udrPr := UdrPfdResponse{}
+	if err != nil {
+		log.Errf("UDR PFD request failed: %v", err)
+		udrPr.ResponseCode = http.StatusServiceUnavailable
+		udrPr.Pd = sbUnreachableProblem("UDR", err)
+		return udrPr, err
+	}
+
+	udrPr.ResponseCode = uint16(rsp.code)
+	if rsp.code >= 300 {
+		udrPr.Pd = sbProblemDetails(rsp)
+		if rsp.code == http.StatusNotFound && notFoundOk {
+			return udrPr, nil
+		}
+		return udrPr, errors.New("UDR PFD failure response " +
+			strconv.Itoa(rsp.code))
+	}
+
+	if rsp.code == http.StatusNoContent || len(rsp.body) == 0 {
+		return udrPr, nil
+	}
+
+	appPfd := PfdDataForApp{}
+	if err = json.Unmarshal(rsp.body, &appPfd); err != nil {
+		log.Errf("UDR PFD response decode failed: %v", err)
+		udrPr.ResponseCode = http.StatusInternalServerError
+		udrPr.Pd = &ProblemDetails{Title: "Invalid UDR response",
+			Status: http.StatusInternalServerError}
+		return udrPr, err
+	}
+	udrPr.AppPfd = &appPfd
+	return udrPr, nil
+}
+
+// UdrPfdDataCreate sends PUT to the PFD data of the application
+// Successful response : 201 or 200 and body contains PfdDataForApp, or 204
+func (udr *UdrPfdClient) UdrPfdDataCreate(ctx context.Context,
+	body PfdDataForApp) (UdrPfdResponse, error) {
+
+	log.Infof("UDR PfdDataCreate Entered for %s", string(body.AppID))
+
+	uri, err := udr.pfdsURI(ctx, UdrAppID(body.AppID))
+	if err != nil {
+		return pfdResponse(sbResponse{}, err, false)
+	}
+	rsp, err := udr.sb.send(ctx, http.MethodPut, uri, "application/json",
+		body)
+	return pfdResponse(rsp, err, false)
+}
+
+// UdrPfdDataGet sends GET to the PFD data of the application
+// Successful response : 200 and body contains PfdDataForApp. AppPfd is nil
+// if the application is not found.
+func (udr *UdrPfdClient) UdrPfdDataGet(ctx context.Context,
+	appID UdrAppID) (UdrPfdResponse, error) {
+
+	log.Infof("UDR PfdDataGet Entered for %s", string(appID))
+
+	uri, err := udr.pfdsURI(ctx, appID)
+	if err != nil {
+		return pfdResponse(sbResponse{}, err, true)
+	}
+	rsp, err := udr.sb.send(ctx, http.MethodGet, uri, "", nil)
+	return pfdResponse(rsp, err, true)
+}
+
+// UdrPfdDataDelete sends DELETE to the PFD data of the application
+// Successful response : 204. An application not found is not an error.
+func (udr *UdrPfdClient) UdrPfdDataDelete(ctx context.Context,
+	appID UdrAppID) (UdrPfdResponse, error) {
+
+	log.Infof("UDR PfdDataDelete Entered for %s", string(appID))
+
+	uri, err := udr.pfdsURI(ctx, appID)
+	if err != nil {
+		return pfdResponse(sbResponse{}, err, true)
+	}
+	rsp, err := udr.sb.send(ctx, http.MethodDelete, uri, "", nil)
+	return pfdResponse(rsp, err, true)
+}
@@ -0,0 +1,133 @@
+/* SPDX-License-Identifier: Apache-2.0
+* Copyright (c) 2020 Intel Corporation
+ */
+
+package ngcnef_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	ngcnef "github.com/open-ness/epcforedge/ngc/pkg/nef"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+var _ = Describe("NEF UDR PFD Client", func() {
+
+	var (
+		udr *fakeUDR
+		cfg ngcnef.Config
+	)
+
+	BeforeEach(func() {
+		udr = newFakeUDR("")
+		cfg = ngcnef.Config{UserAgent: "NEF-OPENNESS-1912"}
+		cfg.UDRConfig.APIRoot = udr.server.URL
+	})
+
+	AfterEach(func() {
+		udr.server.Close()
+	})
+
+	It("Create, get and delete the PFDs of an application", func() {
+
+		client, err := ngcnef.NewUDRPfdHTTPClient(&cfg)
+		Expect(err).Should(BeNil())
+		ctx := context.Background()
+
+		app := ngcnef.PfdDataForApp{AppID: "app1",
+			Pfds: []ngcnef.PfdContent{{PfdID: "pfd1",
+				DomainNames: []string{"www.example.com"}}}}
+		rsp, err := client.UdrPfdDataCreate(ctx, app)
+		Expect(err).Should(BeNil())
+		Expect(rsp.ResponseCode).Should(Equal(uint16(http.StatusCreated)))
+
+		rsp, err = client.UdrPfdDataGet(ctx, "app1")
+		Expect(err).Should(BeNil())
+		Expect(rsp.ResponseCode).Should(Equal(uint16(http.StatusOK)))
+		Expect(rsp.AppPfd.Pfds[0].PfdID).Should(Equal("pfd1"))
+
+		rsp, err = client.UdrPfdDataDelete(ctx, "app1")
+		Expect(err).Should(BeNil())
+		Expect(rsp.ResponseCode).Should(Equal(uint16(http.StatusNoContent)))
+
+		rsp, err = client.UdrPfdDataGet(ctx, "app1")
+		Expect(err).Should(BeNil())
+		Expect(rsp.ResponseCode).Should(Equal(uint16(http.StatusNotFound)))
+		Expect(rsp.AppPfd).Should(BeNil())
+	})
+
+	It("Encodes PfdDataForApp as defined by the UDR", func() {
+
+		var body []byte
+		server := httptest.NewServer(h2c.NewHandler(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				body, _ = ioutil.ReadAll(r.Body)
+				w.WriteHeader(http.StatusNoContent)
+			}), &http2.Server{}))
+		defer server.Close()
+		cfg.UDRConfig.APIRoot = server.URL
+		client, err := ngcnef.NewUDRPfdHTTPClient(&cfg)
+		Expect(err).Should(BeNil())
+
+		app := ngcnef.PfdDataForApp{AppID: "app1",
+			Pfds: []ngcnef.PfdContent{{PfdID: "pfd1"}}}
+		_, err = client.UdrPfdDataCreate(context.Background(), app)
+		Expect(err).Should(BeNil())
+		Expect(bytes.Contains(body, []byte(`"applicationId":"app1"`))).
+			Should(BeTrue())
+		Expect(bytes.Contains(body, []byte(`"pfdId":"pfd1"`))).
+			Should(BeTrue())
+	})
+
+	It("Returns an error for a failure response", func() {
+
+		client, err := ngcnef.NewUDRPfdHTTPClient(&cfg)
+		Expect(err).Should(BeNil())
+
+		ngcnef.TestClient = true
+		rsp, err := client.UdrPfdDataCreate(context.Background(),
+			ngcnef.PfdDataForApp{AppID: "app1"})
+		ngcnef.TestClient = false
+		Expect(err).ShouldNot(BeNil())
+		Expect(rsp.ResponseCode).Should(Equal(uint16(http.StatusBadRequest)))
+		Expect(rsp.Pd).ShouldNot(BeNil())
+	})
+
+	It("PFD transaction is stored in the UDR", func() {
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			err := ngcnef.Run(ctx, NefTestCfgBasepath+"valid.json")
+			Expect(err).To(BeNil())
+		}()
+		time.Sleep(2 * time.Second)
+
+		postbody, _ := ioutil.ReadFile(testJSONPFDPath +
+			"AF_NEF_PFD_POST_001.json")
+		rr, req := CreatePFDReqForNEF(ctx, "POST", "", "", postbody)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusCreated))
+
+		app, ok := fakeUDRG.pfdApp("app1")
+		Expect(ok).Should(BeTrue())
+		Expect(len(app.Pfds)).Should(Equal(2))
+		Expect(app.CachingTime).ShouldNot(BeNil())
+		_, err := time.Parse(time.RFC3339, string(*app.CachingTime))
+		Expect(err).Should(BeNil())
+
+		rr, req = CreatePFDReqForNEF(ctx, "DELETE", "10000", "", nil)
+		ngcnef.NefAppG.NefRouter.ServeHTTP(rr, req.WithContext(ctx))
+		Expect(rr.Code).Should(Equal(http.StatusNoContent))
+		_, ok = fakeUDRG.pfdApp("app1")
+		Expect(ok).Should(BeFalse())
+	})
+})
@@ -10,8 +10,14 @@ import (
 	"net/http"
 	"path/filepath"
 	"strconv"
+	"sync"
 )
 
+// stubMu guards AllRecords, NewRecordAFServiceID, testDataIDs and the proxy
+// target. The handlers run concurrently with each other and with the reload
+// of the configuration.
+var stubMu sync.Mutex
+
 // AllRecords store all the AFService
 var AllRecords []AFService
 
@@ -21,42 +27,109 @@ var NewRecordAFServiceID int
 // AFServiceIDBaseValue is base value for the id to allocate
 const AFServiceIDBaseValue = 123456
 
+// testDataIDs are the service IDs of the records loaded from the test data
+var testDataIDs = map[string]bool{}
+
 // APIStubInit : stub init
 func APIStubInit(apistubTestdatapath string) error {
+
+	records, err := APIStubLoadTestData(apistubTestdatapath)
+	if err != nil {
+		return err
+	}
+
+	stubMu.Lock()
+	defer stubMu.Unlock()
 	// Init value
 	NewRecordAFServiceID = AFServiceIDBaseValue // BaseValue for new AFServiceID
-	if 0 == len(apistubTestdatapath) {
+	if records == nil {
 		return nil
 	}
+	AllRecords = nil
+	testDataIDs = map[string]bool{}
+	addTestData(records)
+	log.Infof("[APISTUB MODE] Init with num %d: \n", len(AllRecords))
+	return nil
+}
+
+// APIStubLoadTestData : reads the records of the test data file, none if the
+// path is empty
+func APIStubLoadTestData(apistubTestdatapath string) ([]AFService, error) {
+
+	var records []AFService
+
+	if 0 == len(apistubTestdatapath) {
+		return nil, nil
+	}
 	// Read records from test stub file
 	cfgData, err := ioutil.ReadFile(filepath.Clean(apistubTestdatapath))
 	if err != nil {
-		return err
+		return nil, err
 	}
-	err = json.Unmarshal(cfgData, &AllRecords)
-	if err != nil {
-		return err
+	if err = json.Unmarshal(cfgData, &records); err != nil {
+		return nil, err
 	}
-	log.Infof("[APISTUB MODE] Init with num %d: \n", len(AllRecords))
-	for _, a := range AllRecords {
+	if records == nil {
+		records = []AFService{}
+	}
+	return records, nil
+}
+
+// addTestData appends the records of the test data, stubMu must be held
+func addTestData(records []AFService) {
+
+	for _, a := range records {
 		// ignore serviceID in the test, allocate new serviceID
-		a.AFServiceID = APIStubNewAFServiceID()
+		a.AFServiceID = newAFServiceID()
+		testDataIDs[a.AFServiceID] = true
+		AllRecords = append(AllRecords, a)
 	}
+}
 
-	return nil
+// replaceTestData replaces the records previously loaded from the test data,
+// the records added since are kept. stubMu must be held.
+func replaceTestData(records []AFService) {
+
+	kept := AllRecords[:0]
+	for _, a := range AllRecords {
+		if !testDataIDs[a.AFServiceID] {
+			kept = append(kept, a)
+		}
+	}
+	AllRecords = kept
+	testDataIDs = map[string]bool{}
+	addTestData(records)
+	log.Infof("[APISTUB MODE] Reloaded test data num %d: \n", len(records))
 }
 
 // APIStubReset : stub reset
 func APIStubReset() error {
 
-	AllRecords = nil
-	NewRecordAFServiceID = AFServiceIDBaseValue // BaseValue for new AFServiceID
+	stubMu.Lock()
+	defer stubMu.Unlock()
+	reset()
 	return nil
 
 }
 
+// reset drops the records, stubMu must be held
+func reset() {
+
+	AllRecords = nil
+	testDataIDs = map[string]bool{}
+	NewRecordAFServiceID = AFServiceIDBaseValue // BaseValue for new AFServiceID
+}
+
 // APIStubPrintAll : stub print all
 func APIStubPrintAll() {
+
+	stubMu.Lock()
+	defer stubMu.Unlock()
+	printAll()
+}
+
+// printAll prints the records, stubMu must be held
+func printAll() {
 	// Print all records
 	log.Infof("[APISTUB MODE] NewAFServiceID: %d\n", NewRecordAFServiceID)
 	log.Infof("[APISTUB MODE] AllRecords num is: %d\n", len(AllRecords))
@@ -64,6 +137,14 @@ func APIStubPrintAll() {
 
 // APIStubNewAFServiceID : allocate new service id
 func APIStubNewAFServiceID() string {
+
+	stubMu.Lock()
+	defer stubMu.Unlock()
+	return newAFServiceID()
+}
+
+// newAFServiceID allocates a new service id, stubMu must be held
+func newAFServiceID() string {
 	NewRecordAFServiceID++
 	return strconv.Itoa(NewRecordAFServiceID)
 }
@@ -71,6 +152,14 @@ func APIStubNewAFServiceID() string {
 // APIStubGetRecordIndex : get record by service id
 func APIStubGetRecordIndex(serviceID string) int {
 
+	stubMu.Lock()
+	defer stubMu.Unlock()
+	return recordIndex(serviceID)
+}
+
+// recordIndex returns the index of the record, stubMu must be held
+func recordIndex(serviceID string) int {
+
 	log.Infof("[APISTUB MODE]  Searching: %s\n", serviceID)
 	// loop recorded AFID
 	for i, a := range AllRecords {
@@ -85,8 +174,10 @@ func APIStubGetRecordIndex(serviceID string) int {
 func APIStubGetAll(w http.ResponseWriter, r *http.Request) {
 
 	log.Infof("URL GetAll: %s\n", r.URL.Path)
+	stubMu.Lock()
 	log.Infof("Number of All Records is: %d", len(AllRecords))
 	ret, _ := json.Marshal(AllRecords)
+	stubMu.Unlock()
 	if ret != nil {
 		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 		w.WriteHeader(http.StatusOK)
@@ -117,9 +208,11 @@ func APIStubAdd(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	newRecord[0].AFServiceID = APIStubNewAFServiceID()
+	stubMu.Lock()
+	newRecord[0].AFServiceID = newAFServiceID()
 	AllRecords = append(AllRecords, newRecord...)
-	APIStubPrintAll()
+	printAll()
+	stubMu.Unlock()
 
 	// Respons Body.
 	var rspData AFServiceID
@@ -138,13 +231,16 @@ func APIStubAdd(w http.ResponseWriter, r *http.Request) {
 // APIStubDel : delete one from the records
 func APIStubDel(w http.ResponseWriter, r *http.Request) {
 
+	stubMu.Lock()
+	defer stubMu.Unlock()
+
 	log.Infof("URL Del: %s\n", URLBase+r.URL.Path)
 
 	// get AFID
 	vars := mux.Vars(r)
 
 	// get recorded AFService
-	j := APIStubGetRecordIndex(vars["afServiceId"])
+	j := recordIndex(vars["afServiceId"])
 	if j == -1 {
 		log.Errf("Not found in the AllRecords\n")
 		w.WriteHeader(http.StatusNotFound)
@@ -153,9 +249,9 @@ func APIStubDel(w http.ResponseWriter, r *http.Request) {
 
 	AllRecords = append(AllRecords[:j], AllRecords[j+1:]...)
 	if len(AllRecords) == 0 {
-		_ = APIStubReset()
+		reset()
 	}
-	APIStubPrintAll()
+	printAll()
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -167,8 +263,10 @@ func APIStubGet(w http.ResponseWriter, r *http.Request) {
 	// afId check
 	vars := mux.Vars(r)
 	// get recorded AFService
-	j := APIStubGetRecordIndex(vars["afServiceId"])
+	stubMu.Lock()
+	j := recordIndex(vars["afServiceId"])
 	if j == -1 {
+		stubMu.Unlock()
 		log.Errf("Not found in the AllRecords\n")
 		w.WriteHeader(http.StatusNotFound)
 		return
@@ -178,6 +276,7 @@ func APIStubGet(w http.ResponseWriter, r *http.Request) {
 
 	// Respons Body.
 	rspBody := AllRecords[j].LocationService
+	stubMu.Unlock()
 	jData, err := json.Marshal(rspBody)
 	if err != nil {
 		w.WriteHeader(http.StatusNotFound)
@@ -196,15 +295,18 @@ func APIStubUpdate(w http.ResponseWriter, r *http.Request) {
 
 	// afId Check
 	vars := mux.Vars(r)
+	body, _ := ioutil.ReadAll(r.Body)
+
+	stubMu.Lock()
+	defer stubMu.Unlock()
 	// get recorded AFService
-	j := APIStubGetRecordIndex(vars["afServiceId"])
+	j := recordIndex(vars["afServiceId"])
 	if j == -1 {
 		log.Errf("Not found in the AllRecords\n")
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
 
-	body, _ := ioutil.ReadAll(r.Body)
 	log.Infof("[APISTUB MODE] GetRecord with index: %d\n", j)
 	log.Infof("HTTPRequest Body: %s\n", string(body))
 
@@ -25,9 +25,11 @@ var log = logger.DefaultLogger.WithField("oam", nil)
 // The target can be API_STUB_TEST or flexcore.
 //NOTE: current version only support API_STUB.
 func InitProxy(npcEndpoint string, redirectTarget string, path string) error {
+	stubMu.Lock()
 	URLBase = "http://" + npcEndpoint
 	NGCType = redirectTarget
-	if NGCType == apiStub {
+	stubMu.Unlock()
+	if redirectTarget == apiStub {
 		if nil != APIStubInit(path) {
 			return errors.New("init error")
 		}
@@ -39,15 +41,42 @@ func InitProxy(npcEndpoint string, redirectTarget string, path string) error {
 
 }
 
+// ReloadProxy : sets the proxy target and replaces the records of the
+// previous test data by the records read with APIStubLoadTestData. The
+// records added since the previous load are kept.
+func ReloadProxy(npcEndpoint string, redirectTarget string,
+	records []AFService) error {
+
+	if redirectTarget != apiStub {
+		return errors.New("can't not support flexcore")
+	}
+
+	stubMu.Lock()
+	defer stubMu.Unlock()
+	URLBase = "http://" + npcEndpoint
+	NGCType = redirectTarget
+	replaceTestData(records)
+	return nil
+}
+
+// proxyTarget returns the URL base and the type of the NGC
+func proxyTarget() (string, string) {
+
+	stubMu.Lock()
+	defer stubMu.Unlock()
+	return URLBase, NGCType
+}
+
 // ProxyGetAll : get all by proxy
 func ProxyGetAll(w http.ResponseWriter, r *http.Request) {
 
-	log.Infof("URL GetAll: %s\n", URLBase+r.URL.Path)
+	urlBase, ngcType := proxyTarget()
+	log.Infof("URL GetAll: %s\n", urlBase+r.URL.Path)
 
-	if NGCType == apiStub {
+	if ngcType == apiStub {
 		APIStubGetAll(w, r)
 	} else {
-		log.Errf("GetAll Failed with TargetNGC %s\n", NGCType)
+		log.Errf("GetAll Failed with TargetNGC %s\n", ngcType)
 		w.WriteHeader(http.StatusNotFound)
 	}
 }
@@ -55,12 +84,13 @@ func ProxyGetAll(w http.ResponseWriter, r *http.Request) {
 // ProxyAdd : add by proxy
 func ProxyAdd(w http.ResponseWriter, r *http.Request) {
 
-	log.Infof("URL Add: %s\n", URLBase+r.URL.Path)
+	urlBase, ngcType := proxyTarget()
+	log.Infof("URL Add: %s\n", urlBase+r.URL.Path)
 
-	if NGCType == apiStub {
+	if ngcType == apiStub {
 		APIStubAdd(w, r)
 	} else {
-		log.Errf("Add Failed with TargetNGC %s\n", NGCType)
+		log.Errf("Add Failed with TargetNGC %s\n", ngcType)
 		w.WriteHeader(http.StatusNotFound)
 	}
 }
@@ -68,12 +98,13 @@ func ProxyAdd(w http.ResponseWriter, r *http.Request) {
 // ProxyDel : del by proxy
 func ProxyDel(w http.ResponseWriter, r *http.Request) {
 
-	log.Infof("URL Del: %s\n", URLBase+r.URL.Path)
+	urlBase, ngcType := proxyTarget()
+	log.Infof("URL Del: %s\n", urlBase+r.URL.Path)
 
-	if NGCType == apiStub {
+	if ngcType == apiStub {
 		APIStubDel(w, r)
 	} else {
-		log.Errf("Del Failed with TargetNGC %s\n", NGCType)
+		log.Errf("Del Failed with TargetNGC %s\n", ngcType)
 		w.WriteHeader(http.StatusNotFound)
 	}
 
@@ -82,12 +113,13 @@ func ProxyDel(w http.ResponseWriter, r *http.Request) {
 // ProxyGet : get by proxy
 func ProxyGet(w http.ResponseWriter, r *http.Request) {
 
-	log.Infof("URL Get: %s\n", URLBase+r.URL.Path)
+	urlBase, ngcType := proxyTarget()
+	log.Infof("URL Get: %s\n", urlBase+r.URL.Path)
 
-	if NGCType == apiStub {
+	if ngcType == apiStub {
 		APIStubGet(w, r)
 	} else {
-		log.Errf("Get Failed with TargetNGC %s\n", NGCType)
+		log.Errf("Get Failed with TargetNGC %s\n", ngcType)
 		w.WriteHeader(http.StatusNotFound)
 	}
 
@@ -96,12 +128,13 @@ func ProxyGet(w http.ResponseWriter, r *http.Request) {
 // ProxyUpdate : udpate byproxy
 func ProxyUpdate(w http.ResponseWriter, r *http.Request) {
 
-	log.Infof("URL Update: %s\n", URLBase+r.URL.Path)
+	urlBase, ngcType := proxyTarget()
+	log.Infof("URL Update: %s\n", urlBase+r.URL.Path)
 
-	if NGCType == apiStub {
+	if ngcType == apiStub {
 		APIStubUpdate(w, r)
 	} else {
-		log.Errf("Update Failed with TargetNGC %s\n", NGCType)
+		log.Errf("Update Failed with TargetNGC %s\n", ngcType)
 		w.WriteHeader(http.StatusNotFound)
 	}
 
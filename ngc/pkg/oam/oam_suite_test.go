@@ -272,4 +272,30 @@ var _ = Describe("NGC_APIStub", func() {
 
 			})
 	})
+	Describe("APISTUB Reload", func() {
+		It("Will replace the test data and keep the added Records",
+			func() {
+				tmp := testdataBasepath + "testdata_01.json"
+				Expect(APIStubInit(tmp)).To(BeNil())
+				reqBody, err := ioutil.ReadFile(postdataBasepath +
+					"POST001.json")
+				Expect(err).ShouldNot(HaveOccurred())
+				req, _ := http.NewRequest(http.MethodPost, "/services",
+					bytes.NewReader(reqBody))
+				rsp := httptest.NewRecorder()
+				APIStubAdd(rsp, req)
+				Expect(rsp.Code).To(Equal(http.StatusCreated))
+
+				_, err = APIStubLoadTestData("nonexistent-file")
+				Expect(err).NotTo(BeNil())
+				records, err := APIStubLoadTestData(tmp)
+				Expect(err).To(BeNil())
+				Expect(ReloadProxy("v", "Flexcore", records)).NotTo(BeNil())
+				Expect(ReloadProxy("v", "APISTUB", records)).To(BeNil())
+				Expect(len(AllRecords)).To(Equal(2))
+				Expect(APIStubGetRecordIndex("123457")).To(Equal(-1))
+				Expect(APIStubGetRecordIndex("123458")).To(Equal(0))
+				Expect(APIStubGetRecordIndex("123459")).To(Equal(1))
+			})
+	})
 })
@@ -0,0 +1,185 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package oauth2
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Lifetime assumed for the tokens when the token endpoint gives none
+const defaultTokenLifetime = 300 * time.Second
+
+// TokenSource provides the access token of a client, a new token is
+// requested when a tenth of the lifetime of the current one is left
+type TokenSource struct {
+	mu sync.Mutex
+	// Token endpoint, the token is generated locally if empty
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scope        string
+	client       *http.Client
+	// Audience and lifetime of the tokens generated locally, none if empty
+	audience string
+	lifetime time.Duration
+
+	token     string
+	expiry    time.Time
+	refreshAt time.Time
+}
+
+// NewTokenSource creates the token source of the client. The tokens are
+// requested from the token endpoint tokenURL with the HTTP client, or
+// generated with the local OAuth2 configuration if tokenURL is empty.
+func NewTokenSource(tokenURL string, clientID string, clientSecret string,
+	scopes []string, client *http.Client) *TokenSource {
+
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &TokenSource{tokenURL: tokenURL, clientID: clientID,
+		clientSecret: clientSecret, scope: strings.Join(scopes, " "),
+		client: client}
+}
+
+// NewAudienceTokenSource creates the token source of the client generating
+// the tokens bound to the audience with the local OAuth2 configuration. The
+// tokens expire after the lifetime.
+func NewAudienceTokenSource(clientID string, scopes []string,
+	audience string, lifetime time.Duration) *TokenSource {
+
+	return &TokenSource{clientID: clientID, scope: strings.Join(scopes, " "),
+		audience: audience, lifetime: lifetime}
+}
+
+// Token returns a valid access token, refreshing it before its expiry. The
+// current token is returned if the refresh fails while it is still valid.
+func (s *TokenSource) Token() (string, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.token != "" && now.Before(s.refreshAt) {
+		return s.token, nil
+	}
+
+	token, lifetime, err := s.fetch()
+	if err != nil {
+		if s.token != "" && now.Before(s.expiry) {
+			log.Errln("Failed to refresh the access token:", err)
+			return s.token, nil
+		}
+		return "", err
+	}
+	s.token = token
+	s.expiry = now.Add(lifetime)
+	s.refreshAt = now.Add(lifetime - lifetime/10)
+	log.Infoln("Access token of", s.clientID, "expires in", lifetime)
+	return s.token, nil
+}
+
+// Invalidate drops the token rejected by the server so that the next call
+// of Token requests a new one. A token already replaced is ignored.
+func (s *TokenSource) Invalidate(token string) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if token == s.token {
+		s.token = ""
+	}
+}
+
+// fetch requests a new token, the caller must hold mu
+func (s *TokenSource) fetch() (string, time.Duration, error) {
+
+	if s.audience != "" {
+		token, err := GetAudienceAccessToken(s.clientID, s.scope,
+			s.audience, s.lifetime)
+		return token, s.lifetime, err
+	}
+	if s.tokenURL == "" {
+		return s.generate()
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("nfInstanceId", s.clientID)
+	form.Set("nfType", "AF")
+	form.Set("targetNfType", "NEF")
+	form.Set("scope", s.scope)
+	req, err := http.NewRequest(http.MethodPost, s.tokenURL,
+		strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(s.clientID, s.clientSecret)
+
+	rsp, err := s.client.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() {
+		if cerr := rsp.Body.Close(); cerr != nil {
+			log.Errln("Failed to close the token response:", cerr)
+		}
+	}()
+
+	if rsp.StatusCode != http.StatusOK {
+		var tokenErr AccessTokenErr
+		if json.NewDecoder(rsp.Body).Decode(&tokenErr) == nil &&
+			tokenErr.Error != "" {
+			return "", 0, errors.New("Token request rejected: " +
+				tokenErr.Error + " " + tokenErr.ErrorDescription)
+		}
+		return "", 0, errors.New("Token request failed: " + rsp.Status)
+	}
+	var tokenRsp AccessTokenRsp
+	if err = json.NewDecoder(rsp.Body).Decode(&tokenRsp); err != nil {
+		return "", 0, err
+	}
+	if tokenRsp.AccessToken == "" ||
+		!strings.EqualFold(tokenRsp.TokenType, "Bearer") {
+		return "", 0, errors.New("Invalid token response")
+	}
+	lifetime := time.Duration(tokenRsp.ExpiresIn) * time.Second
+	if lifetime <= 0 {
+		lifetime = defaultTokenLifetime
+	}
+	return tokenRsp.AccessToken, lifetime, nil
+}
+
+// generate creates the token with the local OAuth2 configuration
+func (s *TokenSource) generate() (string, time.Duration, error) {
+
+	var oAuth2Cfg = Config{}
+
+	if err := loadJSONConfig(CfgPath, &oAuth2Cfg); err != nil {
+		log.Errln("Failed to load OAuth2 configuration")
+		return "", 0, err
+	}
+	token, err := GetNEFAccessTokenFromNRF(AccessTokenReq{
+		GrantType:    "client_credentials",
+		NfInstanceID: s.clientID,
+		NfType:       "AF",
+		TargetNfType: "NEF",
+		Scope:        s.scope})
+	if err != nil {
+		return "", 0, err
+	}
+	lifetime := time.Duration(oAuth2Cfg.Expiration) * time.Second
+	if lifetime <= 0 {
+		lifetime = defaultTokenLifetime
+	}
+	return token, lifetime, nil
+}
@@ -0,0 +1,414 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package oauth2
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go/v4"
+)
+
+// Signing algorithms of the tokens
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+)
+
+// Algorithm used if none is configured, HS256 is kept for the
+// configurations with only a SigningKey
+const defaultAlgorithm = AlgES256
+
+// Default time in seconds the keys fetched from the JWKS URI are cached
+const defaultJwksCacheTime = 300
+
+// Minimum time between two fetches of the JWKS document when a token is
+// signed by an unknown key
+var jwksMinRefetch = 5 * time.Second
+
+// KeyConfig is an asymmetric signing key of the issuer
+type KeyConfig struct {
+	// Key ID published in the JWKS document and set in the token header
+	Kid string `json:"kid"`
+	// PEM file with the RSA or EC P-256 private key
+	PrivateKeyFile string `json:"privateKeyFile"`
+}
+
+// JWK JSON Web Key (RFC 7517) of a public key
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	// RSA modulus and exponent
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC curve and coordinates
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKSet JSON Web Key Set document published by the issuer
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// loadPrivateKey reads the private key of the algorithm from the PEM file
+func loadPrivateKey(alg string, path string) (crypto.Signer, error) {
+
+	b, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	switch alg {
+	case AlgRS256:
+		return jwt.ParseRSAPrivateKeyFromPEM(b)
+	case AlgES256:
+		key, err := jwt.ParseECPrivateKeyFromPEM(b)
+		if err != nil {
+			return nil, err
+		}
+		if key.Curve != elliptic.P256() {
+			return nil, errors.New("ES256 requires a P-256 key")
+		}
+		return key, nil
+	}
+	return nil, errors.New("Unsupported algorithm " + alg)
+}
+
+// signingKey returns the signing method, the key ID and the key used to
+// sign the tokens. The first key of the configuration signs, the others are
+// only published until the tokens they signed expire.
+func signingKey(cfg *Config) (jwt.SigningMethod, string, interface{},
+	error) {
+
+	alg := algorithm(cfg)
+	switch alg {
+	case AlgHS256:
+		return jwt.SigningMethodHS256, "", []byte(cfg.SigningKey), nil
+	case AlgRS256, AlgES256:
+	default:
+		return nil, "", nil, errors.New("Unsupported algorithm " + alg)
+	}
+
+	if len(cfg.Keys) == 0 {
+		return nil, "", nil, errors.New("No signing key configured")
+	}
+	key, err := loadPrivateKey(alg, cfg.Keys[0].PrivateKeyFile)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return jwt.GetSigningMethod(alg), cfg.Keys[0].Kid, key, nil
+}
+
+// algorithm returns the signing algorithm of the configuration
+func algorithm(cfg *Config) string {
+
+	if cfg.Algorithm != "" {
+		return cfg.Algorithm
+	}
+	if cfg.SigningKey != "" && len(cfg.Keys) == 0 {
+		return AlgHS256
+	}
+	return defaultAlgorithm
+}
+
+// SharedKeySigning returns true if the tokens are signed with the HS256
+// shared key. Every holder of the configuration can then issue tokens with
+// any subject and scope, the scope and subject checks are void.
+func SharedKeySigning() bool {
+
+	var oAuth2Cfg = Config{}
+
+	if err := loadJSONConfig(CfgPath, &oAuth2Cfg); err != nil {
+		return false
+	}
+	return algorithm(&oAuth2Cfg) == AlgHS256
+}
+
+// publicJWK returns the JWK of the public key
+func publicJWK(alg string, kid string, pub crypto.PublicKey) (JWK, error) {
+
+	jwk := JWK{Kid: kid, Use: "sig", Alg: alg}
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(k.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(
+			big.NewInt(int64(k.E)).Bytes())
+	case *ecdsa.PublicKey:
+		jwk.Kty = "EC"
+		jwk.Crv = "P-256"
+		jwk.X = base64.RawURLEncoding.EncodeToString(padCoord(k.X))
+		jwk.Y = base64.RawURLEncoding.EncodeToString(padCoord(k.Y))
+	default:
+		return jwk, errors.New("Unsupported key type")
+	}
+	return jwk, nil
+}
+
+// padCoord returns the 32 bytes of a P-256 coordinate
+func padCoord(v *big.Int) []byte {
+
+	b := make([]byte, 32)
+	vb := v.Bytes()
+	copy(b[len(b)-len(vb):], vb)
+	return b
+}
+
+// publicKey returns the public key of the JWK
+func (jwk *JWK) publicKey() (crypto.PublicKey, error) {
+
+	switch jwk.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if jwk.Crv != "P-256" {
+			return nil, errors.New("Unsupported curve " + jwk.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(),
+			X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, errors.New("EC key not on the curve")
+		}
+		return pub, nil
+	}
+	return nil, errors.New("Unsupported key type " + jwk.Kty)
+}
+
+// PublicJWKS returns the JWKS document with the public keys of the configured
+// signing keys, an empty set for HS256 whose secret is never published
+func PublicJWKS() (JWKSet, error) {
+
+	var oAuth2Cfg = Config{}
+
+	set := JWKSet{Keys: []JWK{}}
+	if err := loadJSONConfig(CfgPath, &oAuth2Cfg); err != nil {
+		return set, err
+	}
+	alg := algorithm(&oAuth2Cfg)
+	if alg == AlgHS256 {
+		return set, nil
+	}
+
+	for _, k := range oAuth2Cfg.Keys {
+		key, err := loadPrivateKey(alg, k.PrivateKeyFile)
+		if err != nil {
+			return set, err
+		}
+		jwk, err := publicJWK(alg, k.Kid, key.Public())
+		if err != nil {
+			return set, err
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set, nil
+}
+
+// ServeJWKS HTTP handler publishing the JWKS document of the issuer
+func ServeJWKS(w http.ResponseWriter, r *http.Request) {
+
+	set, err := PublicJWKS()
+	if err != nil {
+		log.Errln("Failed to load the signing keys:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	b, err := json.Marshal(set)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/jwk-set+json")
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(b); err != nil {
+		log.Errln("Failed to write the JWKS:", err)
+	}
+}
+
+// jwksCache caches the public keys fetched from the JWKS URI by key ID
+type jwksCache struct {
+	mu        sync.Mutex
+	uri       string
+	keys      map[string]crypto.PublicKey
+	expiry    time.Time
+	lastFetch time.Time
+}
+
+var keyCache = &jwksCache{}
+
+// get returns the public key of the key ID. The JWKS document is fetched
+// again, at most every jwksMinRefetch, when the cache expired or when the key
+// is unknown so that the keys added by a rotation are found.
+func (c *jwksCache) get(cfg *Config, kid string) (crypto.PublicKey, error) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if c.uri != cfg.JwksURI {
+		c.uri, c.keys, c.expiry = cfg.JwksURI, nil, time.Time{}
+		c.lastFetch = time.Time{}
+	}
+	key, ok := c.keys[kid]
+	if ok && now.Before(c.expiry) {
+		return key, nil
+	}
+	if now.Sub(c.lastFetch) >= jwksMinRefetch {
+		if err := c.fetch(cfg, now); err != nil {
+			log.Errln("Failed to fetch the JWKS:", err)
+			// The cached keys are used until the issuer is back
+			if ok {
+				return key, nil
+			}
+			return nil, err
+		}
+		key, ok = c.keys[kid]
+	}
+	if !ok {
+		return nil, errors.New("Unknown key " + kid)
+	}
+	return key, nil
+}
+
+// fetch gets the JWKS document, the caller must hold mu
+func (c *jwksCache) fetch(cfg *Config, now time.Time) error {
+
+	c.lastFetch = now
+	client, err := jwksClient(cfg)
+	if err != nil {
+		return err
+	}
+	rsp, err := client.Get(cfg.JwksURI)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := rsp.Body.Close(); cerr != nil {
+			log.Errln("Failed to close the JWKS response:", cerr)
+		}
+	}()
+	if rsp.StatusCode != http.StatusOK {
+		return errors.New("JWKS response " + rsp.Status)
+	}
+
+	var set JWKSet
+	if err = json.NewDecoder(rsp.Body).Decode(&set); err != nil {
+		return err
+	}
+	keys := make(map[string]crypto.PublicKey)
+	for i := range set.Keys {
+		pub, err := set.Keys[i].publicKey()
+		if err != nil {
+			log.Infoln("Skipped JWK", set.Keys[i].Kid, ":", err)
+			continue
+		}
+		keys[set.Keys[i].Kid] = pub
+	}
+
+	cacheTime := cfg.JwksCacheTime
+	if cacheTime <= 0 {
+		cacheTime = defaultJwksCacheTime
+	}
+	c.keys = keys
+	c.expiry = now.Add(time.Duration(cacheTime) * time.Second)
+	log.Infoln("Fetched", len(keys), "keys from", cfg.JwksURI)
+	return nil
+}
+
+// jwksClient returns the HTTP client fetching the JWKS document, trusting
+// the root CA of the configuration if any
+func jwksClient(cfg *Config) (*http.Client, error) {
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	if cfg.JwksRootCACert == "" {
+		return client, nil
+	}
+	b, err := ioutil.ReadFile(filepath.Clean(cfg.JwksRootCACert))
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, errors.New("Invalid root CA " + cfg.JwksRootCACert)
+	}
+	client.Transport = &http.Transport{
+		TLSClientConfig: &tls.Config{RootCAs: pool}}
+	return client, nil
+}
+
+// localKey returns the public key of the key ID from the signing keys of
+// the configuration, used when no JWKS URI is configured
+func localKey(cfg *Config, kid string) (crypto.PublicKey, error) {
+
+	for _, k := range cfg.Keys {
+		if k.Kid != kid {
+			continue
+		}
+		key, err := loadPrivateKey(algorithm(cfg), k.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return key.Public(), nil
+	}
+	return nil, errors.New("Unknown key " + kid)
+}
+
+// verificationKey returns the jwt.Keyfunc returning the key verifying the
+// token. The algorithm of the token must be the configured one.
+func verificationKey(cfg *Config) jwt.Keyfunc {
+
+	return func(token *jwt.Token) (interface{}, error) {
+
+		alg := algorithm(cfg)
+		if token.Method.Alg() != alg {
+			return nil, errors.New("Unexpected signing algorithm " +
+				token.Method.Alg())
+		}
+		if alg == AlgHS256 {
+			return []byte(cfg.SigningKey), nil
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, errors.New("Missing kid in the token header")
+		}
+		if cfg.JwksURI != "" {
+			return keyCache.get(cfg, kid)
+		}
+		return localKey(cfg, kid)
+	}
+}
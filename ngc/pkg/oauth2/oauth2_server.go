@@ -0,0 +1,197 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package oauth2
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// TokenURLPath is the path of the token endpoint
+const TokenURLPath = "/oauth2/token"
+
+// JWKSURLPath is the path of the JWKS document published with the token
+// endpoint
+const JWKSURLPath = "/oauth2/jwks"
+
+// Errors of the token endpoint (RFC 6749 section 5.2)
+const (
+	ErrInvalidRequest       = "invalid_request"
+	ErrInvalidClient        = "invalid_client"
+	ErrInvalidScope         = "invalid_scope"
+	ErrUnsupportedGrantType = "unsupported_grant_type"
+)
+
+// ClientConfig is a client allowed to request tokens from the token endpoint
+type ClientConfig struct {
+	// Client ID, the NF instance ID of the requests and the subject of the
+	// tokens (the AF ID for the AFs)
+	ClientID     string `json:"clientId"`
+	ClientSecret string `json:"clientSecret"`
+	// Scopes the client may request
+	Scopes []string `json:"scopes"`
+}
+
+// AccessTokenRsp successful response of the token endpoint
+type AccessTokenRsp struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in,omitempty"`
+	Scope       string `json:"scope,omitempty"`
+}
+
+// AccessTokenErr error response of the token endpoint
+type AccessTokenErr struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// findClient returns the client of the configuration
+func findClient(cfg *Config, clientID string) *ClientConfig {
+
+	for i := range cfg.Clients {
+		if cfg.Clients[i].ClientID == clientID {
+			return &cfg.Clients[i]
+		}
+	}
+	return nil
+}
+
+// AFClient returns true if the client of the configuration is an AF, i.e.
+// it may request one of the AFScopes
+func AFClient(clientID string) bool {
+
+	var oAuth2Cfg = Config{}
+
+	if err := loadJSONConfig(CfgPath, &oAuth2Cfg); err != nil {
+		return false
+	}
+	client := findClient(&oAuth2Cfg, clientID)
+	if client == nil {
+		return false
+	}
+	for _, s := range AFScopes {
+		if client.grantsScope(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// grantsScope returns true if the scope is one of the scopes of the client
+func (c *ClientConfig) grantsScope(scope string) bool {
+
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// sendTokenRsp sends the JSON body of a token endpoint response
+func sendTokenRsp(w http.ResponseWriter, status int, body interface{}) {
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		log.Errln("Failed to marshal the token response:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	// The tokens must not be cached (RFC 6749 section 5.1)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(status)
+	if _, err = w.Write(b); err != nil {
+		log.Errln("Failed to write the token response:", err)
+	}
+}
+
+// sendTokenErr sends an error response of the token endpoint
+func sendTokenErr(w http.ResponseWriter, status int, code string,
+	desc string) {
+
+	log.Infoln("Access token request rejected:", code, desc)
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Basic realm="`+TokenURLPath+`"`)
+	}
+	sendTokenRsp(w, status, AccessTokenErr{Error: code,
+		ErrorDescription: desc})
+}
+
+// TokenHandler HTTP handler of the token endpoint. The clients send a
+// client_credentials access token request (application/x-www-form-urlencoded
+// as in 3GPP TS 29.510) and authenticate with HTTP Basic or with the
+// client_secret parameter.
+func TokenHandler(w http.ResponseWriter, r *http.Request) {
+
+	var oAuth2Cfg = Config{}
+
+	if err := r.ParseForm(); err != nil {
+		sendTokenErr(w, http.StatusBadRequest, ErrInvalidRequest,
+			"Invalid form body")
+		return
+	}
+	if r.PostForm.Get("grant_type") != "client_credentials" {
+		sendTokenErr(w, http.StatusBadRequest, ErrUnsupportedGrantType,
+			"Only client_credentials is supported")
+		return
+	}
+
+	clientID, secret, basic := r.BasicAuth()
+	if !basic {
+		clientID = r.PostForm.Get("nfInstanceId")
+		secret = r.PostForm.Get("client_secret")
+	}
+	nfInstanceID := r.PostForm.Get("nfInstanceId")
+	scopes := strings.Fields(r.PostForm.Get("scope"))
+	if clientID == "" || len(scopes) == 0 {
+		sendTokenErr(w, http.StatusBadRequest, ErrInvalidRequest,
+			"Missing nfInstanceId or scope")
+		return
+	}
+	if nfInstanceID != "" && nfInstanceID != clientID {
+		sendTokenErr(w, http.StatusBadRequest, ErrInvalidRequest,
+			"nfInstanceId is not the authenticated client")
+		return
+	}
+
+	if err := loadJSONConfig(CfgPath, &oAuth2Cfg); err != nil {
+		log.Errln("Failed to load OAuth2 configuration")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	client := findClient(&oAuth2Cfg, clientID)
+	if client == nil || subtle.ConstantTimeCompare([]byte(secret),
+		[]byte(client.ClientSecret)) != 1 {
+		sendTokenErr(w, http.StatusUnauthorized, ErrInvalidClient,
+			"Client authentication failed")
+		return
+	}
+	for _, s := range scopes {
+		if !client.grantsScope(s) {
+			sendTokenErr(w, http.StatusBadRequest, ErrInvalidScope,
+				"Scope "+s+" not allowed for the client")
+			return
+		}
+	}
+
+	scope := strings.Join(scopes, " ")
+	token, err := GetNEFAccessTokenFromNRF(AccessTokenReq{
+		GrantType:    "client_credentials",
+		NfInstanceID: clientID,
+		NfType:       NfType(r.PostForm.Get("nfType")),
+		TargetNfType: NfType(r.PostForm.Get("targetNfType")),
+		Scope:        scope})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	log.Infoln("Access token issued to", clientID, "for", scope)
+	sendTokenRsp(w, http.StatusOK, AccessTokenRsp{AccessToken: token,
+		TokenType: "Bearer", ExpiresIn: oAuth2Cfg.Expiration, Scope: scope})
+}
@@ -0,0 +1,287 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2020 Intel Corporation
+
+package oauth2
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestOAuth2(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "OAuth2 suite")
+}
+
+var _ = Describe("OAuth2 asymmetric tokens", func() {
+
+	var (
+		dir        string
+		savedPath  string
+		jwksServer *httptest.Server
+		fetchMu    sync.Mutex
+		fetches    int
+	)
+
+	// writeKey writes a new private key of the algorithm in PEM
+	writeKey := func(alg string, name string) string {
+		var block *pem.Block
+		if alg == AlgRS256 {
+			key, err := rsa.GenerateKey(rand.Reader, 2048)
+			Expect(err).Should(BeNil())
+			block = &pem.Block{Type: "RSA PRIVATE KEY",
+				Bytes: x509.MarshalPKCS1PrivateKey(key)}
+		} else {
+			key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).Should(BeNil())
+			b, err := x509.MarshalECPrivateKey(key)
+			Expect(err).Should(BeNil())
+			block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: b}
+		}
+		path := filepath.Join(dir, name+".pem")
+		Expect(ioutil.WriteFile(path, pem.EncodeToMemory(block),
+			0600)).Should(BeNil())
+		return path
+	}
+
+	// writeCfg writes the OAuth2 configuration
+	writeCfg := func(cfg Config) {
+		cfg.Expiration = 600
+		b, err := json.Marshal(cfg)
+		Expect(err).Should(BeNil())
+		Expect(ioutil.WriteFile(CfgPath, b, 0600)).Should(BeNil())
+	}
+
+	token := func() string {
+		t, err := GetNEFAccessTokenFromNRF(AccessTokenReq{
+			NfInstanceID: "AF_01", Scope: ScopeTrafficInfluence})
+		Expect(err).Should(BeNil())
+		return t
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "oauth2")
+		Expect(err).Should(BeNil())
+		savedPath = CfgPath
+		CfgPath = filepath.Join(dir, "oauth2.json")
+		keyCache = &jwksCache{}
+		fetches = 0
+		jwksServer = httptest.NewServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				fetchMu.Lock()
+				fetches++
+				fetchMu.Unlock()
+				ServeJWKS(w, r)
+			}))
+	})
+
+	AfterEach(func() {
+		jwksServer.Close()
+		CfgPath = savedPath
+		os.RemoveAll(dir)
+	})
+
+	It("Signs and verifies RS256 and ES256 tokens", func() {
+
+		for _, alg := range []string{AlgRS256, AlgES256} {
+			writeCfg(Config{Algorithm: alg, Keys: []KeyConfig{{Kid: "k1",
+				PrivateKeyFile: writeKey(alg, "k1")}}})
+
+			claims, status, err := ParseAccessToken(token())
+			Expect(err).Should(BeNil(), alg)
+			Expect(status).Should(Equal(TokenVerificationResult(
+				StatusSuccess)))
+			Expect(claims.Subject).Should(Equal("AF_01"))
+			Expect(claims.HasScope(ScopeTrafficInfluence)).Should(BeTrue())
+
+			set, err := PublicJWKS()
+			Expect(err).Should(BeNil())
+			Expect(set.Keys).Should(HaveLen(1))
+			Expect(set.Keys[0].Kid).Should(Equal("k1"))
+			Expect(set.Keys[0].Alg).Should(Equal(alg))
+		}
+	})
+
+	It("Keeps HS256 for the configurations with only a signing key",
+		func() {
+
+			writeCfg(Config{SigningKey: "secret"})
+			hs256 := token()
+			Expect(SharedKeySigning()).Should(BeTrue())
+
+			writeCfg(Config{SigningKey: "secret", Algorithm: AlgHS256})
+			_, status, err := ParseAccessToken(hs256)
+			Expect(err).Should(BeNil())
+			Expect(status).Should(Equal(TokenVerificationResult(
+				StatusSuccess)))
+
+			// ES256 with the keys
+			writeCfg(Config{Keys: []KeyConfig{{Kid: "k1",
+				PrivateKeyFile: writeKey(AlgES256, "k1")}}})
+			Expect(SharedKeySigning()).Should(BeFalse())
+			es256 := token()
+			_, status, err = ParseAccessToken(es256)
+			Expect(err).Should(BeNil())
+			Expect(status).Should(Equal(TokenVerificationResult(
+				StatusSuccess)))
+		})
+
+	It("Rejects the tokens of another algorithm", func() {
+
+		writeCfg(Config{SigningKey: "secret", Algorithm: AlgHS256})
+		hs256 := token()
+
+		writeCfg(Config{SigningKey: "secret", Algorithm: AlgES256,
+			Keys: []KeyConfig{{Kid: "k1",
+				PrivateKeyFile: writeKey(AlgES256, "k1")}}})
+		_, status, err := ParseAccessToken(hs256)
+		Expect(err).ShouldNot(BeNil())
+		Expect(status).Should(Equal(TokenVerificationResult(
+			StatusInvalidToken)))
+	})
+
+	It("Finds the rotated keys in the JWKS document", func() {
+
+		jwksMinRefetch = 0
+		defer func() { jwksMinRefetch = 5 * time.Second }()
+
+		k1 := KeyConfig{Kid: "k1", PrivateKeyFile: writeKey(AlgES256, "k1")}
+		k2 := KeyConfig{Kid: "k2", PrivateKeyFile: writeKey(AlgES256, "k2")}
+		cfg := Config{Algorithm: AlgES256, Keys: []KeyConfig{k1},
+			JwksURI: jwksServer.URL, JwksCacheTime: 600}
+		writeCfg(cfg)
+		old := token()
+		_, _, err := ParseAccessToken(old)
+		Expect(err).Should(BeNil())
+		_, _, err = ParseAccessToken(old)
+		Expect(err).Should(BeNil())
+		Expect(fetches).Should(Equal(1))
+
+		// The new key signs, the old one is still published
+		cfg.Keys = []KeyConfig{k2, k1}
+		writeCfg(cfg)
+		_, _, err = ParseAccessToken(token())
+		Expect(err).Should(BeNil())
+		Expect(fetches).Should(Equal(2))
+		_, _, err = ParseAccessToken(old)
+		Expect(err).Should(BeNil())
+
+		// The old key is rejected once its removal is fetched
+		cfg.Keys = []KeyConfig{k2}
+		cfg.JwksCacheTime = 1
+		writeCfg(cfg)
+		keyCache.mu.Lock()
+		keyCache.expiry = time.Now()
+		keyCache.mu.Unlock()
+		_, _, err = ParseAccessToken(old)
+		Expect(err).ShouldNot(BeNil())
+	})
+
+	It("Issues the tokens of the clients at the token endpoint", func() {
+
+		writeCfg(Config{SigningKey: "secret", Algorithm: AlgHS256,
+			Clients: []ClientConfig{{
+				ClientID: "AF_01", ClientSecret: "pass",
+				Scopes: []string{ScopeTrafficInfluence}}}})
+		srv := httptest.NewServer(http.HandlerFunc(TokenHandler))
+		defer srv.Close()
+
+		src := NewTokenSource(srv.URL, "AF_01", "pass",
+			[]string{ScopeTrafficInfluence}, nil)
+		t, err := src.Token()
+		Expect(err).Should(BeNil())
+		claims, _, err := ParseAccessToken(t)
+		Expect(err).Should(BeNil())
+		Expect(claims.Subject).Should(Equal("AF_01"))
+
+		// The token is reused until its refresh and replaced once rejected
+		Expect(src.Token()).Should(Equal(t))
+		src.Invalidate(t)
+		src.mu.Lock()
+		Expect(src.token).Should(BeEmpty())
+		src.mu.Unlock()
+		_, err = src.Token()
+		Expect(err).Should(BeNil())
+
+		_, err = NewTokenSource(srv.URL, "AF_01", "wrong",
+			[]string{ScopeTrafficInfluence}, nil).Token()
+		Expect(err).Should(MatchError(ContainSubstring(ErrInvalidClient)))
+		_, err = NewTokenSource(srv.URL, "AF_01", "pass",
+			[]string{ScopeNefAdmin}, nil).Token()
+		Expect(err).Should(MatchError(ContainSubstring(ErrInvalidScope)))
+		_, err = NewTokenSource(srv.URL, "AF_02", "pass",
+			[]string{ScopeTrafficInfluence}, nil).Token()
+		Expect(err).Should(MatchError(ContainSubstring(ErrInvalidClient)))
+	})
+
+	It("Binds the tokens to their audience", func() {
+
+		writeCfg(Config{SigningKey: "secret", Algorithm: AlgHS256})
+		src := NewAudienceTokenSource(NefSubject,
+			[]string{ScopeAfNotification}, "af1:8051", time.Minute)
+		t, err := src.Token()
+		Expect(err).Should(BeNil())
+		src.mu.Lock()
+		Expect(src.expiry).Should(BeTemporally("~",
+			time.Now().Add(time.Minute), time.Second))
+		src.mu.Unlock()
+
+		claims, _, err := ParseAudienceAccessToken(t, "af1:8051")
+		Expect(err).Should(BeNil())
+		Expect(claims.Subject).Should(Equal(NefSubject))
+		Expect(claims.HasScope(ScopeAfNotification)).Should(BeTrue())
+		_, _, err = ParseAudienceAccessToken(t, "af2:8051")
+		Expect(err).ShouldNot(BeNil())
+		// Neither accepted where no audience is expected
+		_, _, err = ParseAccessToken(t)
+		Expect(err).ShouldNot(BeNil())
+		_, _, err = ParseAudienceAccessToken(token(), "af1:8051")
+		Expect(err).ShouldNot(BeNil())
+	})
+
+	It("Refreshes the token before its expiry", func() {
+
+		writeCfg(Config{SigningKey: "secret", Algorithm: AlgHS256,
+			Clients: []ClientConfig{{
+				ClientID: "AF_01", ClientSecret: "pass",
+				Scopes: []string{ScopeTrafficInfluence}}}})
+		srv := httptest.NewServer(http.HandlerFunc(TokenHandler))
+		defer srv.Close()
+
+		src := NewTokenSource(srv.URL, "AF_01", "pass",
+			[]string{ScopeTrafficInfluence}, nil)
+		t, err := src.Token()
+		Expect(err).Should(BeNil())
+		src.mu.Lock()
+		Expect(src.refreshAt).Should(BeTemporally("~",
+			time.Now().Add(540*time.Second), time.Second))
+		src.refreshAt = time.Now()
+		src.mu.Unlock()
+
+		// The current token is kept while the token endpoint is down
+		srv.Close()
+		Expect(src.Token()).Should(Equal(t))
+		src.mu.Lock()
+		src.expiry = time.Now()
+		src.mu.Unlock()
+		_, err = src.Token()
+		Expect(err).ShouldNot(BeNil())
+	})
+})
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"io/ioutil"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/dgrijalva/jwt-go/v4"
@@ -17,8 +18,32 @@ import (
 
 var log = logger.DefaultLogger.WithField("oauth2", nil)
 
-// Path for OAuth2 Configuration file
-const cfgPath string = "configs/oauth2.json"
+// CfgPath is the path of the OAuth2 configuration file
+var CfgPath = "configs/oauth2.json"
+
+// Scopes of the NEF APIs, a token grants the APIs of the space separated
+// scopes of its scope claim
+const (
+	ScopeTrafficInfluence  = "3gpp-traffic-influence"
+	ScopePfdManagement     = "3gpp-pfd-management"
+	ScopeAsSessionWithQos  = "3gpp-as-session-with-qos"
+	ScopeMonitoringEvent   = "3gpp-monitoring-event"
+	ScopeNnefPfdManagement = "nnef-pfdmanagement"
+	ScopeNefAdmin          = "nef-admin"
+	// Scope of the notifications sent by the NEF to the AF
+	ScopeAfNotification = "af-notification"
+	// Scope of the notifications sent by the SMF and the UDM to the NEF,
+	// never granted to the AFs
+	Scope5gcNotification = "5gc-notification"
+)
+
+// NefSubject is the subject of the access tokens sent by the NEF with the
+// notifications to the AF
+const NefSubject = "NEF"
+
+// AFScopes are the scopes of the NEF northbound APIs used by the AF
+var AFScopes = []string{ScopeTrafficInfluence, ScopePfdManagement,
+	ScopeAsSessionWithQos, ScopeMonitoringEvent}
 
 //TokenVerificationResult Result of the token verification
 type TokenVerificationResult string
@@ -35,6 +60,21 @@ const (
 type Config struct {
 	SigningKey string `json:"signingkey"`
 	Expiration int64  `json:"expiration"`
+	// Signing algorithm of the tokens: RS256, ES256 or HS256. HS256 if
+	// empty with only a SigningKey, else ES256. With HS256 the holders of
+	// the shared SigningKey can issue any token.
+	Algorithm string `json:"algorithm"`
+	// RS256/ES256 keys of the issuer, the first one signs the tokens
+	Keys []KeyConfig `json:"keys"`
+	// JWKS document of the issuer used to verify RS256/ES256 tokens, the
+	// keys of the configuration are used if empty
+	JwksURI string `json:"jwksUri"`
+	// Time in seconds the keys of the JWKS document are cached
+	JwksCacheTime int64 `json:"jwksCacheTime"`
+	// Root CA certificate of the JWKS URI
+	JwksRootCACert string `json:"jwksRootCACert"`
+	// Clients allowed to request tokens from the token endpoint
+	Clients []ClientConfig `json:"clients"`
 }
 
 //PlmnID PLMN ID struct
@@ -68,6 +108,17 @@ type AccessTokenClaims struct {
 	jwt.StandardClaims
 }
 
+//HasScope returns true if scope is one of the scopes of the token
+func (c *AccessTokenClaims) HasScope(scope string) bool {
+
+	for _, s := range strings.Fields(c.Scope) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
 // LoadJSONConfig reads a file located at configPath and unmarshals it to
 // config structure
 func loadJSONConfig(configPath string, config interface{}) error {
@@ -87,7 +138,7 @@ func GetNEFAccessTokenFromNRF(accessTokenReq AccessTokenReq) (
 	var oAuth2Cfg = Config{}
 
 	//Read Json config
-	err = loadJSONConfig(cfgPath, &oAuth2Cfg)
+	err = loadJSONConfig(CfgPath, &oAuth2Cfg)
 	if err != nil {
 		log.Errln("Failed to load OAuth2 configuration")
 		return NefAccessToken, err
@@ -103,41 +154,79 @@ func GetNEFAccessTokenFromNRF(accessTokenReq AccessTokenReq) (
 
 	log.Infoln("Token expires in : ", oAuth2Cfg.Expiration, " seconds")
 
-	var mySigningKey = []byte(oAuth2Cfg.SigningKey)
-
 	//log.Infoln("Expiration Set to ", expiration)
 	// Create AccessToken
 	var accessTokenClaims = AccessTokenClaims{
-		"OpenNESS",             //Issuer:
-		"NEF Validation token", //Subject:
-		"AF-NEF",               //Audience:
-		accessTokenReq.Scope,   //Scope:
-		oAuth2Cfg.Expiration,   //Expiration:
+		"OpenNESS",                  //Issuer:
+		accessTokenReq.NfInstanceID, //Subject:
+		"AF-NEF",                    //Audience:
+		accessTokenReq.Scope,        //Scope:
+		oAuth2Cfg.Expiration,        //Expiration:
 		jwt.StandardClaims{ExpiresAt: jwtexp},
 	}
+	return signToken(&oAuth2Cfg, accessTokenClaims)
+}
+
+// GetAudienceAccessToken generates a token of the subject granting the scope
+// to the audience only, it expires after the lifetime. The NEF binds the
+// tokens of its notifications to their destination this way.
+func GetAudienceAccessToken(subject string, scope string, audience string,
+	lifetime time.Duration) (string, error) {
+
+	var oAuth2Cfg = Config{}
+
+	if err := loadJSONConfig(CfgPath, &oAuth2Cfg); err != nil {
+		log.Errln("Failed to load OAuth2 configuration")
+		return "", err
+	}
+
+	return signToken(&oAuth2Cfg, AccessTokenClaims{
+		Issuer:     "OpenNESS",
+		Subject:    subject,
+		Audience:   audience,
+		Scope:      scope,
+		Expiration: int64(lifetime / time.Second),
+		StandardClaims: jwt.StandardClaims{
+			Audience:  jwt.ClaimStrings{audience},
+			ExpiresAt: jwt.At(time.Now().Add(lifetime))}})
+}
+
+// signToken signs the claims with the signing key of the configuration
+func signToken(cfg *Config, claims AccessTokenClaims) (string, error) {
+
+	method, kid, mySigningKey, err := signingKey(cfg)
+	if err != nil {
+		log.Errln("Failed to load the signing key:", err)
+		return "", err
+	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, accessTokenClaims)
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
 	accessToken, SignedStringErr := token.SignedString(mySigningKey)
 
 	if SignedStringErr != nil {
 		log.Info(SignedStringErr)
+		return "", SignedStringErr
 	}
 
 	return accessToken, nil
 }
 
-func fetchNEFAccessTokenFromNRF() (token string, err error) {
+func fetchNEFAccessTokenFromNRF(afID string) (token string, err error) {
 
 	var accessTokenReq AccessTokenReq
 
 	//In case we construct and send it to NRF
 	accessTokenReq.GrantType = "client_credentials"
 
-	//Dont have it right now added static UUID
-	accessTokenReq.NfInstanceID = "0"
+	//The AF ID is the subject of the token, the NEF only grants the
+	//resources of this AF
+	accessTokenReq.NfInstanceID = afID
 	accessTokenReq.NfType = "AF"
 	accessTokenReq.TargetNfType = "NEF"
-	accessTokenReq.Scope = "nnrf-nfm"
+	accessTokenReq.Scope = strings.Join(AFScopes, " ")
 	accessTokenReq.TargetNfInstanceID = "0" //Instance of NEF
 
 	//POST AccessTokenRequest to NRF /oauth2/token
@@ -146,12 +235,12 @@ func fetchNEFAccessTokenFromNRF() (token string, err error) {
 
 //GetAccessToken Get the access token to access NEF NF component. This API can
 //			     be ported to operator provided token access mechanism
-// i/p			: None
+// i/p	afID	: The AF ID the token is granted to
 // o/p  token	: The access token
 //		err		: error code in case of failure or nil in success
-func GetAccessToken() (token string, err error) {
+func GetAccessToken(afID string) (token string, err error) {
 
-	token, err = fetchNEFAccessTokenFromNRF()
+	token, err = fetchNEFAccessTokenFromNRF(afID)
 
 	if err != nil {
 		log.Info("Failed to get NEF access token ")
@@ -166,43 +255,71 @@ func GetAccessToken() (token string, err error) {
 func ValidateAccessToken(reqToken string) (status TokenVerificationResult,
 	err error) {
 
+	_, status, err = ParseAccessToken(reqToken)
+	return status, err
+}
+
+//ParseAccessToken Validate the access token and return its claims. The
+//tokens bound to an audience are rejected.
+// i/p reqToken : token to be validated
+// o/p claims : claims of the token if valid
+//     status : Success/Failure result of the operation
+//     err    : error info of the token validation process.
+func ParseAccessToken(reqToken string) (claims *AccessTokenClaims,
+	status TokenVerificationResult, err error) {
+
+	return parseAccessToken(reqToken)
+}
+
+//ParseAudienceAccessToken Validate the access token bound to the audience
+//and return its claims. The tokens without audience are rejected.
+func ParseAudienceAccessToken(reqToken string, audience string) (
+	claims *AccessTokenClaims, status TokenVerificationResult, err error) {
+
+	claims, status, err = parseAccessToken(reqToken,
+		jwt.WithAudience(audience))
+	if err == nil && len(claims.StandardClaims.Audience) == 0 {
+		log.Infoln("Token has no audience")
+		return nil, StatusInvalidToken, errors.New("Token has no audience")
+	}
+	return claims, status, err
+}
+
+// parseAccessToken validates the token with the parser options
+func parseAccessToken(reqToken string, opts ...jwt.ParserOption) (
+	claims *AccessTokenClaims, status TokenVerificationResult, err error) {
+
 	var oAuth2Cfg = Config{}
 
 	//Read Json config
-	err = loadJSONConfig(cfgPath, &oAuth2Cfg)
+	err = loadJSONConfig(CfgPath, &oAuth2Cfg)
 	if err != nil {
 		log.Errln("Failed to load OAuth2 configuration")
-		return StatusConfigErr, err
+		return nil, StatusConfigErr, err
 	}
-	var mySigningKey = []byte(oAuth2Cfg.SigningKey)
-	claims := &AccessTokenClaims{}
+	claims = &AccessTokenClaims{}
 
-	tkn, err := jwt.ParseWithClaims(reqToken, claims, func(token *jwt.Token) (
-		interface{}, error) {
-		return mySigningKey, nil
-	})
+	tkn, err := jwt.ParseWithClaims(reqToken, claims,
+		verificationKey(&oAuth2Cfg), opts...)
 
 	if err != nil {
 		log.Info(err)
 
 		if err == jwt.ErrSignatureInvalid {
 			log.Info("Token is invalid, ErrSignatureInvalid")
-			return StatusInvalidToken, err
+			return nil, StatusInvalidToken, err
 		}
 		//Check for Validation error
-		validationErr, ok := err.(*jwt.MalformedTokenError)
-
-		if !ok {
-			log.Info(validationErr.Message)
-			return StatusInvalidToken, err
+		if _, ok := err.(*jwt.MalformedTokenError); !ok {
+			return nil, StatusInvalidToken, err
 		}
 
-		return StatusBadRequest, err
+		return nil, StatusBadRequest, err
 	}
 	if !tkn.Valid {
 		log.Info("Token is invalid")
-		return StatusInvalidToken, errors.New("Token is Invalid")
+		return nil, StatusInvalidToken, errors.New("Token is Invalid")
 	}
 	log.Info("OAuth2 Token Validation successful")
-	return StatusSuccess, nil
+	return claims, StatusSuccess, nil
 }
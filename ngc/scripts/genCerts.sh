@@ -169,6 +169,14 @@ then
    exit 1
 fi
 
+echo "Generating OAuth2 Signing Key:"
+openssl ecparam -genkey -name prime256v1 -noout -out "oauth2-key.pem"
+if (($?))
+then
+   echo "OAuth2 signing key generation failed"
+   exit 1
+fi
+
 echo "Print CA Cert Pem:"
 openssl x509 -in root-ca-cert.pem -text -noout
 echo "Print Server Cert Pem:"
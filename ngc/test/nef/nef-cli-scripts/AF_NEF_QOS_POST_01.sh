@@ -0,0 +1,13 @@
+#! /bin/sh
+# SPDX-License-Identifier: Apache-2.0
+# Copyright (c) 2020 Intel Corporation
+
+
+setup_dir=${PWD}
+echo "$setup_dir"
+
+set -e
+
+curl -X POST -i "Content-Type: application/json" --data @./json/AF_NEF_QOS_POST_01.json http://localhost:8091/3gpp-as-session-with-qos/v1/AF_01/subscriptions
+
+exit 0
@@ -0,0 +1,13 @@
+#! /bin/sh
+# SPDX-License-Identifier: Apache-2.0
+# Copyright (c) 2020 Intel Corporation
+
+
+setup_dir=${PWD}
+echo "$setup_dir"
+
+set -e
+
+curl -X POST -i "Content-Type: application/json" --data @./json/UDM_NEF_NOTIF_01.json http://localhost:8091/3gpp-monitoring-event/v1/notification/udm/11131
+
+exit 0